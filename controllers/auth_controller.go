@@ -1,32 +1,37 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
 	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 
 	_ "github.com/cesarbmathec/bets-backend/docs"
 )
 
 // Login godoc
 // @Summary     Iniciar sesión
-// @Description Autentica al usuario y devuelve un token JWT
+// @Description Autentica al usuario y devuelve un token JWT de acceso (15 minutos) y un refresh token para renovarlo. Si el usuario tiene la verificación en dos pasos activa, responde un reto (two_factor_required) que se completa en /auth/2fa/verify.
 // @Tags        auth
 // @Accept      json
 // @Produce     json
 // @Param       request body dtos.LoginRequest true "Credenciales"
-// @Success     200 {object} utils.Response{data=dtos.LoginResponse} "Login exitoso"
+// @Success     200 {object} utils.Response{data=dtos.LoginResponse} "Login exitoso o reto de 2FA (dtos.TwoFactorChallengeResponse)"
 // @Failure		400 {object} utils.Response "Datos de entrada inválidos"
 // @Failure		401 {object} utils.Response "Credenciales incorrectas"
 // @Failure		403 {object} utils.Response "Cuenta desactivada"
 // @Router      /auth/login [post]
 // @example request -json {"email": "admin@betsystem.com", "password": "Admin123!"}
-// @example response -json {"success": true, "message": "Bienvenido al sistema", "data": {"token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...", "user": {"id": 1, "username": "admin", "email": "admin@betsystem.com", "role": "admin"}}}
+// @example response -json {"success": true, "message": "Bienvenido al sistema", "data": {"token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...", "refresh_token": "q3Jd9...", "expires_in": 900, "user": {"id": 1, "username": "admin", "email": "admin@betsystem.com", "role": "admin"}}}
 func Login(c *gin.Context) {
 	var input dtos.LoginRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -68,21 +73,44 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Generar Token JWT
-	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
+	// Con 2FA activa la contraseña solo abre el segundo paso
+	if user.TwoFactorEnabled {
+		challenge, err := services.CreateTwoFactorChallenge(db, user.ID, time.Now())
+		if err != nil {
+			utils.Error(c, http.StatusInternalServerError, "Error generando acceso", nil)
+			return
+		}
+		utils.Success(c, http.StatusOK, "Ingrese el código de verificación en dos pasos", dtos.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+			ExpiresIn:         int(services.TwoFactorChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	issueLogin(c, db, &user)
+}
+
+// issueLogin emite los tokens de un login completo (con o sin segundo paso)
+func issueLogin(c *gin.Context, db *gorm.DB, user *models.User) {
+	// Generar Token JWT y refresh token
+	tokens, err := services.IssueTokens(db, user, clientInfo(c))
 	if err != nil {
 		utils.Error(c, http.StatusInternalServerError, "Error generando acceso", nil)
 		return
 	}
 
 	response := dtos.LoginResponse{
-		Token: token,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 		User: dtos.UserSummary{
 			ID:       user.ID,
 			Username: user.Username,
 			Email:    user.Email,
 			Role:     user.Role,
 		},
+		TwoFactorSetupRequired: services.StaffTwoFactorPending(db, user),
 	}
 
 	utils.Success(c, http.StatusOK, "Bienvenido al sistema", response)
@@ -100,7 +128,7 @@ func Login(c *gin.Context) {
 // @Failure      409 {object} utils.Response "Usuario o email ya existe"
 // @Router       /auth/register [post]
 // @example request -json {"username": "jugador1", "email": "jugador1@example.com", "password": "Pass123!"}
-// @example response -json {"success": true, "message": "Usuario registrado exitosamente", "data": {"token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...", "user": "jugador1"}}
+// @example response -json {"success": true, "message": "Usuario registrado exitosamente", "data": {"token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...", "refresh_token": "q3Jd9...", "expires_in": 900, "user": "jugador1"}}
 func Register(c *gin.Context) {
 	var input dtos.RegisterRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -121,6 +149,10 @@ func Register(c *gin.Context) {
 		FullName:   input.FullName,
 		Phone:      input.Phone,
 		DocumentID: input.DocumentID,
+		Locale:     input.Locale,
+	}
+	if user.Locale == "" {
+		user.Locale = services.DefaultLocale
 	}
 	user.HashPassword(input.Password)
 
@@ -146,10 +178,112 @@ func Register(c *gin.Context) {
 
 	tx.Commit()
 
-	token, _ := utils.GenerateToken(user.ID, user.Username, user.Role)
+	tokens, err := services.IssueTokens(db, &user, clientInfo(c))
+	if err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error generando acceso", nil)
+		return
+	}
 
 	utils.Success(c, http.StatusCreated, "Usuario registrado exitosamente", gin.H{
-		"token": token,
-		"user":  user.Username,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"user":          user.Username,
 	})
 }
+
+// RefreshToken godoc
+// @Summary      Renovar el token de acceso
+// @Description  Canjea el refresh token por un token de acceso y un refresh token nuevos; el usado deja de valer. Reusar un refresh token ya canjeado cierra la sesión de ese dispositivo.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dtos.RefreshTokenRequest true "Refresh token"
+// @Success      200 {object} utils.Response{data=dtos.TokenPairResponse}
+// @Failure      401 {object} utils.Response "Refresh token inválido, expirado o reusado"
+// @Failure      403 {object} utils.Response "Cuenta desactivada"
+// @Router       /auth/refresh [post]
+// @example request -json {"refresh_token": "q3Jd9..."}
+func RefreshToken(c *gin.Context) {
+	var input dtos.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos de entrada inválidos", err.Error())
+		return
+	}
+
+	tx := config.GetDB().Begin()
+	tokens, _, err := services.RotateRefreshToken(tx, input.RefreshToken, clientInfo(c), time.Now())
+	if err != nil {
+		// La revocación de la familia por reuso debe quedar guardada
+		if errors.Is(err, services.ErrRefreshTokenReused) {
+			tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+		switch {
+		case errors.Is(err, services.ErrUserInactive):
+			utils.Error(c, http.StatusForbidden, "Cuenta de usuario desactivada", nil)
+		case errors.Is(err, services.ErrInvalidRefreshToken), errors.Is(err, services.ErrRefreshTokenReused):
+			utils.Error(c, http.StatusUnauthorized, err.Error(), nil)
+		default:
+			utils.Error(c, http.StatusInternalServerError, "Error generando acceso", nil)
+		}
+		return
+	}
+	tx.Commit()
+
+	utils.Success(c, http.StatusOK, "Sesión renovada", dtos.TokenPairResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	})
+}
+
+// Logout godoc
+// @Summary      Cerrar sesión
+// @Description  Revoca el refresh token del dispositivo. El token de acceso vence solo en minutos; para invalidarlo de inmediato usar logout-all.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dtos.RefreshTokenRequest true "Refresh token del dispositivo"
+// @Success      200 {object} utils.Response
+// @Router       /auth/logout [post]
+// @example request -json {"refresh_token": "q3Jd9..."}
+func Logout(c *gin.Context) {
+	var input dtos.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos de entrada inválidos", err.Error())
+		return
+	}
+
+	if err := services.RevokeRefreshToken(config.GetDB(), input.RefreshToken, time.Now()); err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al cerrar sesión", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Sesión cerrada", nil)
+}
+
+// LogoutAllDevices godoc
+// @Summary      Cerrar sesión en todos los dispositivos
+// @Description  Revoca todos los refresh tokens del usuario e invalida de inmediato sus tokens de acceso, incluido el usado en esta petición
+// @Tags         auth
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} utils.Response
+// @Router       /auth/logout-all [post]
+func LogoutAllDevices(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	if err := services.RevokeUserSessions(config.GetDB(), userID, time.Now()); err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al cerrar las sesiones", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Sesiones cerradas en todos los dispositivos", nil)
+}
+
+// clientInfo identifica el dispositivo que pide tokens para listarlo en sus sesiones
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{UserAgent: c.Request.UserAgent(), IPAddress: c.ClientIP()}
+}
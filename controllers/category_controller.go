@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/cesarbmathec/bets-backend/config"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
 	"github.com/cesarbmathec/bets-backend/models"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
@@ -31,6 +32,29 @@ type CategoryResponse struct {
 	Color       string `json:"color"`
 	IsActive    bool   `json:"is_active"`
 	SortOrder   int    `json:"sort_order"`
+
+	Settings       models.CategorySettingsJSON    `json:"settings"`
+	SelectionTypes []models.CategorySelectionType `json:"selection_types"`
+}
+
+// categoryResponse arma la respuesta de una categoría con sus tipos de selección precargados
+func categoryResponse(category models.Category) CategoryResponse {
+	selectionTypes := category.SelectionTypes
+	if selectionTypes == nil {
+		selectionTypes = []models.CategorySelectionType{}
+	}
+	return CategoryResponse{
+		ID:             category.ID,
+		Name:           category.Name,
+		Slug:           category.Slug,
+		Description:    category.Description,
+		Icon:           category.Icon,
+		Color:          category.Color,
+		IsActive:       category.IsActive,
+		SortOrder:      category.SortOrder,
+		Settings:       category.Settings,
+		SelectionTypes: selectionTypes,
+	}
 }
 
 // GetCategories godoc
@@ -44,11 +68,11 @@ type CategoryResponse struct {
 func (cc *CategoryController) GetCategories(c *gin.Context) {
 	var categories []models.Category
 
-	query := config.DB.Where("is_active = ?", true).Order("sort_order ASC, name ASC")
+	query := config.DB.Preload("SelectionTypes").Where("is_active = ?", true).Order("sort_order ASC, name ASC")
 
-	// Si es admin, puede ver todas
-	if c.GetString("role") == "admin" {
-		query = config.DB.Order("sort_order ASC, name ASC")
+	// Quien gestiona categorías puede ver también las inactivas
+	if principal, ok := middleware.PrincipalFromContext(c); ok && principal.HasPermission(models.PermCategoriesManage) {
+		query = config.DB.Preload("SelectionTypes").Order("sort_order ASC, name ASC")
 	}
 
 	if err := query.Find(&categories).Error; err != nil {
@@ -59,16 +83,7 @@ func (cc *CategoryController) GetCategories(c *gin.Context) {
 	// Transformar respuesta
 	var response []CategoryResponse
 	for _, cat := range categories {
-		response = append(response, CategoryResponse{
-			ID:          cat.ID,
-			Name:        cat.Name,
-			Slug:        cat.Slug,
-			Description: cat.Description,
-			Icon:        cat.Icon,
-			Color:       cat.Color,
-			IsActive:    cat.IsActive,
-			SortOrder:   cat.SortOrder,
-		})
+		response = append(response, categoryResponse(cat))
 	}
 
 	utils.Success(c, http.StatusOK, "Lista de categorías", response)
@@ -92,23 +107,12 @@ func (cc *CategoryController) GetCategoryByID(c *gin.Context) {
 	}
 
 	var category models.Category
-	if err := config.DB.First(&category, id).Error; err != nil {
+	if err := config.DB.Preload("SelectionTypes").First(&category, id).Error; err != nil {
 		utils.Error(c, http.StatusNotFound, "Categoría no encontrada", nil)
 		return
 	}
 
-	response := CategoryResponse{
-		ID:          category.ID,
-		Name:        category.Name,
-		Slug:        category.Slug,
-		Description: category.Description,
-		Icon:        category.Icon,
-		Color:       category.Color,
-		IsActive:    category.IsActive,
-		SortOrder:   category.SortOrder,
-	}
-
-	utils.Success(c, http.StatusOK, "Categoría encontrada", response)
+	utils.Success(c, http.StatusOK, "Categoría encontrada", categoryResponse(category))
 }
 
 // CreateCategory godoc
@@ -145,6 +149,7 @@ func (cc *CategoryController) CreateCategory(c *gin.Context) {
 		Color:       input.Color,
 		IsActive:    true,
 		SortOrder:   input.SortOrder,
+		Settings:    input.Settings,
 	}
 
 	if err := config.DB.Create(&category).Error; err != nil {
@@ -152,23 +157,12 @@ func (cc *CategoryController) CreateCategory(c *gin.Context) {
 		return
 	}
 
-	response := CategoryResponse{
-		ID:          category.ID,
-		Name:        category.Name,
-		Slug:        category.Slug,
-		Description: category.Description,
-		Icon:        category.Icon,
-		Color:       category.Color,
-		IsActive:    category.IsActive,
-		SortOrder:   category.SortOrder,
-	}
-
-	utils.Success(c, http.StatusCreated, "Categoría creada exitosamente", response)
+	utils.Success(c, http.StatusCreated, "Categoría creada exitosamente", categoryResponse(category))
 }
 
 // UpdateCategory godoc
 // @Summary      Actualizar una categoría
-// @Description  Actualiza los datos de una categoría existente (Solo Admin)
+// @Description  Actualiza los datos de una categoría existente (Solo Admin). Al renombrarla se actualiza también el nombre de categoría de sus torneos.
 // @Tags         admin
 // @Accept       json
 // @Produce      json
@@ -219,11 +213,26 @@ func (cc *CategoryController) UpdateCategory(c *gin.Context) {
 		updates["sort_order"] = input.SortOrder
 	}
 
-	if err := config.DB.Model(&category).Updates(updates).Error; err != nil {
+	tx := config.DB.Begin()
+
+	if err := tx.Model(&category).Updates(updates).Error; err != nil {
+		tx.Rollback()
 		utils.Error(c, http.StatusInternalServerError, "Error al actualizar categoría", err.Error())
 		return
 	}
 
+	// Los torneos guardan también el nombre de su categoría: renombrarla los actualiza en la misma transacción
+	if input.Name != "" {
+		if err := tx.Model(&models.Tournament{}).Where("category_id = ?", category.ID).
+			Update("category", input.Name).Error; err != nil {
+			tx.Rollback()
+			utils.Error(c, http.StatusInternalServerError, "Error al actualizar la categoría de los torneos", err.Error())
+			return
+		}
+	}
+
+	tx.Commit()
+
 	utils.Success(c, http.StatusOK, "Categoría actualizada", category)
 }
 
@@ -302,13 +311,154 @@ func (cc *CategoryController) ToggleCategoryStatus(c *gin.Context) {
 	utils.Success(c, http.StatusOK, "Estado actualizado", category)
 }
 
+// UpdateCategorySettings godoc
+// @Summary      Actualizar reglas de una categoría
+// @Description  Reemplaza las reglas de cartilla y los valores por defecto que heredan los torneos nuevos de la categoría (Solo Admin)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID de la categoría"
+// @Param        request body models.CategorySettingsJSON true "Reglas de la categoría"
+// @Success      200 {object} utils.Response{data=CategoryResponse} "Reglas actualizadas"
+// @Failure      400 {object} utils.Response "Datos inválidos"
+// @Failure      404 {object} utils.Response "Categoría no encontrada"
+// @Router       /admin/categories/{id}/settings [put]
+// @Security     BearerAuth
+// @example request -json {"min_selections_per_session": 4, "max_selections_per_session": 6, "default_selections_per_session": 5, "default_prize_distribution": [0.7, 0.2, 0.1]}
+func (cc *CategoryController) UpdateCategorySettings(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, "ID inválido", nil)
+		return
+	}
+
+	var input models.CategorySettingsJSON
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+	if input.MaxSelectionsPerSession > 0 && input.MinSelectionsPerSession > input.MaxSelectionsPerSession {
+		utils.Error(c, http.StatusBadRequest, "El mínimo de selecciones no puede superar al máximo", nil)
+		return
+	}
+
+	var category models.Category
+	if err := config.DB.Preload("SelectionTypes").First(&category, id).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Categoría no encontrada", nil)
+		return
+	}
+
+	if err := config.DB.Model(&category).Update("settings", input).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al actualizar reglas", err.Error())
+		return
+	}
+	category.Settings = input
+
+	utils.Success(c, http.StatusOK, "Reglas actualizadas", categoryResponse(category))
+}
+
+// GetCategorySelectionTypes godoc
+// @Summary      Tipos de selección de una categoría
+// @Description  Lista los tipos de selección permitidos en los torneos de la categoría. Una lista vacía significa que se permiten todos.
+// @Tags         categories
+// @Produce      json
+// @Param        id path int true "ID de la categoría"
+// @Success      200 {object} utils.Response{data=[]models.CategorySelectionType} "Tipos de selección"
+// @Failure      404 {object} utils.Response "Categoría no encontrada"
+// @Router       /categories/{id}/selection-types [get]
+func (cc *CategoryController) GetCategorySelectionTypes(c *gin.Context) {
+	var category models.Category
+	if err := config.DB.Preload("SelectionTypes").First(&category, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Categoría no encontrada", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Tipos de selección", categoryResponse(category).SelectionTypes)
+}
+
+// SetCategorySelectionType godoc
+// @Summary      Permitir un tipo de selección en una categoría
+// @Description  Agrega el tipo a la categoría o actualiza su configuración si ya estaba (obligatorio, puntos por defecto) (Solo Admin)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID de la categoría"
+// @Param        request body CategorySelectionTypeRequest true "Tipo de selección"
+// @Success      200 {object} utils.Response{data=models.CategorySelectionType} "Tipo de selección guardado"
+// @Failure      400 {object} utils.Response "Tipo de selección desconocido"
+// @Failure      404 {object} utils.Response "Categoría no encontrada"
+// @Router       /admin/categories/{id}/selection-types [post]
+// @Security     BearerAuth
+// @example request -json {"selection_type": "macho", "display_name": "Macho (Favorito)", "is_required": true, "default_points": 3}
+func (cc *CategoryController) SetCategorySelectionType(c *gin.Context) {
+	var input CategorySelectionTypeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+	if !models.IsValidSelectionType(input.SelectionType) {
+		utils.Error(c, http.StatusBadRequest, "Tipo de selección desconocido", input.SelectionType)
+		return
+	}
+
+	var category models.Category
+	if err := config.DB.First(&category, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Categoría no encontrada", nil)
+		return
+	}
+
+	var selectionType models.CategorySelectionType
+	config.DB.Where("category_id = ? AND selection_type = ?", category.ID, input.SelectionType).First(&selectionType)
+
+	selectionType.CategoryID = category.ID
+	selectionType.SelectionType = input.SelectionType
+	selectionType.DisplayName = input.DisplayName
+	selectionType.Description = input.Description
+	selectionType.IsRequired = input.IsRequired
+	selectionType.DefaultPoints = input.DefaultPoints
+
+	if err := config.DB.Save(&selectionType).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al guardar el tipo de selección", err.Error())
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Tipo de selección guardado", selectionType)
+}
+
+// DeleteCategorySelectionType godoc
+// @Summary      Quitar un tipo de selección de una categoría
+// @Description  Deja de permitir el tipo en los torneos de la categoría. Las selecciones ya creadas no se modifican (Solo Admin)
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "ID de la categoría"
+// @Param        type path string true "Tipo de selección (ej: macho)"
+// @Success      200 {object} utils.Response "Tipo de selección eliminado"
+// @Failure      404 {object} utils.Response "Tipo de selección no encontrado"
+// @Router       /admin/categories/{id}/selection-types/{type} [delete]
+// @Security     BearerAuth
+func (cc *CategoryController) DeleteCategorySelectionType(c *gin.Context) {
+	result := config.DB.Where("category_id = ? AND selection_type = ?", c.Param("id"), c.Param("type")).
+		Delete(&models.CategorySelectionType{})
+	if result.Error != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al eliminar el tipo de selección", result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.Error(c, http.StatusNotFound, "Tipo de selección no encontrado", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Tipo de selección eliminado", nil)
+}
+
 // DTOs para las requests
 type CreateCategoryRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	Icon        string `json:"icon"`
-	Color       string `json:"color"`
-	SortOrder   int    `json:"sort_order"`
+	Name        string                      `json:"name" binding:"required"`
+	Description string                      `json:"description"`
+	Icon        string                      `json:"icon"`
+	Color       string                      `json:"color"`
+	SortOrder   int                         `json:"sort_order"`
+	Settings    models.CategorySettingsJSON `json:"settings"`
 }
 
 type UpdateCategoryRequest struct {
@@ -322,3 +472,11 @@ type UpdateCategoryRequest struct {
 type ToggleCategoryStatusRequest struct {
 	IsActive bool `json:"is_active"`
 }
+
+type CategorySelectionTypeRequest struct {
+	SelectionType string `json:"selection_type" binding:"required"`
+	DisplayName   string `json:"display_name"`
+	Description   string `json:"description"`
+	IsRequired    bool   `json:"is_required"`
+	DefaultPoints int    `json:"default_points" binding:"gte=0"`
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
 	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
 
@@ -77,7 +79,7 @@ func GetEventByID(c *gin.Context) {
 // @Success      200 {object} utils.Response{data=[]models.TournamentEvent}
 // @Router       /events/tournament/{tournament_id} [get]
 func GetTournamentEventsByTournament(c *gin.Context) {
-	tournamentID := c.Param("tournament_id")
+	tournamentID := resolveSlateTournamentID(c.Param("tournament_id"))
 
 	var tournamentEvents []models.TournamentEvent
 	if err := config.DB.
@@ -174,6 +176,7 @@ func UpdateEvent(c *gin.Context) {
 	if input.Venue != "" {
 		event.Venue = input.Venue
 	}
+	previousLine := event.Line
 	if input.Line > 0 {
 		event.Line = input.Line
 	}
@@ -187,11 +190,27 @@ func UpdateEvent(c *gin.Context) {
 		}
 	}
 
-	if err := config.DB.Save(&event).Error; err != nil {
+	userID := middleware.CurrentPrincipal(c).UserID
+	changedBy := userID
+
+	tx := config.DB.Begin()
+	if err := tx.Save(&event).Error; err != nil {
+		tx.Rollback()
 		utils.Error(c, http.StatusInternalServerError, "Error al actualizar evento", err.Error())
 		return
 	}
 
+	// Versionar el cambio de línea
+	if err := services.RecordLineChanges(tx, event.ID, &changedBy, services.LineChange{
+		EntityType: models.LineEntityEvent, EntityID: event.ID, Field: models.LineFieldLine,
+		OldValue: previousLine, NewValue: event.Line,
+	}); err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al registrar historial de línea", err.Error())
+		return
+	}
+	tx.Commit()
+
 	config.DB.Preload("Competitors").First(&event, event.ID)
 	utils.Success(c, http.StatusOK, "Evento actualizado", event)
 }
@@ -336,14 +355,14 @@ func RemoveEventFromTournament(c *gin.Context) {
 
 // SetEventCompetitors godoc
 // @Summary      Establecer competidores de un evento
-// @Description  Asigna los competidores (equipos/caballos) a un evento
+// @Description  Asigna los competidores (equipos/caballos) a un evento. Los existentes se actualizan en su lugar (por id, competitor_id o nombre) conservando su ID; los cambios de cuota y runline quedan en el historial. Los que no se envían se eliminan.
 // @Tags         admin
-// @Param        id path int true "ID del Evento"
+// @Param        event_id path int true "ID del Evento"
 // @Param        request body dtos.SetEventCompetitorsRequest true "Lista de competidores"
-// @Router       /admin/events/{id}/competitors [post]
+// @Router       /admin/events/{event_id}/competitors [post]
 // @Security     BearerAuth
 func SetEventCompetitors(c *gin.Context) {
-	eventID := c.Param("event_id")
+	eventID := utils.StringToUint(c.Param("event_id"))
 	var input dtos.SetEventCompetitorsRequest
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -351,27 +370,15 @@ func SetEventCompetitors(c *gin.Context) {
 		return
 	}
 
+	userID := middleware.CurrentPrincipal(c).UserID
+	changedBy := userID
+
 	tx := config.DB.Begin()
 
-	// Eliminar competidores previos
-	tx.Where("event_id = ?", eventID).Delete(&models.EventCompetitor{})
-
-	for _, comp := range input.Competitors {
-		newComp := models.EventCompetitor{
-			EventID:        utils.StringToUint(eventID),
-			CompetitorID:   comp.CompetitorID,
-			Name:           comp.Name,
-			AssignedNumber: comp.AssignedNumber,
-			Odds:           comp.Odds,
-			Runline:        float64(comp.Runline),
-			SuperRunline:   float64(comp.SuperRunline),
-			IsFavorite:     comp.IsFavorite,
-		}
-		if err := tx.Create(&newComp).Error; err != nil {
-			tx.Rollback()
-			utils.Error(c, http.StatusInternalServerError, "Error al guardar competidores", err.Error())
-			return
-		}
+	if _, err := services.UpsertEventCompetitors(tx, eventID, input.Competitors, &changedBy); err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al guardar competidores", err.Error())
+		return
 	}
 
 	tx.Commit()
@@ -383,6 +390,34 @@ func SetEventCompetitors(c *gin.Context) {
 	utils.Success(c, http.StatusOK, "Competidores actualizados", competitors)
 }
 
+// GetEventLineHistory godoc
+// @Summary      Historial de líneas y cuotas de un evento
+// @Description  Devuelve los cambios de línea, cuota y runline del evento, sus selecciones y competidores en orden cronológico (para gráficas)
+// @Tags         events
+// @Param        id path int true "ID del Evento"
+// @Param        entity_type query string false "Filtrar por entidad (event, selection, competitor)"
+// @Param        field query string false "Filtrar por campo (line, odds, runline, super_runline)"
+// @Produce      json
+// @Success      200 {object} utils.Response{data=[]models.LineHistory}
+// @Router       /events/{id}/line-history [get]
+func GetEventLineHistory(c *gin.Context) {
+	query := config.DB.Where("event_id = ?", c.Param("id"))
+	if entityType := c.Query("entity_type"); entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if field := c.Query("field"); field != "" {
+		query = query.Where("field = ?", field)
+	}
+
+	var history []models.LineHistory
+	if err := query.Order("created_at asc").Find(&history).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener historial", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Historial de líneas", history)
+}
+
 // GetAvailableEventsForTournament godoc
 // @Summary      Listar eventos disponibles para asignar
 // @Description  Obtiene eventos que aún no están asignados a un torneo o están disponibles
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+
+	_ "github.com/cesarbmathec/bets-backend/docs"
+)
+
+// ImportEvents godoc
+// @Summary      Importar eventos desde un fixture CSV o JSON
+// @Description  Crea o actualiza en bloque eventos, competidores (emparejados con el catálogo por nombre o número), líneas, cuotas y selecciones. Acepta JSON en el cuerpo, un archivo `file` (.csv o .json) o un cuerpo text/csv. El CSV lleva una fila por competidor con las columnas event, start_time, venue, line, order, competitor, number, odds, runline, super_runline, favorite; las opciones generales van por query string. En eventos ya en juego no se cambian la línea ni la hora, y los competidores que faltan en el fixture pero tienen selecciones se conservan (con aviso). Los eventos de una liga se asignan a la cartilla de su torneo base y la sesión, si se indica, debe seguir abierta o programada; sin session_id se conserva la sesión del evento. Con dry_run=true se reporta lo que se crearía o actualizaría sin guardar nada. Si alguna fila tiene errores no se guarda ningún cambio.
+// @Tags         admin
+// @Accept       json,mpfd,plain
+// @Produce      json
+// @Param        dry_run query bool false "Solo simular la importación"
+// @Param        tournament_id query int false "Torneo al que asignar los eventos (CSV)"
+// @Param        session_id query int false "Sesión del torneo, abierta o programada (CSV)"
+// @Param        competitor_category query string false "Categoría del catálogo de competidores (CSV)"
+// @Param        generate_selections query bool false "Generar selecciones estándar (CSV)"
+// @Param        file formData file false "Archivo .csv o .json"
+// @Param        request body dtos.EventImportRequest false "Fixture en JSON"
+// @Success      200 {object} utils.Response{data=dtos.EventImportReport} "Importación realizada o simulada"
+// @Failure      400 {object} utils.Response "Archivo inválido"
+// @Failure      422 {object} utils.Response{errors=dtos.EventImportReport} "Filas con errores; no se guardó nada"
+// @Router       /admin/events/import [post]
+// @Security     BearerAuth
+// @example request -json {"tournament_id": 1, "session_id": 3, "generate_selections": true, "events": [{"name": "Leones vs Tigres", "start_time": "2026-10-20T19:00:00Z", "line": 8.5, "competitors": [{"name": "Leones", "odds": -150, "runline": -1.5, "is_favorite": true}, {"name": "Tigres", "odds": 130, "runline": 1.5}]}]}
+func ImportEvents(c *gin.Context) {
+	input, err := bindEventImport(c)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, "Fixture inválido", err.Error())
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	userID := middleware.CurrentPrincipal(c).UserID
+	changedBy := userID
+
+	tx := config.DB.Begin()
+	report, err := services.ImportEvents(tx, input, &changedBy, dryRun)
+	if err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al importar eventos", err.Error())
+		return
+	}
+
+	if len(report.Errors) > 0 && !dryRun {
+		tx.Rollback()
+		utils.Error(c, http.StatusUnprocessableEntity, "El fixture tiene errores; no se guardó ningún cambio", report)
+		return
+	}
+
+	if dryRun {
+		tx.Rollback()
+		utils.Success(c, http.StatusOK, "Simulación de importación", report)
+		return
+	}
+
+	tx.Commit()
+	report.Committed = true
+	utils.Success(c, http.StatusOK, "Eventos importados", report)
+}
+
+// bindEventImport lee el fixture como JSON, archivo subido (.csv/.json) o cuerpo text/csv
+func bindEventImport(c *gin.Context) (*dtos.EventImportRequest, error) {
+	contentType := c.ContentType()
+
+	if strings.HasPrefix(contentType, "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		if strings.EqualFold(filepath.Ext(header.Filename), ".json") {
+			var input dtos.EventImportRequest
+			if err := json.NewDecoder(file).Decode(&input); err != nil {
+				return nil, err
+			}
+			return &input, nil
+		}
+		return csvEventImport(c, file)
+	}
+
+	if contentType == "text/csv" || contentType == "text/plain" {
+		return csvEventImport(c, c.Request.Body)
+	}
+
+	var input dtos.EventImportRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		return nil, err
+	}
+	return &input, nil
+}
+
+// csvEventImport arma el fixture a partir del CSV y las opciones del query string.
+// Los errores de fila del CSV se devuelven junto al resto de errores de la importación.
+func csvEventImport(c *gin.Context, body io.Reader) (*dtos.EventImportRequest, error) {
+	events, rowErrs, err := services.ParseEventImportCSV(body)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &dtos.EventImportRequest{
+		CompetitorCategory: c.Query("competitor_category"),
+		GenerateSelections: c.Query("generate_selections") == "true",
+		Events:             events,
+		ParseErrors:        rowErrs,
+	}
+	if id := utils.StringToUint(c.Query("tournament_id")); id > 0 {
+		input.TournamentID = &id
+	}
+	if id := utils.StringToUint(c.Query("session_id")); id > 0 {
+		input.SessionID = &id
+	}
+	return input, nil
+}
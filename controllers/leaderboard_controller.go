@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	_ "github.com/cesarbmathec/bets-backend/docs"
 )
@@ -17,14 +20,19 @@ import (
 // @Tags         tournaments
 // @Param        id path int true "ID del Torneo"
 // @Success      200 {object} utils.Response{data=[]models.TournamentParticipant}
+// @Failure      403 {object} utils.Response "Torneo privado"
+// @Failure      404 {object} utils.Response "Torneo no encontrado"
 // @Router       /tournaments/{id}/leaderboard [get]
 func GetTournamentLeaderboard(c *gin.Context) {
-	tournamentID := c.Param("id")
+	tournament, ok := loadVisibleTournament(c)
+	if !ok {
+		return
+	}
 	var participants []models.TournamentParticipant
 
 	// Preload User para mostrar nombres, ordenados por puntos DESC
 	if err := config.DB.Preload("User").
-		Where("tournament_id = ?", tournamentID).
+		Where("tournament_id = ?", tournament.ID).
 		Order("total_points desc").
 		Find(&participants).Error; err != nil {
 		utils.Error(c, http.StatusInternalServerError, "Error al obtener clasificación", nil)
@@ -33,3 +41,31 @@ func GetTournamentLeaderboard(c *gin.Context) {
 
 	utils.Success(c, http.StatusOK, "Tabla de clasificación", participants)
 }
+
+// GetProjectedLeaderboard godoc
+// @Summary      Ver clasificación proyectada en vivo
+// @Description  Suma a los puntos liquidados los que darían los picks pendientes de eventos en vivo si terminaran con el marcador actual. Es provisional: no asigna puntos.
+// @Tags         tournaments
+// @Param        id path int true "ID del Torneo"
+// @Success      200 {object} utils.Response{data=[]services.ProjectedStanding}
+// @Failure      403 {object} utils.Response "Torneo privado"
+// @Failure      404 {object} utils.Response "Torneo no encontrado"
+// @Router       /tournaments/id/{id}/leaderboard/projected [get]
+func GetProjectedLeaderboard(c *gin.Context) {
+	tournament, ok := loadVisibleTournament(c)
+	if !ok {
+		return
+	}
+
+	standings, err := services.ProjectedLeaderboard(config.DB, tournament.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.Error(c, http.StatusNotFound, "Torneo no encontrado", nil)
+		return
+	}
+	if err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al calcular la clasificación proyectada", err.Error())
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Clasificación proyectada", standings)
+}
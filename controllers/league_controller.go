@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+
+	_ "github.com/cesarbmathec/bets-backend/docs"
+)
+
+// inviteCodeLength es la longitud de los códigos de invitación generados
+const inviteCodeLength = 8
+
+var errInviteCodeExhausted = errors.New("no se pudo generar un código de invitación único")
+
+// CreateLeague godoc
+// @Summary      Crear una liga privada
+// @Description  Permite a un usuario crear una liga privada (gratuita o con entrada en tokens) que juega la cartilla de un torneo publicado por un admin. El creador queda inscrito automáticamente.
+// @Tags         leagues
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body dtos.CreateLeagueRequest true "Datos de la liga"
+// @Success      201 {object} utils.Response "Liga creada con su código de invitación"
+// @Failure      400 {object} utils.Response "Datos inválidos o torneo base no disponible"
+// @Failure      404 {object} utils.Response "Torneo base no encontrado"
+// @Router       /leagues [post]
+// @example request -json {"base_tournament_id": 1, "name": "Polla de la oficina", "entry_fee_tokens": 0, "max_participants": 20}
+func CreateLeague(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	var input dtos.CreateLeagueRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	// 1. Validar torneo base: debe ser público, abierto y no ser a su vez una liga
+	var base models.Tournament
+	if err := config.DB.First(&base, input.BaseTournamentID).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Torneo base no encontrado", nil)
+		return
+	}
+
+	if base.IsPrivate() || base.ParentTournamentID != nil {
+		utils.Error(c, http.StatusBadRequest, "Solo se pueden crear ligas sobre torneos públicos", nil)
+		return
+	}
+
+	if base.Status != models.TournamentStatusOpen {
+		utils.Error(c, http.StatusBadRequest, "El torneo base no está abierto", nil)
+		return
+	}
+
+	// 2. La liga hereda reglas y fechas del torneo base, sin premios en dinero
+	settings := base.Settings
+	settings.PrizeDistribution = input.PrizeDistribution
+
+	baseID := base.ID
+	league := models.Tournament{
+		Name:               input.Name,
+		Description:        input.Description,
+		Category:           base.Category,
+		CategoryID:         base.CategoryID,
+		Status:             models.TournamentStatusOpen,
+		StartDate:          base.StartDate,
+		EndDate:            base.EndDate,
+		MaxParticipants:    input.MaxParticipants,
+		EntryFee:           0,
+		EntryFeeTokens:     input.EntryFeeTokens,
+		AdminFeePercent:    0,
+		Visibility:         models.TournamentVisibilityPrivate,
+		ParentTournamentID: &baseID,
+		Settings:           settings,
+		CreatedBy:          userID,
+	}
+
+	if err := assignInviteCode(&league); err != nil {
+		utils.Error(c, http.StatusInternalServerError, "No se pudo generar el código de invitación", nil)
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	if err := tx.Create(&league).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusConflict, "No se pudo crear la liga (¿nombre duplicado?)", nil)
+		return
+	}
+
+	// 3. El creador queda inscrito sin costo
+	participant := models.TournamentParticipant{
+		UserID:       userID,
+		TournamentID: league.ID,
+	}
+	if err := tx.Create(&participant).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al inscribir al creador", nil)
+		return
+	}
+
+	tx.Commit()
+
+	utils.Success(c, http.StatusCreated, "Liga creada con éxito", gin.H{
+		"tournament": league,
+		"invite":     inviteResponse(&league),
+	})
+}
+
+// GetTournamentInvite godoc
+// @Summary      Obtener código de invitación
+// @Description  Devuelve el código y enlace de invitación de un torneo privado. Solo para su creador o un admin.
+// @Tags         leagues
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "ID del Torneo"
+// @Success      200 {object} utils.Response{data=dtos.TournamentInviteResponse}
+// @Failure      403 {object} utils.Response "No eres el creador del torneo"
+// @Failure      404 {object} utils.Response "Torneo no encontrado o no es privado"
+// @Router       /tournaments/{id}/invite [get]
+func GetTournamentInvite(c *gin.Context) {
+	tournament, ok := loadOwnedPrivateTournament(c)
+	if !ok {
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Invitación del torneo", inviteResponse(tournament))
+}
+
+// RegenerateTournamentInvite godoc
+// @Summary      Regenerar código de invitación
+// @Description  Invalida el código actual y genera uno nuevo. Solo para su creador o un admin.
+// @Tags         leagues
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "ID del Torneo"
+// @Success      200 {object} utils.Response{data=dtos.TournamentInviteResponse}
+// @Router       /tournaments/{id}/invite/regenerate [post]
+func RegenerateTournamentInvite(c *gin.Context) {
+	tournament, ok := loadOwnedPrivateTournament(c)
+	if !ok {
+		return
+	}
+
+	if err := assignInviteCode(tournament); err != nil {
+		utils.Error(c, http.StatusInternalServerError, "No se pudo generar el código de invitación", nil)
+		return
+	}
+
+	if err := config.DB.Model(tournament).Update("invite_code", tournament.InviteCode).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al guardar el código", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Código de invitación regenerado", inviteResponse(tournament))
+}
+
+// loadOwnedPrivateTournament carga el torneo privado de la ruta y verifica que el usuario
+// sea su creador o administrador. Envía la respuesta de error si no lo es.
+func loadOwnedPrivateTournament(c *gin.Context) (*models.Tournament, bool) {
+	principal := middleware.CurrentPrincipal(c)
+
+	var tournament models.Tournament
+	if err := config.DB.First(&tournament, c.Param("id")).Error; err != nil || !tournament.IsPrivate() {
+		utils.Error(c, http.StatusNotFound, "Torneo privado no encontrado", nil)
+		return nil, false
+	}
+
+	if tournament.CreatedBy != principal.UserID && !principal.IsAdmin() {
+		utils.Error(c, http.StatusForbidden, "Solo el creador del torneo puede ver la invitación", nil)
+		return nil, false
+	}
+
+	return &tournament, true
+}
+
+// assignInviteCode genera un código de invitación que no esté en uso
+func assignInviteCode(tournament *models.Tournament) error {
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := utils.GenerateInviteCode(inviteCodeLength)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		config.DB.Model(&models.Tournament{}).Where("invite_code = ?", code).Count(&count)
+		if count == 0 {
+			tournament.InviteCode = &code
+			return nil
+		}
+	}
+	return errInviteCodeExhausted
+}
+
+// inviteResponse arma la respuesta con el código y enlace de invitación
+func inviteResponse(tournament *models.Tournament) dtos.TournamentInviteResponse {
+	response := dtos.TournamentInviteResponse{TournamentID: tournament.ID}
+	if tournament.InviteCode != nil {
+		response.InviteCode = *tournament.InviteCode
+		response.InviteLink = utils.InviteLink(*tournament.InviteCode)
+	}
+	return response
+}
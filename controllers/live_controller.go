@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+
+	_ "github.com/cesarbmathec/bets-backend/docs"
+)
+
+// UpdateEventLive godoc
+// @Summary      Actualizar el marcador en vivo de un evento
+// @Description  Guarda el marcador parcial, el periodo y los indicadores (marcó primero, etc.) y marca el evento en vivo. No liquida ni asigna puntos.
+// @Tags         admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID del Evento"
+// @Param        request body dtos.LiveUpdateRequest true "Marcador parcial"
+// @Success      200 {object} utils.Response{data=dtos.EventLiveResponse}
+// @Failure      400 {object} utils.Response "Marcador inválido"
+// @Failure      404 {object} utils.Response "Evento no encontrado"
+// @Failure      409 {object} utils.Response "El evento ya terminó o fue cancelado"
+// @Router       /admin/events/{id}/live [put]
+// @example request -json {"period": "Alta del 5to", "competitors": [{"competitor_id": 31, "score": 3, "scored_first": true, "scored_first_inning": true}, {"competitor_id": 32, "score": 1}]}
+func UpdateEventLive(c *gin.Context) {
+	var input dtos.LiveUpdateRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	tx := config.DB.Begin()
+	event, err := services.ApplyLiveUpdate(tx, utils.StringToUint(c.Param("id")), input, time.Now())
+	if err != nil {
+		tx.Rollback()
+		switch {
+		case errors.Is(err, services.ErrEventNotFound):
+			utils.Error(c, http.StatusNotFound, "Evento no encontrado", nil)
+		case errors.Is(err, services.ErrEventNotInPlay):
+			utils.Error(c, http.StatusConflict, err.Error(), nil)
+		case errors.Is(err, services.ErrInvalidResults):
+			utils.Error(c, http.StatusBadRequest, err.Error(), nil)
+		default:
+			utils.Error(c, http.StatusInternalServerError, "Error al actualizar el marcador", err.Error())
+		}
+		return
+	}
+	tx.Commit()
+	services.PublishEventStatus(config.DB, event)
+
+	if err := config.DB.Where("event_id = ?", event.ID).Find(&event.PickableSelections).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener selecciones", err.Error())
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Marcador en vivo actualizado", eventLiveResponse(event))
+}
+
+// GetEventLive godoc
+// @Summary      Marcador en vivo de un evento
+// @Description  Devuelve el marcador parcial y cómo va cada selección (winning, losing, push) si el evento terminara ahora. Es provisional: los puntos solo se asignan al liquidar.
+// @Tags         events
+// @Produce      json
+// @Param        id path int true "ID del Evento"
+// @Success      200 {object} utils.Response{data=dtos.EventLiveResponse}
+// @Failure      404 {object} utils.Response "Evento no encontrado"
+// @Router       /events/{id}/live [get]
+func GetEventLive(c *gin.Context) {
+	var event models.Event
+	if err := config.DB.Preload("Competitors").Preload("PickableSelections").First(&event, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Evento no encontrado", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Marcador en vivo", eventLiveResponse(&event))
+}
+
+// eventLiveResponse arma la respuesta en vivo. Requiere Competitors y PickableSelections.
+func eventLiveResponse(event *models.Event) dtos.EventLiveResponse {
+	response := dtos.EventLiveResponse{
+		EventID:       event.ID,
+		Name:          event.Name,
+		Status:        event.Status,
+		Period:        event.Period,
+		LiveUpdatedAt: event.LiveUpdatedAt,
+		Competitors:   make([]dtos.LiveCompetitorScore, 0, len(event.Competitors)),
+		Selections:    make([]dtos.LiveSelectionStatus, 0, len(event.PickableSelections)),
+	}
+
+	for _, comp := range event.Competitors {
+		response.TotalScore += comp.LiveScore
+		response.Competitors = append(response.Competitors, dtos.LiveCompetitorScore{
+			CompetitorID:      comp.ID,
+			Name:              comp.Name,
+			Score:             comp.LiveScore,
+			Position:          comp.LivePosition,
+			ScoredFirst:       comp.ScoredFirst,
+			ScoredFirstHalf:   comp.ScoredFirstHalf,
+			ScoredSecondHalf:  comp.ScoredSecondHalf,
+			ScoredFirstInning: comp.ScoredFirstInning,
+		})
+	}
+
+	for i := range event.PickableSelections {
+		selection := &event.PickableSelections[i]
+		response.Selections = append(response.Selections, dtos.LiveSelectionStatus{
+			SelectionID:       selection.ID,
+			SelectionType:     selection.SelectionType,
+			Description:       selection.Description,
+			Line:              selection.Line,
+			ProvisionalStatus: services.ProvisionalStatus(event, selection, nil),
+		})
+	}
+
+	return response
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+
+	_ "github.com/cesarbmathec/bets-backend/docs"
+)
+
+// GetMyNotifications godoc
+// @Summary      Mis notificaciones
+// @Description  Bandeja de entrada del usuario, de la más reciente a la más antigua
+// @Tags         notifications
+// @Security     BearerAuth
+// @Produce      json
+// @Param        unread query bool false "Solo no leídas"
+// @Param        limit query int false "Cantidad máxima (por defecto 50)"
+// @Success      200 {object} utils.Response{data=[]models.Notification}
+// @Router       /notifications [get]
+func GetMyNotifications(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at desc, id desc").Limit(limit).Find(&notifications).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener notificaciones", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Tus notificaciones", notifications)
+}
+
+// GetUnreadNotificationCount godoc
+// @Summary      Cantidad de notificaciones sin leer
+// @Tags         notifications
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} utils.Response
+// @Router       /notifications/unread-count [get]
+func GetUnreadNotificationCount(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	var unread int64
+	config.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&unread)
+
+	utils.Success(c, http.StatusOK, "Notificaciones sin leer", gin.H{"unread": unread})
+}
+
+// MarkNotificationRead godoc
+// @Summary      Marcar una notificación como leída
+// @Tags         notifications
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "ID de la notificación"
+// @Success      200 {object} utils.Response{data=models.Notification}
+// @Failure      404 {object} utils.Response "Notificación no encontrada"
+// @Router       /notifications/{id}/read [post]
+func MarkNotificationRead(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	var notification models.Notification
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&notification).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Notificación no encontrada", nil)
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		config.DB.Model(&notification).Update("read_at", now)
+	}
+
+	utils.Success(c, http.StatusOK, "Notificación leída", notification)
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary      Marcar todas mis notificaciones como leídas
+// @Tags         notifications
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} utils.Response
+// @Router       /notifications/read-all [post]
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	result := config.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al marcar notificaciones", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Notificaciones leídas", gin.H{"updated": result.RowsAffected})
+}
+
+// GetNotificationPreferences godoc
+// @Summary      Mis preferencias de notificación
+// @Description  Lista todos los tipos de notificación con su estado; los que nunca se configuraron están activados
+// @Tags         notifications
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} utils.Response{data=[]dtos.NotificationPreferenceItem}
+// @Router       /notifications/preferences [get]
+func GetNotificationPreferences(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+	utils.Success(c, http.StatusOK, "Preferencias de notificación", notificationPreferences(userID))
+}
+
+// UpdateNotificationPreferences godoc
+// @Summary      Cambiar mis preferencias de notificación
+// @Tags         notifications
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body dtos.UpdateNotificationPreferencesRequest true "Tipos a activar o desactivar"
+// @Success      200 {object} utils.Response{data=[]dtos.NotificationPreferenceItem}
+// @Failure      400 {object} utils.Response "Tipo de notificación inválido"
+// @Router       /notifications/preferences [put]
+// @example request -json {"preferences": [{"type": "pick_deadline", "enabled": false}, {"type": "prize_paid", "enabled": true}]}
+func UpdateNotificationPreferences(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	var input dtos.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	for _, item := range input.Preferences {
+		if !models.IsValidNotificationType(item.Type) {
+			utils.Error(c, http.StatusBadRequest, "Tipo de notificación inválido: "+item.Type, models.NotificationTypes)
+			return
+		}
+	}
+
+	tx := config.DB.Begin()
+	for _, item := range input.Preferences {
+		pref := models.NotificationPreference{UserID: userID, Type: item.Type, Enabled: item.Enabled}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+		}).Create(&pref).Error; err != nil {
+			tx.Rollback()
+			utils.Error(c, http.StatusInternalServerError, "Error al guardar preferencias", err.Error())
+			return
+		}
+	}
+	tx.Commit()
+
+	utils.Success(c, http.StatusOK, "Preferencias actualizadas", notificationPreferences(userID))
+}
+
+// notificationPreferences devuelve el estado de cada tipo de notificación para el usuario
+func notificationPreferences(userID uint) []dtos.NotificationPreferenceItem {
+	items := make([]dtos.NotificationPreferenceItem, 0, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		items = append(items, dtos.NotificationPreferenceItem{
+			Type:    notificationType,
+			Enabled: services.NotificationEnabled(config.DB, userID, notificationType),
+		})
+	}
+	return items
+}
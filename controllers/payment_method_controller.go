@@ -5,6 +5,7 @@ import (
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
 	"github.com/cesarbmathec/bets-backend/models"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
@@ -19,7 +20,7 @@ import (
 // @Success     200 {object} utils.Response{data=[]dtos.UserPaymentMethodResponse} "Métodos de pago"
 // @Router      /api/v1/payment-methods [get]
 func GetPaymentMethods(c *gin.Context) {
-	userID := c.GetUint("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 
 	var methods []models.UserPaymentMethod
 	db := config.GetDB()
@@ -61,7 +62,7 @@ func GetPaymentMethods(c *gin.Context) {
 // @Success     201 {object} utils.Response{data=dtos.UserPaymentMethodResponse} "Método de pago creado"
 // @Router      /api/v1/payment-methods [post]
 func CreatePaymentMethod(c *gin.Context) {
-	userID := c.GetUint("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 
 	var input dtos.UserPaymentMethodRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -141,7 +142,7 @@ func CreatePaymentMethod(c *gin.Context) {
 // @Success     200 {object} utils.Response "Método de pago eliminado"
 // @Router      /api/v1/payment-methods/{id} [delete]
 func DeletePaymentMethod(c *gin.Context) {
-	userID := c.GetUint("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 	methodID := c.Param("id")
 
 	db := config.GetDB()
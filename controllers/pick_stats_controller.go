@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+
+	_ "github.com/cesarbmathec/bets-backend/docs"
+)
+
+// GetTrendingPicks godoc
+// @Summary      Selecciones más elegidas
+// @Description  Selecciones más populares del torneo con su porcentaje de participantes. Solo incluye selecciones ya bloqueadas (evento comenzado o sesión cerrada).
+// @Tags         tournaments
+// @Security     BearerAuth
+// @Param        id path int true "ID del Torneo"
+// @Param        session_id query int false "Filtrar por sesión"
+// @Param        limit query int false "Cantidad máxima (por defecto 10)"
+// @Success      200 {object} utils.Response{data=[]dtos.SelectionPopularity}
+// @Router       /tournaments/{id}/stats/trending [get]
+func GetTrendingPicks(c *gin.Context) {
+	tournament, ok := loadVisibleTournament(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+
+	query := config.DB.Preload("Selection.Event").Where("tournament_id = ? AND pick_count > 0", tournament.ID)
+	if sessionID := c.Query("session_id"); sessionID != "" {
+		query = query.Where("session_id = ?", sessionID)
+	}
+
+	var stats []models.SelectionPickStat
+	if err := query.Order("pick_count desc").Find(&stats).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener estadísticas", nil)
+		return
+	}
+
+	lockedStats := lockedPickStats(stats)
+	if len(lockedStats) > limit {
+		lockedStats = lockedStats[:limit]
+	}
+
+	utils.Success(c, http.StatusOK, "Selecciones más elegidas", popularityFromStats(tournament.ID, lockedStats))
+}
+
+// GetUpsetPicks godoc
+// @Summary      Sorpresas del torneo
+// @Description  Selecciones ganadoras que eligió un porcentaje bajo de participantes
+// @Tags         tournaments
+// @Security     BearerAuth
+// @Param        id path int true "ID del Torneo"
+// @Param        max_percentage query number false "Popularidad máxima para considerarla sorpresa (por defecto 25)"
+// @Success      200 {object} utils.Response{data=[]dtos.SelectionPopularity}
+// @Router       /tournaments/{id}/stats/upsets [get]
+func GetUpsetPicks(c *gin.Context) {
+	tournament, ok := loadVisibleTournament(c)
+	if !ok {
+		return
+	}
+
+	maxPercentage, err := strconv.ParseFloat(c.DefaultQuery("max_percentage", "25"), 64)
+	if err != nil || maxPercentage <= 0 {
+		maxPercentage = 25
+	}
+
+	var stats []models.SelectionPickStat
+	if err := config.DB.Preload("Selection").
+		Joins("JOIN pickable_selections ON pickable_selections.id = selection_pick_stats.selection_id").
+		Where("selection_pick_stats.tournament_id = ? AND selection_pick_stats.pick_count > 0 AND pickable_selections.status = ?", tournament.ID, "won").
+		Order("selection_pick_stats.pick_count asc").
+		Find(&stats).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener estadísticas", nil)
+		return
+	}
+
+	upsets := []dtos.SelectionPopularity{}
+	for _, item := range popularityFromStats(tournament.ID, stats) {
+		if item.Percentage <= maxPercentage {
+			upsets = append(upsets, item)
+		}
+	}
+
+	utils.Success(c, http.StatusOK, "Sorpresas del torneo", upsets)
+}
+
+// GetContrarianScores godoc
+// @Summary      Puntaje contrarian de los participantes
+// @Description  Promedio de (100 - popularidad) de los picks liquidados de cada participante. Un puntaje alto indica picks poco populares.
+// @Tags         tournaments
+// @Security     BearerAuth
+// @Param        id path int true "ID del Torneo"
+// @Success      200 {object} utils.Response{data=[]services.ContrarianScore}
+// @Router       /tournaments/{id}/stats/contrarian [get]
+func GetContrarianScores(c *gin.Context) {
+	tournament, ok := loadVisibleTournament(c)
+	if !ok {
+		return
+	}
+
+	scores, err := services.ContrarianScores(config.DB, tournament.ID)
+	if err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al calcular puntajes", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Puntaje contrarian", scores)
+}
+
+// RebuildTournamentPickStats godoc
+// @Summary      Recalcular estadísticas de picks
+// @Description  Reconstruye los contadores de popularidad del torneo a partir de los picks guardados
+// @Tags         admin
+// @Security     BearerAuth
+// @Param        id path int true "ID del Torneo"
+// @Success      200 {object} utils.Response
+// @Router       /admin/tournaments/{id}/stats/rebuild [post]
+func RebuildTournamentPickStats(c *gin.Context) {
+	var tournament models.Tournament
+	if err := config.DB.First(&tournament, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Torneo no encontrado", nil)
+		return
+	}
+
+	tx := config.DB.Begin()
+	if err := services.RebuildPickStats(tx, tournament.ID); err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al recalcular estadísticas", err.Error())
+		return
+	}
+	tx.Commit()
+
+	utils.Success(c, http.StatusOK, "Estadísticas recalculadas", nil)
+}
+
+// lockedPickStats filtra las estadísticas de selecciones ya bloqueadas (requiere Selection.Event)
+func lockedPickStats(stats []models.SelectionPickStat) []models.SelectionPickStat {
+	sessionEnds := make(map[uint]time.Time)
+	now := time.Now()
+
+	var locked []models.SelectionPickStat
+	for _, stat := range stats {
+		end, found := sessionEnds[stat.SessionID]
+		if !found {
+			var session models.Session
+			config.DB.Select("end_time").First(&session, stat.SessionID)
+			end = session.EndTime
+			sessionEnds[stat.SessionID] = end
+		}
+
+		if !now.Before(end) || stat.Selection.Event.HasStarted(now) {
+			locked = append(locked, stat)
+		}
+	}
+	return locked
+}
+
+// popularityFromStats arma la respuesta de popularidad sobre el total de participantes del torneo,
+// conservando el orden de stats
+func popularityFromStats(tournamentID uint, stats []models.SelectionPickStat) []dtos.SelectionPopularity {
+	var participants int64
+	config.DB.Model(&models.TournamentParticipant{}).Where("tournament_id = ?", tournamentID).Count(&participants)
+
+	result := make([]dtos.SelectionPopularity, 0, len(stats))
+	for _, stat := range stats {
+		result = append(result, dtos.SelectionPopularity{
+			SelectionID:   stat.SelectionID,
+			EventID:       stat.EventID,
+			Description:   stat.Selection.Description,
+			SelectionType: stat.Selection.SelectionType,
+			PickCount:     int64(stat.PickCount),
+			Percentage:    services.PickPercentage(stat.PickCount, participants),
+			Status:        stat.Selection.Status,
+		})
+	}
+	return result
+}
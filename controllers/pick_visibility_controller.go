@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	_ "github.com/cesarbmathec/bets-backend/docs"
+)
+
+// GetParticipantCard godoc
+// @Summary      Ver la cartilla de un participante
+// @Description  Muestra los picks de un participante por sesión. Los picks de otros usuarios se ocultan hasta la hora límite de la sesión o hasta que comience su evento.
+// @Tags         tournaments
+// @Security     BearerAuth
+// @Param        id path int true "ID del Torneo"
+// @Param        participant_id path int true "ID del Participante"
+// @Success      200 {object} utils.Response{data=dtos.ParticipantCardResponse}
+// @Failure      403 {object} utils.Response "Torneo privado"
+// @Failure      404 {object} utils.Response "Participante no encontrado"
+// @Router       /tournaments/{id}/participants/{participant_id}/card [get]
+func GetParticipantCard(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	tournament, ok := loadVisibleTournament(c)
+	if !ok {
+		return
+	}
+
+	var participant models.TournamentParticipant
+	if err := config.DB.Preload("User").
+		Where("id = ? AND tournament_id = ?", c.Param("participant_id"), tournament.ID).
+		First(&participant).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Participante no encontrado", nil)
+		return
+	}
+
+	var picks []models.UserPick
+	if err := config.DB.Preload("Session").Preload("Selection.Event").
+		Where("participant_id = ?", participant.ID).
+		Order("session_id asc, id asc").
+		Find(&picks).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener predicciones", nil)
+		return
+	}
+
+	now := time.Now()
+	isOwner := participant.UserID == userID
+
+	card := dtos.ParticipantCardResponse{
+		ParticipantID: participant.ID,
+		Username:      participant.User.Username,
+		TotalPoints:   participant.TotalPoints,
+		Sessions:      []dtos.SessionCard{},
+	}
+
+	sessionIndex := make(map[uint]int)
+	for i := range picks {
+		pick := &picks[i]
+
+		idx, found := sessionIndex[pick.SessionID]
+		if !found {
+			card.Sessions = append(card.Sessions, dtos.SessionCard{
+				SessionID:     pick.SessionID,
+				SessionNumber: pick.Session.SessionNumber,
+				EndTime:       pick.Session.EndTime,
+				Revealed:      !now.Before(pick.Session.EndTime),
+				Picks:         []dtos.CardPick{},
+			})
+			idx = len(card.Sessions) - 1
+			sessionIndex[pick.SessionID] = idx
+		}
+
+		if !isOwner && !pick.IsRevealed(now) {
+			card.Sessions[idx].HiddenCount++
+			continue
+		}
+
+		card.Sessions[idx].Picks = append(card.Sessions[idx].Picks, dtos.CardPick{
+			PickID:        pick.ID,
+			SelectionID:   pick.SelectionID,
+			EventID:       pick.Selection.EventID,
+			EventName:     pick.Selection.Event.Name,
+			Description:   pick.Selection.Description,
+			SelectionType: pick.Selection.SelectionType,
+			Status:        pick.Status,
+			AwardedPoints: pick.AwardedPoints,
+		})
+	}
+
+	utils.Success(c, http.StatusOK, "Cartilla del participante", card)
+}
+
+// GetSessionPickPopularity godoc
+// @Summary      Popularidad de selecciones en una sesión
+// @Description  Porcentaje de participantes del torneo que eligió cada selección. Solo incluye selecciones cuyo evento ya comenzó o cuya sesión ya cerró.
+// @Tags         tournaments
+// @Security     BearerAuth
+// @Param        id path int true "ID del Torneo"
+// @Param        session_id path int true "ID de la Sesión"
+// @Success      200 {object} utils.Response{data=[]dtos.SelectionPopularity}
+// @Failure      404 {object} utils.Response "Sesión no encontrada"
+// @Router       /tournaments/{id}/sessions/{session_id}/popularity [get]
+func GetSessionPickPopularity(c *gin.Context) {
+	tournament, ok := loadVisibleTournament(c)
+	if !ok {
+		return
+	}
+
+	var session models.Session
+	if err := config.DB.Where("id = ? AND tournament_id = ?", c.Param("session_id"), tournament.SlateTournamentID()).
+		First(&session).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Sesión no encontrada", nil)
+		return
+	}
+
+	// Participantes del torneo con cartilla en la sesión
+	participantsWithPicks := config.DB.Model(&models.UserPick{}).
+		Joins("JOIN tournament_participants tp ON tp.id = user_picks.participant_id").
+		Where("tp.tournament_id = ? AND user_picks.session_id = ?", tournament.ID, session.ID)
+
+	var totalParticipants int64
+	participantsWithPicks.Session(&gorm.Session{}).Distinct("user_picks.participant_id").Count(&totalParticipants)
+
+	// Una sola consulta agrupada con los datos de la selección y de su evento (para el bloqueo)
+	type selectionCount struct {
+		SelectionID    uint
+		EventID        uint
+		Description    string
+		SelectionType  string
+		EventStartTime time.Time
+		EventStatus    string
+		PickCount      int64
+	}
+	var counts []selectionCount
+	if err := participantsWithPicks.Session(&gorm.Session{}).
+		Joins("JOIN pickable_selections ps ON ps.id = user_picks.selection_id").
+		Joins("JOIN events e ON e.id = ps.event_id").
+		Select("ps.id AS selection_id, ps.event_id, ps.description, ps.selection_type, " +
+			"e.start_time AS event_start_time, e.status AS event_status, COUNT(*) AS pick_count").
+		Group("ps.id, ps.event_id, ps.description, ps.selection_type, e.start_time, e.status").
+		Order("pick_count DESC, ps.id").
+		Scan(&counts).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al calcular popularidad", nil)
+		return
+	}
+
+	now := time.Now()
+	sessionLocked := !now.Before(session.EndTime)
+
+	popularity := []dtos.SelectionPopularity{}
+	for _, count := range counts {
+		// Mientras la selección siga editable su popularidad es privada
+		event := models.Event{StartTime: count.EventStartTime, Status: count.EventStatus}
+		if !sessionLocked && !event.HasStarted(now) {
+			continue
+		}
+
+		popularity = append(popularity, dtos.SelectionPopularity{
+			SelectionID:   count.SelectionID,
+			EventID:       count.EventID,
+			Description:   count.Description,
+			SelectionType: count.SelectionType,
+			PickCount:     count.PickCount,
+			Percentage:    float64(count.PickCount) * 100 / float64(totalParticipants),
+		})
+	}
+
+	utils.Success(c, http.StatusOK, "Popularidad de selecciones", popularity)
+}
+
+// loadVisibleTournament carga el torneo de la ruta. Los torneos privados solo son visibles
+// para sus participantes y administradores. Envía la respuesta de error si no es visible.
+func loadVisibleTournament(c *gin.Context) (*models.Tournament, bool) {
+	principal := middleware.CurrentPrincipal(c)
+
+	var tournament models.Tournament
+	if err := config.DB.First(&tournament, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Torneo no encontrado", nil)
+		return nil, false
+	}
+
+	if !services.CanViewTournament(config.DB, principal, &tournament) {
+		utils.Error(c, http.StatusForbidden, "Solo los participantes pueden ver este torneo privado", nil)
+		return nil, false
+	}
+
+	return &tournament, true
+}
@@ -1,11 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
 	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
 
@@ -20,6 +24,7 @@ import (
 // @Produce      json
 // @Param        request body dtos.CreateSelectionRequest true "Datos de la selección"
 // @Success      201 {object} utils.Response{data=models.PickableSelection}
+// @Failure      400 {object} utils.Response "Tipo de selección no permitido por la categoría"
 // @Router       /admin/events/selections [post]
 // @Security     BearerAuth
 func CreateSelection(c *gin.Context) {
@@ -36,15 +41,25 @@ func CreateSelection(c *gin.Context) {
 		return
 	}
 
+	// El tipo debe estar permitido por la categoría de los torneos que incluyen el evento
+	if err := services.ValidateSelectionTypeForEvent(config.DB, event.ID, input.SelectionType); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Tipo de selección no permitido", err.Error())
+		return
+	}
+
 	selection := models.PickableSelection{
-		EventID:       input.EventID,
-		Description:   input.Description,
-		SelectionType: input.SelectionType,
-		Line:          input.Line,
-		CompetitorID:  input.CompetitorID,
-		PointsForWin:  input.PointsForWin,
-		PointsForPush: input.PointsForPush,
-		Status:        "pending",
+		EventID:        input.EventID,
+		Description:    input.Description,
+		SelectionType:  input.SelectionType,
+		Line:           input.Line,
+		RunlineHome:    input.RunlineHome,
+		RunlineAway:    input.RunlineAway,
+		IsSuperRunline: input.IsSuperRunline,
+		Odds:           input.Odds,
+		CompetitorID:   input.CompetitorID,
+		PointsForWin:   input.PointsForWin,
+		PointsForPush:  input.PointsForPush,
+		Status:         "pending",
 	}
 
 	if err := config.DB.Create(&selection).Error; err != nil {
@@ -70,3 +85,240 @@ func GetEventSelections(c *gin.Context) {
 
 	utils.Success(c, http.StatusOK, "Opciones disponibles", selections)
 }
+
+// GenerateEventSelections godoc
+// @Summary      Generar selecciones estándar de un evento
+// @Description  Crea macho, hembra, RL, SRL, alta/baja, super alta/baja y empate a partir de los competidores (favorito, runline, super runline), la línea del evento y la super línea de la sesión, según los tipos de la categoría del torneo. Los tipos indicados en selection_types deben estar permitidos por las categorías de los torneos del evento; sin tournament_id, los tipos estándar no permitidos se omiten (skipped). Es idempotente: volver a ejecutarlo actualiza las selecciones pendientes en lugar de duplicarlas.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        event_id path int true "ID del Evento"
+// @Param        request body dtos.GenerateSelectionsRequest true "Opciones de generación"
+// @Success      200 {object} utils.Response{data=services.GenerateResult}
+// @Failure      400 {object} utils.Response "Tipo de selección no permitido por la categoría"
+// @Failure      404 {object} utils.Response "Evento no encontrado"
+// @Router       /admin/events/{event_id}/selections/generate [post]
+// @Security     BearerAuth
+// @example request -json {"tournament_id": 1, "points_for_win": 1}
+func GenerateEventSelections(c *gin.Context) {
+	var input dtos.GenerateSelectionsRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	var event models.Event
+	if err := config.DB.Preload("Competitors").First(&event, c.Param("event_id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Evento no encontrado", nil)
+		return
+	}
+
+	userID := middleware.CurrentPrincipal(c).UserID
+	changedBy := userID
+
+	opts := services.GenerateOptions{
+		SelectionTypes: input.SelectionTypes,
+		DefaultPoints:  input.PointsForWin,
+		PointsForPush:  input.PointsForPush,
+		ChangedBy:      &changedBy,
+	}
+	if opts.DefaultPoints == 0 {
+		opts.DefaultPoints = 1
+	}
+
+	// El torneo aporta los tipos de su categoría, la super línea de la sesión y los puntos por tipo
+	if input.TournamentID != nil {
+		var tournament models.Tournament
+		if err := config.DB.First(&tournament, *input.TournamentID).Error; err != nil {
+			utils.Error(c, http.StatusNotFound, "Torneo no encontrado", nil)
+			return
+		}
+		opts.PointsByType = tournament.Settings.PointsBySelectionType
+
+		if category, err := services.TournamentCategory(config.DB, &tournament); err == nil && len(opts.SelectionTypes) == 0 {
+			for _, t := range category.SelectionTypes {
+				opts.SelectionTypes = append(opts.SelectionTypes, t.SelectionType)
+			}
+		}
+
+		var tournamentEvent models.TournamentEvent
+		if err := config.DB.Preload("Session").
+			Where("event_id = ? AND tournament_id = ? AND session_id IS NOT NULL", event.ID, tournament.SlateTournamentID()).
+			First(&tournamentEvent).Error; err == nil {
+			opts.SuperLine = tournamentEvent.Session.SuperLine
+		}
+	}
+	if input.SuperLine != nil {
+		opts.SuperLine = *input.SuperLine
+	}
+
+	// Los tipos pedidos explícitamente, o los estándar cuando no hay torneo que los limite, deben estar
+	// permitidos por las categorías de los torneos del evento. Los estándar no permitidos se omiten.
+	var disallowed []string
+	if len(input.SelectionTypes) > 0 || input.TournamentID == nil {
+		candidates := opts.SelectionTypes
+		if len(candidates) == 0 {
+			candidates = services.StandardSelectionTypes
+		}
+		allowed := make([]string, 0, len(candidates))
+		for _, selectionType := range candidates {
+			err := services.ValidateSelectionTypeForEvent(config.DB, event.ID, selectionType)
+			switch {
+			case errors.Is(err, services.ErrSelectionTypeNotAllowed) && len(input.SelectionTypes) > 0:
+				utils.Error(c, http.StatusBadRequest, "Tipo de selección no permitido", err.Error())
+				return
+			case errors.Is(err, services.ErrSelectionTypeNotAllowed):
+				disallowed = append(disallowed, selectionType)
+			case err != nil:
+				utils.Error(c, http.StatusInternalServerError, "Error al validar los tipos de selección", err.Error())
+				return
+			default:
+				allowed = append(allowed, selectionType)
+			}
+		}
+		if len(allowed) == 0 {
+			utils.Error(c, http.StatusBadRequest, "Ningún tipo de selección está permitido para este evento", disallowed)
+			return
+		}
+		opts.SelectionTypes = allowed
+	}
+
+	tx := config.DB.Begin()
+	result, err := services.GenerateStandardSelections(tx, &event, opts)
+	if err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al generar selecciones", err.Error())
+		return
+	}
+	tx.Commit()
+	result.Skipped = append(result.Skipped, disallowed...)
+
+	utils.Success(c, http.StatusOK, "Selecciones generadas", result)
+}
+
+// UpdateSelection godoc
+// @Summary      Actualizar una selección
+// @Description  Cambia línea, cuota, runline o puntos de una selección pendiente. Los cambios de línea, cuota y runline (local y visitante) quedan en el historial; los picks existentes se liquidan con los valores vigentes cuando se hicieron.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID de la Selección"
+// @Param        request body dtos.UpdateSelectionRequest true "Campos a actualizar"
+// @Success      200 {object} utils.Response{data=models.PickableSelection}
+// @Failure      400 {object} utils.Response "La selección ya fue liquidada"
+// @Failure      404 {object} utils.Response "Selección no encontrada"
+// @Router       /admin/events/selections/{id} [put]
+// @Security     BearerAuth
+// @example request -json {"line": 8.5, "odds": -120}
+func UpdateSelection(c *gin.Context) {
+	var input dtos.UpdateSelectionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	var selection models.PickableSelection
+	if err := config.DB.First(&selection, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Selección no encontrada", nil)
+		return
+	}
+
+	if selection.Status != "pending" {
+		utils.Error(c, http.StatusBadRequest, "La selección ya fue liquidada", nil)
+		return
+	}
+
+	previous := selection
+	if input.Description != nil {
+		selection.Description = *input.Description
+	}
+	if input.Line != nil {
+		selection.Line = *input.Line
+	}
+	if input.RunlineHome != nil {
+		selection.RunlineHome = *input.RunlineHome
+	}
+	if input.RunlineAway != nil {
+		selection.RunlineAway = *input.RunlineAway
+	}
+	if input.Odds != nil {
+		selection.Odds = *input.Odds
+	}
+	if input.PointsForWin != nil {
+		selection.PointsForWin = *input.PointsForWin
+	}
+	if input.PointsForPush != nil {
+		selection.PointsForPush = *input.PointsForPush
+	}
+	if input.IsSuperRunline != nil {
+		selection.IsSuperRunline = *input.IsSuperRunline
+	}
+
+	userID := middleware.CurrentPrincipal(c).UserID
+	changedBy := userID
+
+	tx := config.DB.Begin()
+	if err := tx.Save(&selection).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al actualizar la selección", err.Error())
+		return
+	}
+
+	if err := services.RecordLineChanges(tx, selection.EventID, &changedBy,
+		services.LineChange{EntityType: models.LineEntitySelection, EntityID: selection.ID, Field: models.LineFieldLine, OldValue: previous.Line, NewValue: selection.Line},
+		services.LineChange{EntityType: models.LineEntitySelection, EntityID: selection.ID, Field: models.LineFieldOdds, OldValue: float64(previous.Odds), NewValue: float64(selection.Odds)},
+		services.LineChange{EntityType: models.LineEntitySelection, EntityID: selection.ID, Field: models.LineFieldRunline, OldValue: previous.RunlineHome, NewValue: selection.RunlineHome},
+		services.LineChange{EntityType: models.LineEntitySelection, EntityID: selection.ID, Field: models.LineFieldRunlineAway, OldValue: previous.RunlineAway, NewValue: selection.RunlineAway},
+	); err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al registrar historial de línea", err.Error())
+		return
+	}
+	tx.Commit()
+
+	utils.Success(c, http.StatusOK, "Selección actualizada", selection)
+}
+
+// GetSelectionPoints godoc
+// @Summary      Puntos de las selecciones en el torneo
+// @Description  Lista cuánto vale acertar cada selección pendiente de la cartilla según el modo de puntuación del torneo. En modo "odds" se calcula con la cuota actual; las selecciones son compartidas entre torneos, así que points_for_win no se modifica. Al liquidar se usa la cuota guardada en cada pick.
+// @Tags         admin
+// @Security     BearerAuth
+// @Param        id path int true "ID del Torneo"
+// @Success      200 {object} utils.Response{data=[]dtos.SelectionPointsResponse}
+// @Failure      404 {object} utils.Response "Torneo no encontrado"
+// @Router       /admin/tournaments/{id}/selection-points [get]
+func GetSelectionPoints(c *gin.Context) {
+	var tournament models.Tournament
+	if err := config.DB.First(&tournament, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Torneo no encontrado", nil)
+		return
+	}
+
+	eventIDs := config.DB.Model(&models.TournamentEvent{}).Select("event_id").
+		Where("tournament_id = ?", tournament.SlateTournamentID())
+
+	var selections []models.PickableSelection
+	if err := config.DB.Preload("Event").
+		Where("event_id IN (?) AND status = ?", eventIDs, "pending").
+		Order("event_id, id").
+		Find(&selections).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener selecciones", nil)
+		return
+	}
+
+	now := time.Now()
+	response := make([]dtos.SelectionPointsResponse, 0, len(selections))
+	for i := range selections {
+		response = append(response, dtos.SelectionPointsResponse{
+			SelectionID:   selections[i].ID,
+			EventID:       selections[i].EventID,
+			SelectionType: selections[i].SelectionType,
+			Odds:          selections[i].Odds,
+			PointsForWin:  tournament.Settings.WinPoints(&selections[i]),
+			Locked:        selections[i].Event.HasStarted(now),
+		})
+	}
+
+	utils.Success(c, http.StatusOK, "Puntos por selección", response)
+}
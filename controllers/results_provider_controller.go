@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	_ "github.com/cesarbmathec/bets-backend/docs"
+)
+
+// CreateEventProviderMapping godoc
+// @Summary      Enlazar un evento con un proveedor de resultados
+// @Description  Registra el ID del evento en un proveedor externo para que el poller consulte su resultado
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        event_id path int true "ID del Evento"
+// @Param        request body dtos.ProviderMappingRequest true "Proveedor e ID externo"
+// @Success      201 {object} utils.Response{data=models.EventProviderMapping}
+// @Failure      404 {object} utils.Response "Evento no encontrado"
+// @Failure      409 {object} utils.Response "El ID externo ya está enlazado"
+// @Router       /admin/events/{event_id}/provider-mappings [post]
+// @Security     BearerAuth
+// @example request -json {"provider": "http", "external_id": "mlb-2026-10-20-nyy-bos"}
+func CreateEventProviderMapping(c *gin.Context) {
+	var input dtos.ProviderMappingRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	var event models.Event
+	if err := config.DB.First(&event, c.Param("event_id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Evento no encontrado", nil)
+		return
+	}
+
+	mapping := models.EventProviderMapping{EventID: event.ID, Provider: input.Provider, ExternalID: input.ExternalID}
+	if err := config.DB.Create(&mapping).Error; err != nil {
+		utils.Error(c, http.StatusConflict, "El ID externo ya está enlazado a un evento", err.Error())
+		return
+	}
+
+	utils.Success(c, http.StatusCreated, "Evento enlazado con el proveedor", mapping)
+}
+
+// GetEventProviderMappings godoc
+// @Summary      Proveedores de resultados de un evento
+// @Tags         admin
+// @Produce      json
+// @Param        event_id path int true "ID del Evento"
+// @Success      200 {object} utils.Response{data=[]models.EventProviderMapping}
+// @Router       /admin/events/{event_id}/provider-mappings [get]
+// @Security     BearerAuth
+func GetEventProviderMappings(c *gin.Context) {
+	var mappings []models.EventProviderMapping
+	config.DB.Where("event_id = ?", c.Param("event_id")).Order("provider").Find(&mappings)
+
+	utils.Success(c, http.StatusOK, "Proveedores del evento", mappings)
+}
+
+// DeleteEventProviderMapping godoc
+// @Summary      Quitar el enlace de un evento con un proveedor
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "ID del enlace"
+// @Success      200 {object} utils.Response
+// @Failure      404 {object} utils.Response "Enlace no encontrado"
+// @Router       /admin/provider-mappings/{id} [delete]
+// @Security     BearerAuth
+func DeleteEventProviderMapping(c *gin.Context) {
+	var mapping models.EventProviderMapping
+	if err := config.DB.First(&mapping, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Enlace no encontrado", nil)
+		return
+	}
+
+	// Borrado definitivo para poder volver a enlazar el mismo ID externo
+	if err := config.DB.Unscoped().Delete(&mapping).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al quitar el enlace", err.Error())
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Enlace eliminado", nil)
+}
+
+// GetResultProposals godoc
+// @Summary      Listar propuestas de resultados
+// @Description  Resultados obtenidos de los proveedores. Por defecto solo los pendientes de confirmación.
+// @Tags         admin
+// @Produce      json
+// @Param        status query string false "Estado (pending, approved, rejected, auto_settled, superseded, all)"
+// @Param        event_id query int false "Filtrar por evento"
+// @Success      200 {object} utils.Response{data=[]models.ResultProposal}
+// @Router       /admin/result-proposals [get]
+// @Security     BearerAuth
+func GetResultProposals(c *gin.Context) {
+	status := c.DefaultQuery("status", models.ProposalStatusPending)
+
+	query := config.DB.Order("created_at desc")
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if eventID := c.Query("event_id"); eventID != "" {
+		query = query.Where("event_id = ?", eventID)
+	}
+
+	var proposals []models.ResultProposal
+	if err := query.Find(&proposals).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener propuestas", err.Error())
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Propuestas de resultados", proposals)
+}
+
+// ApproveResultProposal godoc
+// @Summary      Aprobar una propuesta de resultado
+// @Description  Liquida el evento con el resultado propuesto por el proveedor. Las demás propuestas pendientes del evento quedan reemplazadas.
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "ID de la propuesta"
+// @Success      200 {object} utils.Response{data=services.EventSettlement}
+// @Failure      404 {object} utils.Response "Propuesta no encontrada"
+// @Failure      409 {object} utils.Response "La propuesta ya fue revisada o el evento ya está liquidado"
+// @Router       /admin/result-proposals/{id}/approve [post]
+// @Security     BearerAuth
+func ApproveResultProposal(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	tx := config.DB.Begin()
+	settlement, err := services.ApproveProposal(tx, utils.StringToUint(c.Param("id")), userID)
+	if err != nil {
+		tx.Rollback()
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.Error(c, http.StatusNotFound, "Propuesta no encontrada", nil)
+		case errors.Is(err, services.ErrProposalNotPending), errors.Is(err, services.ErrEventAlreadySettled), errors.Is(err, services.ErrInvalidResults):
+			utils.Error(c, http.StatusConflict, err.Error(), nil)
+		default:
+			utils.Error(c, http.StatusInternalServerError, "Error al liquidar el evento", err.Error())
+		}
+		return
+	}
+
+	tx.Commit()
+	services.PublishEventSettled(config.DB, settlement)
+	services.NotifyPicksSettled(config.DB, settlement)
+	services.EmitEventSettled(config.DB, settlement)
+	utils.Success(c, http.StatusOK, "Resultado aprobado y evento liquidado", settlement)
+}
+
+// RejectResultProposal godoc
+// @Summary      Rechazar una propuesta de resultado
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID de la propuesta"
+// @Param        request body dtos.ReviewProposalRequest false "Motivo"
+// @Success      200 {object} utils.Response{data=models.ResultProposal}
+// @Failure      404 {object} utils.Response "Propuesta no encontrada"
+// @Failure      409 {object} utils.Response "La propuesta ya fue revisada"
+// @Router       /admin/result-proposals/{id}/reject [post]
+// @Security     BearerAuth
+func RejectResultProposal(c *gin.Context) {
+	var input dtos.ReviewProposalRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+			return
+		}
+	}
+
+	userID := middleware.CurrentPrincipal(c).UserID
+	proposal, err := services.RejectProposal(config.DB, utils.StringToUint(c.Param("id")), userID, input.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.Error(c, http.StatusNotFound, "Propuesta no encontrada", nil)
+		case errors.Is(err, services.ErrProposalNotPending):
+			utils.Error(c, http.StatusConflict, err.Error(), nil)
+		default:
+			utils.Error(c, http.StatusInternalServerError, "Error al rechazar la propuesta", err.Error())
+		}
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Propuesta rechazada", proposal)
+}
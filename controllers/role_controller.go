@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// roleError traduce los errores de la gestión de roles a respuestas HTTP
+func roleError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrUnknownPermission):
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+	case errors.Is(err, services.ErrRoleEscalation):
+		utils.Error(c, http.StatusForbidden, "No puede otorgar permisos que usted no tiene", nil)
+	case errors.Is(err, services.ErrSystemRole), errors.Is(err, services.ErrRoleInUse):
+		utils.Error(c, http.StatusConflict, err.Error(), nil)
+	default:
+		utils.Error(c, http.StatusInternalServerError, fallback, nil)
+	}
+}
+
+// GetPermissions godoc
+// @Summary      Listar permisos
+// @Description  Permisos que se pueden otorgar a los roles
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} utils.Response{data=[]dtos.PermissionResponse}
+// @Router       /admin/permissions [get]
+// @Security     BearerAuth
+func GetPermissions(c *gin.Context) {
+	codes := services.AllPermissionCodes()
+	permissions := make([]dtos.PermissionResponse, len(codes))
+	for i, code := range codes {
+		permissions[i] = dtos.PermissionResponse{Code: code, Description: models.PermissionDescriptions[code]}
+	}
+
+	utils.Success(c, http.StatusOK, "Permisos disponibles", permissions)
+}
+
+// GetRoles godoc
+// @Summary      Listar roles
+// @Description  Roles con sus permisos. admin y user son del sistema y no se pueden editar.
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} utils.Response{data=[]models.Role}
+// @Router       /admin/roles [get]
+// @Security     BearerAuth
+func GetRoles(c *gin.Context) {
+	var roles []models.Role
+	config.DB.Preload("Permissions").Order("id").Find(&roles)
+
+	utils.Success(c, http.StatusOK, "Roles", roles)
+}
+
+// CreateRole godoc
+// @Summary      Crear un rol
+// @Description  Crea un rol de operador. Solo se pueden otorgar permisos que el actor tenga.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request body dtos.CreateRoleRequest true "Nombre y permisos"
+// @Success      201 {object} utils.Response{data=models.Role}
+// @Failure      400 {object} utils.Response "Datos inválidos o permiso desconocido"
+// @Failure      403 {object} utils.Response "Permisos que el actor no tiene"
+// @Failure      409 {object} utils.Response "Ya existe un rol con ese nombre"
+// @Router       /admin/roles [post]
+// @Security     BearerAuth
+// @example request -json {"name": "cashier", "description": "Caja: solo retiros", "permissions": ["withdrawals:read", "withdrawals:approve"]}
+func CreateRole(c *gin.Context) {
+	var input dtos.CreateRoleRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	var existing int64
+	config.DB.Model(&models.Role{}).Where("name = ?", input.Name).Count(&existing)
+	if existing > 0 {
+		utils.Error(c, http.StatusConflict, "Ya existe un rol con ese nombre", nil)
+		return
+	}
+
+	role, err := services.CreateRole(config.DB, middleware.CurrentPrincipal(c), input.Name, input.Description, input.Permissions)
+	if err != nil {
+		roleError(c, err, "No se pudo crear el rol")
+		return
+	}
+
+	utils.Success(c, http.StatusCreated, "Rol creado", role)
+}
+
+// UpdateRole godoc
+// @Summary      Actualizar un rol
+// @Description  Reemplaza los permisos de un rol de operador. Los usuarios con el rol ven el cambio en su siguiente petición.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID del rol"
+// @Param        request body dtos.UpdateRoleRequest true "Permisos"
+// @Success      200 {object} utils.Response{data=models.Role}
+// @Failure      404 {object} utils.Response "Rol no encontrado"
+// @Failure      409 {object} utils.Response "Rol del sistema"
+// @Router       /admin/roles/{id} [put]
+// @Security     BearerAuth
+// @example request -json {"permissions": ["events:read", "events:settle"]}
+func UpdateRole(c *gin.Context) {
+	var input dtos.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	var role models.Role
+	if err := config.DB.First(&role, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Rol no encontrado", nil)
+		return
+	}
+
+	if err := services.UpdateRolePermissions(config.DB, middleware.CurrentPrincipal(c), &role, input.Description, input.Permissions); err != nil {
+		roleError(c, err, "No se pudo actualizar el rol")
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Rol actualizado", role)
+}
+
+// DeleteRole godoc
+// @Summary      Eliminar un rol
+// @Description  Solo roles de operador que ningún usuario tenga asignado
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "ID del rol"
+// @Success      200 {object} utils.Response
+// @Failure      404 {object} utils.Response "Rol no encontrado"
+// @Failure      409 {object} utils.Response "Rol del sistema o asignado a usuarios"
+// @Router       /admin/roles/{id} [delete]
+// @Security     BearerAuth
+func DeleteRole(c *gin.Context) {
+	var role models.Role
+	if err := config.DB.First(&role, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Rol no encontrado", nil)
+		return
+	}
+
+	if err := services.DeleteRole(config.DB, &role); err != nil {
+		roleError(c, err, "No se pudo eliminar el rol")
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Rol eliminado", nil)
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
 	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
 
@@ -51,7 +53,8 @@ func CreateSession(c *gin.Context) {
 		StartTime:     input.StartTime,
 		EndTime:       input.EndTime,
 		Description:   input.Description,
-		Status:        "open",
+		SuperLine:     input.SuperLine,
+		Status:        models.InitialSessionStatus(input.StartTime),
 	}
 
 	if err := config.DB.Create(&session).Error; err != nil {
@@ -69,9 +72,16 @@ func CreateSession(c *gin.Context) {
 // @Param        id path int true "ID del Torneo"
 // @Produce      json
 // @Success      200 {object} utils.Response{data=[]models.Session}
+// @Failure      403 {object} utils.Response "Torneo privado"
+// @Failure      404 {object} utils.Response "Torneo no encontrado"
 // @Router       /tournaments/{id}/sessions [get]
 func GetTournamentSessions(c *gin.Context) {
-	tournamentID := c.Param("id")
+	tournament, ok := loadVisibleTournament(c)
+	if !ok {
+		return
+	}
+	// Las ligas de usuarios comparten las sesiones de su torneo base
+	tournamentID := tournament.SlateTournamentID()
 	var sessions []models.Session
 
 	// Cargar sesiones del torneo (sin preload de Events - ahora via TournamentEvent)
@@ -129,11 +139,12 @@ func GetSessionByID(c *gin.Context) {
 
 // UpdateSessionStatus godoc
 // @Summary      Actualizar estado de una sesión
-// @Description  Cambia el estado de una sesión (open, closed, settled)
+// @Description  Cambia el estado de una sesión siguiendo el ciclo scheduled → open → closed → settled. Para liquidar, todos los eventos deben estar completados. Cada cambio queda en el historial.
 // @Tags         admin
 // @Param        id path int true "ID de la Sesión"
 // @Param        request body dtos.UpdateSessionStatusRequest true "Nuevo estado"
 // @Success      200 {object} utils.Response
+// @Failure      409 {object} utils.Response "Transición no permitida"
 // @Router       /admin/sessions/{id}/status [patch]
 // @Security     BearerAuth
 func UpdateSessionStatus(c *gin.Context) {
@@ -151,27 +162,52 @@ func UpdateSessionStatus(c *gin.Context) {
 		return
 	}
 
-	session.Status = input.Status
-	if err := config.DB.Save(&session).Error; err != nil {
-		utils.Error(c, http.StatusInternalServerError, "Error al actualizar estado", nil)
+	userID := middleware.CurrentPrincipal(c).UserID
+	actorID := userID
+
+	tx := config.DB.Begin()
+	if err := services.TransitionSession(tx, &session, input.Status, &actorID, input.Note); err != nil {
+		tx.Rollback()
+		if transitionRejected(err) {
+			utils.Error(c, http.StatusConflict, "No se puede cambiar el estado de la sesión", err.Error())
+		} else {
+			utils.Error(c, http.StatusInternalServerError, "Error al cambiar el estado de la sesión", nil)
+		}
 		return
 	}
+	tx.Commit()
+	services.PublishSessionStatus(config.DB, &session)
+	services.EmitSessionOpened(config.DB, &session)
 
 	utils.Success(c, http.StatusOK, "Estado de sesión actualizado", session)
 }
 
-// SubmitPicks godoc
+// GetSessionStatusHistory godoc
+// @Summary      Historial de estados de la sesión
+// @Description  Lista los cambios de estado de la sesión con el actor y la fecha
+// @Tags         admin
+// @Param        id path int true "ID de la Sesión"
+// @Produce      json
+// @Success      200 {object} utils.Response{data=[]models.StatusTransition}
+// @Router       /admin/sessions/{id}/history [get]
+// @Security     BearerAuth
+func GetSessionStatusHistory(c *gin.Context) {
+	getStatusHistory(c, models.EntityTypeSession)
+}
+
+// SubmitPicksBySession godoc
 // @Summary      Enviar predicciones para una sesión
-// @Description  Permite a un participante enviar sus selecciones para una sesión específica
+// @Description  Envía la cartilla completa de la sesión: reemplaza los picks anteriores. Los picks sobre eventos ya comenzados están congelados y deben incluirse.
 // @Tags         users
 // @Security     ApiKeyAuth
 // @Param        id path int true "ID del Torneo"
 // @Param        request body dtos.SubmitPicksBySessionRequest true "Datos de las predicciones"
 // @Success      201 {object} utils.Response
+// @Failure      422 {object} utils.Response{errors=[]services.RuleViolation} "Violaciones de reglas de la cartilla"
 // @Router       /tournaments/{id}/sessions/picks [post]
 func SubmitPicksBySession(c *gin.Context) {
 	tournamentID := c.Param("id")
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 
 	var input dtos.SubmitPicksBySessionRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -197,13 +233,23 @@ func SubmitPicksBySession(c *gin.Context) {
 		return
 	}
 
-	if session.TournamentID != utils.StringToUint(tournamentID) {
+	// 4. Obtener configuración del torneo
+	var tournament models.Tournament
+	if err := tx.First(&tournament, tournamentID).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusNotFound, "Torneo no encontrado", nil)
+		return
+	}
+
+	// Las ligas de usuarios juegan las sesiones de su torneo base
+	slateID := tournament.SlateTournamentID()
+	if session.TournamentID != slateID {
 		tx.Rollback()
 		utils.Error(c, http.StatusBadRequest, "La sesión no pertenece a este torneo", nil)
 		return
 	}
 
-	if session.Status != "open" {
+	if session.Status != models.SessionStatusOpen {
 		tx.Rollback()
 		utils.Error(c, http.StatusBadRequest, "La sesión no está abierta para predicciones", nil)
 		return
@@ -216,98 +262,103 @@ func SubmitPicksBySession(c *gin.Context) {
 		return
 	}
 
-	// 4. Obtener configuración del torneo
-	var tournament models.Tournament
-	if err := tx.First(&tournament, tournamentID).Error; err != nil {
+	// 5. Evaluar la cartilla contra las reglas del torneo y su categoría (todas las violaciones a la vez)
+	violations, err := services.ValidateSlate(tx, &tournament, &session, input.SelectionIDs)
+	if err != nil {
 		tx.Rollback()
-		utils.Error(c, http.StatusNotFound, "Torneo no encontrado", nil)
+		utils.Error(c, http.StatusInternalServerError, "Error al validar la cartilla", err.Error())
+		return
+	}
+	if len(violations) > 0 {
+		tx.Rollback()
+		utils.Error(c, http.StatusUnprocessableEntity, "La cartilla no cumple las reglas del torneo", violations)
 		return
 	}
 
-	// 5. Validar cantidad de selecciones requeridas
-	requiredSelections := tournament.Settings.SelectionsPerSession
-	if len(input.SelectionIDs) != requiredSelections {
+	// 6. Cargar la cartilla actual: los picks sobre eventos comenzados están congelados
+	var currentPicks []models.UserPick
+	if err := tx.Preload("Selection.Event").
+		Where("participant_id = ? AND session_id = ?", participant.ID, session.ID).
+		Find(&currentPicks).Error; err != nil {
 		tx.Rollback()
-		utils.Error(c, http.StatusBadRequest,
-			fmt.Sprintf("Debe hacer exactamente %d selecciones por sesión", requiredSelections), nil)
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener predicciones actuales", nil)
 		return
 	}
 
-	// 6. Validar tipos de selección obligatorios (si están configurados)
-	if len(tournament.Settings.RequiredSelectionTypes) > 0 {
-		for i, selectionID := range input.SelectionIDs {
-			var sel models.PickableSelection
-			if err := tx.First(&sel, selectionID).Error; err != nil {
-				tx.Rollback()
-				utils.Error(c, http.StatusNotFound, fmt.Sprintf("Selección #%d no encontrada", selectionID), nil)
-				return
-			}
+	now := time.Now()
+	requested := make(map[uint]bool, len(input.SelectionIDs))
+	for _, selectionID := range input.SelectionIDs {
+		requested[selectionID] = true
+	}
 
-			if i < len(tournament.Settings.RequiredSelectionTypes) {
-				requiredType := tournament.Settings.RequiredSelectionTypes[i]
-				if sel.SelectionType != requiredType {
-					tx.Rollback()
-					utils.Error(c, http.StatusBadRequest,
-						fmt.Sprintf("La selección #%d debe ser de tipo '%s' (posición %d)", i+1, requiredType, i+1), nil)
-					return
-				}
-			}
+	existing := make(map[uint]models.UserPick, len(currentPicks))
+	for _, pick := range currentPicks {
+		if pick.Selection.Event.HasStarted(now) && !requested[pick.SelectionID] {
+			tx.Rollback()
+			utils.Error(c, http.StatusConflict,
+				fmt.Sprintf("El pick #%d está congelado porque su evento ya comenzó; debe mantenerse en la cartilla", pick.SelectionID), nil)
+			return
 		}
+		existing[pick.SelectionID] = pick
 	}
 
 	var savedPicks []models.UserPick
 
-	// 7. Procesar cada selección
+	// 7. Procesar cada selección (ya validadas por el motor de reglas)
 	for _, selectionID := range input.SelectionIDs {
-		var selection models.PickableSelection
-		// Preload Event para validar fechas
-		if err := tx.Preload("Event").First(&selection, selectionID).Error; err != nil {
+		if pick, ok := existing[selectionID]; ok {
+			// Se mantiene sin cambios
+			savedPicks = append(savedPicks, pick)
+			delete(existing, selectionID)
 			continue
 		}
 
-		// Validar que el evento de la selección pertenezca al torneo actual (ahora via TournamentEvent)
-		tournamentIDUint := utils.StringToUint(tournamentID)
-
-		// Verificar que existe una relación en TournamentEvent para este evento y torneo
-		var tournamentEvent models.TournamentEvent
-		err := tx.Where("event_id = ? AND tournament_id = ?", selection.EventID, tournamentIDUint).First(&tournamentEvent).Error
-		if err != nil {
+		var selection models.PickableSelection
+		// Preload Event para validar fechas
+		if err := tx.Preload("Event").First(&selection, selectionID).Error; err != nil {
 			tx.Rollback()
-			utils.Error(c, http.StatusBadRequest, "La selección no pertenece a este torneo", nil)
+			utils.Error(c, http.StatusNotFound, fmt.Sprintf("Selección #%d no encontrada", selectionID), nil)
 			return
 		}
 
 		// Validar que el evento no haya comenzado
-		if time.Now().After(selection.Event.StartTime) {
+		if selection.Event.HasStarted(now) {
 			tx.Rollback()
 			utils.Error(c, http.StatusBadRequest, "El evento ya ha comenzado", nil)
 			return
 		}
 
-		// Buscar si ya existe un pick para esta selección por este participante en esta sesión
-		var existingPick models.UserPick
-		err = tx.Where("participant_id = ? AND selection_id = ? AND session_id = ?",
-			participant.ID, selection.ID, session.ID).First(&existingPick).Error
+		newPick := models.UserPick{
+			ParticipantID: participant.ID,
+			SelectionID:   selection.ID,
+			SessionID:     session.ID,
+			Status:        "pending",
+		}
+		services.SnapshotPick(tx, &newPick, &selection)
+		if err := tx.Create(&newPick).Error; err != nil {
+			tx.Rollback()
+			utils.Error(c, http.StatusInternalServerError, "Error al guardar predicción", err.Error())
+			return
+		}
+		if err := services.AdjustPickStat(tx, participant.TournamentID, session.ID, &selection, 1); err != nil {
+			tx.Rollback()
+			utils.Error(c, http.StatusInternalServerError, "Error al actualizar estadísticas", err.Error())
+			return
+		}
+		savedPicks = append(savedPicks, newPick)
+	}
 
-		if err == nil {
-			// Ya existe, actualizar
-			existingPick.SessionID = session.ID
-			tx.Save(&existingPick)
-			savedPicks = append(savedPicks, existingPick)
-		} else {
-			// Crear nuevo pick
-			newPick := models.UserPick{
-				ParticipantID: participant.ID,
-				SelectionID:   selection.ID,
-				SessionID:     session.ID,
-				Status:        "pending",
-			}
-			if err := tx.Create(&newPick).Error; err != nil {
-				tx.Rollback()
-				utils.Error(c, http.StatusInternalServerError, "Error al guardar predicción", err.Error())
-				return
-			}
-			savedPicks = append(savedPicks, newPick)
+	// 8. La cartilla enviada reemplaza a la anterior: se eliminan los picks que ya no están
+	for _, pick := range existing {
+		if err := tx.Delete(&pick).Error; err != nil {
+			tx.Rollback()
+			utils.Error(c, http.StatusInternalServerError, "Error al reemplazar predicciones", err.Error())
+			return
+		}
+		if err := services.AdjustPickStat(tx, participant.TournamentID, pick.SessionID, &pick.Selection, -1); err != nil {
+			tx.Rollback()
+			utils.Error(c, http.StatusInternalServerError, "Error al actualizar estadísticas", err.Error())
+			return
 		}
 	}
 
@@ -317,26 +368,42 @@ func SubmitPicksBySession(c *gin.Context) {
 
 // GetSessionPicks godoc
 // @Summary      Ver predicciones de un usuario en una sesión
-// @Description  Obtiene las predicciones realizadas por el usuario en una sesión específica
+// @Description  Obtiene las predicciones realizadas por el usuario en una sesión específica. El participante se resuelve por el torneo de la sesión (o sus ligas); use tournament_id para elegir una liga concreta.
 // @Tags         users
 // @Security     ApiKeyAuth
 // @Param        session_id path int true "ID de la Sesión"
+// @Param        tournament_id query int false "ID del torneo o liga"
 // @Success      200 {object} utils.Response{data=[]models.UserPick}
 // @Router       /my-sessions/{session_id}/picks [get]
 func GetSessionPicks(c *gin.Context) {
 	sessionID := c.Param("session_id")
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 
-	// Buscar el participant
-	var participant models.TournamentParticipant
-	if err := config.DB.Where("user_id = ?", userID).First(&participant).Error; err != nil {
-		utils.Error(c, http.StatusNotFound, "No estás inscrito en ningún torneo", nil)
+	var session models.Session
+	if err := config.DB.First(&session, sessionID).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Sesión no encontrada", nil)
+		return
+	}
+
+	// Inscripciones del usuario en el torneo de la sesión o en ligas que juegan su cartilla
+	query := config.DB.Model(&models.TournamentParticipant{}).
+		Joins("JOIN tournaments ON tournaments.id = tournament_participants.tournament_id").
+		Where("tournament_participants.user_id = ?", userID).
+		Where("tournaments.id = ? OR tournaments.parent_tournament_id = ?", session.TournamentID, session.TournamentID)
+	if tournamentID := c.Query("tournament_id"); tournamentID != "" {
+		query = query.Where("tournaments.id = ?", tournamentID)
+	}
+
+	var participantIDs []uint
+	query.Pluck("tournament_participants.id", &participantIDs)
+	if len(participantIDs) == 0 {
+		utils.Error(c, http.StatusNotFound, "No estás inscrito en el torneo de esta sesión", nil)
 		return
 	}
 
 	var picks []models.UserPick
 	if err := config.DB.Preload("Selection").
-		Where("participant_id = ? AND session_id = ?", participant.ID, sessionID).
+		Where("participant_id IN ? AND session_id = ?", participantIDs, session.ID).
 		Find(&picks).Error; err != nil {
 		utils.Error(c, http.StatusInternalServerError, "Error al obtener predicciones", nil)
 		return
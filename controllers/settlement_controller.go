@@ -1,14 +1,17 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"path/filepath"
+	"strings"
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/dtos"
 	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
 	_ "github.com/cesarbmathec/bets-backend/docs"
 )
@@ -18,12 +21,14 @@ import (
 // @Description  Establece los resultados finales de un evento, evalúa las selecciones y asigna puntos a los participantes.
 // @Tags         admin
 // @Security     BearerAuth
-// @Param        id path int true "ID del Evento a liquidar"
+// @Param        event_id path int true "ID del Evento a liquidar"
 // @Param        request body dtos.SetEventResultRequest true "Resultados finales de los competidores"
-// @Success      200 {object} utils.Response
-// @Router       /admin/events/{id}/settle [post]
+// @Success      200 {object} utils.Response{data=services.EventSettlement}
+// @Failure      400 {object} utils.Response "Resultados inválidos o evento ya liquidado"
+// @Failure      404 {object} utils.Response "Evento no encontrado"
+// @Router       /admin/events/{event_id}/settle [post]
 func SettleEvent(c *gin.Context) {
-	eventID := c.Param("id")
+	eventID := utils.StringToUint(c.Param("event_id"))
 
 	var input dtos.SetEventResultRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -33,120 +38,110 @@ func SettleEvent(c *gin.Context) {
 
 	tx := config.DB.Begin()
 
-	// 1. Cargar Evento y sus relaciones
-	var event models.Event
-	if err := tx.Preload("Competitors").Preload("PickableSelections").First(&event, eventID).Error; err != nil {
+	settlement, err := services.SettleEvent(tx, eventID, input.Results, input.ResultNote)
+	if err != nil {
 		tx.Rollback()
-		utils.Error(c, http.StatusNotFound, "Evento no encontrado", nil)
+		switch {
+		case errors.Is(err, services.ErrEventNotFound):
+			utils.Error(c, http.StatusNotFound, "Evento no encontrado", nil)
+		case errors.Is(err, services.ErrEventAlreadySettled), errors.Is(err, services.ErrInvalidResults):
+			utils.Error(c, http.StatusBadRequest, err.Error(), nil)
+		default:
+			utils.Error(c, http.StatusInternalServerError, "Error al liquidar el evento", err.Error())
+		}
 		return
 	}
 
-	if event.Status == "completed" {
-		tx.Rollback()
-		utils.Error(c, http.StatusBadRequest, "Este evento ya ha sido liquidado", nil)
+	tx.Commit()
+	services.PublishEventSettled(config.DB, settlement)
+	services.NotifyPicksSettled(config.DB, settlement)
+	services.EmitEventSettled(config.DB, settlement)
+	utils.Success(c, http.StatusOK, "Evento liquidado y puntos asignados correctamente", settlement)
+}
+
+// SettleSessionResults godoc
+// @Summary      Cargar y liquidar los resultados de toda una sesión
+// @Description  Recibe los resultados de varios eventos de la sesión (JSON, archivo `file` .csv o cuerpo text/csv con columnas event_id, competitor_id, final_score, position, result_note), valida todos y los liquida en el orden de la sesión en una sola transacción. Si algún evento falla no se guarda nada. Devuelve los puntos otorgados por participante.
+// @Tags         admin
+// @Security     BearerAuth
+// @Accept       json,mpfd,plain
+// @Produce      json
+// @Param        id path int true "ID de la Sesión"
+// @Param        file formData file false "Archivo .csv con los resultados"
+// @Param        request body dtos.SessionResultsRequest false "Resultados por evento"
+// @Success      200 {object} utils.Response{data=services.SessionSettlement}
+// @Failure      400 {object} utils.Response "Datos inválidos"
+// @Failure      404 {object} utils.Response "Sesión no encontrada"
+// @Failure      422 {object} utils.Response{errors=[]dtos.ImportRowError} "Eventos con resultados inválidos; no se liquidó nada"
+// @Router       /admin/sessions/{id}/results [post]
+// @example request -json {"events": [{"event_id": 10, "results": [{"competitor_id": 31, "position": 1}, {"competitor_id": 32, "position": 2}]}, {"event_id": 11, "result_note": "Final 5-3", "results": [{"competitor_id": 33, "final_score": 5}, {"competitor_id": 34, "final_score": 3}]}]}
+func SettleSessionResults(c *gin.Context) {
+	var session models.Session
+	if err := config.DB.First(&session, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Sesión no encontrada", nil)
 		return
 	}
 
-	// 2. Actualizar resultados en los competidores del evento
-	competitorResults := make(map[uint]dtos.CompetitorResult)
-	totalScore := 0
-	for _, res := range input.Results {
-		competitorResults[res.CompetitorID] = res
-		totalScore += res.FinalScore
-		err := tx.Model(&models.EventCompetitor{}).Where("event_id = ? AND id = ?", event.ID, res.CompetitorID).Updates(map[string]interface{}{
-			"final_score": res.FinalScore,
-			"position":    res.Position,
-		}).Error
-		if err != nil {
-			tx.Rollback()
-			utils.Error(c, http.StatusInternalServerError, "Error al actualizar resultados de competidores", err.Error())
-			return
-		}
+	inputs, rowErrs, err := bindSessionResults(c)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos de resultado inválidos", err.Error())
+		return
+	}
+	if len(rowErrs) > 0 {
+		utils.Error(c, http.StatusUnprocessableEntity, "El archivo tiene filas inválidas; no se liquidó nada", rowErrs)
+		return
 	}
 
-	// 3. Evaluar cada 'PickableSelection' del evento
-	for _, selection := range event.PickableSelections {
-		status := "lost" // Por defecto, una selección es perdedora
-		points := 0
-
-		switch selection.SelectionType {
-		case "alta":
-			if float64(totalScore) > selection.Line {
-				status = "won"
-				points = selection.PointsForWin
-			} else if float64(totalScore) == selection.Line {
-				status = "push"
-				points = selection.PointsForPush
-			}
-		case "baja":
-			if float64(totalScore) < selection.Line {
-				status = "won"
-				points = selection.PointsForWin
-			} else if float64(totalScore) == selection.Line {
-				status = "push"
-				points = selection.PointsForPush
-			}
-		case "macho", "hembra", "ganador": // 'macho' y 'hembra' son terminos para favorito/no favorito
-			if selection.CompetitorID != nil {
-				if result, ok := competitorResults[*selection.CompetitorID]; ok {
-					// Asumimos que el ganador es el que tiene la posición 1 o el score más alto en un vs
-					if result.Position == 1 { // Ideal para carreras
-						status = "won"
-						points = selection.PointsForWin
-					}
-				}
-			}
-		case "empate":
-			if len(event.Competitors) == 2 {
-				c1Score := competitorResults[event.Competitors[0].ID].FinalScore
-				c2Score := competitorResults[event.Competitors[1].ID].FinalScore
-				if c1Score == c2Score {
-					status = "won"
-					points = selection.PointsForWin
-				}
-			}
-		}
-
-		// Actualizar el estado de la PickableSelection
-		tx.Model(&selection).Update("status", status)
+	tx := config.DB.Begin()
 
-		// 4. Actualizar todos los UserPicks que apuntan a esta selección y sumar los puntos
-		if points > 0 {
-			if err := updateUserPicksAndPoints(tx, selection.ID, status, points); err != nil {
-				tx.Rollback()
-				utils.Error(c, http.StatusInternalServerError, "Error al actualizar predicciones de usuarios", err.Error())
-				return
-			}
-		}
+	report, validationErrs, err := services.SettleSessionResults(tx, session.ID, inputs)
+	if err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al liquidar la sesión; no se guardó ningún resultado", err.Error())
+		return
 	}
-
-	// 5. Marcar el evento como completado
-	event.Status = "completed"
-	if err := tx.Save(&event).Error; err != nil {
+	if len(validationErrs) > 0 {
 		tx.Rollback()
-		utils.Error(c, http.StatusInternalServerError, "Error al finalizar el evento", err.Error())
+		utils.Error(c, http.StatusUnprocessableEntity, "Hay eventos con resultados inválidos; no se liquidó nada", validationErrs)
 		return
 	}
 
 	tx.Commit()
-	utils.Success(c, http.StatusOK, "Evento liquidado y puntos asignados correctamente", nil)
+	for _, settlement := range report.Events {
+		services.PublishEventSettled(config.DB, settlement)
+		services.EmitEventSettled(config.DB, settlement)
+	}
+	services.NotifySessionPicksSettled(config.DB, &session, report)
+	utils.Success(c, http.StatusOK, "Sesión liquidada y puntos asignados correctamente", report)
 }
 
-// updateUserPicksAndPoints actualiza los UserPicks y los puntos totales de los participantes.
-func updateUserPicksAndPoints(tx *gorm.DB, selectionID uint, status string, points int) error {
-	// Actualizar el estado y los puntos de todos los UserPicks para esta selección
-	if err := tx.Model(&models.UserPick{}).
-		Where("selection_id = ?", selectionID).
-		Updates(map[string]interface{}{"status": status, "awarded_points": points}).Error; err != nil {
-		return err
+// bindSessionResults lee los resultados como JSON, archivo CSV subido o cuerpo text/csv
+func bindSessionResults(c *gin.Context) ([]dtos.EventResultInput, []dtos.ImportRowError, error) {
+	contentType := c.ContentType()
+
+	if strings.HasPrefix(contentType, "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, nil, err
+		}
+		if !strings.EqualFold(filepath.Ext(header.Filename), ".csv") {
+			return nil, nil, errors.New("el archivo debe ser .csv")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer file.Close()
+		return services.ParseSessionResultsCSV(file)
 	}
 
-	// Sumar los puntos a los participantes correspondientes.
-	return tx.Exec(`
-        UPDATE tournament_participants
-        SET total_points = total_points + ?
-        WHERE id IN (
-            SELECT participant_id FROM user_picks WHERE selection_id = ?
-        )
-    `, points, selectionID).Error
+	if contentType == "text/csv" || contentType == "text/plain" {
+		return services.ParseSessionResultsCSV(c.Request.Body)
+	}
+
+	var input dtos.SessionResultsRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		return nil, nil, err
+	}
+	return input.Events, nil, nil
 }
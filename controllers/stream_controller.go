@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+
+	_ "github.com/cesarbmathec/bets-backend/docs"
+)
+
+// maxStreamChannels limita cuántos torneos y eventos puede seguir una sola conexión
+const maxStreamChannels = 50
+
+// streamHeartbeat mantiene viva la conexión a través de proxies que cortan las conexiones inactivas
+const streamHeartbeat = 25 * time.Second
+
+// StreamRecheckInterval es cada cuánto se vuelve a validar al usuario de un flujo abierto; si fue
+// desactivado o cerró sus sesiones, el flujo se corta
+var StreamRecheckInterval = 30 * time.Second
+
+// CreateStreamTicket godoc
+// @Summary      Ticket para abrir el flujo SSE
+// @Description  Emite un ticket de un solo uso que vence a los 30 segundos para abrir /stream?ticket=... desde EventSource, que no permite enviar la cabecera Authorization
+// @Tags         realtime
+// @Produce      json
+// @Success      201 {object} utils.Response{data=dtos.StreamTicketResponse}
+// @Router       /stream/ticket [post]
+// @Security     BearerAuth
+func CreateStreamTicket(c *gin.Context) {
+	ticket, err := services.IssueStreamTicket(config.DB, middleware.CurrentPrincipal(c), time.Now())
+	if err != nil {
+		utils.Error(c, http.StatusInternalServerError, "No se pudo emitir el ticket", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusCreated, "Ticket de conexión emitido", dtos.StreamTicketResponse{
+		Ticket:    ticket,
+		ExpiresIn: int(services.StreamTicketTTL.Seconds()),
+	})
+}
+
+// StreamUpdates godoc
+// @Summary      Actualizaciones en tiempo real (SSE)
+// @Description  Abre un flujo Server-Sent Events con los mensajes de los torneos y eventos indicados más el canal privado del usuario (saldo de billetera). Tipos: event.status, event.settled, leaderboard.updated, session.status, wallet.balance. Los torneos privados solo se pueden seguir siendo participante. La autenticación va en la cabecera Authorization o, desde EventSource, en ?ticket= (ver POST /stream/ticket). El flujo se cierra con un evento "revoked" si el usuario es desactivado o cierra sus sesiones.
+// @Tags         realtime
+// @Produce      text/event-stream
+// @Param        tournaments query string false "IDs de torneos separados por coma"
+// @Param        events query string false "IDs de eventos separados por coma"
+// @Param        ticket query string false "Ticket de un solo uso si no se envía la cabecera Authorization"
+// @Success      200 {object} services.BrokerMessage
+// @Failure      400 {object} utils.Response "Canales inválidos"
+// @Failure      401 {object} utils.Response "Token o ticket inválido"
+// @Failure      403 {object} utils.Response "Torneo privado del que no es participante"
+// @Failure      404 {object} utils.Response "Torneo no encontrado"
+// @Router       /stream [get]
+// @Security     BearerAuth
+func StreamUpdates(c *gin.Context) {
+	principal := middleware.CurrentPrincipal(c)
+	userID := principal.UserID
+	channels := []string{services.UserChannel(userID)}
+	var tournamentIDs []uint
+
+	for _, param := range []struct {
+		query   string
+		channel func(uint) string
+	}{
+		{"tournaments", services.TournamentChannel},
+		{"events", services.EventChannel},
+	} {
+		for _, raw := range strings.Split(c.Query(param.query), ",") {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				continue
+			}
+			id := utils.StringToUint(raw)
+			if id == 0 {
+				utils.Error(c, http.StatusBadRequest, fmt.Sprintf("ID inválido en %s: %s", param.query, raw), nil)
+				return
+			}
+			channels = append(channels, param.channel(id))
+			if param.query == "tournaments" {
+				tournamentIDs = append(tournamentIDs, id)
+			}
+		}
+	}
+	if len(channels) > maxStreamChannels+1 {
+		utils.Error(c, http.StatusBadRequest, fmt.Sprintf("Máximo %d torneos y eventos por conexión", maxStreamChannels), nil)
+		return
+	}
+
+	// Los mensajes de un torneo privado (clasificación, sesiones) solo son para sus participantes
+	for _, id := range tournamentIDs {
+		var tournament models.Tournament
+		if err := config.DB.First(&tournament, id).Error; err != nil {
+			utils.Error(c, http.StatusNotFound, fmt.Sprintf("Torneo %d no encontrado", id), nil)
+			return
+		}
+		if !services.CanViewTournament(config.DB, principal, &tournament) {
+			utils.Error(c, http.StatusForbidden, fmt.Sprintf("Solo los participantes pueden seguir el torneo privado %d", id), nil)
+			return
+		}
+	}
+
+	messages, err := services.GetBroker().Subscribe(c.Request.Context(), channels...)
+	if err != nil {
+		utils.Error(c, http.StatusServiceUnavailable, "No se pudo suscribir a las actualizaciones", err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Evita que nginx acumule el flujo
+
+	c.SSEvent("ready", gin.H{"channels": channels})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	recheck := time.NewTicker(StreamRecheckInterval)
+	defer recheck.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return false
+			}
+			c.SSEvent(msg.Type, msg)
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+		case <-recheck.C:
+			// El flujo puede durar horas: se corta si el usuario fue desactivado o revocó sus sesiones
+			current, err := services.LoadPrincipal(config.DB, userID)
+			if err != nil || !current.IsActive || current.TokenVersion != principal.TokenVersion {
+				c.SSEvent("revoked", gin.H{"reason": "Sesión revocada. Inicia sesión de nuevo"})
+				return false
+			}
+		}
+		return true
+	})
+}
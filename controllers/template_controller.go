@@ -0,0 +1,517 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	_ "github.com/cesarbmathec/bets-backend/docs"
+)
+
+// CreateTournamentTemplate godoc
+// @Summary      Crear plantilla de torneo
+// @Description  Guarda reglas, esquema de selecciones y calendario de sesiones (relativo a la fecha de inicio) para reutilizarlos. Puede capturarse desde un torneo existente con source_tournament_id, lo que también guarda sus asignaciones de eventos.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request body dtos.CreateTemplateRequest true "Datos de la plantilla"
+// @Success      201 {object} utils.Response{data=models.TournamentTemplate}
+// @Failure      400 {object} utils.Response "Datos inválidos"
+// @Failure      404 {object} utils.Response "Torneo origen no encontrado"
+// @Router       /admin/tournament-templates [post]
+// @Security     BearerAuth
+// @example request -json {"name": "Polla semanal", "category": "Hipica", "duration_minutes": 10080, "entry_fee": 10, "settings": {"selections_per_session": 6, "total_sessions": 2}, "sessions": [{"session_number": 1, "start_offset_minutes": 0, "end_offset_minutes": 720}, {"session_number": 2, "start_offset_minutes": 1440, "end_offset_minutes": 2160}]}
+func CreateTournamentTemplate(c *gin.Context) {
+	var input dtos.CreateTemplateRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	var template models.TournamentTemplate
+	if input.SourceTournamentID != nil {
+		var source models.Tournament
+		if err := config.DB.First(&source, *input.SourceTournamentID).Error; err != nil {
+			utils.Error(c, http.StatusNotFound, "Torneo origen no encontrado", nil)
+			return
+		}
+
+		var sessions []models.Session
+		if err := config.DB.Where("tournament_id = ?", source.ID).Order("session_number asc").Find(&sessions).Error; err != nil {
+			utils.Error(c, http.StatusInternalServerError, "Error al obtener las sesiones del torneo origen", err.Error())
+			return
+		}
+
+		var tournamentEvents []models.TournamentEvent
+		if err := config.DB.Preload("Event").Preload("Session").
+			Where("tournament_id = ?", source.ID).
+			Find(&tournamentEvents).Error; err != nil {
+			utils.Error(c, http.StatusInternalServerError, "Error al obtener los eventos del torneo origen", err.Error())
+			return
+		}
+
+		template = templateFromTournament(&source, sessions, tournamentEvents)
+		template.Name = input.Name
+		if input.Description != "" {
+			template.Description = input.Description
+		}
+	} else {
+		if input.Category == "" || input.DurationMinutes <= 0 {
+			utils.Error(c, http.StatusBadRequest, "Debe indicar categoría y duración del torneo", nil)
+			return
+		}
+
+		sessions := make(models.TemplateSessions, len(input.Sessions))
+		for i, s := range input.Sessions {
+			sessions[i] = models.TemplateSession{
+				SessionNumber:      s.SessionNumber,
+				StartOffsetMinutes: s.StartOffsetMinutes,
+				EndOffsetMinutes:   s.EndOffsetMinutes,
+				SuperLine:          s.SuperLine,
+				Description:        s.Description,
+			}
+		}
+
+		template = models.TournamentTemplate{
+			Name:            input.Name,
+			Description:     input.Description,
+			Category:        input.Category,
+			DurationMinutes: input.DurationMinutes,
+			MaxParticipants: input.MaxParticipants,
+			EntryFee:        input.EntryFee,
+			EntryFeeTokens:  input.EntryFeeTokens,
+			PrizeBonus:      input.PrizeBonus,
+			AdminFeePercent: input.AdminFeePercent,
+			Settings:        settingsFromRequest(input.Settings),
+			Sessions:        sessions,
+		}
+	}
+
+	template.CreatedBy = userID
+
+	if err := config.DB.Create(&template).Error; err != nil {
+		utils.Error(c, http.StatusConflict, "No se pudo crear la plantilla (¿nombre duplicado?)", err.Error())
+		return
+	}
+
+	utils.Success(c, http.StatusCreated, "Plantilla creada con éxito", template)
+}
+
+// GetTournamentTemplates godoc
+// @Summary      Listar plantillas de torneo
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} utils.Response{data=[]models.TournamentTemplate}
+// @Router       /admin/tournament-templates [get]
+// @Security     BearerAuth
+func GetTournamentTemplates(c *gin.Context) {
+	var templates []models.TournamentTemplate
+	if err := config.DB.Order("name asc").Find(&templates).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener plantillas", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Plantillas de torneo", templates)
+}
+
+// GetTournamentTemplateByID godoc
+// @Summary      Ver plantilla de torneo
+// @Tags         admin
+// @Param        id path int true "ID de la Plantilla"
+// @Produce      json
+// @Success      200 {object} utils.Response{data=models.TournamentTemplate}
+// @Failure      404 {object} utils.Response "Plantilla no encontrada"
+// @Router       /admin/tournament-templates/{id} [get]
+// @Security     BearerAuth
+func GetTournamentTemplateByID(c *gin.Context) {
+	var template models.TournamentTemplate
+	if err := config.DB.First(&template, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Plantilla no encontrada", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Plantilla encontrada", template)
+}
+
+// DeleteTournamentTemplate godoc
+// @Summary      Eliminar plantilla de torneo
+// @Tags         admin
+// @Param        id path int true "ID de la Plantilla"
+// @Produce      json
+// @Success      200 {object} utils.Response
+// @Failure      404 {object} utils.Response "Plantilla no encontrada"
+// @Router       /admin/tournament-templates/{id} [delete]
+// @Security     BearerAuth
+func DeleteTournamentTemplate(c *gin.Context) {
+	var template models.TournamentTemplate
+	if err := config.DB.First(&template, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Plantilla no encontrada", nil)
+		return
+	}
+
+	if err := config.DB.Delete(&template).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al eliminar plantilla", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Plantilla eliminada", nil)
+}
+
+// InstantiateTournamentTemplate godoc
+// @Summary      Crear torneo desde plantilla
+// @Description  Crea en una sola transacción el torneo, su configuración, todas sus sesiones y una copia de los eventos asignados (competidores y selecciones, sin resultados), calculando las fechas a partir de start_date
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID de la Plantilla"
+// @Param        request body dtos.InstantiateTemplateRequest true "Nombre y fecha de inicio"
+// @Success      201 {object} utils.Response{data=models.Tournament}
+// @Failure      400 {object} utils.Response "Un evento de la plantilla ya no existe"
+// @Failure      404 {object} utils.Response "Plantilla no encontrada"
+// @Router       /admin/tournament-templates/{id}/instantiate [post]
+// @Security     BearerAuth
+// @example request -json {"name": "Polla semanal 12/10", "start_date": "2026-10-12T12:00:00Z"}
+func InstantiateTournamentTemplate(c *gin.Context) {
+	var input dtos.InstantiateTemplateRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	var template models.TournamentTemplate
+	if err := config.DB.First(&template, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Plantilla no encontrada", nil)
+		return
+	}
+
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	tournament := models.Tournament{
+		Name:            input.Name,
+		Description:     template.Description,
+		Category:        template.Category,
+		Status:          models.TournamentStatusOpen,
+		StartDate:       input.StartDate,
+		EndDate:         template.EndDateFrom(input.StartDate),
+		MaxParticipants: template.MaxParticipants,
+		EntryFee:        template.EntryFee,
+		EntryFeeTokens:  template.EntryFeeTokens,
+		PrizeBonus:      template.PrizeBonus,
+		AdminFeePercent: template.AdminFeePercent,
+		Visibility:      models.TournamentVisibilityPublic,
+		Settings:        template.Settings,
+		CreatedBy:       userID,
+	}
+
+	// Las plantillas guardan la categoría por nombre
+	if category, err := services.ResolveCategory(config.DB, nil, template.Category); err == nil {
+		tournament.Category = category.Name
+		tournament.CategoryID = &category.ID
+	}
+
+	if input.Visibility == models.TournamentVisibilityPrivate {
+		tournament.Visibility = models.TournamentVisibilityPrivate
+		if err := assignInviteCode(&tournament); err != nil {
+			utils.Error(c, http.StatusInternalServerError, "No se pudo generar el código de invitación", nil)
+			return
+		}
+	}
+
+	tx := config.DB.Begin()
+
+	if err := tx.Create(&tournament).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusConflict, "No se pudo crear el torneo (¿nombre duplicado?)", err.Error())
+		return
+	}
+
+	sessionByNumber := make(map[int]uint, len(template.Sessions))
+	for _, ts := range template.Sessions {
+		session := models.Session{
+			TournamentID:  tournament.ID,
+			SessionNumber: ts.SessionNumber,
+			StartTime:     input.StartDate.Add(time.Duration(ts.StartOffsetMinutes) * time.Minute),
+			EndTime:       input.StartDate.Add(time.Duration(ts.EndOffsetMinutes) * time.Minute),
+			SuperLine:     ts.SuperLine,
+			Description:   ts.Description,
+			Status:        models.InitialSessionStatus(input.StartDate.Add(time.Duration(ts.StartOffsetMinutes) * time.Minute)),
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			tx.Rollback()
+			utils.Error(c, http.StatusBadRequest, fmt.Sprintf("Error al crear la sesión #%d", ts.SessionNumber), err.Error())
+			return
+		}
+		sessionByNumber[ts.SessionNumber] = session.ID
+	}
+
+	// Los eventos de la plantilla se duplican a su hora relativa y se asignan a su sesión
+	for _, te := range template.Events {
+		var source models.Event
+		if err := tx.Preload("Competitors").Preload("PickableSelections").First(&source, te.EventID).Error; err != nil {
+			tx.Rollback()
+			utils.Error(c, http.StatusBadRequest, fmt.Sprintf("El evento %d de la plantilla ya no existe", te.EventID), err.Error())
+			return
+		}
+
+		startTime := input.StartDate.Add(time.Duration(te.StartOffsetMinutes) * time.Minute)
+		newEvent, err := cloneEvent(tx, &source, startTime.Sub(source.StartTime))
+		if err != nil {
+			tx.Rollback()
+			utils.Error(c, http.StatusInternalServerError, "Error al crear los eventos de la plantilla", err.Error())
+			return
+		}
+
+		assignment := models.TournamentEvent{TournamentID: tournament.ID, EventID: newEvent.ID, Order: te.Order}
+		if sessionID, ok := sessionByNumber[te.SessionNumber]; ok {
+			assignment.SessionID = &sessionID
+		}
+		if err := tx.Create(&assignment).Error; err != nil {
+			tx.Rollback()
+			utils.Error(c, http.StatusInternalServerError, "Error al asignar los eventos de la plantilla", err.Error())
+			return
+		}
+	}
+
+	tx.Commit()
+	services.EmitTournamentCreated(config.DB, &tournament)
+	utils.Success(c, http.StatusCreated, "Torneo creado desde plantilla", tournament)
+}
+
+// CloneTournament godoc
+// @Summary      Clonar un torneo
+// @Description  Crea en una sola transacción una copia del torneo con sus reglas y sesiones desplazadas a la nueva fecha de inicio. Con clone_events también duplica los eventos asignados (competidores y selecciones, sin resultados).
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID del Torneo a clonar"
+// @Param        request body dtos.CloneTournamentRequest true "Nombre y nueva fecha de inicio"
+// @Success      201 {object} utils.Response{data=models.Tournament}
+// @Failure      404 {object} utils.Response "Torneo no encontrado"
+// @Router       /admin/tournaments/{id}/clone [post]
+// @Security     BearerAuth
+// @example request -json {"name": "Polla semanal 19/10", "start_date": "2026-10-19T12:00:00Z", "clone_events": true}
+func CloneTournament(c *gin.Context) {
+	var input dtos.CloneTournamentRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	var source models.Tournament
+	if err := config.DB.First(&source, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Torneo no encontrado", nil)
+		return
+	}
+
+	userID := middleware.CurrentPrincipal(c).UserID
+	shift := input.StartDate.Sub(source.StartDate)
+
+	clone := models.Tournament{
+		Name:               input.Name,
+		Description:        source.Description,
+		Category:           source.Category,
+		CategoryID:         source.CategoryID,
+		Status:             models.TournamentStatusOpen,
+		StartDate:          input.StartDate,
+		EndDate:            source.EndDate.Add(shift),
+		MaxParticipants:    source.MaxParticipants,
+		EntryFee:           source.EntryFee,
+		EntryFeeTokens:     source.EntryFeeTokens,
+		PrizeBonus:         source.PrizeBonus,
+		AdminFeePercent:    source.AdminFeePercent,
+		Visibility:         source.Visibility,
+		ParentTournamentID: source.ParentTournamentID,
+		Settings:           source.Settings,
+		CreatedBy:          userID,
+	}
+
+	if clone.IsPrivate() {
+		if err := assignInviteCode(&clone); err != nil {
+			utils.Error(c, http.StatusInternalServerError, "No se pudo generar el código de invitación", nil)
+			return
+		}
+	}
+
+	tx := config.DB.Begin()
+
+	if err := tx.Create(&clone).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusConflict, "No se pudo crear el torneo (¿nombre duplicado?)", err.Error())
+		return
+	}
+
+	// 1. Sesiones desplazadas, guardando la correspondencia de IDs para los eventos
+	var sessions []models.Session
+	if err := tx.Where("tournament_id = ?", source.ID).Order("session_number asc").Find(&sessions).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener sesiones", err.Error())
+		return
+	}
+
+	sessionMap := make(map[uint]uint, len(sessions))
+	for _, s := range sessions {
+		newSession := models.Session{
+			TournamentID:  clone.ID,
+			SessionNumber: s.SessionNumber,
+			StartTime:     s.StartTime.Add(shift),
+			EndTime:       s.EndTime.Add(shift),
+			SuperLine:     s.SuperLine,
+			Description:   s.Description,
+			Status:        models.InitialSessionStatus(s.StartTime.Add(shift)),
+		}
+		if err := tx.Create(&newSession).Error; err != nil {
+			tx.Rollback()
+			utils.Error(c, http.StatusInternalServerError, "Error al clonar sesiones", err.Error())
+			return
+		}
+		sessionMap[s.ID] = newSession.ID
+	}
+
+	// 2. Eventos asignados (opcional)
+	if input.CloneEvents {
+		var tournamentEvents []models.TournamentEvent
+		if err := tx.Preload("Event.Competitors").
+			Preload("Event.PickableSelections").
+			Where("tournament_id = ?", source.ID).
+			Find(&tournamentEvents).Error; err != nil {
+			tx.Rollback()
+			utils.Error(c, http.StatusInternalServerError, "Error al obtener eventos", err.Error())
+			return
+		}
+
+		for _, te := range tournamentEvents {
+			newEvent, err := cloneEvent(tx, &te.Event, shift)
+			if err != nil {
+				tx.Rollback()
+				utils.Error(c, http.StatusInternalServerError, "Error al clonar eventos", err.Error())
+				return
+			}
+
+			assignment := models.TournamentEvent{
+				TournamentID: clone.ID,
+				EventID:      newEvent.ID,
+				Order:        te.Order,
+			}
+			if te.SessionID != nil {
+				if newSessionID, ok := sessionMap[*te.SessionID]; ok {
+					assignment.SessionID = &newSessionID
+				}
+			}
+			if err := tx.Create(&assignment).Error; err != nil {
+				tx.Rollback()
+				utils.Error(c, http.StatusInternalServerError, "Error al asignar eventos clonados", err.Error())
+				return
+			}
+		}
+	}
+
+	tx.Commit()
+	services.EmitTournamentCreated(config.DB, &clone)
+	utils.Success(c, http.StatusCreated, "Torneo clonado con éxito", clone)
+}
+
+// templateFromTournament captura la configuración, el calendario y los eventos asignados de un
+// torneo como plantilla
+func templateFromTournament(t *models.Tournament, sessions []models.Session, tournamentEvents []models.TournamentEvent) models.TournamentTemplate {
+	templateSessions := make(models.TemplateSessions, len(sessions))
+	for i, s := range sessions {
+		templateSessions[i] = models.TemplateSession{
+			SessionNumber:      s.SessionNumber,
+			StartOffsetMinutes: int(s.StartTime.Sub(t.StartDate).Minutes()),
+			EndOffsetMinutes:   int(s.EndTime.Sub(t.StartDate).Minutes()),
+			SuperLine:          s.SuperLine,
+			Description:        s.Description,
+		}
+	}
+
+	templateEvents := make(models.TemplateEvents, len(tournamentEvents))
+	for i, te := range tournamentEvents {
+		templateEvents[i] = models.TemplateEvent{
+			EventID:            te.EventID,
+			StartOffsetMinutes: int(te.Event.StartTime.Sub(t.StartDate).Minutes()),
+			Order:              te.Order,
+		}
+		if te.SessionID != nil {
+			templateEvents[i].SessionNumber = te.Session.SessionNumber
+		}
+	}
+
+	return models.TournamentTemplate{
+		Description:     t.Description,
+		Category:        t.Category,
+		DurationMinutes: int(t.EndDate.Sub(t.StartDate).Minutes()),
+		MaxParticipants: t.MaxParticipants,
+		EntryFee:        t.EntryFee,
+		EntryFeeTokens:  t.EntryFeeTokens,
+		PrizeBonus:      t.PrizeBonus,
+		AdminFeePercent: t.AdminFeePercent,
+		Settings:        t.Settings,
+		Sessions:        templateSessions,
+		Events:          templateEvents,
+	}
+}
+
+// cloneEvent duplica un evento con sus competidores y selecciones, desplazando su hora
+// de inicio y sin copiar resultados. El nombre incluye la nueva fecha porque el slug es único.
+func cloneEvent(tx *gorm.DB, source *models.Event, shift time.Duration) (*models.Event, error) {
+	startTime := source.StartTime.Add(shift)
+	event := models.Event{
+		Name:      fmt.Sprintf("%s (%s)", source.Name, startTime.Format("02/01/2006")),
+		Order:     source.Order,
+		StartTime: startTime,
+		Venue:     source.Venue,
+		Line:      source.Line,
+		Status:    "scheduled",
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return nil, err
+	}
+
+	// Las selecciones de macho/hembra apuntan al competidor del evento: mapear IDs
+	competitorMap := make(map[uint]uint, len(source.Competitors))
+	for _, comp := range source.Competitors {
+		newComp := models.EventCompetitor{
+			EventID:        event.ID,
+			CompetitorID:   comp.CompetitorID,
+			Name:           comp.Name,
+			AssignedNumber: comp.AssignedNumber,
+			Odds:           comp.Odds,
+			Runline:        comp.Runline,
+			SuperRunline:   comp.SuperRunline,
+			IsFavorite:     comp.IsFavorite,
+		}
+		if err := tx.Create(&newComp).Error; err != nil {
+			return nil, err
+		}
+		competitorMap[comp.ID] = newComp.ID
+	}
+
+	for _, sel := range source.PickableSelections {
+		newSel := sel
+		newSel.BaseModel = models.BaseModel{}
+		newSel.EventID = event.ID
+		newSel.Event = models.Event{}
+		newSel.Status = "pending"
+		if sel.CompetitorID != nil {
+			if newCompID, ok := competitorMap[*sel.CompetitorID]; ok {
+				newSel.CompetitorID = &newCompID
+			}
+		}
+		if err := tx.Create(&newSel).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &event, nil
+}
@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
 	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
 
@@ -36,43 +39,78 @@ func CreateTournament(c *gin.Context) {
 	}
 
 	// Extraemos el ID del admin
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	// La categoría debe existir y estar activa; aporta valores por defecto y tipos permitidos
+	category, err := services.ResolveCategory(config.DB, input.CategoryID, input.Category)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, "Categoría inválida", err.Error())
+		return
+	}
+	if !category.IsActive {
+		utils.Error(c, http.StatusBadRequest, "Categoría inválida", services.ErrCategoryInactive.Error())
+		return
+	}
 
 	// Mapeo del DTO de settings al modelo de settings
-	settings := models.TournamentSettings{
-		PrizeDistribution:      input.Settings.PrizeDistribution,
-		SelectionsPerSession:   input.Settings.SelectionsPerSession,
-		HorseRacingPoints:      input.Settings.HorseRacingPoints,
-		RequiredSelectionTypes: input.Settings.RequiredSelectionTypes,
-		TotalSessions:          input.Settings.TotalSessions,
+	settings := settingsFromRequest(input.Settings)
+	services.ApplyCategoryDefaults(category, &settings)
+	if err := services.ValidateTournamentSettings(category, settings); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Configuración incompatible con la categoría", err.Error())
+		return
 	}
 
 	tournament := models.Tournament{
 		Name:            input.Name,
 		Description:     input.Description,
-		Category:        input.Category,
+		Category:        category.Name,
+		CategoryID:      &category.ID,
 		StartDate:       input.StartDate,
 		EndDate:         input.EndDate,
 		EntryFee:        input.EntryFee,
 		EntryFeeTokens:  input.EntryFeeTokens,
 		PrizeBonus:      input.PrizeBonus,
 		AdminFeePercent: input.AdminFeePercent,
+		MaxParticipants: input.MaxParticipants,
+		Visibility:      models.TournamentVisibilityPublic,
 		Settings:        settings,
-		CreatedBy:       userID.(uint),
-		Status:          "open",
+		CreatedBy:       userID,
+		Status:          models.TournamentStatusOpen,
+	}
+
+	if input.Status == models.TournamentStatusDraft {
+		tournament.Status = models.TournamentStatusDraft
+	}
+
+	// Los torneos privados reciben un código de invitación para compartir
+	if input.Visibility == models.TournamentVisibilityPrivate {
+		tournament.Visibility = models.TournamentVisibilityPrivate
+		if err := assignInviteCode(&tournament); err != nil {
+			utils.Error(c, http.StatusInternalServerError, "No se pudo generar el código de invitación", nil)
+			return
+		}
 	}
 
 	if err := config.DB.Create(&tournament).Error; err != nil {
 		utils.Error(c, http.StatusInternalServerError, "No se pudo crear el torneo", nil)
 		return
 	}
+	services.EmitTournamentCreated(config.DB, &tournament)
+
+	if tournament.IsPrivate() {
+		utils.Success(c, http.StatusCreated, "Torneo creado con éxito", gin.H{
+			"tournament": tournament,
+			"invite":     inviteResponse(&tournament),
+		})
+		return
+	}
 
 	utils.Success(c, http.StatusCreated, "Torneo creado con éxito", tournament)
 }
 
 // GetTournaments godoc
 // @Summary      Listar torneos
-// @Description  Obtiene todos los torneos disponibles en el sistema
+// @Description  Obtiene todos los torneos públicos disponibles en el sistema (los privados solo se acceden por invitación)
 // @Tags         tournaments
 // @Produce      json
 // @Success      200 {object} utils.Response{data=[]models.Tournament} "Lista de torneos"
@@ -81,7 +119,7 @@ func CreateTournament(c *gin.Context) {
 // @example response -json {"success": true, "message": "Lista de torneos", "data": [{"id": 1, "name": "Quiniela Semanal", "category": "Futbol", "status": "open", "entry_fee": 10.00}]}
 func GetTournaments(c *gin.Context) {
 	var tournaments []models.Tournament
-	config.DB.Find(&tournaments)
+	config.DB.Where("visibility = ?", models.TournamentVisibilityPublic).Find(&tournaments)
 	// Devolver siempre 200 con array vacío si no hay torneos (más consistente con REST)
 	if tournaments == nil {
 		tournaments = []models.Tournament{}
@@ -92,7 +130,7 @@ func GetTournaments(c *gin.Context) {
 
 // GetTournamentByID godoc
 // @Summary      Ver detalle de torneo por ID
-// @Description  Obtiene la información completa de un torneo usando su ID numérico
+// @Description  Obtiene la información completa de un torneo usando su ID numérico. Los torneos privados solo los ven sus participantes y los administradores (con token).
 // @Tags         tournaments
 // @Param        id path int true "ID del Torneo"
 // @Produce      json
@@ -103,7 +141,9 @@ func GetTournamentByID(c *gin.Context) {
 	id := c.Param("id")
 	var tournament models.Tournament
 
-	if err := config.DB.First(&tournament, id).Error; err != nil {
+	// Los privados se ocultan como inexistentes a quien no participa, igual que en el listado
+	if err := config.DB.First(&tournament, id).Error; err != nil ||
+		!services.CanViewTournament(config.DB, middleware.CurrentPrincipal(c), &tournament) {
 		utils.Error(c, http.StatusNotFound, "Torneo no encontrado por ID", nil)
 		return
 	}
@@ -113,13 +153,14 @@ func GetTournamentByID(c *gin.Context) {
 
 // UpdateTournamentStatus godoc
 // @Summary      Actualizar estado o finalizar torneo
-// @Description  Cambia el estado del torneo (open, closed, finished). Al cambiar a finished se distribuyen los premios.
+// @Description  Cambia el estado del torneo siguiendo el ciclo draft → open → running → closed → finished (o cancelled). Para finalizar, todas las sesiones deben estar liquidadas; al finalizar se distribuyen los premios. Cada cambio queda en el historial.
 // @Tags         admin
 // @Param        id path int true "ID del Torneo"
 // @Param        request body dtos.UpdateStatusRequest true "Nuevo estado"
 // @Success      200 {object} utils.Response "Estado actualizado"
 // @Failure      400 {object} utils.Response "Estado inválido"
 // @Failure      404 {object} utils.Response "Torneo no encontrado"
+// @Failure      409 {object} utils.Response "Transición no permitida"
 // @Failure      403 {object} utils.Response "Se requiere rol de administrador"
 // @Router       /admin/tournaments/{id}/status [patch]
 // @Security     BearerAuth
@@ -132,16 +173,37 @@ func UpdateTournamentStatus(c *gin.Context) {
 		return
 	}
 
+	userID := middleware.CurrentPrincipal(c).UserID
+	actorID := userID
+
+	tx := config.DB.Begin()
+
 	var tournament models.Tournament
-	if err := config.DB.First(&tournament, id).Error; err != nil {
+	if err := tx.First(&tournament, id).Error; err != nil {
+		tx.Rollback()
 		utils.Error(c, http.StatusNotFound, "Torneo no encontrado", nil)
 		return
 	}
 
-	// Lógica de Finalización y Reparto de Premios
-	if input.Status == "finished" && tournament.Status != "finished" {
-		tx := config.DB.Begin()
+	// Validar transición y guardas (ej: todas las sesiones liquidadas antes de finalizar)
+	if err := services.TransitionTournament(tx, &tournament, input.Status, &actorID, input.Note); err != nil {
+		tx.Rollback()
+		if transitionRejected(err) {
+			utils.Error(c, http.StatusConflict, "No se puede cambiar el estado del torneo", err.Error())
+		} else {
+			utils.Error(c, http.StatusInternalServerError, "Error al cambiar el estado del torneo", nil)
+		}
+		return
+	}
 
+	// Lógica de Finalización y Reparto de Premios
+	type prizePayout struct {
+		wallet   models.Wallet
+		position int
+		amount   float64
+	}
+	var payouts []prizePayout
+	if input.Status == models.TournamentStatusFinished {
 		// 1. Obtener ganadores (Ranking) según la cantidad de premios definidos
 		var winners []models.TournamentParticipant
 		limit := len(tournament.Settings.PrizeDistribution)
@@ -182,6 +244,7 @@ func UpdateTournamentStatus(c *gin.Context) {
 						utils.Error(c, http.StatusInternalServerError, "Error depositando premio", nil)
 						return
 					}
+					payouts = append(payouts, prizePayout{wallet: wallet, position: i + 1, amount: prizeAmount})
 
 					// Registrar Transacción
 					trx := models.Transaction{
@@ -196,21 +259,37 @@ func UpdateTournamentStatus(c *gin.Context) {
 				}
 			}
 		}
-		tournament.Status = "finished"
-		tx.Save(&tournament)
-		tx.Commit()
-	} else {
-		// Cambio de estado normal (ej: open -> closed)
-		tournament.Status = input.Status
-		config.DB.Save(&tournament)
+	}
+
+	tx.Commit()
+	for i := range payouts {
+		services.PublishWalletBalance(&payouts[i].wallet)
+		services.NotifyPrizePaid(config.DB, payouts[i].wallet.UserID, &tournament, payouts[i].position, payouts[i].amount)
+		services.EmitPrizePaid(config.DB, payouts[i].wallet.UserID, &tournament, payouts[i].position, payouts[i].amount)
+	}
+	if input.Status == models.TournamentStatusFinished {
+		services.EmitLeaderboardFinal(config.DB, tournament.ID)
 	}
 
 	utils.Success(c, http.StatusOK, "Estado actualizado y premios procesados (si aplica)", tournament)
 }
 
+// GetTournamentStatusHistory godoc
+// @Summary      Historial de estados del torneo
+// @Description  Lista los cambios de estado del torneo con el actor y la fecha
+// @Tags         admin
+// @Param        id path int true "ID del Torneo"
+// @Produce      json
+// @Success      200 {object} utils.Response{data=[]models.StatusTransition}
+// @Router       /admin/tournaments/{id}/history [get]
+// @Security     BearerAuth
+func GetTournamentStatusHistory(c *gin.Context) {
+	getStatusHistory(c, models.EntityTypeTournament)
+}
+
 // GetTournamentBySlug godoc
 // @Summary      Ver detalle de torneo por Slug
-// @Description  Obtiene la información completa de un torneo usando su Slug único
+// @Description  Obtiene la información completa de un torneo usando su Slug único. Los torneos privados solo los ven sus participantes y los administradores (con token).
 // @Tags         tournaments
 // @Param        slug path string true "Slug del Torneo"
 // @Produce      json
@@ -221,7 +300,8 @@ func GetTournamentBySlug(c *gin.Context) {
 	slug := c.Param("slug")
 	var tournament models.Tournament
 
-	if err := config.DB.Where("slug = ?", slug).First(&tournament).Error; err != nil {
+	if err := config.DB.Where("slug = ?", slug).First(&tournament).Error; err != nil ||
+		!services.CanViewTournament(config.DB, middleware.CurrentPrincipal(c), &tournament) {
 		utils.Error(c, http.StatusNotFound, "Torneo no encontrado por slug", nil)
 		return
 	}
@@ -236,10 +316,15 @@ func GetTournamentBySlug(c *gin.Context) {
 // @Param        id path int true "ID del Torneo"
 // @Produce      json
 // @Success      200 {object} utils.Response{data=[]models.Event} "Eventos del torneo"
+// @Failure      403 {object} utils.Response "Torneo privado"
 // @Failure      404 {object} utils.Response "Torneo no encontrado"
 // @Router       /tournaments/{id}/events [get]
 func GetTournamentEvents(c *gin.Context) {
-	id := c.Param("id")
+	tournament, ok := loadVisibleTournament(c)
+	if !ok {
+		return
+	}
+	id := tournament.SlateTournamentID()
 	var tournamentEvents []models.TournamentEvent
 
 	// Obtener los eventos del torneo a través de la tabla TournamentEvent
@@ -249,7 +334,7 @@ func GetTournamentEvents(c *gin.Context) {
 		Preload("Event.PickableSelections").
 		Preload("Session").
 		Where("tournament_id = ?", id).
-		Order("tournament_events.order asc").
+		Order("tournament_events.\"order\" asc").
 		Find(&tournamentEvents).Error; err != nil {
 		utils.Error(c, http.StatusInternalServerError, "Error al obtener eventos", nil)
 		return
@@ -321,11 +406,12 @@ func GetTournamentSessions(c *gin.Context) {
 // @Security     BearerAuth
 func GetMyTournaments(c *gin.Context) {
 	// Obtener ID del usuario del contexto (del middleware de auth)
-	userID, exists := c.Get("userID")
+	principal, exists := middleware.PrincipalFromContext(c)
 	if !exists {
 		utils.Error(c, http.StatusUnauthorized, "Usuario no autenticado", nil)
 		return
 	}
+	userID := principal.UserID
 
 	// Obtener IDs de torneos donde participa el usuario
 	var participantIDs []uint
@@ -355,3 +441,53 @@ func GetMyTournaments(c *gin.Context) {
 }
 
 // GetTournamentLeaderboard ya está definido en leaderboard_controller.go
+
+// settingsFromRequest mapea el DTO de reglas al modelo de configuración del torneo
+func settingsFromRequest(input dtos.TournamentSettingsRequest) models.TournamentSettings {
+	return models.TournamentSettings{
+		PrizeDistribution:      input.PrizeDistribution,
+		SelectionsPerSession:   input.SelectionsPerSession,
+		HorseRacingPoints:      input.HorseRacingPoints,
+		RequiredSelectionTypes: input.RequiredSelectionTypes,
+		TotalSessions:          input.TotalSessions,
+		ScoringMode:            input.ScoringMode,
+		OddsScoring: models.OddsScoring{
+			Formula:    input.OddsScoring.Formula,
+			BasePoints: input.OddsScoring.BasePoints,
+			Rounding:   input.OddsScoring.Rounding,
+			MinPoints:  input.OddsScoring.MinPoints,
+			MaxPoints:  input.OddsScoring.MaxPoints,
+		},
+	}
+}
+
+// getStatusHistory responde con el historial de estados de la entidad indicada por el parámetro id
+func getStatusHistory(c *gin.Context, entityType string) {
+	var history []models.StatusTransition
+	if err := config.DB.
+		Where("entity_type = ? AND entity_id = ?", entityType, c.Param("id")).
+		Order("created_at asc").
+		Find(&history).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener historial", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Historial de estados", history)
+}
+
+// resolveSlateTournamentID devuelve el ID del torneo cuya cartilla (sesiones y eventos)
+// corresponde al torneo indicado. Para ligas de usuarios es el torneo base.
+func resolveSlateTournamentID(tournamentID string) uint {
+	var tournament models.Tournament
+	if err := config.DB.Select("id", "parent_tournament_id").First(&tournament, tournamentID).Error; err != nil {
+		return utils.StringToUint(tournamentID)
+	}
+	return tournament.SlateTournamentID()
+}
+
+// transitionRejected indica si el cambio de estado falló por reglas del ciclo de vida (409) y no
+// por un error de base de datos (500)
+func transitionRejected(err error) bool {
+	return errors.Is(err, services.ErrInvalidTransition) || errors.Is(err, services.ErrTransitionConflict) ||
+		errors.Is(err, services.ErrTransitionBlocked)
+}
@@ -2,19 +2,22 @@ package controllers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
 	"github.com/cesarbmathec/bets-backend/models"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	_ "github.com/cesarbmathec/bets-backend/docs"
 )
 
 // JoinTournament godoc
 // @Summary      Inscribirse en un torneo
-// @Description  Permite a un usuario unirse a un torneo pagando la entrada (EntryFee)
+// @Description  Permite a un usuario unirse a un torneo pagando la entrada (EntryFee). Los torneos privados requieren invite_code.
 // @Tags         users
 // @Security     BearerAuth
 // @Param        id path int true "ID del Torneo"
@@ -23,7 +26,7 @@ import (
 // @Router       /tournaments/{id}/join [post]
 func JoinTournament(c *gin.Context) {
 	tournamentID := c.Param("id")
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 
 	var input dtos.JoinTournamentRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -41,6 +44,50 @@ func JoinTournament(c *gin.Context) {
 		return
 	}
 
+	// Los torneos privados solo admiten inscripción con el código de invitación
+	if tournament.IsPrivate() && (tournament.InviteCode == nil || !strings.EqualFold(*tournament.InviteCode, input.InviteCode)) {
+		tx.Rollback()
+		utils.Error(c, http.StatusForbidden, "Este torneo es privado. Se requiere un código de invitación válido", nil)
+		return
+	}
+
+	joinTournament(c, tx, &tournament, userID, input.PayWithTokens)
+}
+
+// JoinTournamentByCode godoc
+// @Summary      Unirse a un torneo privado con código
+// @Description  Inscribe al usuario en el torneo privado (o liga) asociado al código de invitación
+// @Tags         users
+// @Security     BearerAuth
+// @Param        request body dtos.JoinByCodeRequest true "Código de invitación"
+// @Success      201 {object} utils.Response{data=models.TournamentParticipant}
+// @Failure      404 {object} utils.Response "Código de invitación inválido"
+// @Router       /tournaments/join-by-code [post]
+func JoinTournamentByCode(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	var input dtos.JoinByCodeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	var tournament models.Tournament
+	code := strings.ToUpper(strings.TrimSpace(input.InviteCode))
+	if err := tx.Where("invite_code = ?", code).First(&tournament).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusNotFound, "Código de invitación inválido", nil)
+		return
+	}
+
+	joinTournament(c, tx, &tournament, userID, input.PayWithTokens)
+}
+
+// joinTournament completa la inscripción (cupo, pago y registro) dentro de la transacción
+// recibida y envía la respuesta. Hace commit o rollback según el resultado.
+func joinTournament(c *gin.Context, tx *gorm.DB, tournament *models.Tournament, userID uint, payWithTokens bool) {
 	if tournament.Status != "open" {
 		tx.Rollback()
 		utils.Error(c, http.StatusBadRequest, "El torneo no está abierto para inscripciones", nil)
@@ -55,6 +102,17 @@ func JoinTournament(c *gin.Context) {
 		return
 	}
 
+	// Verificar cupo disponible
+	if tournament.MaxParticipants > 0 {
+		var count int64
+		tx.Model(&models.TournamentParticipant{}).Where("tournament_id = ?", tournament.ID).Count(&count)
+		if count >= int64(tournament.MaxParticipants) {
+			tx.Rollback()
+			utils.Error(c, http.StatusConflict, "El torneo alcanzó el límite de participantes", nil)
+			return
+		}
+	}
+
 	// 3. Gestionar Pago (Wallet)
 	var wallet models.Wallet
 	if err := tx.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
@@ -67,7 +125,7 @@ func JoinTournament(c *gin.Context) {
 	currency := "USD"
 
 	// Lógica simple: Si pide pagar con tokens y el torneo tiene costo en tokens > 0
-	if input.PayWithTokens && tournament.EntryFeeTokens > 0 {
+	if payWithTokens && tournament.EntryFeeTokens > 0 {
 		if wallet.TokenBalance < tournament.EntryFeeTokens {
 			tx.Rollback()
 			utils.Error(c, http.StatusBadRequest, "Saldo de tokens insuficiente", nil)
@@ -95,7 +153,7 @@ func JoinTournament(c *gin.Context) {
 
 	// 4. Crear Inscripción
 	participant := models.TournamentParticipant{
-		UserID:       userID.(uint),
+		UserID:       userID,
 		TournamentID: tournament.ID,
 		TotalPoints:  0,
 	}
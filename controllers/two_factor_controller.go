@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// twoFactorError traduce los errores de la verificación en dos pasos a respuestas HTTP
+func twoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode), errors.Is(err, services.ErrInvalidTwoFactorChallenge):
+		utils.Error(c, http.StatusUnauthorized, err.Error(), nil)
+	case errors.Is(err, services.ErrTwoFactorLocked):
+		utils.Error(c, http.StatusTooManyRequests, err.Error(), nil)
+	case errors.Is(err, services.ErrUserInactive):
+		utils.Error(c, http.StatusForbidden, "Cuenta de usuario desactivada", nil)
+	case errors.Is(err, services.ErrTwoFactorRequired):
+		utils.Error(c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, services.ErrTwoFactorNotEnabled), errors.Is(err, services.ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, services.ErrTwoFactorNotSetUp):
+		utils.Error(c, http.StatusConflict, err.Error(), nil)
+	default:
+		utils.Error(c, http.StatusInternalServerError, "Error en la verificación en dos pasos", nil)
+	}
+}
+
+// currentUser carga el usuario autenticado completo (con secreto TOTP) para operar su 2FA
+func currentUser(c *gin.Context) (*models.User, bool) {
+	var user models.User
+	if err := config.DB.First(&user, middleware.CurrentPrincipal(c).UserID).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Usuario no encontrado", nil)
+		return nil, false
+	}
+	return &user, true
+}
+
+// VerifyTwoFactorLogin godoc
+// @Summary      Segundo paso del login
+// @Description  Completa un login con 2FA usando el challenge_token recibido en /auth/login y un código de la app o de recuperación. El reto vence a los 5 minutos o tras 5 códigos erróneos.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dtos.TwoFactorLoginRequest true "Reto y código"
+// @Success      200 {object} utils.Response{data=dtos.LoginResponse}
+// @Failure      401 {object} utils.Response "Código o reto inválido"
+// @Router       /auth/2fa/verify [post]
+// @example request -json {"challenge_token": "Zk3x...", "code": "123456"}
+func VerifyTwoFactorLogin(c *gin.Context) {
+	var input dtos.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos de entrada inválidos", err.Error())
+		return
+	}
+
+	user, err := services.CompleteTwoFactorChallenge(config.DB, input.ChallengeToken, input.Code, time.Now())
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	issueLogin(c, config.DB, user)
+}
+
+// GetTwoFactorStatus godoc
+// @Summary      Estado de la verificación en dos pasos
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} utils.Response{data=dtos.TwoFactorStatusResponse}
+// @Router       /auth/2fa [get]
+// @Security     BearerAuth
+func GetTwoFactorStatus(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	status := dtos.TwoFactorStatusResponse{
+		Enabled:  user.TwoFactorEnabled,
+		Required: services.RequireStaffTwoFactor && middleware.CurrentPrincipal(c).IsStaff(),
+	}
+	if user.TwoFactorEnabled {
+		status.RemainingRecoveryCodes = services.RemainingRecoveryCodes(config.DB, user.ID)
+	}
+
+	utils.Success(c, http.StatusOK, "Verificación en dos pasos", status)
+}
+
+// SetupTwoFactor godoc
+// @Summary      Iniciar alta de 2FA
+// @Description  Genera un secreto TOTP y su URI otpauth:// para escanear como QR. La 2FA no se activa hasta confirmar un código en /auth/2fa/enable; repetir el alta reemplaza el secreto pendiente.
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} utils.Response{data=dtos.TwoFactorSetupResponse}
+// @Failure      409 {object} utils.Response "La 2FA ya está activa"
+// @Router       /auth/2fa/setup [post]
+// @Security     BearerAuth
+func SetupTwoFactor(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	secret, uri, err := services.BeginTwoFactorSetup(config.DB, user)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Escanee el código QR con su app autenticadora y confirme un código", dtos.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: uri,
+	})
+}
+
+// EnableTwoFactor godoc
+// @Summary      Activar 2FA
+// @Description  Confirma el alta con un código de la app y devuelve 10 códigos de recuperación de un solo uso, que no se vuelven a mostrar
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dtos.TwoFactorCodeRequest true "Código de la app"
+// @Success      200 {object} utils.Response{data=dtos.RecoveryCodesResponse}
+// @Failure      401 {object} utils.Response "Código inválido"
+// @Failure      409 {object} utils.Response "Alta no iniciada o 2FA ya activa"
+// @Router       /auth/2fa/enable [post]
+// @Security     BearerAuth
+// @example request -json {"code": "123456"}
+func EnableTwoFactor(c *gin.Context) {
+	var input dtos.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos de entrada inválidos", err.Error())
+		return
+	}
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	codes, err := services.EnableTwoFactor(config.DB, user, input.Code, time.Now())
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Verificación en dos pasos activada. Guarde los códigos de recuperación: no se volverán a mostrar.", dtos.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor godoc
+// @Summary      Desactivar 2FA
+// @Description  Apaga la 2FA con un código de la app o de recuperación. No disponible para roles de administración mientras sea obligatoria.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dtos.TwoFactorCodeRequest true "Código"
+// @Success      200 {object} utils.Response
+// @Failure      401 {object} utils.Response "Código inválido"
+// @Failure      403 {object} utils.Response "El rol exige 2FA"
+// @Failure      429 {object} utils.Response "2FA bloqueada por 15 minutos tras 5 códigos erróneos"
+// @Router       /auth/2fa/disable [post]
+// @Security     BearerAuth
+// @example request -json {"code": "123456"}
+func DisableTwoFactor(c *gin.Context) {
+	var input dtos.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos de entrada inválidos", err.Error())
+		return
+	}
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	staff := middleware.CurrentPrincipal(c).IsStaff()
+	if err := services.DisableTwoFactor(config.DB, user, staff, input.Code, time.Now()); err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Verificación en dos pasos desactivada", nil)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Regenerar códigos de recuperación
+// @Description  Invalida los códigos anteriores y devuelve 10 nuevos
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dtos.TwoFactorCodeRequest true "Código"
+// @Success      200 {object} utils.Response{data=dtos.RecoveryCodesResponse}
+// @Failure      401 {object} utils.Response "Código inválido"
+// @Failure      429 {object} utils.Response "2FA bloqueada por 15 minutos tras 5 códigos erróneos"
+// @Router       /auth/2fa/recovery-codes [post]
+// @Security     BearerAuth
+// @example request -json {"code": "123456"}
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var input dtos.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos de entrada inválidos", err.Error())
+		return
+	}
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	codes, err := services.RegenerateRecoveryCodes(config.DB, user, input.Code, time.Now())
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Códigos de recuperación regenerados", dtos.RecoveryCodesResponse{RecoveryCodes: codes})
+}
@@ -1,11 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
 	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -50,12 +54,14 @@ func GetUserByID(c *gin.Context) {
 
 // UpdateUserRole godoc
 // @Summary      Actualizar rol de usuario
-// @Description  Permite al admin cambiar el rol de un usuario (user o admin)
+// @Description  Asigna a un usuario un rol existente (user, admin o un rol de operador). No se puede asignar ni quitar un rol con permisos que el actor no tenga, cambiar el propio rol ni, sin ser admin, cambiar el rol de un admin. Si el rol cambia, se revocan sus sesiones.
 // @Tags         admin
 // @Security     BearerAuth
 // @Param        id path int true "ID del Usuario"
 // @Param        request body dtos.UpdateUserRoleRequest true "Nuevo rol"
 // @Success      200 {object} utils.Response
+// @Failure      400 {object} utils.Response "El rol no existe"
+// @Failure      403 {object} utils.Response "Rol propio, de un admin o con permisos que el actor no tiene"
 // @Router       /admin/users/{id}/role [patch]
 func UpdateUserRole(c *gin.Context) {
 	id := c.Param("id")
@@ -72,16 +78,25 @@ func UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	// No permitir que un admin se degrade a sí mismo
-	currentUserID, _ := c.Get("userID")
-	if currentUserID.(uint) == user.ID && input.Role != "admin" {
-		utils.Error(c, http.StatusForbidden, "No puede degradar su propio rol de administrador", nil)
+	// Nadie cambia su propio rol: evita autodegradarse o autoascenderse
+	actor := middleware.CurrentPrincipal(c)
+	if actor.UserID == user.ID {
+		utils.Error(c, http.StatusForbidden, "No puede cambiar su propio rol", nil)
 		return
 	}
 
-	user.Role = input.Role
-	if err := config.DB.Save(&user).Error; err != nil {
-		utils.Error(c, http.StatusInternalServerError, "Error al actualizar rol", nil)
+	// Los tokens emitidos con el rol anterior dejan de valer
+	if err := services.AssignRole(config.DB, actor, &user, input.Role); err != nil {
+		switch {
+		case errors.Is(err, services.ErrRoleNotFound):
+			utils.Error(c, http.StatusBadRequest, "El rol no existe", nil)
+		case errors.Is(err, services.ErrRoleEscalation):
+			utils.Error(c, http.StatusForbidden, "No puede asignar ni quitar un rol con permisos que usted no tiene", nil)
+		case errors.Is(err, services.ErrAdminProtected):
+			utils.Error(c, http.StatusForbidden, err.Error(), nil)
+		default:
+			utils.Error(c, http.StatusInternalServerError, "Error al actualizar rol", nil)
+		}
 		return
 	}
 
@@ -90,7 +105,7 @@ func UpdateUserRole(c *gin.Context) {
 
 // UpdateUserStatus godoc
 // @Summary      Actualizar estado de usuario
-// @Description  Activa o desactiva un usuario
+// @Description  Activa o desactiva un usuario. Al desactivarlo se revocan todas sus sesiones.
 // @Tags         admin
 // @Security     BearerAuth
 // @Param        id path int true "ID del Usuario"
@@ -113,17 +128,40 @@ func UpdateUserStatus(c *gin.Context) {
 	}
 
 	// No permitir que un admin se desactive a sí mismo
-	currentUserID, _ := c.Get("userID")
-	if currentUserID.(uint) == user.ID && !input.IsActive {
+	actor := middleware.CurrentPrincipal(c)
+	if actor.UserID == user.ID && !input.IsActive {
 		utils.Error(c, http.StatusForbidden, "No puede desactivarse a sí mismo", nil)
 		return
 	}
 
+	// Solo un administrador cambia el estado de otro administrador, y un operador no puede
+	// desactivar a quien tiene permisos que él no tiene
+	if err := services.CanManageUser(config.DB, actor, &user); err != nil {
+		switch {
+		case errors.Is(err, services.ErrAdminProtected):
+			utils.Error(c, http.StatusForbidden, "Solo un administrador puede cambiar el estado de otro administrador", nil)
+		case errors.Is(err, services.ErrRoleEscalation):
+			utils.Error(c, http.StatusForbidden, "No puede cambiar el estado de un usuario con permisos que usted no tiene", nil)
+		default:
+			utils.Error(c, http.StatusInternalServerError, "Error al verificar permisos", nil)
+		}
+		return
+	}
+
 	user.IsActive = input.IsActive
-	if err := config.DB.Save(&user).Error; err != nil {
+	if err := config.DB.Model(&user).Update("is_active", input.IsActive).Error; err != nil {
 		utils.Error(c, http.StatusInternalServerError, "Error al actualizar estado", nil)
 		return
 	}
+	services.InvalidatePrincipal(user.ID)
+
+	// Un usuario desactivado pierde todas sus sesiones
+	if !user.IsActive {
+		if err := services.RevokeUserSessions(config.DB, user.ID, time.Now()); err != nil {
+			utils.Error(c, http.StatusInternalServerError, "Estado actualizado pero no se pudieron revocar sus sesiones", nil)
+			return
+		}
+	}
 
 	utils.Success(c, http.StatusOK, "Estado actualizado correctamente", user)
 }
@@ -136,7 +174,7 @@ func UpdateUserStatus(c *gin.Context) {
 // @Success      200 {object} utils.Response{data=models.User}
 // @Router       /me [get]
 func GetMyProfile(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 	var user models.User
 
 	if err := config.DB.First(&user, userID).Error; err != nil {
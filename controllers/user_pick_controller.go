@@ -1,32 +1,169 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
 	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	_ "github.com/cesarbmathec/bets-backend/docs"
 )
 
-// SubmitPicks godoc
-// @Summary      Enviar predicciones para un torneo (versión anterior)
-// @Description  Permite a un participante enviar una o varias selecciones
+// GetMyPicks godoc
+// @Summary      Mis predicciones
+// @Description  Lista todos los picks del usuario en todos sus torneos, con evento, competidor, línea y puntos obtenidos. Los picks pendientes de eventos en vivo traen provisional_status (winning, losing, push).
 // @Tags         users
 // @Security     BearerAuth
-// @Param        id path int true "ID del Torneo"
-// @Param        request body dtos.SubmitPicksRequest true "IDs de las selecciones"
-// @Success      201 {object} utils.Response
-// @Router       /tournaments/{id}/picks [post]
-func SubmitPicks(c *gin.Context) {
-	tournamentID := c.Param("id")
-	userID, _ := c.Get("userID")
-
-	var input dtos.SubmitPicksRequest
+// @Param        status query string false "Filtrar por estado (pending, won, lost, push)"
+// @Param        session_id query int false "Filtrar por sesión"
+// @Param        tournament_id query int false "Filtrar por torneo"
+// @Produce      json
+// @Success      200 {object} utils.Response{data=[]dtos.MyPickResponse}
+// @Router       /my-picks [get]
+func GetMyPicks(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	query := config.DB.
+		Joins("JOIN tournament_participants ON tournament_participants.id = user_picks.participant_id").
+		Where("tournament_participants.user_id = ?", userID)
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("user_picks.status = ?", status)
+	}
+	if sessionID := c.Query("session_id"); sessionID != "" {
+		query = query.Where("user_picks.session_id = ?", sessionID)
+	}
+	if tournamentID := c.Query("tournament_id"); tournamentID != "" {
+		query = query.Where("tournament_participants.tournament_id = ?", tournamentID)
+	}
+
+	var picks []models.UserPick
+	if err := query.
+		Preload("Participant.Tournament").
+		Preload("Session").
+		Preload("Selection.Event.Competitors").
+		Order("user_picks.created_at desc").
+		Find(&picks).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener predicciones", nil)
+		return
+	}
+
+	response := make([]dtos.MyPickResponse, 0, len(picks))
+	for _, pick := range picks {
+		item := dtos.MyPickResponse{
+			PickID:         pick.ID,
+			TournamentID:   pick.Participant.TournamentID,
+			TournamentName: pick.Participant.Tournament.Name,
+			SessionID:      pick.SessionID,
+			SessionNumber:  pick.Session.SessionNumber,
+			SelectionID:    pick.SelectionID,
+			SelectionType:  pick.Selection.SelectionType,
+			Description:    pick.Selection.Description,
+			EventID:        pick.Selection.EventID,
+			EventName:      pick.Selection.Event.Name,
+			EventStartTime: pick.Selection.Event.StartTime,
+			EventStatus:    pick.Selection.Event.Status,
+			Line:           pick.Selection.Line,
+			Odds:           pick.Selection.Odds,
+			Status:         pick.Status,
+			AwardedPoints:  pick.AwardedPoints,
+			CreatedAt:      pick.CreatedAt,
+		}
+
+		// Línea y cuota con que se hizo el pick (los picks anteriores al snapshot usan las actuales)
+		if pick.LineAtPick != nil {
+			item.Line = *pick.LineAtPick
+		}
+		if pick.OddsAtPick != nil {
+			item.Odds = *pick.OddsAtPick
+		}
+
+		// CompetitorID de la selección referencia al competidor dentro del evento
+		if pick.Selection.CompetitorID != nil {
+			item.CompetitorID = pick.Selection.CompetitorID
+			for _, competitor := range pick.Selection.Event.Competitors {
+				if competitor.ID == *pick.Selection.CompetitorID {
+					item.CompetitorName = competitor.Name
+					break
+				}
+			}
+		}
+
+		if pick.Status == "pending" {
+			item.ProvisionalStatus = services.ProvisionalStatus(&pick.Selection.Event, &pick.Selection, pick.LineAtPick)
+		}
+
+		response = append(response, item)
+	}
+
+	utils.Success(c, http.StatusOK, "Tus predicciones", response)
+}
+
+// GetMyPicksSummary godoc
+// @Summary      Resumen de mis predicciones por sesión
+// @Description  Cuenta los picks ganados, perdidos, empatados y pendientes del usuario en cada sesión
+// @Tags         users
+// @Security     BearerAuth
+// @Param        tournament_id query int false "Filtrar por torneo"
+// @Produce      json
+// @Success      200 {object} utils.Response{data=[]dtos.SessionPickSummary}
+// @Router       /my-picks/summary [get]
+func GetMyPicksSummary(c *gin.Context) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	query := config.DB.Model(&models.UserPick{}).
+		Select(`tournament_participants.tournament_id AS tournament_id,
+			user_picks.session_id AS session_id,
+			tournament_sessions.session_number AS session_number,
+			COUNT(*) AS total,
+			SUM(CASE WHEN user_picks.status = 'won' THEN 1 ELSE 0 END) AS won,
+			SUM(CASE WHEN user_picks.status = 'lost' THEN 1 ELSE 0 END) AS lost,
+			SUM(CASE WHEN user_picks.status = 'push' THEN 1 ELSE 0 END) AS push,
+			SUM(CASE WHEN user_picks.status = 'pending' THEN 1 ELSE 0 END) AS pending,
+			COALESCE(SUM(user_picks.awarded_points), 0) AS points`).
+		Joins("JOIN tournament_participants ON tournament_participants.id = user_picks.participant_id").
+		Joins("JOIN tournament_sessions ON tournament_sessions.id = user_picks.session_id").
+		Where("tournament_participants.user_id = ?", userID)
+
+	if tournamentID := c.Query("tournament_id"); tournamentID != "" {
+		query = query.Where("tournament_participants.tournament_id = ?", tournamentID)
+	}
+
+	summary := []dtos.SessionPickSummary{}
+	if err := query.
+		Group("tournament_participants.tournament_id, user_picks.session_id, tournament_sessions.session_number").
+		Order("tournament_participants.tournament_id asc, tournament_sessions.session_number asc").
+		Scan(&summary).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al calcular resumen", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Resumen de tus predicciones", summary)
+}
+
+// UpdatePick godoc
+// @Summary      Cambiar un pick
+// @Description  Reemplaza la selección de un pick mientras la sesión esté abierta y su evento no haya comenzado. La cartilla resultante se evalúa con las reglas del torneo.
+// @Tags         users
+// @Security     BearerAuth
+// @Param        pick_id path int true "ID del Pick"
+// @Param        request body dtos.UpdatePickRequest true "Nueva selección"
+// @Success      200 {object} utils.Response{data=models.UserPick}
+// @Failure      404 {object} utils.Response "Pick no encontrado"
+// @Failure      409 {object} utils.Response "Pick congelado o sesión cerrada"
+// @Failure      422 {object} utils.Response "Violaciones de reglas de la cartilla"
+// @Router       /my-picks/{pick_id} [put]
+// @example request -json {"selection_id": 42}
+func UpdatePick(c *gin.Context) {
+	var input dtos.UpdatePickRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
 		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
 		return
@@ -34,68 +171,153 @@ func SubmitPicks(c *gin.Context) {
 
 	tx := config.DB.Begin()
 
-	// 1. Validar que el usuario es participante del torneo
-	var participant models.TournamentParticipant
-	if err := tx.Where("user_id = ? AND tournament_id = ?", userID, tournamentID).First(&participant).Error; err != nil {
+	pick, ok := loadEditablePick(c, tx)
+	if !ok {
 		tx.Rollback()
-		utils.Error(c, http.StatusForbidden, "No estás inscrito en este torneo", nil)
 		return
 	}
 
-	var savedPicks []models.UserPick
-	var err error
+	if input.SelectionID == pick.SelectionID {
+		tx.Rollback()
+		utils.Success(c, http.StatusOK, "Sin cambios", pick)
+		return
+	}
 
-	// 2. Procesar cada selección
-	for _, selectionID := range input.SelectionIDs {
-		var selection models.PickableSelection
-		// Preload Event para validar fechas
-		if err = tx.Preload("Event").First(&selection, selectionID).Error; err != nil {
-			continue // Si una selección no existe, la saltamos o podríamos retornar error
-		}
+	var selection models.PickableSelection
+	if err := tx.Preload("Event").First(&selection, input.SelectionID).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusNotFound, "Selección no encontrada", nil)
+		return
+	}
 
-		// Validar que el evento de la selección pertenezca al torneo actual (ahora via TournamentEvent)
-		tournamentIDUint := utils.StringToUint(tournamentID)
+	if selection.Event.HasStarted(time.Now()) {
+		tx.Rollback()
+		utils.Error(c, http.StatusBadRequest, "El evento de la nueva selección ya ha comenzado", nil)
+		return
+	}
 
-		// Verificar que existe una relación en TournamentEvent para este evento y torneo
-		var tournamentEvent models.TournamentEvent
-		err = tx.Where("event_id = ? AND tournament_id = ?", selection.EventID, tournamentIDUint).First(&tournamentEvent).Error
-		if err != nil {
-			tx.Rollback()
-			utils.Error(c, http.StatusBadRequest, "La selección #"+utils.UintToString(selection.ID)+" no pertenece a este torneo.", nil)
-			return
-		}
+	var tournament models.Tournament
+	if err := tx.First(&tournament, pick.Participant.TournamentID).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusNotFound, "Torneo no encontrado", nil)
+		return
+	}
 
-		// 3. Validar que el evento no haya comenzado
-		if time.Now().After(selection.Event.StartTime) {
-			tx.Rollback()
-			utils.Error(c, http.StatusBadRequest, "El evento '"+selection.Event.Name+"' ya ha comenzado o cerrado", nil)
-			return
-		}
+	// Con tipos obligatorios configurados, el cambio debe conservar el tipo del pick
+	if !tournament.Settings.FreeSelection && len(tournament.Settings.RequiredSelectionTypes) > 0 &&
+		selection.SelectionType != pick.Selection.SelectionType {
+		tx.Rollback()
+		utils.Error(c, http.StatusBadRequest,
+			fmt.Sprintf("La nueva selección debe ser de tipo '%s'", pick.Selection.SelectionType), nil)
+		return
+	}
 
-		// 4. Guardar o Actualizar el Pick
-		// Buscamos si ya existe un pick para esta selección por este participante para evitar duplicados
-		var existingPick models.UserPick
-		err := tx.Where("participant_id = ? AND selection_id = ?", participant.ID, selection.ID).First(&existingPick).Error
-
-		if err == nil {
-			// Ya existe, no hacemos nada (o podríamos actualizar si hubiera campos extra)
-			savedPicks = append(savedPicks, existingPick)
-		} else {
-			// Crear nuevo pick
-			newPick := models.UserPick{
-				ParticipantID: participant.ID,
-				SelectionID:   selection.ID,
-				Status:        "pending",
-			}
-			if err := tx.Create(&newPick).Error; err != nil {
-				tx.Rollback()
-				utils.Error(c, http.StatusInternalServerError, "Error al guardar predicción", err.Error())
-				return
-			}
-			savedPicks = append(savedPicks, newPick)
-		}
+	// Evaluar la cartilla resultante (misma sesión, sin duplicados ni contradicciones)
+	var selectionIDs []uint
+	tx.Model(&models.UserPick{}).
+		Where("participant_id = ? AND session_id = ? AND id <> ?", pick.ParticipantID, pick.SessionID, pick.ID).
+		Pluck("selection_id", &selectionIDs)
+	selectionIDs = append(selectionIDs, selection.ID)
+
+	violations, err := services.ValidatePartialSlate(tx, &tournament, &pick.Session, selectionIDs)
+	if err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al validar la cartilla", err.Error())
+		return
+	}
+	if len(violations) > 0 {
+		tx.Rollback()
+		utils.Error(c, http.StatusUnprocessableEntity, "La cartilla no cumple las reglas del torneo", violations)
+		return
+	}
+
+	services.SnapshotPick(tx, pick, &selection)
+	// Sin omitir las asociaciones precargadas, GORM reescribiría selection_id con la selección anterior
+	if err := tx.Model(pick).Omit("Participant", "Session", "Selection").Updates(map[string]interface{}{
+		"selection_id": selection.ID,
+		"line_at_pick": pick.LineAtPick,
+		"odds_at_pick": pick.OddsAtPick,
+	}).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al actualizar el pick", err.Error())
+		return
+	}
+
+	if err := services.AdjustPickStat(tx, tournament.ID, pick.SessionID, &pick.Selection, -1); err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al actualizar estadísticas", err.Error())
+		return
+	}
+	if err := services.AdjustPickStat(tx, tournament.ID, pick.SessionID, &selection, 1); err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al actualizar estadísticas", err.Error())
+		return
 	}
 
 	tx.Commit()
-	utils.Success(c, http.StatusCreated, "Predicciones guardadas", savedPicks)
+
+	pick.SelectionID = selection.ID
+	pick.Selection = selection
+	utils.Success(c, http.StatusOK, "Pick actualizado", pick)
+}
+
+// DeletePick godoc
+// @Summary      Eliminar un pick
+// @Description  Elimina un pick mientras la sesión esté abierta y su evento no haya comenzado
+// @Tags         users
+// @Security     BearerAuth
+// @Param        pick_id path int true "ID del Pick"
+// @Success      200 {object} utils.Response
+// @Failure      404 {object} utils.Response "Pick no encontrado"
+// @Failure      409 {object} utils.Response "Pick congelado o sesión cerrada"
+// @Router       /my-picks/{pick_id} [delete]
+func DeletePick(c *gin.Context) {
+	tx := config.DB.Begin()
+
+	pick, ok := loadEditablePick(c, tx)
+	if !ok {
+		tx.Rollback()
+		return
+	}
+
+	if err := tx.Delete(pick).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al eliminar el pick", nil)
+		return
+	}
+
+	if err := services.AdjustPickStat(tx, pick.Participant.TournamentID, pick.SessionID, &pick.Selection, -1); err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al actualizar estadísticas", err.Error())
+		return
+	}
+
+	tx.Commit()
+	utils.Success(c, http.StatusOK, "Pick eliminado", nil)
+}
+
+// loadEditablePick carga el pick de la ruta y verifica que sea del usuario, que su sesión siga
+// abierta y que su evento no haya comenzado. Envía la respuesta de error si no es editable.
+func loadEditablePick(c *gin.Context, tx *gorm.DB) (*models.UserPick, bool) {
+	userID := middleware.CurrentPrincipal(c).UserID
+
+	var pick models.UserPick
+	if err := tx.Preload("Participant").Preload("Session").Preload("Selection.Event").
+		First(&pick, c.Param("pick_id")).Error; err != nil || pick.Participant.UserID != userID {
+		utils.Error(c, http.StatusNotFound, "Pick no encontrado", nil)
+		return nil, false
+	}
+
+	now := time.Now()
+	if pick.Session.Status != models.SessionStatusOpen || now.After(pick.Session.EndTime) {
+		utils.Error(c, http.StatusConflict, "La sesión ya no acepta cambios", nil)
+		return nil, false
+	}
+
+	if pick.Selection.Event.HasStarted(now) {
+		utils.Error(c, http.StatusConflict, "El pick está congelado porque su evento ya comenzó", nil)
+		return nil, false
+	}
+
+	return &pick, true
 }
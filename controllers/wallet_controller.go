@@ -5,7 +5,9 @@ import (
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
 	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
 
@@ -20,7 +22,7 @@ import (
 // @Success      200 {object} utils.Response{data=dtos.WalletResponse}
 // @Router       /wallet/balance [get]
 func GetBalance(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 	var wallet models.Wallet
 
 	if err := config.DB.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
@@ -58,7 +60,7 @@ func DepositMoney(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 
 	// Usamos una transacción de BD para asegurar integridad
 	tx := config.DB.Begin()
@@ -87,6 +89,7 @@ func DepositMoney(c *gin.Context) {
 	tx.Create(&transaction)
 
 	tx.Commit()
+	services.PublishWalletBalance(&wallet)
 	utils.Success(c, http.StatusOK, "Depósito exitoso", wallet)
 }
 
@@ -98,7 +101,7 @@ func DepositMoney(c *gin.Context) {
 // @Success      200 {object} utils.Response{data=[]dtos.TransactionResponse}
 // @Router       /wallet/history [get]
 func GetTransactionHistory(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 	var wallet models.Wallet
 
 	// 1. Buscamos la billetera del usuario
@@ -139,7 +142,7 @@ func GetTransactionHistory(c *gin.Context) {
 // @Success      200 {object} utils.Response{data=dtos.UserStatsResponse}
 // @Router       /wallet/statistics [get]
 func GetUserStatistics(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 	var wallet models.Wallet
 
 	if err := config.DB.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	_ "github.com/cesarbmathec/bets-backend/docs"
+)
+
+// validateWebhookEventTypes revisa que todos los tipos existan
+func validateWebhookEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !models.IsValidWebhookEventType(eventType) {
+			return fmt.Errorf("tipo de evento inválido: %s", eventType)
+		}
+	}
+	return nil
+}
+
+// CreateWebhook godoc
+// @Summary      Registrar un webhook
+// @Description  Suscribe el endpoint de un socio a los tipos de evento indicados. Cada entrega va firmada con HMAC-SHA256 del secreto sobre "{X-Webhook-Timestamp}.{cuerpo}" en la cabecera X-Webhook-Signature. El secreto solo se devuelve en esta respuesta.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request body dtos.CreateWebhookRequest true "Endpoint y eventos"
+// @Success      201 {object} utils.Response{data=dtos.WebhookCreatedResponse}
+// @Failure      400 {object} utils.Response "Datos inválidos o tipo de evento desconocido"
+// @Router       /admin/webhooks [post]
+// @Security     BearerAuth
+// @example request -json {"name": "Bot de Telegram", "url": "https://partner.example.com/webhooks/bets", "event_types": ["event.settled", "prize.paid"]}
+func CreateWebhook(c *gin.Context) {
+	var input dtos.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+	if err := validateWebhookEventTypes(input.EventTypes); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		var err error
+		if secret, err = services.NewWebhookSecret(); err != nil {
+			utils.Error(c, http.StatusInternalServerError, "No se pudo generar el secreto", nil)
+			return
+		}
+	}
+
+	userID := middleware.CurrentPrincipal(c).UserID
+	webhook := models.WebhookSubscription{
+		Name:       input.Name,
+		URL:        input.URL,
+		Secret:     secret,
+		EventTypes: models.WebhookEventList(input.EventTypes),
+		IsActive:   true,
+		CreatedBy:  userID,
+	}
+	if err := config.DB.Create(&webhook).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "No se pudo crear el webhook", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusCreated, "Webhook creado. Guarda el secreto: no se volverá a mostrar.", dtos.WebhookCreatedResponse{Webhook: webhook, Secret: secret})
+}
+
+// GetWebhooks godoc
+// @Summary      Listar webhooks
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} utils.Response{data=[]models.WebhookSubscription}
+// @Router       /admin/webhooks [get]
+// @Security     BearerAuth
+func GetWebhooks(c *gin.Context) {
+	var webhooks []models.WebhookSubscription
+	config.DB.Order("id").Find(&webhooks)
+
+	utils.Success(c, http.StatusOK, "Webhooks registrados", webhooks)
+}
+
+// UpdateWebhook godoc
+// @Summary      Actualizar un webhook
+// @Description  Cambia nombre, URL, eventos o lo activa/desactiva. Un webhook desactivado deja de recibir eventos nuevos.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID del webhook"
+// @Param        request body dtos.UpdateWebhookRequest true "Campos a cambiar"
+// @Success      200 {object} utils.Response{data=models.WebhookSubscription}
+// @Failure      404 {object} utils.Response "Webhook no encontrado"
+// @Router       /admin/webhooks/{id} [put]
+// @Security     BearerAuth
+// @example request -json {"is_active": false}
+func UpdateWebhook(c *gin.Context) {
+	var input dtos.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+	if err := validateWebhookEventTypes(input.EventTypes); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	var webhook models.WebhookSubscription
+	if err := config.DB.First(&webhook, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Webhook no encontrado", nil)
+		return
+	}
+
+	if input.Name != nil {
+		webhook.Name = *input.Name
+	}
+	if input.URL != nil {
+		webhook.URL = *input.URL
+	}
+	if len(input.EventTypes) > 0 {
+		webhook.EventTypes = models.WebhookEventList(input.EventTypes)
+	}
+	if input.IsActive != nil {
+		webhook.IsActive = *input.IsActive
+	}
+	if err := config.DB.Save(&webhook).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "No se pudo actualizar el webhook", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Webhook actualizado", webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary      Eliminar un webhook
+// @Description  Sus entregas pendientes pasan a dead-letter en el siguiente intento
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "ID del webhook"
+// @Success      200 {object} utils.Response
+// @Failure      404 {object} utils.Response "Webhook no encontrado"
+// @Router       /admin/webhooks/{id} [delete]
+// @Security     BearerAuth
+func DeleteWebhook(c *gin.Context) {
+	var webhook models.WebhookSubscription
+	if err := config.DB.First(&webhook, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Webhook no encontrado", nil)
+		return
+	}
+
+	config.DB.Delete(&webhook)
+	utils.Success(c, http.StatusOK, "Webhook eliminado", nil)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary      Entregas de un webhook
+// @Description  Historial de entregas del webhook, de la más reciente a la más antigua
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "ID del webhook"
+// @Param        status query string false "pending, delivered o dead"
+// @Param        limit query int false "Cantidad máxima (por defecto 50)"
+// @Success      200 {object} utils.Response{data=[]models.WebhookDelivery}
+// @Router       /admin/webhooks/{id}/deliveries [get]
+// @Security     BearerAuth
+func GetWebhookDeliveries(c *gin.Context) {
+	query := config.DB.Where("subscription_id = ?", c.Param("id"))
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	listWebhookDeliveries(c, query, "Entregas del webhook")
+}
+
+// GetDeadWebhookDeliveries godoc
+// @Summary      Dead-letter de webhooks
+// @Description  Entregas que agotaron los reintentos, de todos los webhooks. Se pueden reenviar con replay.
+// @Tags         admin
+// @Produce      json
+// @Param        limit query int false "Cantidad máxima (por defecto 50)"
+// @Success      200 {object} utils.Response{data=[]models.WebhookDelivery}
+// @Router       /admin/webhook-deliveries/dead [get]
+// @Security     BearerAuth
+func GetDeadWebhookDeliveries(c *gin.Context) {
+	listWebhookDeliveries(c, config.DB.Where("status = ?", models.WebhookDeliveryDead), "Entregas en dead-letter")
+}
+
+func listWebhookDeliveries(c *gin.Context, query *gorm.DB, message string) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("created_at desc, id desc").Limit(limit).Find(&deliveries).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener entregas", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, message, deliveries)
+}
+
+// ReplayWebhookDelivery godoc
+// @Summary      Reenviar una entrega de webhook
+// @Description  Vuelve a poner en cola una entrega entregada o en dead-letter con los intentos en cero. El cuerpo y el ID del evento no cambian.
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "ID de la entrega"
+// @Success      200 {object} utils.Response{data=models.WebhookDelivery}
+// @Failure      404 {object} utils.Response "Entrega no encontrada"
+// @Failure      409 {object} utils.Response "La entrega ya está pendiente o el webhook está desactivado"
+// @Router       /admin/webhook-deliveries/{id}/replay [post]
+// @Security     BearerAuth
+func ReplayWebhookDelivery(c *gin.Context) {
+	var delivery models.WebhookDelivery
+	if err := config.DB.First(&delivery, c.Param("id")).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Entrega no encontrada", nil)
+		return
+	}
+
+	if err := services.ReplayWebhookDelivery(config.DB, &delivery, time.Now()); err != nil {
+		if errors.Is(err, services.ErrWebhookAlreadyPending) || errors.Is(err, services.ErrWebhookSubscriptionInactive) {
+			utils.Error(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		utils.Error(c, http.StatusInternalServerError, "No se pudo reenviar la entrega", err.Error())
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Entrega en cola para reenvío", delivery)
+}
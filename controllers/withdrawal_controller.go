@@ -1,14 +1,21 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/dtos"
+	middleware "github.com/cesarbmathec/bets-backend/middlewares"
 	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Constants for withdrawal limits and verification
@@ -32,14 +39,14 @@ var withdrawalVerifications = make(map[uint]struct {
 
 // CreateWithdrawal godoc
 // @Summary      Solicitar retiro
-// @Description  Crea una solicitud de retiro con verificación de código
+// @Description  Crea una solicitud de retiro con verificación de código. El código se envía por correo (y SMS si hay teléfono) y nunca se devuelve en la respuesta.
 // @Tags         wallet
 // @Security     BearerAuth
 // @Param        withdrawal body dtos.WithdrawalRequest true "Datos del retiro"
-// @Success      200 {object} utils.Response
+// @Success      200 {object} utils.Response{data=dtos.WithdrawalPendingVerificationResponse}
 // @Router       /wallet/withdraw [post]
 func CreateWithdrawal(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 
 	var input dtos.WithdrawalRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -68,12 +75,18 @@ func CreateWithdrawal(c *gin.Context) {
 	}
 
 	// Verificar límites de retiro
-	limits, _ := getWithdrawalLimits(userID.(uint), availableBalance)
+	limits, _ := getWithdrawalLimits(userID, availableBalance)
 	if input.Amount > limits.AvailableToday {
 		utils.Error(c, http.StatusBadRequest, "Excedes el límite de retiro diario", nil)
 		return
 	}
 
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Usuario no encontrado", nil)
+		return
+	}
+
 	// Verificar que el método de pago exista y pertenezca al usuario
 	var paymentMethod models.UserPaymentMethod
 	if err := config.DB.Where("id = ? AND user_id = ?", input.PaymentMethodID, userID).First(&paymentMethod).Error; err != nil {
@@ -83,7 +96,7 @@ func CreateWithdrawal(c *gin.Context) {
 
 	// Crear solicitud de retiro
 	withdrawal := models.Withdrawal{
-		UserID:          userID.(uint),
+		UserID:          userID,
 		Amount:          input.Amount,
 		PreviousBalance: availableBalance,
 		NewBalance:      availableBalance - input.Amount,
@@ -108,7 +121,23 @@ func CreateWithdrawal(c *gin.Context) {
 		return
 	}
 
+	// El código se envía por correo/SMS desde el outbox después del commit; nunca en la respuesta.
+	// Si no sale antes de que venza, el outbox lo descarta.
+	expiresAt := time.Now().Add(time.Duration(VerificationExpiryMins) * time.Minute)
+	if err := services.EnqueueExpiringUserMessage(tx, &user, services.TemplateWithdrawalOTP, map[string]interface{}{
+		"Username":  user.Username,
+		"Amount":    withdrawal.Amount,
+		"Code":      withdrawal.WithdrawalCode,
+		"ExpiresIn": VerificationExpiryMins,
+	}, expiresAt); err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "No se pudo enviar el código de verificación", nil)
+		return
+	}
+
 	tx.Commit()
+	services.WakeOutbox()
+	services.PublishWalletBalance(&wallet)
 
 	// Generar código de verificación
 	withdrawalVerifications[withdrawal.ID] = struct {
@@ -120,18 +149,21 @@ func CreateWithdrawal(c *gin.Context) {
 	}{
 		Code:      withdrawal.WithdrawalCode,
 		Attempts:  0,
-		ExpiresAt: time.Now().Add(time.Duration(VerificationExpiryMins) * time.Minute),
+		ExpiresAt: expiresAt,
 		Verified:  false,
 	}
 
-	// Respuesta con el código de verificación
-	response := dtos.WithdrawalWithCodeResponse{
-		ID:             withdrawal.ID,
-		Amount:         withdrawal.Amount,
-		Status:         withdrawal.Status,
-		WithdrawalCode: withdrawal.WithdrawalCode,
-		Message:        "Código de verificación enviado. Tienes 30 minutos para verificar el retiro.",
-		ExpiresIn:      VerificationExpiryMins,
+	sentTo := []string{maskRecipient(user.Email)}
+	if user.Phone != "" {
+		sentTo = append(sentTo, maskRecipient(user.Phone))
+	}
+	response := dtos.WithdrawalPendingVerificationResponse{
+		ID:        withdrawal.ID,
+		Amount:    withdrawal.Amount,
+		Status:    withdrawal.Status,
+		SentTo:    sentTo,
+		Message:   "Código de verificación enviado. Tienes 30 minutos para verificar el retiro.",
+		ExpiresIn: VerificationExpiryMins,
 	}
 
 	utils.Success(c, http.StatusOK, "Retiro solicitado. Verifica con el código enviado.", response)
@@ -146,7 +178,7 @@ func CreateWithdrawal(c *gin.Context) {
 // @Success      200 {object} utils.Response
 // @Router       /wallet/withdraw/verify [post]
 func VerifyWithdrawal(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 
 	var input dtos.VerifyWithdrawalRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -177,7 +209,7 @@ func VerifyWithdrawal(c *gin.Context) {
 	// Verificar si expiró
 	if time.Now().After(verification.ExpiresAt) {
 		// Expirar el retiro
-		rejectWithdrawal(withdrawal.ID, "Código de verificación expirado")
+		rejectWithdrawal(withdrawal.ID, "Código de verificación expirado", nil)
 		utils.Error(c, http.StatusBadRequest, "El código ha expirado. Por favor, solicita un nuevo retiro.", nil)
 		return
 	}
@@ -189,7 +221,7 @@ func VerifyWithdrawal(c *gin.Context) {
 
 		if verification.Attempts >= MaxVerificationAttempts {
 			// Bloquear después de 3 intentos fallidos
-			rejectWithdrawal(withdrawal.ID, "Demasiados intentos fallidos")
+			rejectWithdrawal(withdrawal.ID, "Demasiados intentos fallidos", nil)
 			utils.Error(c, http.StatusTooManyRequests, "Has excedido los intentos permitidos. El retiro ha sido cancelado.", nil)
 			return
 		}
@@ -212,8 +244,7 @@ func VerifyWithdrawal(c *gin.Context) {
 	withdrawal.VerifiedAt = &now
 	config.DB.Save(&withdrawal)
 
-	// Enviar notificación al usuario (en producción, enviar email/SMS)
-	// Por ahora solo respondemos éxito
+	services.NotifyWithdrawalStatus(config.DB, &withdrawal, "verified")
 
 	utils.Success(c, http.StatusOK, "Retiro verificado exitosamente", map[string]interface{}{
 		"withdrawal_id": withdrawal.ID,
@@ -230,7 +261,7 @@ func VerifyWithdrawal(c *gin.Context) {
 // @Success      200 {object} utils.Response
 // @Router       /wallet/withdraw/history [get]
 func GetWithdrawalHistory(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 
 	var withdrawals []models.Withdrawal
 	if err := config.DB.Where("user_id = ?", userID).
@@ -309,7 +340,7 @@ func GetWithdrawalHistory(c *gin.Context) {
 // @Success      200 {object} utils.Response
 // @Router       /wallet/withdraw/limits [get]
 func GetWithdrawalLimits(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 
 	var wallet models.Wallet
 	if err := config.DB.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
@@ -318,7 +349,7 @@ func GetWithdrawalLimits(c *gin.Context) {
 	}
 
 	availableBalance := wallet.Balance + wallet.BonusBalance
-	limits, _ := getWithdrawalLimits(userID.(uint), availableBalance)
+	limits, _ := getWithdrawalLimits(userID, availableBalance)
 
 	utils.Success(c, http.StatusOK, "Límites de retiro", limits)
 }
@@ -331,7 +362,7 @@ func GetWithdrawalLimits(c *gin.Context) {
 // @Success      200 {object} utils.Response
 // @Router       /wallet/withdraw/pending [get]
 func GetPendingWithdrawal(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 
 	var withdrawal models.Withdrawal
 	if err := config.DB.Where("user_id = ? AND status = ?", userID, "pending").Order("created_at desc").First(&withdrawal).Error; err != nil {
@@ -342,7 +373,7 @@ func GetPendingWithdrawal(c *gin.Context) {
 	// Verificar si ya expiró
 	if verification, exists := withdrawalVerifications[withdrawal.ID]; exists {
 		if time.Now().After(verification.ExpiresAt) && !verification.Verified {
-			rejectWithdrawal(withdrawal.ID, "Tiempo de verificación expirado")
+			rejectWithdrawal(withdrawal.ID, "Tiempo de verificación expirado", nil)
 			utils.Error(c, http.StatusBadRequest, "El tiempo de verificación ha expirado", nil)
 			return
 		}
@@ -368,7 +399,7 @@ func GetPendingWithdrawal(c *gin.Context) {
 // @Success      200 {object} utils.Response
 // @Router       /wallet/withdraw/cancel [post]
 func CancelWithdrawal(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentPrincipal(c).UserID
 
 	var input dtos.CancelWithdrawalRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -385,6 +416,15 @@ func CancelWithdrawal(c *gin.Context) {
 	// Revertir el monto congelado
 	tx := config.DB.Begin()
 
+	// Marcar retiro como cancelado solo si sigue pendiente (un operador pudo aprobarlo entretanto)
+	claimed, err := claimWithdrawal(tx, withdrawal.ID, "pending", map[string]interface{}{"status": "cancelled"})
+	if err != nil || !claimed {
+		tx.Rollback()
+		utils.Error(c, http.StatusNotFound, "Retiro no encontrado o ya procesado", nil)
+		return
+	}
+	withdrawal.Status = "cancelled"
+
 	var wallet models.Wallet
 	if err := tx.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
 		tx.Rollback()
@@ -396,11 +436,9 @@ func CancelWithdrawal(c *gin.Context) {
 	wallet.FrozenBalance -= withdrawal.Amount
 	tx.Save(&wallet)
 
-	// Marcar retiro como cancelado
-	withdrawal.Status = "cancelled"
-	tx.Save(&withdrawal)
-
 	tx.Commit()
+	services.PublishWalletBalance(&wallet)
+	services.NotifyWithdrawalStatus(config.DB, &withdrawal, "cancelled")
 
 	// Limpiar verificación
 	delete(withdrawalVerifications, withdrawal.ID)
@@ -408,16 +446,273 @@ func CancelWithdrawal(c *gin.Context) {
 	utils.Success(c, http.StatusOK, "Retiro cancelado exitosamente", nil)
 }
 
-// Helper function to reject a withdrawal
-func rejectWithdrawal(withdrawalID uint, reason string) {
+// GetAdminWithdrawals godoc
+// @Summary      Listar retiros (admin)
+// @Description  Retiros de todos los usuarios, del más reciente al más antiguo, con usuario y método de pago
+// @Tags         admin
+// @Produce      json
+// @Param        status query string false "pending, approved, rejected, completed o cancelled"
+// @Success      200 {object} utils.Response{data=[]models.Withdrawal}
+// @Router       /admin/withdrawals [get]
+// @Security     BearerAuth
+func GetAdminWithdrawals(c *gin.Context) {
+	query := config.DB.Preload("User").Preload("PaymentMethod").Order("created_at desc")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var withdrawals []models.Withdrawal
+	if err := query.Find(&withdrawals).Error; err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al obtener retiros", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Retiros", withdrawals)
+}
+
+// ApproveWithdrawal godoc
+// @Summary      Aprobar retiro
+// @Description  Aprueba un retiro pendiente y verificado: descuenta el monto del saldo (primero líquido, luego bono), libera el congelado y registra el movimiento.
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "ID del retiro"
+// @Success      200 {object} utils.Response{data=models.Withdrawal}
+// @Failure      404 {object} utils.Response "Retiro no encontrado o ya procesado"
+// @Failure      409 {object} utils.Response "Retiro sin verificar, saldo insuficiente o ya procesado por otro operador"
+// @Router       /admin/withdrawals/{id}/approve [post]
+// @Security     BearerAuth
+func ApproveWithdrawal(c *gin.Context) {
+	operatorID := middleware.CurrentPrincipal(c).UserID
+
 	tx := config.DB.Begin()
 
 	var withdrawal models.Withdrawal
-	if err := tx.First(&withdrawal, withdrawalID).Error; err != nil {
+	if err := tx.Where("id = ? AND status = ?", c.Param("id"), "pending").First(&withdrawal).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusNotFound, "Retiro no encontrado o ya procesado", nil)
+		return
+	}
+	if !withdrawal.Verified {
 		tx.Rollback()
+		utils.Error(c, http.StatusConflict, "El usuario aún no verificó el retiro", nil)
 		return
 	}
 
+	// Reclamar el retiro antes de tocar la billetera: si otro operador ya lo procesó no se descuenta dos veces
+	now := time.Now()
+	claimed, err := claimWithdrawal(tx, withdrawal.ID, "pending", map[string]interface{}{
+		"status":       "approved",
+		"processed_at": now,
+		"processed_by": operatorID,
+	})
+	if err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al aprobar retiro", nil)
+		return
+	}
+	if !claimed {
+		tx.Rollback()
+		utils.Error(c, http.StatusConflict, "El retiro ya fue procesado", nil)
+		return
+	}
+	withdrawal.Status = "approved"
+	withdrawal.ProcessedAt = &now
+	withdrawal.ProcessedBy = &operatorID
+
+	var wallet models.Wallet
+	if err := tx.Where("user_id = ?", withdrawal.UserID).First(&wallet).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusNotFound, "Billetera no encontrada", nil)
+		return
+	}
+
+	previousBalance := wallet.Balance + wallet.BonusBalance
+	if previousBalance < withdrawal.Amount {
+		tx.Rollback()
+		utils.Error(c, http.StatusConflict, "Saldo insuficiente para aprobar el retiro", nil)
+		return
+	}
+
+	// Descontar primero del saldo líquido y el resto del bono
+	fromBalance := withdrawal.Amount
+	if fromBalance > wallet.Balance {
+		fromBalance = wallet.Balance
+	}
+	wallet.Balance -= fromBalance
+	wallet.BonusBalance -= withdrawal.Amount - fromBalance
+	wallet.FrozenBalance -= withdrawal.Amount
+	if err := tx.Save(&wallet).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al actualizar billetera", nil)
+		return
+	}
+
+	transaction := models.Transaction{
+		WalletID:        wallet.ID,
+		Amount:          -withdrawal.Amount,
+		PreviousBalance: previousBalance,
+		NewBalance:      wallet.Balance + wallet.BonusBalance,
+		Type:            "withdraw",
+		Description:     fmt.Sprintf("Retiro #%d aprobado", withdrawal.ID),
+		ReferenceID:     &withdrawal.ID,
+		ReferenceType:   "withdrawals",
+		Status:          "completed",
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		tx.Rollback()
+		utils.Error(c, http.StatusInternalServerError, "Error al registrar el movimiento", nil)
+		return
+	}
+
+	tx.Commit()
+	services.PublishWalletBalance(&wallet)
+	services.NotifyWithdrawalStatus(config.DB, &withdrawal, "approved")
+	delete(withdrawalVerifications, withdrawal.ID)
+
+	utils.Success(c, http.StatusOK, "Retiro aprobado", withdrawal)
+}
+
+// RejectWithdrawal godoc
+// @Summary      Rechazar retiro
+// @Description  Rechaza un retiro pendiente y devuelve el monto congelado al saldo del usuario
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID del retiro"
+// @Param        request body dtos.RejectWithdrawalRequest true "Motivo"
+// @Success      200 {object} utils.Response
+// @Failure      404 {object} utils.Response "Retiro no encontrado o ya procesado"
+// @Failure      409 {object} utils.Response "Procesado por otro operador mientras se rechazaba"
+// @Router       /admin/withdrawals/{id}/reject [post]
+// @Security     BearerAuth
+// @example request -json {"reason": "Datos de la cuenta no coinciden con el titular"}
+func RejectWithdrawal(c *gin.Context) {
+	var input dtos.RejectWithdrawalRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Datos inválidos", err.Error())
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Error(c, http.StatusBadRequest, "ID de retiro inválido", nil)
+		return
+	}
+
+	operatorID := middleware.CurrentPrincipal(c).UserID
+	if err := rejectWithdrawal(uint(id), input.Reason, &operatorID); err != nil {
+		if errors.Is(err, errWithdrawalAlreadyProcessed) {
+			utils.Error(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		utils.Error(c, http.StatusNotFound, "Retiro no encontrado o ya procesado", nil)
+		return
+	}
+
+	utils.Success(c, http.StatusOK, "Retiro rechazado", nil)
+}
+
+// CompleteWithdrawal godoc
+// @Summary      Completar retiro
+// @Description  Marca como pagado un retiro aprobado, una vez enviado el dinero al método de pago del usuario
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "ID del retiro"
+// @Success      200 {object} utils.Response{data=models.Withdrawal}
+// @Failure      404 {object} utils.Response "Retiro no encontrado o no aprobado"
+// @Failure      409 {object} utils.Response "Completado por otro operador"
+// @Router       /admin/withdrawals/{id}/complete [post]
+// @Security     BearerAuth
+func CompleteWithdrawal(c *gin.Context) {
+	var withdrawal models.Withdrawal
+	if err := config.DB.Where("id = ? AND status = ?", c.Param("id"), "approved").First(&withdrawal).Error; err != nil {
+		utils.Error(c, http.StatusNotFound, "Retiro no encontrado o no aprobado", nil)
+		return
+	}
+
+	operatorID := middleware.CurrentPrincipal(c).UserID
+	now := time.Now()
+	claimed, err := claimWithdrawal(config.DB, withdrawal.ID, "approved", map[string]interface{}{
+		"status":       "completed",
+		"processed_at": now,
+		"processed_by": operatorID,
+	})
+	if err != nil {
+		utils.Error(c, http.StatusInternalServerError, "Error al completar retiro", nil)
+		return
+	}
+	if !claimed {
+		utils.Error(c, http.StatusConflict, "El retiro ya fue procesado", nil)
+		return
+	}
+	withdrawal.Status = "completed"
+	withdrawal.ProcessedAt = &now
+	withdrawal.ProcessedBy = &operatorID
+	services.NotifyWithdrawalStatus(config.DB, &withdrawal, "completed")
+
+	utils.Success(c, http.StatusOK, "Retiro completado", withdrawal)
+}
+
+// errWithdrawalAlreadyProcessed indica que otro operador cambió el estado del retiro primero
+var errWithdrawalAlreadyProcessed = errors.New("el retiro ya fue procesado")
+
+// claimWithdrawal aplica updates solo si el retiro sigue en el estado from (compare-and-set).
+// Devuelve false si otro proceso lo cambió primero, en cuyo caso no debe tocarse la billetera.
+func claimWithdrawal(db *gorm.DB, withdrawalID uint, from string, updates map[string]interface{}) (bool, error) {
+	result := db.Model(&models.Withdrawal{}).
+		Where("id = ? AND status = ?", withdrawalID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// maskRecipient oculta la mayor parte de un correo o teléfono para mostrar a dónde se envió el código
+func maskRecipient(recipient string) string {
+	if at := strings.Index(recipient, "@"); at > 0 {
+		return recipient[:1] + "***" + recipient[at:]
+	}
+	if len(recipient) > 4 {
+		return "***" + recipient[len(recipient)-4:]
+	}
+	return "***"
+}
+
+// rejectWithdrawal rechaza un retiro pendiente y libera el monto congelado en la billetera;
+// processedBy es nil cuando lo rechaza el sistema (código expirado o demasiados intentos).
+func rejectWithdrawal(withdrawalID uint, reason string, processedBy *uint) error {
+	tx := config.DB.Begin()
+
+	var withdrawal models.Withdrawal
+	if err := tx.Where("id = ? AND status = ?", withdrawalID, "pending").First(&withdrawal).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Marcar como rechazado antes de descongelar: si ya se aprobó o canceló no se devuelve el monto
+	now := time.Now()
+	updates := map[string]interface{}{"status": "rejected", "rejected_reason": reason}
+	if processedBy != nil {
+		updates["processed_at"] = now
+		updates["processed_by"] = *processedBy
+	}
+	claimed, err := claimWithdrawal(tx, withdrawal.ID, "pending", updates)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if !claimed {
+		tx.Rollback()
+		return errWithdrawalAlreadyProcessed
+	}
+	withdrawal.Status = "rejected"
+	withdrawal.RejectedReason = reason
+	if processedBy != nil {
+		withdrawal.ProcessedAt = &now
+		withdrawal.ProcessedBy = processedBy
+	}
+
 	// Revertir el monto congelado
 	var wallet models.Wallet
 	if err := tx.Where("user_id = ?", withdrawal.UserID).First(&wallet).Error; err == nil {
@@ -425,15 +720,15 @@ func rejectWithdrawal(withdrawalID uint, reason string) {
 		tx.Save(&wallet)
 	}
 
-	// Marcar como rechazado
-	withdrawal.Status = "rejected"
-	withdrawal.RejectedReason = reason
-	tx.Save(&withdrawal)
-
 	tx.Commit()
+	if wallet.ID != 0 {
+		services.PublishWalletBalance(&wallet)
+	}
+	services.NotifyWithdrawalStatus(config.DB, &withdrawal, "rejected")
 
 	// Limpiar verificación
 	delete(withdrawalVerifications, withdrawalID)
+	return nil
 }
 
 // Helper function to get withdrawal limits
@@ -10,8 +10,60 @@ type LoginRequest struct {
 
 // LoginResponse estructura de respuesta tras un login exitoso
 type LoginResponse struct {
-	Token string      `json:"token"`
-	User  UserSummary `json:"user"`
+	Token        string      `json:"token"`
+	RefreshToken string      `json:"refresh_token"`
+	ExpiresIn    int         `json:"expires_in"` // Segundos de vida de token
+	User         UserSummary `json:"user"`
+	// TwoFactorSetupRequired avisa que el rol exige 2FA y aún no está activa: /admin queda bloqueado hasta activarla
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required,omitempty"`
+}
+
+// TwoFactorChallengeResponse se devuelve en lugar de los tokens cuando el usuario tiene 2FA activa
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"` // Segundos para enviar el código
+}
+
+// TwoFactorLoginRequest completa el login con el reto y un código TOTP o de recuperación
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required,min=6,max=20" example:"123456"`
+}
+
+// TwoFactorCodeRequest confirma una operación de 2FA con un código TOTP o de recuperación
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,min=6,max=20" example:"123456"`
+}
+
+// TwoFactorSetupResponse trae el secreto y el URI otpauth:// para mostrar como QR
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// RecoveryCodesResponse lista los códigos de recuperación; solo se muestran al generarlos
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorStatusResponse resume el estado de la verificación en dos pasos del usuario
+type TwoFactorStatusResponse struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"` // El rol la exige
+	RemainingRecoveryCodes int64 `json:"remaining_recovery_codes"`
+}
+
+// RefreshTokenRequest canjea un refresh token por un par nuevo, o lo revoca en logout
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenPairResponse es el par de tokens emitido al renovar la sesión
+type TokenPairResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
 }
 
 // RegisterRequest estructura para el registro de nuevos apostadores
@@ -23,6 +75,7 @@ type RegisterRequest struct {
 	FullName   string `json:"full_name"`
 	Phone      string `json:"phone"`
 	DocumentID string `json:"document_id"`
+	Locale     string `json:"locale" binding:"omitempty,oneof=es en"` // Idioma de correos y SMS (es por defecto)
 }
 
 // UserSummary información básica del usuario para el frontend
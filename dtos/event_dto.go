@@ -6,7 +6,7 @@ import "time"
 type CreateEventRequest struct {
 	Name      string  `json:"name" binding:"required" example:"Barcelona vs Real Madrid"`
 	Venue     string  `json:"venue" example:"Camp Nou"`
-	Line      float64 `json:"line" example:2.5`
+	Line      float64 `json:"line" example:"2.5"`
 	StartTime string  `json:"start_time" binding:"required" example:"2026-02-28T15:00:00Z"`
 }
 
@@ -34,13 +34,14 @@ type SetEventCompetitorsRequest struct {
 
 // EventCompetitorInput - Datos de un competidor en un evento
 type EventCompetitorInput struct {
-	CompetitorID   *uint  `json:"competitor_id"`
-	Name           string `json:"name" binding:"required"`
-	AssignedNumber int    `json:"assigned_number"`
-	Odds           int    `json:"odds"`
-	Runline        int    `json:"runline"`
-	SuperRunline   int    `json:"super_runline"`
-	IsFavorite     bool   `json:"is_favorite"`
+	ID             uint    `json:"id"` // ID del competidor en el evento (para actualizar en su lugar)
+	CompetitorID   *uint   `json:"competitor_id"`
+	Name           string  `json:"name" binding:"required"`
+	AssignedNumber int     `json:"assigned_number"`
+	Odds           int     `json:"odds"`
+	Runline        float64 `json:"runline"`       // Ej: -1.5
+	SuperRunline   float64 `json:"super_runline"` // Ej: -2.5
+	IsFavorite     bool    `json:"is_favorite"`
 }
 
 // SettleEventRequest - Liquidar un evento
@@ -98,3 +99,36 @@ type EventCompetitorResponse struct {
 	Position       int    `json:"position"`
 	IsScratched    bool   `json:"is_scratched"`
 }
+
+// EventLiveResponse - Marcador en vivo de un evento con el estado provisional de sus selecciones
+type EventLiveResponse struct {
+	EventID       uint                  `json:"event_id"`
+	Name          string                `json:"name"`
+	Status        string                `json:"status"`
+	Period        string                `json:"period,omitempty"`
+	LiveUpdatedAt *time.Time            `json:"live_updated_at,omitempty"`
+	TotalScore    int                   `json:"total_score"` // Suma del marcador en vivo
+	Competitors   []LiveCompetitorScore `json:"competitors"`
+	Selections    []LiveSelectionStatus `json:"selections"`
+}
+
+// LiveCompetitorScore - Marcador parcial de un competidor
+type LiveCompetitorScore struct {
+	CompetitorID      uint   `json:"competitor_id"`
+	Name              string `json:"name"`
+	Score             int    `json:"score"`
+	Position          int    `json:"position,omitempty"`
+	ScoredFirst       bool   `json:"scored_first"`
+	ScoredFirstHalf   bool   `json:"scored_first_half"`
+	ScoredSecondHalf  bool   `json:"scored_second_half"`
+	ScoredFirstInning bool   `json:"scored_first_inning"`
+}
+
+// LiveSelectionStatus - Cómo va una selección con el marcador actual (winning, losing, push)
+type LiveSelectionStatus struct {
+	SelectionID       uint    `json:"selection_id"`
+	SelectionType     string  `json:"selection_type"`
+	Description       string  `json:"description"`
+	Line              float64 `json:"line,omitempty"`
+	ProvisionalStatus string  `json:"provisional_status"`
+}
@@ -0,0 +1,78 @@
+package dtos
+
+// EventImportRequest describe un fixture de eventos (cartelera hípica, jornada de béisbol, etc.)
+// con sus competidores, líneas y cuotas. En CSV, las opciones generales llegan por query string.
+type EventImportRequest struct {
+	TournamentID       *uint            `json:"tournament_id"`       // Opcional: asigna los eventos al torneo
+	SessionID          *uint            `json:"session_id"`          // Opcional: sesión del torneo
+	CompetitorCategory string           `json:"competitor_category"` // Categoría del catálogo para emparejar competidores
+	GenerateSelections bool             `json:"generate_selections"` // Genera el set estándar de selecciones de cada evento
+	Events             []EventImportRow `json:"events" binding:"required,min=1"`
+
+	// Filas del CSV que no se pudieron leer; se reportan junto al resto de errores
+	ParseErrors []ImportRowError `json:"-"`
+}
+
+// EventImportRow es un evento del fixture. Los eventos existentes se reconocen por nombre (slug).
+type EventImportRow struct {
+	Row         int                   `json:"row,omitempty"` // Fila del archivo, para reportar errores
+	Name        string                `json:"name"`
+	Venue       string                `json:"venue"`
+	StartTime   string                `json:"start_time"` // RFC3339
+	Line        float64               `json:"line"`
+	Order       int                   `json:"order"`
+	Competitors []CompetitorImportRow `json:"competitors"`
+	Selections  []SelectionImportRow  `json:"selections"`
+}
+
+// CompetitorImportRow es un competidor del evento. Se empareja con el catálogo por nombre o por número.
+type CompetitorImportRow struct {
+	Row            int     `json:"row,omitempty"`
+	Name           string  `json:"name"`
+	AssignedNumber int     `json:"assigned_number"`
+	Odds           int     `json:"odds"`
+	Runline        float64 `json:"runline"`
+	SuperRunline   float64 `json:"super_runline"`
+	IsFavorite     bool    `json:"is_favorite"`
+}
+
+// SelectionImportRow es una selección explícita del evento (solo JSON)
+type SelectionImportRow struct {
+	SelectionType string  `json:"selection_type"`
+	Description   string  `json:"description"`
+	Line          float64 `json:"line"`
+	Odds          int     `json:"odds"`
+	Competitor    string  `json:"competitor"` // Nombre del competidor del evento (macho, hembra, runline)
+	PointsForWin  int     `json:"points_for_win"`
+	PointsForPush int     `json:"points_for_push"`
+}
+
+// ImportRowError señala un problema en una fila del fixture
+type ImportRowError struct {
+	Row     int    `json:"row,omitempty"`
+	Event   string `json:"event,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// EventImportResult resume lo que se hizo (o se haría, en dry-run) con un evento
+type EventImportResult struct {
+	Row                int    `json:"row,omitempty"`
+	Name               string `json:"name"`
+	EventID            uint   `json:"event_id,omitempty"` // Vacío en dry-run para eventos nuevos
+	Action             string `json:"action"`             // created, updated, unchanged
+	CompetitorsCreated int    `json:"competitors_created"`
+	CompetitorsUpdated int    `json:"competitors_updated"`
+	CompetitorsRemoved int    `json:"competitors_removed"`
+	SelectionsCreated  int    `json:"selections_created"`
+	SelectionsUpdated  int    `json:"selections_updated"`
+}
+
+// EventImportReport es la respuesta de la importación
+type EventImportReport struct {
+	DryRun    bool                `json:"dry_run"`
+	Committed bool                `json:"committed"`
+	Events    []EventImportResult `json:"events"`
+	Errors    []ImportRowError    `json:"errors"`
+	Warnings  []ImportRowError    `json:"warnings"`
+}
@@ -0,0 +1,12 @@
+package dtos
+
+// NotificationPreferenceItem indica si el usuario recibe un tipo de notificación.
+type NotificationPreferenceItem struct {
+	Type    string `json:"type" binding:"required" example:"pick_deadline"`
+	Enabled bool   `json:"enabled"`
+}
+
+// UpdateNotificationPreferencesRequest cambia las preferencias indicadas; las demás no se tocan.
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceItem `json:"preferences" binding:"required,min=1,dive"`
+}
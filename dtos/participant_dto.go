@@ -1,6 +1,57 @@
 package dtos
 
+import "time"
+
 // JoinTournamentRequest define las opciones para unirse a un torneo.
 type JoinTournamentRequest struct {
-	PayWithTokens bool `json:"pay_with_tokens"` // true para pagar con tokens, false para saldo real
+	PayWithTokens bool   `json:"pay_with_tokens"` // true para pagar con tokens, false para saldo real
+	InviteCode    string `json:"invite_code"`     // Requerido para torneos privados
+}
+
+// JoinByCodeRequest define los datos para unirse a un torneo privado usando su código.
+type JoinByCodeRequest struct {
+	InviteCode    string `json:"invite_code" binding:"required"`
+	PayWithTokens bool   `json:"pay_with_tokens"`
+}
+
+// ParticipantCardResponse es la cartilla pública de un participante por sesión.
+type ParticipantCardResponse struct {
+	ParticipantID uint          `json:"participant_id"`
+	Username      string        `json:"username"`
+	TotalPoints   int           `json:"total_points"`
+	Sessions      []SessionCard `json:"sessions"`
+}
+
+// SessionCard agrupa los picks de una sesión. Los picks aún no bloqueados se ocultan
+// a los demás participantes y solo se informa cuántos hay.
+type SessionCard struct {
+	SessionID     uint       `json:"session_id"`
+	SessionNumber int        `json:"session_number"`
+	EndTime       time.Time  `json:"end_time"`
+	Revealed      bool       `json:"revealed"`     // true cuando ya pasó la hora límite de la sesión
+	Picks         []CardPick `json:"picks"`        // Picks visibles
+	HiddenCount   int        `json:"hidden_count"` // Picks aún ocultos
+}
+
+// CardPick es un pick visible dentro de la cartilla de un participante.
+type CardPick struct {
+	PickID        uint   `json:"pick_id"`
+	SelectionID   uint   `json:"selection_id"`
+	EventID       uint   `json:"event_id"`
+	EventName     string `json:"event_name"`
+	Description   string `json:"description"`
+	SelectionType string `json:"selection_type"`
+	Status        string `json:"status"`
+	AwardedPoints int    `json:"awarded_points"`
+}
+
+// SelectionPopularity indica qué porcentaje de los participantes eligió una selección.
+type SelectionPopularity struct {
+	SelectionID   uint    `json:"selection_id"`
+	EventID       uint    `json:"event_id"`
+	Description   string  `json:"description"`
+	SelectionType string  `json:"selection_type"`
+	PickCount     int64   `json:"pick_count"`
+	Percentage    float64 `json:"percentage"`       // Sobre los participantes del torneo (o con cartilla en la sesión)
+	Status        string  `json:"status,omitempty"` // Resultado de la selección si ya fue liquidada
 }
@@ -0,0 +1,42 @@
+package dtos
+
+import "time"
+
+// MyPickResponse es un pick del usuario con su evento, competidor y línea.
+type MyPickResponse struct {
+	PickID         uint      `json:"pick_id"`
+	TournamentID   uint      `json:"tournament_id"`
+	TournamentName string    `json:"tournament_name"`
+	SessionID      uint      `json:"session_id"`
+	SessionNumber  int       `json:"session_number"`
+	SelectionID    uint      `json:"selection_id"`
+	SelectionType  string    `json:"selection_type"`
+	Description    string    `json:"description"`
+	EventID        uint      `json:"event_id"`
+	EventName      string    `json:"event_name"`
+	EventStartTime time.Time `json:"event_start_time"`
+	EventStatus    string    `json:"event_status"`
+	CompetitorID   *uint     `json:"competitor_id,omitempty"`
+	CompetitorName string    `json:"competitor_name,omitempty"`
+	Line           float64   `json:"line,omitempty"`
+	Odds           int       `json:"odds,omitempty"`
+	Status         string    `json:"status"` // pending, won, lost, push
+	AwardedPoints  int       `json:"awarded_points"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Solo para picks pendientes de eventos en vivo: winning, losing o push según el marcador actual
+	ProvisionalStatus string `json:"provisional_status,omitempty"`
+}
+
+// SessionPickSummary resume los resultados de los picks del usuario en una sesión.
+type SessionPickSummary struct {
+	TournamentID  uint `json:"tournament_id"`
+	SessionID     uint `json:"session_id"`
+	SessionNumber int  `json:"session_number"`
+	Total         int  `json:"total"`
+	Won           int  `json:"won"`
+	Lost          int  `json:"lost"`
+	Push          int  `json:"push"`
+	Pending       int  `json:"pending"`
+	Points        int  `json:"points"`
+}
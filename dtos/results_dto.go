@@ -0,0 +1,12 @@
+package dtos
+
+// ProviderMappingRequest enlaza un evento con su ID en un proveedor de resultados.
+type ProviderMappingRequest struct {
+	Provider   string `json:"provider" binding:"required"`    // Ej: "file", "http"
+	ExternalID string `json:"external_id" binding:"required"` // ID del evento en el proveedor
+}
+
+// ReviewProposalRequest acompaña el rechazo de una propuesta de resultado.
+type ReviewProposalRequest struct {
+	Note string `json:"note" binding:"max=255"`
+}
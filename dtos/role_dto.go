@@ -0,0 +1,20 @@
+package dtos
+
+// CreateRoleRequest crea un rol de operador con los permisos indicados.
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,min=3,max=50" example:"cashier"`
+	Description string   `json:"description" binding:"max=200" example:"Caja: solo retiros"`
+	Permissions []string `json:"permissions" binding:"required,min=1" example:"withdrawals:read,withdrawals:approve"`
+}
+
+// UpdateRoleRequest reemplaza los permisos de un rol y, si se envía, su descripción.
+type UpdateRoleRequest struct {
+	Description *string  `json:"description" binding:"omitempty,max=200"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// PermissionResponse describe un permiso disponible para asignar a roles.
+type PermissionResponse struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
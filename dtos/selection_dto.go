@@ -21,8 +21,37 @@ type CreateSelectionRequest struct {
 	PointsForPush int   `json:"points_for_push"`
 }
 
-type SubmitPicksRequest struct {
-	SelectionIDs []uint `json:"selection_ids" binding:"required,min=1"`
+// UpdateSelectionRequest actualiza línea, cuota o puntos de una selección (solo los campos enviados).
+// Los picks ya hechos conservan la línea y cuota vigentes cuando se hicieron.
+type UpdateSelectionRequest struct {
+	Description    *string  `json:"description"`
+	Line           *float64 `json:"line"`
+	RunlineHome    *float64 `json:"runline_home"`
+	RunlineAway    *float64 `json:"runline_away"`
+	Odds           *int     `json:"odds"`
+	PointsForWin   *int     `json:"points_for_win" binding:"omitempty,gte=0"`
+	PointsForPush  *int     `json:"points_for_push" binding:"omitempty,gte=0"`
+	IsSuperRunline *bool    `json:"is_super_runline"`
+}
+
+// GenerateSelectionsRequest configura la generación del set estándar de selecciones de un evento.
+// Con tournament_id se toman los tipos de la categoría, la super línea de la sesión y los puntos por tipo del torneo.
+type GenerateSelectionsRequest struct {
+	TournamentID   *uint    `json:"tournament_id"`
+	SelectionTypes []string `json:"selection_types"` // Opcional: reemplaza los tipos de la categoría
+	SuperLine      *float64 `json:"super_line"`      // Opcional: reemplaza la super línea de la sesión
+	PointsForWin   int      `json:"points_for_win" binding:"gte=0"`
+	PointsForPush  int      `json:"points_for_push" binding:"gte=0"`
+}
+
+// SelectionPointsResponse son los puntos que vale acertar una selección en un torneo concreto
+type SelectionPointsResponse struct {
+	SelectionID   uint   `json:"selection_id"`
+	EventID       uint   `json:"event_id"`
+	SelectionType string `json:"selection_type"`
+	Odds          int    `json:"odds"`
+	PointsForWin  int    `json:"points_for_win"`
+	Locked        bool   `json:"locked"` // El evento ya comenzó: los picks existentes conservan su cuota
 }
 
 /*
@@ -9,11 +9,14 @@ type CreateSessionRequest struct {
 	StartTime     time.Time `json:"start_time" binding:"required"`           // Cuándo abre la sesión
 	EndTime       time.Time `json:"end_time" binding:"required"`             // Hora límite (antes de partidos)
 	Description   string    `json:"description"`                             // Opcional
+	SuperLine     float64   `json:"super_line"`                              // Para super alta/baja
 }
 
 // UpdateSessionStatusRequest define el cuerpo para actualizar el estado de una sesión.
+// Las transiciones válidas son scheduled → open → closed → settled.
 type UpdateSessionStatusRequest struct {
 	Status string `json:"status" binding:"required,oneof=open closed settled"`
+	Note   string `json:"note"` // Motivo del cambio (queda en el historial)
 }
 
 // SubmitPicksBySessionRequest define los datos para enviar predicciones de una sesión específica.
@@ -22,6 +25,11 @@ type SubmitPicksBySessionRequest struct {
 	SelectionIDs []uint `json:"selection_ids" binding:"required,min=1"`
 }
 
+// UpdatePickRequest define el cuerpo para cambiar la selección de un pick existente.
+type UpdatePickRequest struct {
+	SelectionID uint `json:"selection_id" binding:"required"` // Nueva selección
+}
+
 // SessionResponse define la estructura de respuesta para una sesión.
 type SessionResponse struct {
 	ID            uint      `json:"id"`
@@ -2,7 +2,8 @@ package dtos
 
 // SettleEventRequest defines the data needed to settle an event and calculate points.
 type SetEventResultRequest struct {
-	Results []CompetitorResult `json:"results" binding:"required,min=1,dive"`
+	Results    []CompetitorResult `json:"results" binding:"required,min=1,dive"`
+	ResultNote string             `json:"result_note"` // Opcional: "Resultado oficial" por defecto
 }
 
 // CompetitorResult holds the final outcome for a single competitor.
@@ -11,3 +12,32 @@ type CompetitorResult struct {
 	FinalScore   int  `json:"final_score"` // Para deportes de equipo (goles, puntos)
 	Position     int  `json:"position"`    // Para carreras (1ro, 2do, 3ro)
 }
+
+// SessionResultsRequest carga los resultados de varios eventos de una sesión para liquidarlos juntos.
+type SessionResultsRequest struct {
+	Events []EventResultInput `json:"events" binding:"required,min=1,dive"`
+}
+
+// EventResultInput son los resultados de un evento dentro de la carga masiva.
+type EventResultInput struct {
+	EventID    uint               `json:"event_id" binding:"required"`
+	ResultNote string             `json:"result_note"`
+	Results    []CompetitorResult `json:"results" binding:"required,min=1,dive"`
+}
+
+// LiveUpdateRequest actualiza el marcador en vivo de un evento. No liquida ni asigna puntos.
+type LiveUpdateRequest struct {
+	Period      string                 `json:"period" binding:"max=50"` // Ej: "Alta del 5to"
+	Competitors []LiveCompetitorUpdate `json:"competitors" binding:"required,min=1,dive"`
+}
+
+// LiveCompetitorUpdate es el marcador parcial de un competidor. Los indicadores nulos no se modifican.
+type LiveCompetitorUpdate struct {
+	CompetitorID      uint  `json:"competitor_id" binding:"required"`
+	Score             int   `json:"score"`
+	Position          int   `json:"position"` // Para carreras: posición actual
+	ScoredFirst       *bool `json:"scored_first,omitempty"`
+	ScoredFirstHalf   *bool `json:"scored_first_half,omitempty"`
+	ScoredSecondHalf  *bool `json:"scored_second_half,omitempty"`
+	ScoredFirstInning *bool `json:"scored_first_inning,omitempty"`
+}
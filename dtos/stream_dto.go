@@ -0,0 +1,7 @@
+package dtos
+
+// StreamTicketResponse es el ticket de un solo uso para abrir /stream?ticket=... desde EventSource
+type StreamTicketResponse struct {
+	Ticket    string `json:"ticket" example:"Zk3x..."`
+	ExpiresIn int    `json:"expires_in" example:"30"` // Segundos para abrir el flujo
+}
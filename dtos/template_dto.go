@@ -0,0 +1,45 @@
+package dtos
+
+import "time"
+
+// CreateTemplateRequest define los datos para crear una plantilla de torneo.
+// Si se indica SourceTournamentID, la plantilla se captura de ese torneo y los
+// demás campos (salvo Name) se ignoran.
+type CreateTemplateRequest struct {
+	Name               string                    `json:"name" binding:"required"`
+	Description        string                    `json:"description"`
+	SourceTournamentID *uint                     `json:"source_tournament_id"`
+	Category           string                    `json:"category"`
+	DurationMinutes    int                       `json:"duration_minutes" binding:"gte=0"`
+	MaxParticipants    int                       `json:"max_participants" binding:"gte=0"`
+	EntryFee           float64                   `json:"entry_fee" binding:"gte=0"`
+	EntryFeeTokens     int                       `json:"entry_fee_tokens" binding:"gte=0"`
+	PrizeBonus         float64                   `json:"prize_bonus" binding:"gte=0"`
+	AdminFeePercent    float64                   `json:"admin_fee_percent" binding:"gte=0,lte=100"`
+	Settings           TournamentSettingsRequest `json:"settings"`
+	Sessions           []TemplateSessionInput    `json:"sessions" binding:"dive"`
+}
+
+// TemplateSessionInput define una sesión relativa a la fecha de inicio del torneo.
+type TemplateSessionInput struct {
+	SessionNumber      int     `json:"session_number" binding:"required,min=1"`
+	StartOffsetMinutes int     `json:"start_offset_minutes" binding:"gte=0"`
+	EndOffsetMinutes   int     `json:"end_offset_minutes" binding:"gtefield=StartOffsetMinutes"`
+	SuperLine          float64 `json:"super_line"`
+	Description        string  `json:"description"`
+}
+
+// InstantiateTemplateRequest define los datos para crear un torneo a partir de una plantilla.
+type InstantiateTemplateRequest struct {
+	Name       string    `json:"name" binding:"required"`
+	StartDate  time.Time `json:"start_date" binding:"required"`
+	Visibility string    `json:"visibility" binding:"omitempty,oneof=public private"`
+}
+
+// CloneTournamentRequest define los datos para clonar un torneo desplazando sus fechas.
+type CloneTournamentRequest struct {
+	Name      string    `json:"name" binding:"required"`
+	StartDate time.Time `json:"start_date" binding:"required"`
+	// Duplicar también los eventos (con competidores y selecciones) desplazando su hora
+	CloneEvents bool `json:"clone_events"`
+}
@@ -6,16 +6,38 @@ import "time"
 type CreateTournamentRequest struct {
 	Name            string                    `json:"name" binding:"required"`
 	Description     string                    `json:"description"`
-	Category        string                    `json:"category" binding:"required"` // "Hipica", "Futbol", etc.
+	CategoryID      *uint                     `json:"category_id"`                                    // Categoría del torneo (o por nombre en category)
+	Category        string                    `json:"category" binding:"required_without=CategoryID"` // "Hipica", "Futbol", etc.
 	StartDate       time.Time                 `json:"start_date" binding:"required"`
 	EndDate         time.Time                 `json:"end_date" binding:"required"`
 	EntryFee        float64                   `json:"entry_fee" binding:"gte=0"`
 	EntryFeeTokens  int                       `json:"entry_fee_tokens" binding:"gte=0"`
 	PrizeBonus      float64                   `json:"prize_bonus" binding:"gte=0"`
 	AdminFeePercent float64                   `json:"admin_fee_percent" binding:"gte=0,lte=100"`
+	MaxParticipants int                       `json:"max_participants" binding:"gte=0"`
+	Visibility      string                    `json:"visibility" binding:"omitempty,oneof=public private"` // public por defecto
+	Status          string                    `json:"status" binding:"omitempty,oneof=draft open"`         // open por defecto
 	Settings        TournamentSettingsRequest `json:"settings"`
 }
 
+// CreateLeagueRequest define los datos para que un usuario cree una liga privada
+// sobre la cartilla (sesiones y eventos) de un torneo publicado por un admin.
+type CreateLeagueRequest struct {
+	BaseTournamentID  uint      `json:"base_tournament_id" binding:"required"`
+	Name              string    `json:"name" binding:"required,min=3"`
+	Description       string    `json:"description"`
+	EntryFeeTokens    int       `json:"entry_fee_tokens" binding:"gte=0"` // Las ligas solo admiten tokens o entrada gratuita
+	MaxParticipants   int       `json:"max_participants" binding:"gte=0"`
+	PrizeDistribution []float64 `json:"prize_distribution"`
+}
+
+// TournamentInviteResponse contiene el código y enlace para compartir un torneo privado.
+type TournamentInviteResponse struct {
+	TournamentID uint   `json:"tournament_id"`
+	InviteCode   string `json:"invite_code"`
+	InviteLink   string `json:"invite_link"`
+}
+
 // TournamentSettingsRequest define las reglas específicas del torneo en la creación.
 type TournamentSettingsRequest struct {
 	PrizeDistribution      []float64 `json:"prize_distribution"`       // Ej: [0.7, 0.2, 0.1]
@@ -23,9 +45,24 @@ type TournamentSettingsRequest struct {
 	HorseRacingPoints      []int     `json:"horse_racing_points"`      // Ej: [10, 5, 3]
 	RequiredSelectionTypes []string  `json:"required_selection_types"` // Ej: ["macho", "hembra", "alta", "baja", "runline"]
 	TotalSessions          int       `json:"total_sessions"`           // Ej: 5 (Lunes a Viernes)
+
+	// Puntuación por cuotas (opcional): scoring_mode "odds" deriva los puntos de la cuota americana
+	ScoringMode string             `json:"scoring_mode" binding:"omitempty,oneof=fixed odds"`
+	OddsScoring OddsScoringRequest `json:"odds_scoring"`
+}
+
+// OddsScoringRequest define la fórmula y el redondeo de la puntuación por cuotas.
+type OddsScoringRequest struct {
+	Formula    string  `json:"formula" binding:"omitempty,oneof=decimal profit"` // decimal: base × cuota decimal, profit: base × ganancia
+	BasePoints float64 `json:"base_points" binding:"gte=0"`
+	Rounding   string  `json:"rounding" binding:"omitempty,oneof=round floor ceil"`
+	MinPoints  int     `json:"min_points" binding:"gte=0"`
+	MaxPoints  int     `json:"max_points" binding:"gte=0"`
 }
 
 // UpdateStatusRequest define el cuerpo para actualizar el estado de un torneo.
+// Las transiciones válidas son draft → open → running → closed → finished, o cancelled.
 type UpdateStatusRequest struct {
-	Status string `json:"status" binding:"required,oneof=open closed finished"`
+	Status string `json:"status" binding:"required,oneof=open running closed finished cancelled"`
+	Note   string `json:"note"` // Motivo del cambio (queda en el historial)
 }
@@ -2,7 +2,7 @@ package dtos
 
 // UpdateUserRoleRequest define los datos para actualizar el rol de un usuario.
 type UpdateUserRoleRequest struct {
-	Role string `json:"role" binding:"required,oneof=user admin"`
+	Role string `json:"role" binding:"required,max=50" example:"results_operator"`
 }
 
 // UpdateUserStatusRequest define los datos para activar/desactivar un usuario.
@@ -0,0 +1,25 @@
+package dtos
+
+import "github.com/cesarbmathec/bets-backend/models"
+
+// CreateWebhookRequest registra el endpoint de un socio. Sin secreto se genera uno.
+type CreateWebhookRequest struct {
+	Name       string   `json:"name" binding:"required,max=100" example:"Bot de Telegram"`
+	URL        string   `json:"url" binding:"required,url,max=500" example:"https://partner.example.com/webhooks/bets"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=100"`
+	EventTypes []string `json:"event_types" binding:"required,min=1" example:"event.settled,prize.paid"`
+}
+
+// UpdateWebhookRequest cambia los campos enviados de un webhook; el secreto no se puede cambiar.
+type UpdateWebhookRequest struct {
+	Name       *string  `json:"name" binding:"omitempty,max=100"`
+	URL        *string  `json:"url" binding:"omitempty,url,max=500"`
+	EventTypes []string `json:"event_types" binding:"omitempty,min=1"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+// WebhookCreatedResponse devuelve el webhook y su secreto, que solo se muestra esta vez.
+type WebhookCreatedResponse struct {
+	Webhook models.WebhookSubscription `json:"webhook"`
+	Secret  string                     `json:"secret"`
+}
@@ -21,6 +21,11 @@ type CancelWithdrawalRequest struct {
 	WithdrawalID uint `json:"withdrawal_id" binding:"required"`
 }
 
+// RejectWithdrawalRequest carries the reason shown to the user when an operator rejects a withdrawal
+type RejectWithdrawalRequest struct {
+	Reason string `json:"reason" binding:"required,min=5,max=500" example:"Datos de la cuenta no coinciden con el titular"`
+}
+
 // Response DTOs
 
 // WithdrawalResponse represents a withdrawal in responses
@@ -38,14 +43,14 @@ type WithdrawalResponse struct {
 	PaymentMethod   UserPaymentMethodResponse `json:"payment_method"`
 }
 
-// WithdrawalWithCodeResponse includes the verification code (only shown once)
-type WithdrawalWithCodeResponse struct {
-	ID             uint    `json:"id"`
-	Amount         float64 `json:"amount"`
-	Status         string  `json:"status"`
-	WithdrawalCode string  `json:"withdrawal_code"`
-	Message        string  `json:"message"`
-	ExpiresIn      int     `json:"expires_in_minutes"` // Minutes until code expires
+// WithdrawalPendingVerificationResponse confirms the request; the code is sent by email/SMS, never in the response
+type WithdrawalPendingVerificationResponse struct {
+	ID        uint     `json:"id"`
+	Amount    float64  `json:"amount"`
+	Status    string   `json:"status"`
+	SentTo    []string `json:"sent_to"` // Masked destinations, e.g. "j***@example.com"
+	Message   string   `json:"message"`
+	ExpiresIn int      `json:"expires_in_minutes"` // Minutes until code expires
 }
 
 // WithdrawalHistoryResponse represents the withdrawal history
@@ -17,14 +17,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/migrations"
+	"github.com/cesarbmathec/bets-backend/models"
 	"github.com/cesarbmathec/bets-backend/routes"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
@@ -47,6 +52,69 @@ func main() {
 	// Ejecutar Migraciones y Seeds iniciales
 	migrations.RunMigrations(db)
 
+	// Iniciar el scheduler de aperturas/cierres automáticos (desactivable con SCHEDULER_ENABLED=false)
+	if os.Getenv("SCHEDULER_ENABLED") != "false" {
+		interval := 30 * time.Second
+		if seconds, err := strconv.Atoi(os.Getenv("SCHEDULER_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+			interval = time.Duration(seconds) * time.Second
+		}
+		services.NewScheduler(db, interval).Start(context.Background())
+		log.Printf("⏱️  Scheduler iniciado (cada %s)", interval)
+	}
+
+	// Iniciar el poller de resultados si hay proveedores configurados
+	var providers []services.ResultsProvider
+	if path := os.Getenv("RESULTS_PROVIDER_FILE"); path != "" {
+		providers = append(providers, services.NewFileResultsProvider("file", path))
+	}
+	if baseURL := os.Getenv("RESULTS_PROVIDER_HTTP_URL"); baseURL != "" {
+		providers = append(providers, services.NewHTTPResultsProvider("http", baseURL))
+	}
+	if len(providers) > 0 {
+		interval := 60 * time.Second
+		if seconds, err := strconv.Atoi(os.Getenv("RESULTS_POLL_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+			interval = time.Duration(seconds) * time.Second
+		}
+		services.NewResultsPoller(db, interval, providers...).Start(context.Background())
+		log.Printf("📡 Poller de resultados iniciado con %d proveedor(es) (cada %s)", len(providers), interval)
+	}
+
+	// Iniciar el despachador de correos y SMS del outbox. Sin proveedor configurado, los mensajes
+	// se escriben en el log (o en NOTIFIER_LOG_FILE) para desarrollo.
+	emailNotifier := services.Notifier(services.NewLogNotifier(models.ChannelEmail, os.Getenv("NOTIFIER_LOG_FILE")))
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		emailNotifier = services.NewSMTPNotifier(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM"))
+	}
+	smsNotifier := services.Notifier(services.NewLogNotifier(models.ChannelSMS, os.Getenv("NOTIFIER_LOG_FILE")))
+	if gatewayURL := os.Getenv("SMS_GATEWAY_URL"); gatewayURL != "" {
+		smsNotifier = services.NewSMSGatewayNotifier(gatewayURL, os.Getenv("SMS_GATEWAY_TOKEN"))
+	}
+	outboxInterval := 10 * time.Second
+	if seconds, err := strconv.Atoi(os.Getenv("OUTBOX_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		outboxInterval = time.Duration(seconds) * time.Second
+	}
+	services.NewOutboxDispatcher(db, outboxInterval, emailNotifier, smsNotifier).Start(context.Background())
+
+	// Iniciar el despachador de webhooks de socios
+	webhookInterval := 10 * time.Second
+	if seconds, err := strconv.Atoi(os.Getenv("WEBHOOK_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		webhookInterval = time.Duration(seconds) * time.Second
+	}
+	services.NewWebhookDispatcher(db, webhookInterval).Start(context.Background())
+
+	// Verificación en dos pasos: obligatoria para roles con permisos de administración
+	if os.Getenv("REQUIRE_STAFF_2FA") == "false" {
+		services.RequireStaffTwoFactor = false
+		log.Println("⚠️  2FA no obligatoria para administradores (REQUIRE_STAFF_2FA=false)")
+	}
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		services.TOTPIssuer = issuer
+	}
+
 	// Configurar el Router
 	r := routes.SetupRouter()
 
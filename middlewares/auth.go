@@ -3,7 +3,10 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/cesarbmathec/bets-backend/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -24,18 +27,88 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		claims, err := utils.ValidateToken(tokenString)
+		authenticate(c, tokenString)
+	}
+}
+
+// OptionalAuthMiddleware identifica al usuario si la petición trae token, sin exigirlo. Lo usan
+// las rutas públicas que muestran más a un usuario autenticado (p. ej. sus torneos privados).
+// Un token inválido o revocado se rechaza igual que en AuthMiddleware.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.Next()
+			return
+		}
+
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if tokenString == authHeader {
+			utils.Error(c, http.StatusUnauthorized, "Formato de token inválido", nil)
+			c.Abort()
+			return
+		}
+
+		authenticate(c, tokenString)
+	}
+}
+
+// StreamAuthMiddleware es como AuthMiddleware pero también acepta ?ticket=, un ticket de un solo
+// uso emitido por POST /stream/ticket, porque EventSource en el navegador no permite enviar
+// cabeceras. El JWT nunca viaja en la URL.
+func StreamAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "); tokenString != "" {
+			authenticate(c, tokenString)
+			return
+		}
+
+		raw := c.Query("ticket")
+		if raw == "" {
+			utils.Error(c, http.StatusUnauthorized, "Se requiere token de autorización o ticket de conexión", nil)
+			c.Abort()
+			return
+		}
+
+		ticket, err := services.RedeemStreamTicket(config.DB, raw, time.Now())
 		if err != nil {
-			utils.Error(c, http.StatusUnauthorized, "Token inválido o expirado", nil)
+			utils.Error(c, http.StatusUnauthorized, "Ticket de conexión inválido o vencido", nil)
 			c.Abort()
 			return
 		}
 
-		// Inyectamos datos críticos en el contexto
-		c.Set("userID", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("role", claims.Role) // Cambiado de RoleID a Role (string) según nuestro modelo User
+		principal, err := services.LoadPrincipal(config.DB, ticket.UserID)
+		if err != nil || !principal.IsActive || principal.TokenVersion != ticket.TokenVersion {
+			utils.Error(c, http.StatusUnauthorized, "Sesión revocada. Inicia sesión de nuevo", nil)
+			c.Abort()
+			return
+		}
 
+		c.Set(principalKey, principal)
 		c.Next()
 	}
 }
+
+// authenticate valida el token e inyecta el usuario en el contexto. El token se rechaza si el
+// usuario ya no existe, fue desactivado o subió su TokenVersion (logout global, cambio de rol).
+func authenticate(c *gin.Context, tokenString string) {
+	claims, err := utils.ValidateToken(tokenString)
+	if err != nil {
+		utils.Error(c, http.StatusUnauthorized, "Token inválido o expirado", nil)
+		c.Abort()
+		return
+	}
+
+	principal, err := services.LoadPrincipal(config.DB, claims.UserID)
+	if err != nil || !principal.IsActive || principal.TokenVersion != claims.TokenVersion {
+		utils.Error(c, http.StatusUnauthorized, "Sesión revocada. Inicia sesión de nuevo", nil)
+		c.Abort()
+		return
+	}
+
+	// El rol y el estado salen de la base de datos, no del token
+	c.Set(principalKey, principal)
+	c.Set(sessionKey, claims.ID)
+
+	c.Next()
+}
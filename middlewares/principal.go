@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey es la clave del contexto donde AuthMiddleware guarda el usuario autenticado
+const principalKey = "principal"
+
+// sessionKey guarda el jti del token de acceso; identifica la sesión para los step-up de 2FA
+const sessionKey = "session_id"
+
+// PrincipalFromContext devuelve el usuario autenticado si la ruta pasó por AuthMiddleware
+func PrincipalFromContext(c *gin.Context) (services.Principal, bool) {
+	value, exists := c.Get(principalKey)
+	if !exists {
+		return services.Principal{}, false
+	}
+	principal, ok := value.(services.Principal)
+	return principal, ok
+}
+
+// CurrentPrincipal devuelve el usuario autenticado. Solo para rutas detrás de AuthMiddleware;
+// en otras devuelve un Principal vacío (UserID 0, sin rol).
+func CurrentPrincipal(c *gin.Context) services.Principal {
+	principal, _ := PrincipalFromContext(c)
+	return principal
+}
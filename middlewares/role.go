@@ -10,8 +10,8 @@ import (
 // RequireAdmin middleware verifica que el usuario tenga rol de administrador
 func RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Obtener el rol del contexto (establecido por AuthMiddleware)
-		role, exists := c.Get("role")
+		// Obtener el usuario del contexto (establecido por AuthMiddleware)
+		principal, exists := PrincipalFromContext(c)
 		if !exists {
 			utils.Error(c, http.StatusUnauthorized, "No autorizado", nil)
 			c.Abort()
@@ -19,7 +19,7 @@ func RequireAdmin() gin.HandlerFunc {
 		}
 
 		// Verificar que el rol sea admin
-		if role != "admin" {
+		if !principal.IsAdmin() {
 			utils.Error(c, http.StatusForbidden, "Acceso denegado. Se requiere rol de administrador", nil)
 			c.Abort()
 			return
@@ -29,6 +29,28 @@ func RequireAdmin() gin.HandlerFunc {
 	}
 }
 
+// RequirePermission middleware verifica que el rol del usuario otorgue todos los permisos indicados
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, exists := PrincipalFromContext(c)
+		if !exists {
+			utils.Error(c, http.StatusUnauthorized, "No autorizado", nil)
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !principal.HasPermission(permission) {
+				utils.Error(c, http.StatusForbidden, "Acceso denegado. Se requiere el permiso "+permission, nil)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
 // RequireUser middleware verifica que el usuario esté autenticado
 // (ya existe en AuthMiddleware, pero es útil para documentación)
 func RequireUser() gin.HandlerFunc {
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/config"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorHeader lleva el código TOTP (o de recuperación) en las acciones que exigen step-up
+const TwoFactorHeader = "X-2FA-Code"
+
+// RequireTwoFactor middleware impide usar permisos de administración sin la verificación en dos
+// pasos activa, mientras services.RequireStaffTwoFactor esté encendido
+func RequireTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, exists := PrincipalFromContext(c)
+		if !exists {
+			utils.Error(c, http.StatusUnauthorized, "No autorizado", nil)
+			c.Abort()
+			return
+		}
+
+		if services.RequireStaffTwoFactor && principal.IsStaff() && !principal.TwoFactorEnabled {
+			utils.Error(c, http.StatusForbidden, "Su rol exige la verificación en dos pasos. Actívela en /auth/2fa/setup", map[string]interface{}{
+				"two_factor_setup_required": true,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireStepUp middleware pide el segundo factor en la cabecera X-2FA-Code antes de una acción
+// sensible. Un código válido vale para la misma sesión durante services.StepUpTTL. Los usuarios
+// sin 2FA pasan, salvo el staff cuando se les exige. Los códigos erróneos cuentan para el
+// bloqueo por usuario (services.TwoFactorLockout).
+func RequireStepUp() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, exists := PrincipalFromContext(c)
+		if !exists {
+			utils.Error(c, http.StatusUnauthorized, "No autorizado", nil)
+			c.Abort()
+			return
+		}
+
+		if !principal.TwoFactorEnabled {
+			if services.RequireStaffTwoFactor && principal.IsStaff() {
+				utils.Error(c, http.StatusForbidden, "Su rol exige la verificación en dos pasos. Actívela en /auth/2fa/setup", map[string]interface{}{
+					"two_factor_setup_required": true,
+				})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		now := time.Now()
+		sessionID := c.GetString(sessionKey)
+		if services.HasStepUp(sessionID, now) {
+			c.Next()
+			return
+		}
+
+		code := c.GetHeader(TwoFactorHeader)
+		if code == "" {
+			utils.Error(c, http.StatusForbidden, "Esta acción requiere el código de verificación en dos pasos", map[string]interface{}{
+				"two_factor_required": true,
+			})
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		if err := config.DB.First(&user, principal.UserID).Error; err != nil {
+			utils.Error(c, http.StatusUnauthorized, "No autorizado", nil)
+			c.Abort()
+			return
+		}
+		if err := services.VerifySecondFactorWithLockout(config.DB, &user, code, now); err != nil {
+			if errors.Is(err, services.ErrTwoFactorLocked) {
+				utils.Error(c, http.StatusTooManyRequests, err.Error(), nil)
+				c.Abort()
+				return
+			}
+			utils.Error(c, http.StatusForbidden, "Código de verificación inválido", map[string]interface{}{
+				"two_factor_required": true,
+			})
+			c.Abort()
+			return
+		}
+
+		services.GrantStepUp(sessionID, now)
+		c.Next()
+	}
+}
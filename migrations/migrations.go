@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -28,15 +29,42 @@ func RunMigrations(db *gorm.DB) {
 		&models.UserPaymentMethod{},
 		&models.Category{},
 		&models.CategorySelectionType{},
-		&models.CategorySettingsJSON{},
 		&models.Competitor{}, // Catálogo global de competidores
 		&models.Withdrawal{}, // Retiros
+		&models.TournamentTemplate{},
+		&models.StatusTransition{},       // Historial de estados de torneos y sesiones
+		&models.JobLease{},               // Candado del scheduler entre réplicas
+		&models.SelectionPickStat{},      // Popularidad de selecciones por torneo
+		&models.LineHistory{},            // Movimiento de líneas y cuotas
+		&models.EventProviderMapping{},   // IDs de eventos en proveedores de resultados
+		&models.ResultProposal{},         // Resultados de proveedores pendientes de confirmación
+		&models.Notification{},           // Bandeja de notificaciones de los usuarios
+		&models.NotificationPreference{}, // Tipos de notificación que cada usuario recibe
+		&models.OutboxMessage{},          // Correos y SMS pendientes de envío
+		&models.WebhookSubscription{},    // Endpoints de socios suscritos a eventos
+		&models.WebhookDelivery{},        // Entregas de webhooks con reintentos y dead-letter
+		&models.RefreshToken{},           // Refresh tokens (hash) con rotación por familia
+		&models.Permission{},             // Permisos del panel de administración
+		&models.Role{},                   // Roles con sus permisos (role_permissions)
+		&models.RecoveryCode{},           // Códigos de recuperación de 2FA (hash)
+		&models.TwoFactorChallenge{},     // Segundo paso pendiente de logins con 2FA
+		&models.StreamTicket{},           // Tickets de un solo uso para abrir el flujo SSE
 	)
 
 	if err != nil {
 		log.Fatal("❌ Error migrando tablas:", err)
 	}
 
+	// Sembrar permisos y roles por defecto (admin recibe siempre todos los permisos)
+	if err := services.SeedRBAC(db); err != nil {
+		log.Printf("⚠️  Error sembrando roles y permisos: %v", err)
+	}
+
+	// Enlazar torneos existentes con su categoría (antes la categoría era solo texto)
+	if err := services.BackfillTournamentCategories(db); err != nil {
+		log.Printf("⚠️  Error enlazando torneos con categorías: %v", err)
+	}
+
 	// Crear usuario administrador inicial si no existe
 	var admin models.User
 	if err := db.Where("email = ?", "admin@admin.com").First(&admin).Error; err != nil {
@@ -15,6 +15,26 @@ type Category struct {
 	Color       string `gorm:"size:7" json:"color"`           // Color hexadecimal (ej: "#FF5733")
 	IsActive    bool   `gorm:"default:true" json:"is_active"` // Si la categoría está disponible
 	SortOrder   int    `gorm:"default:0" json:"sort_order"`   // Orden de显示
+
+	// Reglas de cartilla y valores por defecto para los torneos de esta categoría
+	Settings CategorySettingsJSON `gorm:"type:json" json:"settings"`
+
+	// Tipos de selección permitidos (vacío = se permiten todos)
+	SelectionTypes []CategorySelectionType `gorm:"foreignKey:CategoryID" json:"selection_types,omitempty"`
+}
+
+// AllowsSelectionType indica si la categoría permite el tipo de selección.
+// Requiere SelectionTypes precargado; una categoría sin tipos configurados permite cualquiera.
+func (c *Category) AllowsSelectionType(selectionType string) bool {
+	if len(c.SelectionTypes) == 0 {
+		return true
+	}
+	for _, t := range c.SelectionTypes {
+		if t.SelectionType == selectionType {
+			return true
+		}
+	}
+	return false
 }
 
 // TournamentSelectionType define los tipos de selección disponibles para una categoría
@@ -26,13 +46,18 @@ type CategorySelectionType struct {
 	DisplayName   string   `gorm:"size:50" json:"display_name"`            // Nombre a mostrar (ej: "Macho (Favorito)")
 	Description   string   `gorm:"type:text" json:"description"`
 	IsRequired    bool     `gorm:"default:false" json:"is_required"` // Si es obligatorio para esta categoría
+	DefaultPoints int      `gorm:"default:0" json:"default_points"`  // Puntos por defecto en torneos nuevos (0 = sin valor)
 }
 
 // CategorySettingsJSON para guardar configuración adicional en JSON
 type CategorySettingsJSON struct {
-	MinSelectionsPerSession int  `json:"min_selections_per_session"`
-	MaxSelectionsPerSession int  `json:"max_selections_per_session"`
-	AllowMultipleEvents     bool `json:"allow_multiple_events"`
+	MinSelectionsPerSession int  `json:"min_selections_per_session"` // 0 = sin mínimo
+	MaxSelectionsPerSession int  `json:"max_selections_per_session"` // 0 = sin máximo
+	AllowMultipleEvents     bool `json:"allow_multiple_events"`      // Permite varios picks sobre el mismo evento
+
+	// Valores por defecto para torneos nuevos de la categoría
+	DefaultSelectionsPerSession int       `json:"default_selections_per_session"`
+	DefaultPrizeDistribution    []float64 `json:"default_prize_distribution"`
 }
 
 // Scan implementa el scanner para JSON
@@ -40,7 +65,13 @@ func (cs *CategorySettingsJSON) Scan(value interface{}) error {
 	if value == nil {
 		return nil
 	}
-	return json.Unmarshal(value.([]byte), cs)
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, cs)
+	case string:
+		return json.Unmarshal([]byte(v), cs)
+	}
+	return nil
 }
 
 // Value implementa el valuer para JSON
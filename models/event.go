@@ -36,6 +36,10 @@ type Event struct {
 	// Total de score/puntos/carreras del evento (para liquidar alta/baja)
 	TotalScore float64 `gorm:"type:decimal(10,2);default:0" json:"total_score"`
 
+	// Estado en vivo (provisional, no asigna puntos): periodo actual y última actualización
+	Period        string     `gorm:"size:50" json:"period,omitempty"` // Ej: "Alta del 5to", "2do tiempo"
+	LiveUpdatedAt *time.Time `json:"live_updated_at,omitempty"`
+
 	// Relaciones
 	Competitors        []EventCompetitor   `gorm:"foreignKey:EventID" json:"competitors"`
 	PickableSelections []PickableSelection `gorm:"foreignKey:EventID" json:"pickable_selections,omitempty"`
@@ -89,6 +93,10 @@ type EventCompetitor struct {
 	Position    int  `gorm:"default:0" json:"position,omitempty"`    // Para carreras (1 para el ganador)
 	IsScratched bool `gorm:"default:false" json:"is_scratched"`      // Para caballos que se retiran antes
 
+	// Marcador en vivo (se actualiza durante el evento; no se usa para liquidar)
+	LiveScore    int `gorm:"default:0" json:"live_score"`
+	LivePosition int `gorm:"default:0" json:"live_position,omitempty"`
+
 	// Resultados adicionales
 	ScoredFirst       bool `gorm:"default:false" json:"scored_first"`        // Marcó primero
 	ScoredFirstHalf   bool `gorm:"default:false" json:"scored_first_half"`   // Marcó en primer tiempo/cuartos
@@ -96,6 +104,12 @@ type EventCompetitor struct {
 	ScoredFirstInning bool `gorm:"default:false" json:"scored_first_inning"` // Marcó en primer inning (béisbol)
 }
 
+// HasStarted indica si el evento ya comenzó (por hora o porque el scheduler lo marcó en vivo).
+// Los picks sobre eventos comenzados quedan congelados.
+func (e *Event) HasStarted(now time.Time) bool {
+	return !now.Before(e.StartTime) || e.Status == "live" || e.Status == "completed"
+}
+
 // Hook para automatizar el Slug y validaciones
 func (e *Event) BeforeSave(tx *gorm.DB) (err error) {
 	if e.Name != "" {
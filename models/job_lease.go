@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// JobLease es un candado en base de datos que asegura que solo una réplica
+// ejecute los trabajos programados. El dueño debe renovarlo antes de ExpiresAt.
+type JobLease struct {
+	BaseModel
+	Name      string    `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	Owner     string    `gorm:"size:150;not null" json:"owner"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+}
+
+func (JobLease) TableName() string {
+	return "job_leases"
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Estados del ciclo de vida de un torneo
+const (
+	TournamentStatusDraft     = "draft"     // En preparación, no visible para inscripciones
+	TournamentStatusOpen      = "open"      // Abierto para inscripciones
+	TournamentStatusRunning   = "running"   // En juego
+	TournamentStatusClosed    = "closed"    // Sin más sesiones abiertas, pendiente de premios
+	TournamentStatusFinished  = "finished"  // Premios repartidos
+	TournamentStatusCancelled = "cancelled" // Cancelado
+)
+
+// Estados del ciclo de vida de una sesión
+const (
+	SessionStatusScheduled = "scheduled" // Programada, aún no abre para picks
+	SessionStatusOpen      = "open"      // Abierta para picks
+	SessionStatusClosed    = "closed"    // Cerrada, esperando resultados
+	SessionStatusSettled   = "settled"   // Liquidada
+)
+
+// tournamentTransitions define las transiciones válidas del torneo (origen -> destinos)
+var tournamentTransitions = map[string][]string{
+	TournamentStatusDraft:   {TournamentStatusOpen, TournamentStatusCancelled},
+	TournamentStatusOpen:    {TournamentStatusRunning, TournamentStatusCancelled},
+	TournamentStatusRunning: {TournamentStatusClosed, TournamentStatusCancelled},
+	TournamentStatusClosed:  {TournamentStatusFinished, TournamentStatusCancelled},
+}
+
+// sessionTransitions define las transiciones válidas de la sesión (origen -> destinos)
+var sessionTransitions = map[string][]string{
+	SessionStatusScheduled: {SessionStatusOpen},
+	SessionStatusOpen:      {SessionStatusClosed},
+	SessionStatusClosed:    {SessionStatusSettled},
+}
+
+// CanTournamentTransition indica si el torneo puede pasar del estado from al estado to
+func CanTournamentTransition(from, to string) bool {
+	return containsStatus(tournamentTransitions[from], to)
+}
+
+// CanSessionTransition indica si la sesión puede pasar del estado from al estado to
+func CanSessionTransition(from, to string) bool {
+	return containsStatus(sessionTransitions[from], to)
+}
+
+// InitialSessionStatus devuelve el estado inicial de una sesión según su hora de apertura
+func InitialSessionStatus(startTime time.Time) string {
+	if startTime.After(time.Now()) {
+		return SessionStatusScheduled
+	}
+	return SessionStatusOpen
+}
+
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
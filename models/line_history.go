@@ -0,0 +1,31 @@
+package models
+
+// Entidades y campos versionados en el historial de líneas
+const (
+	LineEntityEvent      = "event"
+	LineEntitySelection  = "selection"
+	LineEntityCompetitor = "competitor"
+
+	LineFieldLine         = "line"
+	LineFieldOdds         = "odds"
+	LineFieldRunline      = "runline"
+	LineFieldRunlineAway  = "runline_away"
+	LineFieldSuperRunline = "super_runline"
+)
+
+// LineHistory registra cada cambio de línea, cuota o runline de un evento, selección o competidor.
+// Permite graficar el movimiento de la línea y auditar qué valor estaba vigente en cada momento.
+type LineHistory struct {
+	BaseModel
+	EventID    uint    `gorm:"not null;index" json:"event_id"`
+	EntityType string  `gorm:"size:20;not null;index:idx_line_history_entity" json:"entity_type"` // event, selection, competitor
+	EntityID   uint    `gorm:"not null;index:idx_line_history_entity" json:"entity_id"`
+	Field      string  `gorm:"size:20;not null" json:"field"` // line, odds, runline, runline_away, super_runline
+	OldValue   float64 `gorm:"type:decimal(10,2)" json:"old_value"`
+	NewValue   float64 `gorm:"type:decimal(10,2)" json:"new_value"`
+	ChangedBy  *uint   `json:"changed_by,omitempty"`
+}
+
+func (LineHistory) TableName() string {
+	return "line_history"
+}
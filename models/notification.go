@@ -0,0 +1,88 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Tipos de notificación
+const (
+	NotificationWithdrawalStatus = "withdrawal_status" // Cambio de estado de un retiro
+	NotificationSessionOpening   = "session_opening"   // Una sesión abre pronto para picks
+	NotificationPickDeadline     = "pick_deadline"     // Falta una hora para el cierre de picks
+	NotificationPicksSettled     = "picks_settled"     // Se liquidaron picks del usuario
+	NotificationPrizePaid        = "prize_paid"        // Se acreditó un premio
+)
+
+// NotificationTypes lista los tipos en el orden en que se muestran en las preferencias
+var NotificationTypes = []string{
+	NotificationWithdrawalStatus,
+	NotificationSessionOpening,
+	NotificationPickDeadline,
+	NotificationPicksSettled,
+	NotificationPrizePaid,
+}
+
+// IsValidNotificationType indica si el tipo es uno de los tipos de notificación del sistema
+func IsValidNotificationType(notificationType string) bool {
+	for _, t := range NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationData son datos extra para que el frontend enlace la notificación (ej: tournament_id)
+type NotificationData map[string]interface{}
+
+// Scan implementa el scanner para JSON
+func (d *NotificationData) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	}
+	return nil
+}
+
+// Value implementa el valuer para JSON
+func (d NotificationData) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// Notification es un mensaje en la bandeja de entrada del usuario
+type Notification struct {
+	BaseModel
+	UserID uint             `gorm:"not null;index;uniqueIndex:idx_notification_dedupe" json:"user_id"`
+	Type   string           `gorm:"size:50;not null;index" json:"type"`
+	Title  string           `gorm:"size:150;not null" json:"title"`
+	Body   string           `gorm:"type:text" json:"body"`
+	Data   NotificationData `gorm:"type:json" json:"data,omitempty"`
+	ReadAt *time.Time       `gorm:"index" json:"read_at"`
+
+	// DedupeKey evita repetir avisos programados (ej: "pick_deadline:12"); nil si no aplica
+	DedupeKey *string `gorm:"size:100;uniqueIndex:idx_notification_dedupe" json:"-"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationPreference guarda si el usuario quiere recibir un tipo de notificación.
+// Sin registro, el tipo está activado.
+type NotificationPreference struct {
+	BaseModel
+	UserID  uint   `gorm:"not null;uniqueIndex:idx_user_notification_type" json:"user_id"`
+	Type    string `gorm:"size:50;not null;uniqueIndex:idx_user_notification_type" json:"type"`
+	Enabled bool   `gorm:"not null" json:"enabled"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
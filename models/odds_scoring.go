@@ -0,0 +1,86 @@
+package models
+
+import "math"
+
+// Modos de puntuación del torneo
+const (
+	ScoringModeFixed = "fixed" // Puntos fijos de PickableSelection.PointsForWin
+	ScoringModeOdds  = "odds"  // Puntos derivados de la cuota americana de la selección
+)
+
+// Fórmulas para convertir cuotas en puntos
+const (
+	OddsFormulaDecimal = "decimal" // base × cuota decimal (incluye la unidad apostada)
+	OddsFormulaProfit  = "profit"  // base × ganancia por unidad apostada
+)
+
+// OddsScoring configura cómo se derivan los puntos de una selección a partir de su cuota.
+// Ej: base 10 con fórmula profit → -300 = 3.33 pts, +400 = 40 pts.
+type OddsScoring struct {
+	Formula    string  `json:"formula"`     // decimal (por defecto) o profit
+	BasePoints float64 `json:"base_points"` // Multiplicador (por defecto 1)
+	Rounding   string  `json:"rounding"`    // round (por defecto), floor o ceil
+	MinPoints  int     `json:"min_points"`  // 0 = sin mínimo
+	MaxPoints  int     `json:"max_points"`  // 0 = sin máximo
+}
+
+// AmericanToDecimal convierte una cuota americana (-300, +400) a decimal (1.33, 5.0)
+func AmericanToDecimal(odds int) float64 {
+	switch {
+	case odds > 0:
+		return 1 + float64(odds)/100
+	case odds < 0:
+		return 1 + 100/math.Abs(float64(odds))
+	}
+	return 0
+}
+
+// Points calcula los puntos por acertar una selección con la cuota dada
+func (o OddsScoring) Points(odds int) int {
+	decimal := AmericanToDecimal(odds)
+	if decimal == 0 {
+		return 0
+	}
+
+	base := o.BasePoints
+	if base == 0 {
+		base = 1
+	}
+
+	value := base * decimal
+	if o.Formula == OddsFormulaProfit {
+		value = base * (decimal - 1)
+	}
+
+	var points int
+	switch o.Rounding {
+	case "floor":
+		points = int(math.Floor(value))
+	case "ceil":
+		points = int(math.Ceil(value))
+	default:
+		points = int(math.Round(value))
+	}
+
+	if o.MinPoints > 0 && points < o.MinPoints {
+		points = o.MinPoints
+	}
+	if o.MaxPoints > 0 && points > o.MaxPoints {
+		points = o.MaxPoints
+	}
+	return points
+}
+
+// WinPoints devuelve los puntos que otorga acertar la selección en este torneo.
+// En modo odds se usa la cuota de la selección; sin cuota se recurre a PointsForWin.
+func (ts TournamentSettings) WinPoints(selection *PickableSelection) int {
+	return ts.WinPointsAtOdds(selection, selection.Odds)
+}
+
+// WinPointsAtOdds es como WinPoints pero con una cuota dada (ej: la vigente al hacer el pick)
+func (ts TournamentSettings) WinPointsAtOdds(selection *PickableSelection, odds int) int {
+	if ts.ScoringMode == ScoringModeOdds && odds != 0 {
+		return ts.OddsScoring.Points(odds)
+	}
+	return selection.PointsForWin
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Canales de envío de mensajes salientes
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Estados de un mensaje en el outbox
+const (
+	OutboxStatusPending = "pending" // Esperando envío o reintento
+	OutboxStatusSent    = "sent"    // Entregado al proveedor
+	OutboxStatusFailed  = "failed"  // Agotó los reintentos
+	OutboxStatusExpired = "expired" // Venció antes de enviarse (ej: un código ya inválido)
+)
+
+// OutboxMessage es un correo o SMS ya renderizado que se guarda en la misma transacción que
+// el cambio que lo origina y que el despachador envía después del commit, con reintentos.
+type OutboxMessage struct {
+	BaseModel
+	UserID        *uint      `gorm:"index" json:"user_id,omitempty"`
+	Channel       string     `gorm:"size:10;not null" json:"channel"`
+	Recipient     string     `gorm:"size:150;not null" json:"recipient"`
+	Template      string     `gorm:"size:50;not null" json:"template"`
+	Locale        string     `gorm:"size:5;not null" json:"locale"`
+	Subject       string     `gorm:"size:200" json:"subject,omitempty"`
+	Body          string     `gorm:"type:text;not null" json:"-"` // Puede contener códigos de verificación; se vacía al terminar
+	Status        string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	ExpiresAt     *time.Time `gorm:"index" json:"expires_at,omitempty"` // Pasada esta hora ya no se envía
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
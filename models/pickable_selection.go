@@ -20,6 +20,27 @@ const (
 	SelectionTypeCarreraPosicion = "carrera_posicion"    // Posición en carrera de caballos
 )
 
+// IsValidSelectionType indica si el tipo es uno de los tipos de selección del sistema
+func IsValidSelectionType(selectionType string) bool {
+	switch selectionType {
+	case SelectionTypeMacho, SelectionTypeHembra, SelectionTypeMachoRL, SelectionTypeHembraRL,
+		SelectionTypeMachoSRL, SelectionTypeHembraSRL, SelectionTypeAlta, SelectionTypeBaja,
+		SelectionTypeEmpate, SelectionTypeSuperAlta, SelectionTypeSuperBaja, SelectionTypeMarcaPrimero,
+		SelectionTypeMarcaPrimeroT, SelectionTypePrimeraMitad, SelectionTypeSegundaMitad, SelectionTypeCarreraPosicion:
+		return true
+	}
+	return false
+}
+
+// IsRunlineSelectionType indica si el tipo se liquida con hándicap (runline o super runline)
+func IsRunlineSelectionType(selectionType string) bool {
+	switch selectionType {
+	case SelectionTypeMachoRL, SelectionTypeHembraRL, SelectionTypeMachoSRL, SelectionTypeHembraSRL:
+		return true
+	}
+	return false
+}
+
 // PickableSelection define una opción de pronóstico configurable por el admin para un evento.
 // Ej: "Gana Real Madrid", "Alta de 2.5 goles", "Runline -1.5".
 type PickableSelection struct {
@@ -0,0 +1,78 @@
+package models
+
+// Permisos del panel de administración. Cada ruta de /admin exige uno.
+const (
+	PermUsersRead          = "users:read"          // Ver usuarios
+	PermUsersManage        = "users:manage"        // Activar y desactivar usuarios
+	PermRolesManage        = "roles:manage"        // Crear roles y asignarlos a usuarios
+	PermTournamentsRead    = "tournaments:read"    // Ver historial, plantillas y sesiones
+	PermTournamentsManage  = "tournaments:manage"  // Torneos, plantillas, sesiones y asignación de eventos
+	PermEventsRead         = "events:read"         // Ver eventos, selecciones y propuestas de resultados
+	PermEventsManage       = "events:manage"       // Eventos, selecciones, competidores e importación
+	PermEventsSettle       = "events:settle"       // Liquidar, marcador en vivo y propuestas de resultados
+	PermCategoriesManage   = "categories:manage"   // Categorías y catálogo de competidores
+	PermWithdrawalsRead    = "withdrawals:read"    // Ver retiros de todos los usuarios
+	PermWithdrawalsApprove = "withdrawals:approve" // Aprobar, rechazar y completar retiros
+	PermWebhooksRead       = "webhooks:read"       // Ver webhooks y sus entregas
+	PermWebhooksManage     = "webhooks:manage"     // Crear webhooks y reenviar entregas
+)
+
+// PermissionDescriptions describe cada permiso; es la lista completa que se siembra en la base de datos
+var PermissionDescriptions = map[string]string{
+	PermUsersRead:          "Ver usuarios",
+	PermUsersManage:        "Activar y desactivar usuarios",
+	PermRolesManage:        "Crear roles y asignarlos a usuarios",
+	PermTournamentsRead:    "Ver historial, plantillas y sesiones",
+	PermTournamentsManage:  "Torneos, plantillas, sesiones y asignación de eventos",
+	PermEventsRead:         "Ver eventos, selecciones y propuestas de resultados",
+	PermEventsManage:       "Eventos, selecciones, competidores e importación",
+	PermEventsSettle:       "Liquidar eventos, marcador en vivo y propuestas de resultados",
+	PermCategoriesManage:   "Categorías y catálogo de competidores",
+	PermWithdrawalsRead:    "Ver retiros de todos los usuarios",
+	PermWithdrawalsApprove: "Aprobar, rechazar y completar retiros",
+	PermWebhooksRead:       "Ver webhooks y sus entregas",
+	PermWebhooksManage:     "Crear webhooks y reenviar entregas",
+}
+
+// Roles del sistema. admin tiene todos los permisos y user ninguno; los de operador se crean
+// con permisos por defecto que luego se pueden ajustar.
+const (
+	RoleAdmin              = "admin"
+	RoleUser               = "user"
+	RoleResultsOperator    = "results_operator"
+	RoleWithdrawalOperator = "withdrawals_operator"
+	RoleAuditor            = "auditor"
+)
+
+// Permission es un permiso que se puede otorgar a un rol
+type Permission struct {
+	BaseModel
+	Code        string `gorm:"size:50;uniqueIndex;not null" json:"code"`
+	Description string `gorm:"size:200" json:"description"`
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// Role agrupa permisos. User.Role guarda el nombre del rol asignado.
+type Role struct {
+	BaseModel
+	Name        string       `gorm:"size:50;uniqueIndex;not null" json:"name"`
+	Description string       `gorm:"size:200" json:"description"`
+	IsSystem    bool         `gorm:"default:false" json:"is_system"` // admin y user no se editan ni eliminan
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+// PermissionCodes devuelve los códigos de los permisos del rol (requiere Permissions precargado)
+func (r *Role) PermissionCodes() []string {
+	codes := make([]string, 0, len(r.Permissions))
+	for _, p := range r.Permissions {
+		codes = append(codes, p.Code)
+	}
+	return codes
+}
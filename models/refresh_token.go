@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RefreshToken es un token de renovación guardado como hash. Cada uso lo reemplaza por uno
+// nuevo de la misma familia; si se presenta uno ya reemplazado se revoca toda la familia
+// porque indica que el token fue robado.
+type RefreshToken struct {
+	BaseModel
+	UserID       uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	FamilyID     string     `gorm:"size:50;not null;index" json:"family_id"` // Sesión de un dispositivo a través de las rotaciones
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	ReplacedByID *uint      `json:"replaced_by_id,omitempty"`
+	UserAgent    string     `gorm:"size:255" json:"user_agent,omitempty"`
+	IPAddress    string     `gorm:"size:45" json:"ip_address,omitempty"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Estados de una propuesta de resultado
+const (
+	ProposalStatusPending     = "pending"      // Esperando confirmación del admin
+	ProposalStatusApproved    = "approved"     // Aprobada por un admin y liquidada
+	ProposalStatusRejected    = "rejected"     // Descartada por un admin
+	ProposalStatusAutoSettled = "auto_settled" // Liquidada porque dos proveedores coincidieron
+	ProposalStatusSuperseded  = "superseded"   // Reemplazada por un dato más nuevo o por otra propuesta
+)
+
+// EventProviderMapping enlaza un evento con su ID en un proveedor externo de resultados
+type EventProviderMapping struct {
+	BaseModel
+	EventID    uint   `gorm:"not null;index" json:"event_id"`
+	Event      Event  `gorm:"foreignKey:EventID" json:"-"`
+	Provider   string `gorm:"size:50;not null;uniqueIndex:idx_provider_external" json:"provider"`
+	ExternalID string `gorm:"size:100;not null;uniqueIndex:idx_provider_external" json:"external_id"`
+}
+
+func (EventProviderMapping) TableName() string {
+	return "event_provider_mappings"
+}
+
+// ProposedResult es el resultado de un competidor del evento según un proveedor
+type ProposedResult struct {
+	CompetitorID uint `json:"competitor_id"` // ID del competidor en el evento
+	FinalScore   int  `json:"final_score"`
+	Position     int  `json:"position"`
+}
+
+// ProposedResults se guarda como JSON en la propuesta
+type ProposedResults []ProposedResult
+
+// Scan implementa el scanner para JSON
+func (r *ProposedResults) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	}
+	return nil
+}
+
+// Value implementa el valuer para JSON
+func (r ProposedResults) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// ResultProposal es un resultado obtenido de un proveedor que espera confirmación antes de liquidar
+type ResultProposal struct {
+	BaseModel
+	EventID    uint            `gorm:"not null;index" json:"event_id"`
+	Event      Event           `gorm:"foreignKey:EventID" json:"-"`
+	Provider   string          `gorm:"size:50;not null;index" json:"provider"`
+	ExternalID string          `gorm:"size:100" json:"external_id"`
+	Results    ProposedResults `gorm:"type:json" json:"results"`
+	Status     string          `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	ReviewedBy *uint           `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time      `json:"reviewed_at,omitempty"`
+	Note       string          `gorm:"size:255" json:"note,omitempty"`
+}
+
+func (ResultProposal) TableName() string {
+	return "result_proposals"
+}
+
+// SameResults indica si dos propuestas traen el mismo resultado (sin importar el orden)
+func (p *ResultProposal) SameResults(other ProposedResults) bool {
+	if len(p.Results) != len(other) {
+		return false
+	}
+	byCompetitor := make(map[uint]ProposedResult, len(p.Results))
+	for _, r := range p.Results {
+		byCompetitor[r.CompetitorID] = r
+	}
+	for _, r := range other {
+		if mine, ok := byCompetitor[r.CompetitorID]; !ok || mine != r {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,18 @@
+package models
+
+// SelectionPickStat lleva el conteo de picks por selección dentro de un torneo.
+// Se actualiza incrementalmente al enviar, cambiar o eliminar picks.
+type SelectionPickStat struct {
+	BaseModel
+	TournamentID uint `gorm:"not null;uniqueIndex:idx_selection_pick_stat" json:"tournament_id"`
+	SelectionID  uint `gorm:"not null;uniqueIndex:idx_selection_pick_stat" json:"selection_id"`
+	SessionID    uint `gorm:"index" json:"session_id"`
+	EventID      uint `gorm:"index" json:"event_id"`
+	PickCount    int  `gorm:"default:0" json:"pick_count"`
+
+	Selection PickableSelection `gorm:"foreignKey:SelectionID" json:"selection,omitempty"`
+}
+
+func (SelectionPickStat) TableName() string {
+	return "selection_pick_stats"
+}
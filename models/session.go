@@ -29,7 +29,8 @@ type Session struct {
 	// Descripción opcional de la sesión (ej: "Jornada de Lunes - 5 partidos")
 	Description string `gorm:"size:255" json:"description"`
 
-	// Estado de la sesión: open (abierta para picks), closed (cerrada), settled (liquidada)
+	// Estado de la sesión: scheduled (programada), open (abierta para picks), closed (cerrada), settled (liquidada)
+	// El scheduler la abre en StartTime y la cierra en EndTime
 	Status string `gorm:"size:20;default:'open';index" json:"status"`
 
 	// Relaciones - los eventos de una sesión se gestionan a través de TournamentEvent
@@ -0,0 +1,24 @@
+package models
+
+// Tipos de entidad con historial de estados
+const (
+	EntityTypeTournament = "tournament"
+	EntityTypeSession    = "session"
+)
+
+// StatusTransition registra cada cambio de estado de un torneo o sesión para auditoría.
+type StatusTransition struct {
+	BaseModel
+	EntityType string `gorm:"size:20;not null;index:idx_status_transition_entity" json:"entity_type"` // tournament, session
+	EntityID   uint   `gorm:"not null;index:idx_status_transition_entity" json:"entity_id"`
+	FromStatus string `gorm:"size:20" json:"from_status"`
+	ToStatus   string `gorm:"size:20;not null" json:"to_status"`
+	ActorID    *uint  `json:"actor_id"` // Nil cuando el cambio lo hace el sistema
+	Note       string `gorm:"type:text" json:"note,omitempty"`
+
+	Actor *User `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+}
+
+func (StatusTransition) TableName() string {
+	return "status_transitions"
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// StreamTicket es un ticket de un solo uso y vida corta para abrir el flujo SSE. EventSource no
+// permite cabeceras, y así el JWT no viaja en la URL (logs de proxies, historial).
+// Se guarda el hash del ticket.
+type StreamTicket struct {
+	BaseModel
+	UserID       uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	TokenVersion int        `gorm:"not null" json:"-"` // Versión de token del usuario al emitirlo
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	ConsumedAt   *time.Time `json:"consumed_at,omitempty"`
+}
+
+func (StreamTicket) TableName() string {
+	return "stream_tickets"
+}
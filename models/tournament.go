@@ -43,6 +43,12 @@ type TournamentSettings struct {
 
 	// Categoría del deporte: "futbol", "beisbol", "caballos", "basquet", etc.
 	SportCategory string `json:"sport_category"`
+
+	// Modo de puntuación: "fixed" (por defecto) u "odds" (según la cuota de cada selección)
+	ScoringMode string `json:"scoring_mode,omitempty"`
+
+	// Fórmula y redondeo para el modo "odds"
+	OddsScoring OddsScoring `json:"odds_scoring"`
 }
 
 // Implementación para guardar JSON en Gorm (MySQL/Postgres)
@@ -54,12 +60,19 @@ func (ts TournamentSettings) Value() (driver.Value, error) {
 	return json.Marshal(ts)
 }
 
+// Visibilidad de un torneo
+const (
+	TournamentVisibilityPublic  = "public"  // Aparece en el listado global
+	TournamentVisibilityPrivate = "private" // Solo accesible con código de invitación
+)
+
 type Tournament struct {
 	BaseModel
 	Name        string    `gorm:"size:100;not null;index" json:"name" binding:"required"`
 	Slug        string    `gorm:"size:120;uniqueIndex;not null" json:"slug"` // URL amigable
 	Description string    `gorm:"type:text" json:"description"`
-	Category    string    `gorm:"size:50;not null;index" json:"category"` // "Hipica", "Futbol"
+	Category    string    `gorm:"size:50;not null;index" json:"category"` // Nombre de la categoría: "Hipica", "Futbol"
+	CategoryID  *uint     `gorm:"index" json:"category_id,omitempty"`     // Categoría que define tipos y reglas
 	Status      string    `gorm:"size:20;not null;default:'open';index" json:"status"`
 	StartDate   time.Time `gorm:"not null" json:"start_date"`
 	EndDate     time.Time `gorm:"not null" json:"end_date"`
@@ -67,6 +80,17 @@ type Tournament struct {
 	// Límite de participantes (0 = sin límite)
 	MaxParticipants int `gorm:"default:0" json:"max_participants"`
 
+	// Visibilidad: public (listado global) o private (solo por invitación)
+	Visibility string `gorm:"size:20;not null;default:'public';index" json:"visibility"`
+
+	// Código para unirse a torneos privados. Solo se muestra al creador/admin.
+	InviteCode *string `gorm:"size:20;uniqueIndex" json:"-"`
+
+	// Torneo base publicado por un admin cuyas sesiones y eventos se juegan en esta liga.
+	// Nil para torneos normales.
+	ParentTournamentID *uint       `gorm:"index" json:"parent_tournament_id,omitempty"`
+	ParentTournament   *Tournament `gorm:"foreignKey:ParentTournamentID" json:"-"`
+
 	// Campos Financieros
 	EntryFee        float64 `gorm:"type:decimal(12,2);not null" json:"entry_fee"`            // Costo de inscripción
 	EntryFeeTokens  int     `gorm:"default:0" json:"entry_fee_tokens"`                       // Costo de inscripción en Tokens
@@ -96,6 +120,10 @@ func (t *Tournament) BeforeSave(tx *gorm.DB) (err error) {
 		// Se podrían añadir más validaciones, como que todos los eventos estén 'completed'
 	}
 
+	if t.Visibility == "" {
+		t.Visibility = TournamentVisibilityPublic
+	}
+
 	// 3. Validación de fechas lógica
 	if t.EndDate.Before(t.StartDate) {
 		return errors.New("la fecha de finalización no puede ser anterior a la de inicio")
@@ -104,6 +132,20 @@ func (t *Tournament) BeforeSave(tx *gorm.DB) (err error) {
 	return nil
 }
 
+// IsPrivate indica si el torneo requiere código de invitación
+func (t *Tournament) IsPrivate() bool {
+	return t.Visibility == TournamentVisibilityPrivate
+}
+
+// SlateTournamentID devuelve el ID del torneo que aporta las sesiones y eventos.
+// Una liga de usuarios juega la cartilla de su torneo base.
+func (t *Tournament) SlateTournamentID() uint {
+	if t.ParentTournamentID != nil {
+		return *t.ParentTournamentID
+	}
+	return t.ID
+}
+
 func (Tournament) TableName() string {
 	return "tournaments"
 }
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// TemplateSession define una sesión de la plantilla relativa a la fecha de inicio del torneo.
+type TemplateSession struct {
+	SessionNumber int `json:"session_number"`
+
+	// Minutos desde StartDate del torneo hasta la apertura de la sesión
+	StartOffsetMinutes int `json:"start_offset_minutes"`
+
+	// Minutos desde StartDate del torneo hasta la hora límite de la sesión
+	EndOffsetMinutes int `json:"end_offset_minutes"`
+
+	SuperLine   float64 `json:"super_line"`
+	Description string  `json:"description"`
+}
+
+// TemplateSessions es el calendario de sesiones guardado como JSON
+type TemplateSessions []TemplateSession
+
+// Scan implementa el scanner para JSON
+func (ts *TemplateSessions) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	return json.Unmarshal(value.([]byte), ts)
+}
+
+// Value implementa el valuer para JSON
+func (ts TemplateSessions) Value() (driver.Value, error) {
+	return json.Marshal(ts)
+}
+
+// TemplateEvent es un evento asignado en el torneo de origen. Al instanciar la plantilla se
+// duplica (con competidores y selecciones) a la hora relativa y se asigna a su sesión.
+type TemplateEvent struct {
+	// Evento de origen que se duplica
+	EventID uint `json:"event_id"`
+
+	// Minutos desde StartDate del torneo hasta el inicio del evento
+	StartOffsetMinutes int `json:"start_offset_minutes"`
+
+	// Sesión del torneo a la que se asigna (0 = sin sesión)
+	SessionNumber int `json:"session_number"`
+	Order         int `json:"order"`
+}
+
+// TemplateEvents es la lista de eventos asignados guardada como JSON
+type TemplateEvents []TemplateEvent
+
+// Scan implementa el scanner para JSON
+func (te *TemplateEvents) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	return json.Unmarshal(value.([]byte), te)
+}
+
+// Value implementa el valuer para JSON
+func (te TemplateEvents) Value() (driver.Value, error) {
+	return json.Marshal(te)
+}
+
+// TournamentTemplate guarda la configuración reutilizable de un torneo recurrente
+// (ej: la "polla" semanal de caballos): reglas, esquema de selecciones y calendario de sesiones.
+type TournamentTemplate struct {
+	BaseModel
+	Name        string `gorm:"size:100;not null;uniqueIndex" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+	Category    string `gorm:"size:50;not null" json:"category"`
+
+	// Duración total del torneo en minutos (EndDate = StartDate + DurationMinutes)
+	DurationMinutes int `gorm:"not null" json:"duration_minutes"`
+
+	MaxParticipants int     `gorm:"default:0" json:"max_participants"`
+	EntryFee        float64 `gorm:"type:decimal(12,2);default:0" json:"entry_fee"`
+	EntryFeeTokens  int     `gorm:"default:0" json:"entry_fee_tokens"`
+	PrizeBonus      float64 `gorm:"type:decimal(12,2);default:0" json:"prize_bonus"`
+	AdminFeePercent float64 `gorm:"type:decimal(5,2);default:10.0" json:"admin_fee_percent"`
+
+	// Reglas del torneo, incluye el esquema de tipos de selección (RequiredSelectionTypes)
+	Settings TournamentSettings `gorm:"type:json" json:"settings"`
+
+	// Calendario de sesiones relativo a la fecha de inicio
+	Sessions TemplateSessions `gorm:"type:json" json:"sessions"`
+
+	// Asignaciones de eventos capturadas del torneo de origen
+	Events TemplateEvents `gorm:"type:json" json:"events"`
+
+	CreatedBy uint `json:"created_by"`
+}
+
+// EndDateFrom calcula la fecha de finalización para un torneo que inicia en start
+func (t *TournamentTemplate) EndDateFrom(start time.Time) time.Time {
+	return start.Add(time.Duration(t.DurationMinutes) * time.Minute)
+}
+
+func (TournamentTemplate) TableName() string {
+	return "tournament_templates"
+}
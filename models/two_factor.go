@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// RecoveryCode es un código de recuperación de un solo uso para entrar sin la app TOTP.
+// Se guarda como hash; el usuario solo lo ve al generarlo.
+type RecoveryCode struct {
+	BaseModel
+	UserID   uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash string     `gorm:"size:64;not null;index" json:"-"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// TwoFactorChallenge es el segundo paso pendiente de un login con 2FA: la contraseña ya se
+// verificó y falta el código. Se guarda el hash del token que recibe el cliente.
+type TwoFactorChallenge struct {
+	BaseModel
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	Attempts   int        `gorm:"default:0" json:"attempts"`
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+}
+
+func (TwoFactorChallenge) TableName() string {
+	return "two_factor_challenges"
+}
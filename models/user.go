@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -12,6 +14,21 @@ type User struct {
 	Password string `gorm:"not null" json:"-"` // Oculto en JSON
 	Role     string `gorm:"size:20;default:'user'" json:"role"`
 	IsActive bool   `gorm:"default:true" json:"is_active"`
+	Locale   string `gorm:"size:5;default:'es'" json:"locale"` // Idioma de correos y SMS (es, en)
+
+	// TokenVersion se incrementa para revocar todos los tokens emitidos (logout global,
+	// desactivación o cambio de rol)
+	TokenVersion int `gorm:"default:0;not null" json:"-"`
+
+	// Verificación en dos pasos (TOTP). El secreto se guarda al iniciar el alta y solo se
+	// exige el código cuando TwoFactorEnabled es true.
+	TwoFactorEnabled bool   `gorm:"default:false" json:"two_factor_enabled"`
+	TwoFactorSecret  string `gorm:"size:64" json:"-"`
+	TwoFactorCounter int64  `gorm:"default:0" json:"-"` // Último intervalo TOTP usado; impide reutilizar un código
+	// Códigos erróneos seguidos en step-up, desactivación y regeneración; al llegar al máximo la
+	// 2FA queda bloqueada hasta TwoFactorLockedUntil
+	TwoFactorFailedAttempts int        `gorm:"default:0;not null" json:"-"`
+	TwoFactorLockedUntil    *time.Time `json:"-"`
 
 	// Datos personales
 	FullName   string `gorm:"size:200" json:"full_name"`
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // UserPick es la elección que un participante hace para una selección disponible.
 type UserPick struct {
 	BaseModel
@@ -11,6 +13,10 @@ type UserPick struct {
 	Status        string `gorm:"size:20;default:'pending'" json:"status"` // pending, won, lost, push
 	AwardedPoints int    `gorm:"default:0" json:"awarded_points"`
 
+	// Línea y cuota vigentes cuando se hizo el pick: la liquidación se evalúa contra estos valores
+	LineAtPick *float64 `gorm:"type:decimal(10,2)" json:"line_at_pick,omitempty"`
+	OddsAtPick *int     `json:"odds_at_pick,omitempty"`
+
 	Participant TournamentParticipant `gorm:"foreignKey:ParticipantID" json:"-"`
 	Selection   PickableSelection     `gorm:"foreignKey:SelectionID" json:"selection"`
 	Session     Session               `gorm:"foreignKey:SessionID" json:"session,omitempty"`
@@ -19,3 +25,9 @@ type UserPick struct {
 func (UserPick) TableName() string {
 	return "user_picks"
 }
+
+// IsRevealed indica si el pick ya puede mostrarse a otros participantes: al cerrar la sesión
+// o al comenzar su evento. Requiere Session y Selection.Event precargados.
+func (p *UserPick) IsRevealed(now time.Time) bool {
+	return !now.Before(p.Session.EndTime) || p.Selection.Event.HasStarted(now)
+}
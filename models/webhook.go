@@ -0,0 +1,110 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Tipos de eventos que se envían a los webhooks de socios
+const (
+	WebhookTournamentCreated = "tournament.created" // Se creó un torneo público
+	WebhookSessionOpened     = "session.opened"     // Una sesión abrió para picks
+	WebhookEventSettled      = "event.settled"      // Se liquidó un evento
+	WebhookLeaderboardFinal  = "leaderboard.final"  // Clasificación final de un torneo finalizado
+	WebhookPrizePaid         = "prize.paid"         // Se acreditó un premio
+)
+
+// WebhookEventTypes lista los tipos a los que se puede suscribir un webhook
+var WebhookEventTypes = []string{
+	WebhookTournamentCreated,
+	WebhookSessionOpened,
+	WebhookEventSettled,
+	WebhookLeaderboardFinal,
+	WebhookPrizePaid,
+}
+
+// IsValidWebhookEventType indica si el tipo es uno de los eventos de webhook del sistema
+func IsValidWebhookEventType(eventType string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Estados de una entrega de webhook
+const (
+	WebhookDeliveryPending   = "pending"   // Esperando envío o reintento
+	WebhookDeliveryDelivered = "delivered" // El receptor respondió 2xx
+	WebhookDeliveryDead      = "dead"      // Agotó los reintentos; se puede reenviar a mano
+)
+
+// WebhookEventList son los tipos de evento a los que está suscrito un webhook
+type WebhookEventList []string
+
+// Scan implementa el scanner para JSON
+func (l *WebhookEventList) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	}
+	return nil
+}
+
+// Value implementa el valuer para JSON
+func (l WebhookEventList) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+// Includes indica si la lista contiene el tipo de evento
+func (l WebhookEventList) Includes(eventType string) bool {
+	for _, t := range l {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscription es un endpoint de un socio que recibe eventos firmados con su secreto
+type WebhookSubscription struct {
+	BaseModel
+	Name       string           `gorm:"size:100;not null" json:"name"`
+	URL        string           `gorm:"size:500;not null" json:"url"`
+	Secret     string           `gorm:"size:100;not null" json:"-"` // Solo se muestra al crear el webhook
+	EventTypes WebhookEventList `gorm:"type:json;not null" json:"event_types"`
+	IsActive   bool             `gorm:"default:true" json:"is_active"`
+	CreatedBy  uint             `json:"created_by"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// WebhookDelivery es el envío de un evento a una suscripción, con su historial de reintentos
+type WebhookDelivery struct {
+	BaseModel
+	SubscriptionID uint       `gorm:"not null;index" json:"subscription_id"`
+	EventID        string     `gorm:"size:40;not null;index" json:"event_id"` // Igual en todas las suscripciones para que el socio deduplique
+	EventType      string     `gorm:"size:50;not null" json:"event_type"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"not null;index" json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+
+	Subscription WebhookSubscription `gorm:"foreignKey:SubscriptionID" json:"-"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
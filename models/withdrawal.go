@@ -1,7 +1,9 @@
 package models
 
 import (
+	"crypto/rand"
 	"fmt"
+	"math/big"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,7 +17,7 @@ type Withdrawal struct {
 	NewBalance      float64    `gorm:"type:decimal(12,2)" json:"new_balance"`
 	PaymentMethodID uint       `gorm:"not null" json:"payment_method_id"`
 	Status          string     `gorm:"size:20;default:'pending'" json:"status"` // pending, approved, rejected, completed
-	WithdrawalCode  string     `gorm:"size:10" json:"withdrawal_code"`          // Código de verificación
+	WithdrawalCode  string     `gorm:"size:10" json:"-"`                        // Código de verificación (solo se envía por correo/SMS)
 	Verified        bool       `gorm:"default:false" json:"verified"`
 	VerifiedAt      *time.Time `json:"verified_at"`
 	RejectedReason  string     `gorm:"type:text" json:"rejected_reason,omitempty"`
@@ -34,8 +36,12 @@ func (Withdrawal) TableName() string {
 // BeforeCreate genera un código de verificación único
 func (w *Withdrawal) BeforeCreate(tx *gorm.DB) error {
 	if w.WithdrawalCode == "" {
-		// Generar código de 6 dígitos
-		w.WithdrawalCode = fmt.Sprintf("%06d", time.Now().UnixNano()%1000000)
+		// Generar código aleatorio de 6 dígitos
+		n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+		if err != nil {
+			return err
+		}
+		w.WithdrawalCode = fmt.Sprintf("%06d", n.Int64())
 	}
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/cesarbmathec/bets-backend/controllers"
 	middleware "github.com/cesarbmathec/bets-backend/middlewares"
+	"github.com/cesarbmathec/bets-backend/models"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -61,7 +62,7 @@ func SetupRouter() *gin.Engine {
 		r.Use(cors.New(cors.Config{
 			AllowOrigins:     []string{"*"},
 			AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-			AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Requested-With", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Requested-With", "Access-Control-Request-Method", "Access-Control-Request-Headers", middleware.TwoFactorHeader},
 			ExposeHeaders:    []string{"Content-Length", "Authorization"},
 			AllowCredentials: false,
 			MaxAge:           86400,
@@ -70,7 +71,7 @@ func SetupRouter() *gin.Engine {
 		r.Use(cors.New(cors.Config{
 			AllowOrigins:     allowedOrigins,
 			AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-			AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Requested-With", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Requested-With", "Access-Control-Request-Method", "Access-Control-Request-Headers", middleware.TwoFactorHeader},
 			ExposeHeaders:    []string{"Content-Length", "Authorization"},
 			AllowCredentials: allowCredentials,
 			MaxAge:           86400,
@@ -98,6 +99,21 @@ func SetupRouter() *gin.Engine {
 		{
 			auth.POST("/login", authLimiter.Middleware(), controllers.Login)
 			auth.POST("/register", authLimiter.Middleware(), controllers.Register)
+			auth.POST("/refresh", authLimiter.Middleware(), controllers.RefreshToken)
+			auth.POST("/logout", authLimiter.Middleware(), controllers.Logout)
+			auth.POST("/logout-all", middleware.AuthMiddleware(), controllers.LogoutAllDevices)
+
+			// Verificación en dos pasos (TOTP)
+			auth.POST("/2fa/verify", authLimiter.Middleware(), controllers.VerifyTwoFactorLogin)
+			twoFactor := auth.Group("/2fa")
+			twoFactor.Use(middleware.AuthMiddleware())
+			{
+				twoFactor.GET("", controllers.GetTwoFactorStatus)
+				twoFactor.POST("/setup", controllers.SetupTwoFactor)
+				twoFactor.POST("/enable", authLimiter.Middleware(), controllers.EnableTwoFactor)
+				twoFactor.POST("/disable", authLimiter.Middleware(), controllers.DisableTwoFactor)
+				twoFactor.POST("/recovery-codes", authLimiter.Middleware(), controllers.RegenerateRecoveryCodes)
+			}
 		}
 
 		// --- RUTAS PÚBLICAS DE CONSULTA --- //
@@ -108,15 +124,18 @@ func SetupRouter() *gin.Engine {
 			categories.GET("", categoryCtrl.GetCategories)
 			categories.GET("/", categoryCtrl.GetCategories)
 			categories.GET("/:id", categoryCtrl.GetCategoryByID)
+			categories.GET("/:id/selection-types", categoryCtrl.GetCategorySelectionTypes)
 		}
 
 		tournaments := api.Group("/tournaments")
+		tournaments.Use(middleware.OptionalAuthMiddleware())
 		{
 			// Ruta sin slash para evitar redirección 301
 			tournaments.GET("", controllers.GetTournaments)
 			tournaments.GET("/", controllers.GetTournaments)
 			tournaments.GET("/id/:id", controllers.GetTournamentByID)
 			tournaments.GET("/id/:id/leaderboard", controllers.GetTournamentLeaderboard)
+			tournaments.GET("/id/:id/leaderboard/projected", controllers.GetProjectedLeaderboard)
 			tournaments.GET("/s/:slug", controllers.GetTournamentBySlug)
 			tournaments.GET("/id/:id/events", controllers.GetTournamentEvents)
 			tournaments.GET("/id/:id/sessions", controllers.GetTournamentSessions)
@@ -134,6 +153,8 @@ func SetupRouter() *gin.Engine {
 			events.GET("/", controllers.GetGlobalEvents)
 			events.GET("/:id", controllers.GetEventByID)
 			events.GET("/:id/selections", controllers.GetEventSelections)
+			events.GET("/:id/line-history", controllers.GetEventLineHistory)
+			events.GET("/:id/live", controllers.GetEventLive)
 			events.GET("/tournament/:tournament_id", controllers.GetTournamentEventsByTournament)
 		}
 
@@ -146,6 +167,11 @@ func SetupRouter() *gin.Engine {
 			competitors.GET("/:id", controllers.GetCompetitorByID)
 		}
 
+		// --- TIEMPO REAL (SSE) --- //
+		// Fuera del grupo protegido: desde EventSource se entra con un ticket de un solo uso por query
+		api.POST("/stream/ticket", middleware.AuthMiddleware(), controllers.CreateStreamTicket)
+		api.GET("/stream", middleware.StreamAuthMiddleware(), controllers.StreamUpdates)
+
 		// --- RUTAS PROTEGIDAS (Usuario autenticado) --- //
 		protected := api.Group("/")
 		protected.Use(middleware.AuthMiddleware())
@@ -158,16 +184,29 @@ func SetupRouter() *gin.Engine {
 			{
 				// Inscripción y picks
 				userRoutes.POST("/tournaments/:id/join", controllers.JoinTournament)
+				userRoutes.POST("/tournaments/join-by-code", controllers.JoinTournamentByCode)
 				userRoutes.POST("/tournaments/:id/sessions/picks", controllers.SubmitPicksBySession)
 
+				// Transparencia: cartillas de otros participantes y popularidad (tras el bloqueo)
+				userRoutes.GET("/tournaments/:id/participants/:participant_id/card", controllers.GetParticipantCard)
+				userRoutes.GET("/tournaments/:id/sessions/:session_id/popularity", controllers.GetSessionPickPopularity)
+				userRoutes.GET("/tournaments/:id/stats/trending", controllers.GetTrendingPicks)
+				userRoutes.GET("/tournaments/:id/stats/upsets", controllers.GetUpsetPicks)
+				userRoutes.GET("/tournaments/:id/stats/contrarian", controllers.GetContrarianScores)
+
+				// Ligas privadas e invitaciones
+				userRoutes.POST("/leagues", controllers.CreateLeague)
+				userRoutes.GET("/tournaments/:id/invite", controllers.GetTournamentInvite)
+				userRoutes.POST("/tournaments/:id/invite/regenerate", controllers.RegenerateTournamentInvite)
+
 				// Billetera
 				userRoutes.GET("/wallet/balance", controllers.GetBalance)
 				userRoutes.POST("/wallet/deposit", controllers.DepositMoney)
 				userRoutes.GET("/wallet/history", controllers.GetTransactionHistory)
 				userRoutes.GET("/wallet/statistics", controllers.GetUserStatistics)
 
-				// Withdrawals
-				userRoutes.POST("/wallet/withdraw", controllers.CreateWithdrawal)
+				// Withdrawals (con 2FA activa se pide el código en X-2FA-Code)
+				userRoutes.POST("/wallet/withdraw", middleware.RequireStepUp(), controllers.CreateWithdrawal)
 				userRoutes.POST("/wallet/withdraw/verify", controllers.VerifyWithdrawal)
 				userRoutes.GET("/wallet/withdraw/history", controllers.GetWithdrawalHistory)
 				userRoutes.GET("/wallet/withdraw/limits", controllers.GetWithdrawalLimits)
@@ -176,88 +215,167 @@ func SetupRouter() *gin.Engine {
 
 				// Ver mis picks de sesión
 				userRoutes.GET("/my-sessions/:session_id/picks", controllers.GetSessionPicks)
+				userRoutes.GET("/my-picks", controllers.GetMyPicks)
+				userRoutes.GET("/my-picks/summary", controllers.GetMyPicksSummary)
+				userRoutes.PUT("/my-picks/:pick_id", controllers.UpdatePick)
+				userRoutes.DELETE("/my-picks/:pick_id", controllers.DeletePick)
+
+				// Notificaciones
+				userRoutes.GET("/notifications", controllers.GetMyNotifications)
+				userRoutes.GET("/notifications/unread-count", controllers.GetUnreadNotificationCount)
+				userRoutes.POST("/notifications/read-all", controllers.MarkAllNotificationsRead)
+				userRoutes.POST("/notifications/:id/read", controllers.MarkNotificationRead)
+				userRoutes.GET("/notifications/preferences", controllers.GetNotificationPreferences)
+				userRoutes.PUT("/notifications/preferences", controllers.UpdateNotificationPreferences)
 
 				// Métodos de pago (retiro)
 				userRoutes.GET("/payment-methods", controllers.GetPaymentMethods)
-				userRoutes.POST("/payment-methods", controllers.CreatePaymentMethod)
-				userRoutes.DELETE("/payment-methods/:id", controllers.DeletePaymentMethod)
+				userRoutes.POST("/payment-methods", middleware.RequireStepUp(), controllers.CreatePaymentMethod)
+				userRoutes.DELETE("/payment-methods/:id", middleware.RequireStepUp(), controllers.DeletePaymentMethod)
 			}
 		}
 
 		// --- RUTAS PROTEGIDAS DE ADMINISTRADOR --- //
 		admin := api.Group("/admin")
+		// Cada ruta exige un permiso; el rol admin los tiene todos. Sin 2FA activa no se entra.
 		admin.Use(middleware.AuthMiddleware())
-		admin.Use(middleware.RequireAdmin())
+		admin.Use(middleware.RequireTwoFactor())
+		can := middleware.RequirePermission
 		{
 			// Gestión de usuarios
 			adminUsers := admin.Group("/users")
 			{
 				// Rutas duales para evitar redirección
-				adminUsers.GET("", controllers.GetUsers)
-				adminUsers.GET("/", controllers.GetUsers)
-				adminUsers.GET("/:id", controllers.GetUserByID)
-				adminUsers.PATCH("/:id/role", controllers.UpdateUserRole)
-				adminUsers.PATCH("/:id/status", controllers.UpdateUserStatus)
+				adminUsers.GET("", can(models.PermUsersRead), controllers.GetUsers)
+				adminUsers.GET("/", can(models.PermUsersRead), controllers.GetUsers)
+				adminUsers.GET("/:id", can(models.PermUsersRead), controllers.GetUserByID)
+				adminUsers.PATCH("/:id/role", can(models.PermRolesManage), controllers.UpdateUserRole)
+				adminUsers.PATCH("/:id/status", can(models.PermUsersManage), controllers.UpdateUserStatus)
 			}
 
 			// Gestión de Torneos
 			adminTournaments := admin.Group("/tournaments")
 			{
 				// Rutas duales para evitar redirección 307
-				adminTournaments.POST("", controllers.CreateTournament)
-				adminTournaments.POST("/", controllers.CreateTournament)
-				adminTournaments.PATCH("/:id/status", controllers.UpdateTournamentStatus)
+				adminTournaments.POST("", can(models.PermTournamentsManage), controllers.CreateTournament)
+				adminTournaments.POST("/", can(models.PermTournamentsManage), controllers.CreateTournament)
+				adminTournaments.PATCH("/:id/status", can(models.PermTournamentsManage), middleware.RequireStepUp(), controllers.UpdateTournamentStatus)
+				adminTournaments.POST("/:id/clone", can(models.PermTournamentsManage), controllers.CloneTournament)
+				adminTournaments.GET("/:id/history", can(models.PermTournamentsRead), controllers.GetTournamentStatusHistory)
+				adminTournaments.POST("/:id/stats/rebuild", can(models.PermTournamentsManage), controllers.RebuildTournamentPickStats)
+				adminTournaments.GET("/:id/selection-points", can(models.PermTournamentsRead), controllers.GetSelectionPoints)
+			}
+
+			// Plantillas de torneo
+			adminTemplates := admin.Group("/tournament-templates")
+			{
+				adminTemplates.GET("", can(models.PermTournamentsRead), controllers.GetTournamentTemplates)
+				adminTemplates.POST("", can(models.PermTournamentsManage), controllers.CreateTournamentTemplate)
+				adminTemplates.GET("/:id", can(models.PermTournamentsRead), controllers.GetTournamentTemplateByID)
+				adminTemplates.DELETE("/:id", can(models.PermTournamentsManage), controllers.DeleteTournamentTemplate)
+				adminTemplates.POST("/:id/instantiate", can(models.PermTournamentsManage), controllers.InstantiateTournamentTemplate)
 			}
 
 			// Gestión de Sesiones
 			adminSessions := admin.Group("/sessions")
 			{
-				adminSessions.POST("", controllers.CreateSession)
-				adminSessions.POST("/", controllers.CreateSession)
-				adminSessions.PATCH("/:id/status", controllers.UpdateSessionStatus)
+				adminSessions.POST("", can(models.PermTournamentsManage), controllers.CreateSession)
+				adminSessions.POST("/", can(models.PermTournamentsManage), controllers.CreateSession)
+				adminSessions.PATCH("/:id/status", can(models.PermTournamentsManage), controllers.UpdateSessionStatus)
+				adminSessions.GET("/:id/history", can(models.PermTournamentsRead), controllers.GetSessionStatusHistory)
+				adminSessions.POST("/:id/results", can(models.PermEventsSettle), controllers.SettleSessionResults)
 			}
 
 			// Gestión de Eventos Globales - Rutas específicas primero
 			adminEvents := admin.Group("/events")
 			{
-				adminEvents.POST("/:event_id/selections", controllers.CreateSelection)
-				adminEvents.GET("/:event_id/selections", controllers.GetEventSelections)
-				adminEvents.POST("/:event_id/competitors", controllers.SetEventCompetitors)
-				adminEvents.POST("/:event_id/settle", controllers.SettleEvent)
-				adminEvents.POST("", controllers.CreateGlobalEvent)
-				adminEvents.POST("/", controllers.CreateGlobalEvent)
-				adminEvents.PUT("/:id", controllers.UpdateEvent)
-				adminEvents.DELETE("/:id", controllers.DeleteEvent)
-				adminEvents.GET("/available", controllers.GetAvailableEventsForTournament)
+				adminEvents.POST("/:event_id/selections", can(models.PermEventsManage), controllers.CreateSelection)
+				adminEvents.POST("/:event_id/selections/generate", can(models.PermEventsManage), controllers.GenerateEventSelections)
+				adminEvents.GET("/:event_id/selections", can(models.PermEventsRead), controllers.GetEventSelections)
+				adminEvents.POST("/:event_id/competitors", can(models.PermEventsManage), controllers.SetEventCompetitors)
+				adminEvents.POST("/:event_id/settle", can(models.PermEventsSettle), controllers.SettleEvent)
+				adminEvents.PUT("/:id/live", can(models.PermEventsSettle), controllers.UpdateEventLive)
+				adminEvents.POST("/:event_id/provider-mappings", can(models.PermEventsManage), controllers.CreateEventProviderMapping)
+				adminEvents.GET("/:event_id/provider-mappings", can(models.PermEventsRead), controllers.GetEventProviderMappings)
+				adminEvents.POST("/import", can(models.PermEventsManage), controllers.ImportEvents)
+				adminEvents.POST("", can(models.PermEventsManage), controllers.CreateGlobalEvent)
+				adminEvents.POST("/", can(models.PermEventsManage), controllers.CreateGlobalEvent)
+				adminEvents.PUT("/:id", can(models.PermEventsManage), controllers.UpdateEvent)
+				adminEvents.PUT("/selections/:id", can(models.PermEventsManage), controllers.UpdateSelection)
+				adminEvents.DELETE("/:id", can(models.PermEventsManage), controllers.DeleteEvent)
+				adminEvents.GET("/available", can(models.PermEventsRead), controllers.GetAvailableEventsForTournament)
+			}
+
+			// Resultados de proveedores externos
+			admin.DELETE("/provider-mappings/:id", can(models.PermEventsManage), controllers.DeleteEventProviderMapping)
+			adminProposals := admin.Group("/result-proposals")
+			{
+				adminProposals.GET("", can(models.PermEventsRead), controllers.GetResultProposals)
+				adminProposals.POST("/:id/approve", can(models.PermEventsSettle), controllers.ApproveResultProposal)
+				adminProposals.POST("/:id/reject", can(models.PermEventsSettle), controllers.RejectResultProposal)
+			}
+
+			// Webhooks de socios
+			adminWebhooks := admin.Group("/webhooks")
+			{
+				adminWebhooks.GET("", can(models.PermWebhooksRead), controllers.GetWebhooks)
+				adminWebhooks.POST("", can(models.PermWebhooksManage), controllers.CreateWebhook)
+				adminWebhooks.PUT("/:id", can(models.PermWebhooksManage), controllers.UpdateWebhook)
+				adminWebhooks.DELETE("/:id", can(models.PermWebhooksManage), controllers.DeleteWebhook)
+				adminWebhooks.GET("/:id/deliveries", can(models.PermWebhooksRead), controllers.GetWebhookDeliveries)
+			}
+			admin.GET("/webhook-deliveries/dead", can(models.PermWebhooksRead), controllers.GetDeadWebhookDeliveries)
+			admin.POST("/webhook-deliveries/:id/replay", can(models.PermWebhooksManage), controllers.ReplayWebhookDelivery)
+
+			// Retiros de los usuarios
+			adminWithdrawals := admin.Group("/withdrawals")
+			{
+				adminWithdrawals.GET("", can(models.PermWithdrawalsRead), controllers.GetAdminWithdrawals)
+				adminWithdrawals.POST("/:id/approve", can(models.PermWithdrawalsApprove), middleware.RequireStepUp(), controllers.ApproveWithdrawal)
+				adminWithdrawals.POST("/:id/reject", can(models.PermWithdrawalsApprove), controllers.RejectWithdrawal)
+				adminWithdrawals.POST("/:id/complete", can(models.PermWithdrawalsApprove), middleware.RequireStepUp(), controllers.CompleteWithdrawal)
+			}
+
+			// Roles y permisos
+			adminRoles := admin.Group("/roles")
+			{
+				adminRoles.GET("", can(models.PermRolesManage), controllers.GetRoles)
+				adminRoles.POST("", can(models.PermRolesManage), controllers.CreateRole)
+				adminRoles.PUT("/:id", can(models.PermRolesManage), controllers.UpdateRole)
+				adminRoles.DELETE("/:id", can(models.PermRolesManage), controllers.DeleteRole)
 			}
+			admin.GET("/permissions", can(models.PermRolesManage), controllers.GetPermissions)
 
 			// Gestión de Eventos en Torneos (asignación)
 			adminTournamentEvents := admin.Group("/tournament-events")
 			{
-				adminTournamentEvents.POST("", controllers.AssignEventToTournament)
-				adminTournamentEvents.DELETE("/:id", controllers.RemoveEventFromTournament)
+				adminTournamentEvents.POST("", can(models.PermTournamentsManage), controllers.AssignEventToTournament)
+				adminTournamentEvents.DELETE("/:id", can(models.PermTournamentsManage), controllers.RemoveEventFromTournament)
 			}
 
 			// Gestión de Categorías
 			adminCategories := admin.Group("/categories")
 			{
-				adminCategories.POST("", categoryCtrl.CreateCategory)
-				adminCategories.POST("/", categoryCtrl.CreateCategory)
-				adminCategories.PUT("", categoryCtrl.UpdateCategory)
-				adminCategories.PUT("/:id", categoryCtrl.UpdateCategory)
-				adminCategories.DELETE("", categoryCtrl.DeleteCategory)
-				adminCategories.DELETE("/:id", categoryCtrl.DeleteCategory)
-				adminCategories.PATCH("", categoryCtrl.ToggleCategoryStatus)
-				adminCategories.PATCH("/:id/status", categoryCtrl.ToggleCategoryStatus)
+				adminCategories.POST("", can(models.PermCategoriesManage), categoryCtrl.CreateCategory)
+				adminCategories.POST("/", can(models.PermCategoriesManage), categoryCtrl.CreateCategory)
+				adminCategories.PUT("", can(models.PermCategoriesManage), categoryCtrl.UpdateCategory)
+				adminCategories.PUT("/:id", can(models.PermCategoriesManage), categoryCtrl.UpdateCategory)
+				adminCategories.DELETE("", can(models.PermCategoriesManage), categoryCtrl.DeleteCategory)
+				adminCategories.DELETE("/:id", can(models.PermCategoriesManage), categoryCtrl.DeleteCategory)
+				adminCategories.PATCH("", can(models.PermCategoriesManage), categoryCtrl.ToggleCategoryStatus)
+				adminCategories.PATCH("/:id/status", can(models.PermCategoriesManage), categoryCtrl.ToggleCategoryStatus)
+				adminCategories.PUT("/:id/settings", can(models.PermCategoriesManage), categoryCtrl.UpdateCategorySettings)
+				adminCategories.POST("/:id/selection-types", can(models.PermCategoriesManage), categoryCtrl.SetCategorySelectionType)
+				adminCategories.DELETE("/:id/selection-types/:type", can(models.PermCategoriesManage), categoryCtrl.DeleteCategorySelectionType)
 			}
 
 			// Gestión de Competidores (catálogo global)
 			adminCompetitors := admin.Group("/competitors")
 			{
-				adminCompetitors.POST("", controllers.CreateCompetitor)
-				adminCompetitors.POST("/", controllers.CreateCompetitor)
-				adminCompetitors.PUT("/:id", controllers.UpdateCompetitor)
-				adminCompetitors.DELETE("/:id", controllers.DeleteCompetitor)
+				adminCompetitors.POST("", can(models.PermCategoriesManage), controllers.CreateCompetitor)
+				adminCompetitors.POST("/", can(models.PermCategoriesManage), controllers.CreateCompetitor)
+				adminCompetitors.PUT("/:id", can(models.PermCategoriesManage), controllers.UpdateCompetitor)
+				adminCompetitors.DELETE("/:id", can(models.PermCategoriesManage), controllers.DeleteCompetitor)
 			}
 		}
 	}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenTTL es la vida de un refresh token; cada rotación emite uno nuevo con la vida completa
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// Errores de la renovación de tokens
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token inválido o expirado")
+	ErrRefreshTokenReused  = errors.New("refresh token ya usado; se cerró la sesión del dispositivo")
+	ErrUserInactive        = errors.New("cuenta de usuario desactivada")
+)
+
+// TokenPair es el token de acceso más el refresh token que lo renueva
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int // Segundos de vida del token de acceso
+}
+
+// ClientInfo identifica el dispositivo que pide los tokens
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// IssueTokens inicia una sesión nueva (una familia de refresh tokens) para el usuario
+func IssueTokens(db *gorm.DB, user *models.User, client ClientInfo) (*TokenPair, error) {
+	familyID, err := utils.RandomToken(18)
+	if err != nil {
+		return nil, err
+	}
+	pair, _, err := issueTokenPair(db, user, familyID, client, time.Now())
+	return pair, err
+}
+
+func issueTokenPair(db *gorm.DB, user *models.User, familyID string, client ClientInfo, now time.Time) (*TokenPair, *models.RefreshToken, error) {
+	accessToken, err := utils.GenerateToken(user.ID, user.Username, user.Role, user.TokenVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refresh := models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(raw),
+		FamilyID:  familyID,
+		ExpiresAt: now.Add(RefreshTokenTTL),
+		UserAgent: truncate(client.UserAgent, 255),
+		IPAddress: truncate(client.IPAddress, 45),
+	}
+	if err := db.Create(&refresh).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: raw,
+		ExpiresIn:    int(utils.AccessTokenTTL.Seconds()),
+	}, &refresh, nil
+}
+
+// RotateRefreshToken canjea un refresh token por un par nuevo y revoca el usado. Presentar un
+// token ya rotado revoca toda su familia (ErrRefreshTokenReused). Requiere una transacción.
+func RotateRefreshToken(tx *gorm.DB, raw string, client ClientInfo, now time.Time) (*TokenPair, *models.User, error) {
+	var current models.RefreshToken
+	if err := tx.Where("token_hash = ?", utils.HashToken(raw)).First(&current).Error; err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	if current.RevokedAt != nil {
+		if current.ReplacedByID != nil {
+			if err := revokeRefreshTokens(tx.Where("family_id = ?", current.FamilyID), now); err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, ErrRefreshTokenReused
+		}
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if !now.Before(current.ExpiresAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	var user models.User
+	if err := tx.First(&user, current.UserID).Error; err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if !user.IsActive {
+		return nil, nil, ErrUserInactive
+	}
+
+	pair, next, err := issueTokenPair(tx, &user, current.FamilyID, client, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Solo gana una rotación concurrente del mismo token
+	result := tx.Model(&models.RefreshToken{}).Where("id = ? AND revoked_at IS NULL", current.ID).
+		Updates(map[string]interface{}{"revoked_at": now, "replaced_by_id": next.ID})
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	return pair, &user, nil
+}
+
+// RevokeRefreshToken cierra la sesión del dispositivo dueño del token (toda su familia).
+// Un token desconocido no es error para que logout sea idempotente.
+func RevokeRefreshToken(db *gorm.DB, raw string, now time.Time) error {
+	var current models.RefreshToken
+	if err := db.Where("token_hash = ?", utils.HashToken(raw)).First(&current).Error; err != nil {
+		return nil
+	}
+	return revokeRefreshTokens(db.Where("family_id = ?", current.FamilyID), now)
+}
+
+// RevokeUserSessions revoca todos los tokens del usuario: sube su TokenVersion, con lo que los
+// tokens de acceso dejan de valer en el siguiente request, y revoca sus refresh tokens.
+func RevokeUserSessions(db *gorm.DB, userID uint, now time.Time) error {
+	if err := db.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+		return err
+	}
+	InvalidatePrincipal(userID)
+	return revokeRefreshTokens(db.Where("user_id = ?", userID), now)
+}
+
+func revokeRefreshTokens(scope *gorm.DB, now time.Time) error {
+	return scope.Model(&models.RefreshToken{}).Where("revoked_at IS NULL").Update("revoked_at", now).Error
+}
+
+func truncate(value string, size int) string {
+	if len(value) > size {
+		return value[:size]
+	}
+	return value
+}
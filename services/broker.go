@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Tipos de mensajes en tiempo real
+const (
+	MessageEventStatus        = "event.status"        // Cambio de estado o marcador en vivo de un evento
+	MessageEventSettled       = "event.settled"       // Evento liquidado
+	MessageLeaderboardUpdated = "leaderboard.updated" // Cambió la clasificación de un torneo
+	MessageSessionStatus      = "session.status"      // Sesión abierta, cerrada o liquidada
+	MessageWalletBalance      = "wallet.balance"      // Cambió el saldo de la billetera (canal privado del usuario)
+	MessageNotification       = "notification"        // Nueva notificación en la bandeja (canal privado del usuario)
+)
+
+// BrokerMessage es un mensaje publicado en un canal. Data va serializado para que un broker
+// externo pueda transportarlo tal cual.
+type BrokerMessage struct {
+	Channel string          `json:"channel"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+	SentAt  time.Time       `json:"sent_at"`
+}
+
+// Broker distribuye mensajes entre publicadores y suscriptores. La implementación por defecto
+// vive en memoria; con varias réplicas se reemplaza por un broker externo (Redis, NATS, etc.)
+// con SetBroker.
+type Broker interface {
+	Publish(ctx context.Context, msg BrokerMessage) error
+	// Subscribe entrega los mensajes de los canales hasta que ctx se cancela; entonces cierra el canal
+	Subscribe(ctx context.Context, channels ...string) (<-chan BrokerMessage, error)
+}
+
+// Canales por torneo, evento y usuario
+func TournamentChannel(tournamentID uint) string { return fmt.Sprintf("tournament:%d", tournamentID) }
+func EventChannel(eventID uint) string           { return fmt.Sprintf("event:%d", eventID) }
+func UserChannel(userID uint) string             { return fmt.Sprintf("user:%d", userID) }
+
+// subscriberBuffer es cuántos mensajes puede acumular un cliente lento antes de perderlos
+const subscriberBuffer = 64
+
+// MemoryBroker es un broker en memoria para una sola instancia del servidor
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	channels map[string]map[chan BrokerMessage]struct{}
+}
+
+// NewMemoryBroker crea un broker en memoria vacío
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{channels: make(map[string]map[chan BrokerMessage]struct{})}
+}
+
+// Publish entrega el mensaje a los suscriptores del canal sin bloquear: si el buffer de un
+// suscriptor está lleno, ese mensaje se descarta para él.
+func (b *MemoryBroker) Publish(ctx context.Context, msg BrokerMessage) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.channels[msg.Channel] {
+		select {
+		case sub <- msg:
+		default:
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, channels ...string) (<-chan BrokerMessage, error) {
+	sub := make(chan BrokerMessage, subscriberBuffer)
+
+	b.mu.Lock()
+	for _, channel := range channels {
+		if b.channels[channel] == nil {
+			b.channels[channel] = make(map[chan BrokerMessage]struct{})
+		}
+		b.channels[channel][sub] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		for _, channel := range channels {
+			delete(b.channels[channel], sub)
+			if len(b.channels[channel]) == 0 {
+				delete(b.channels, channel)
+			}
+		}
+		b.mu.Unlock()
+		close(sub)
+	}()
+
+	return sub, nil
+}
+
+var (
+	brokerMu      sync.RWMutex
+	currentBroker Broker = NewMemoryBroker()
+)
+
+// SetBroker reemplaza el broker global (ej: por uno externo al arrancar)
+func SetBroker(b Broker) {
+	brokerMu.Lock()
+	defer brokerMu.Unlock()
+	currentBroker = b
+}
+
+// GetBroker devuelve el broker global
+func GetBroker() Broker {
+	brokerMu.RLock()
+	defer brokerMu.RUnlock()
+	return currentBroker
+}
+
+// Publish serializa data y la publica en el canal. Los errores solo se registran: el tiempo real
+// es un complemento y nunca debe hacer fallar la operación que lo origina.
+func Publish(channel, msgType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("⚠️  Tiempo real: no se pudo serializar %s: %v", msgType, err)
+		return
+	}
+
+	msg := BrokerMessage{Channel: channel, Type: msgType, Data: payload, SentAt: time.Now()}
+	if err := GetBroker().Publish(context.Background(), msg); err != nil {
+		log.Printf("⚠️  Tiempo real: no se pudo publicar %s en %s: %v", msgType, channel, err)
+	}
+}
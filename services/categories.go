@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryNotFound        = errors.New("categoría no encontrada")
+	ErrCategoryInactive        = errors.New("la categoría no está activa")
+	ErrSelectionTypeNotAllowed = errors.New("tipo de selección no permitido por la categoría")
+)
+
+// ResolveCategory busca la categoría por ID o, si no se indica, por nombre (sin distinguir mayúsculas).
+// Precarga los tipos de selección permitidos.
+func ResolveCategory(db *gorm.DB, categoryID *uint, name string) (*models.Category, error) {
+	var category models.Category
+	query := db.Preload("SelectionTypes")
+
+	var err error
+	if categoryID != nil {
+		err = query.First(&category, *categoryID).Error
+	} else {
+		err = query.Where("LOWER(name) = LOWER(?)", strings.TrimSpace(name)).First(&category).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// TournamentCategory devuelve la categoría del torneo (por CategoryID, o por nombre en torneos sin backfill)
+func TournamentCategory(db *gorm.DB, tournament *models.Tournament) (*models.Category, error) {
+	return ResolveCategory(db, tournament.CategoryID, tournament.Category)
+}
+
+// ApplyCategoryDefaults completa los valores no indicados en el torneo con los de la categoría
+func ApplyCategoryDefaults(category *models.Category, settings *models.TournamentSettings) {
+	if settings.SelectionsPerSession == 0 {
+		settings.SelectionsPerSession = category.Settings.DefaultSelectionsPerSession
+	}
+	if len(settings.PrizeDistribution) == 0 && len(category.Settings.DefaultPrizeDistribution) > 0 {
+		settings.PrizeDistribution = append([]float64(nil), category.Settings.DefaultPrizeDistribution...)
+	}
+
+	if len(settings.RequiredSelectionTypes) == 0 {
+		for _, t := range category.SelectionTypes {
+			if t.IsRequired {
+				settings.RequiredSelectionTypes = append(settings.RequiredSelectionTypes, t.SelectionType)
+			}
+		}
+	}
+
+	for _, t := range category.SelectionTypes {
+		if t.DefaultPoints <= 0 {
+			continue
+		}
+		if settings.PointsBySelectionType == nil {
+			settings.PointsBySelectionType = make(map[string]int)
+		}
+		if _, ok := settings.PointsBySelectionType[t.SelectionType]; !ok {
+			settings.PointsBySelectionType[t.SelectionType] = t.DefaultPoints
+		}
+	}
+}
+
+// ValidateTournamentSettings verifica que los tipos de selección del torneo estén permitidos por la categoría
+func ValidateTournamentSettings(category *models.Category, settings models.TournamentSettings) error {
+	for _, t := range settings.RequiredSelectionTypes {
+		if !category.AllowsSelectionType(t) {
+			return fmt.Errorf("%w: %s en %s", ErrSelectionTypeNotAllowed, t, category.Name)
+		}
+	}
+	for t := range settings.PointsBySelectionType {
+		if !category.AllowsSelectionType(t) {
+			return fmt.Errorf("%w: %s en %s", ErrSelectionTypeNotAllowed, t, category.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateSelectionTypeForEvent verifica que el tipo de selección esté permitido en las
+// categorías de todos los torneos que incluyen el evento.
+func ValidateSelectionTypeForEvent(db *gorm.DB, eventID uint, selectionType string) error {
+	var tournaments []models.Tournament
+	if err := db.Where("id IN (?)", db.Model(&models.TournamentEvent{}).Select("tournament_id").Where("event_id = ?", eventID)).
+		Find(&tournaments).Error; err != nil {
+		return err
+	}
+
+	checked := make(map[uint]bool)
+	for i := range tournaments {
+		category, err := TournamentCategory(db, &tournaments[i])
+		if errors.Is(err, ErrCategoryNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if checked[category.ID] {
+			continue
+		}
+		checked[category.ID] = true
+
+		if !category.AllowsSelectionType(selectionType) {
+			return fmt.Errorf("%w: %s en %s", ErrSelectionTypeNotAllowed, selectionType, category.Name)
+		}
+	}
+	return nil
+}
+
+// BackfillTournamentCategories enlaza los torneos sin CategoryID con la categoría de su nombre,
+// creando las categorías que falten.
+func BackfillTournamentCategories(db *gorm.DB) error {
+	var names []string
+	if err := db.Model(&models.Tournament{}).Where("category_id IS NULL AND category <> ''").
+		Distinct().Pluck("category", &names).Error; err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		category, err := ResolveCategory(db, nil, name)
+		if errors.Is(err, ErrCategoryNotFound) {
+			category = &models.Category{Name: name, Slug: slug.Make(name), IsActive: true}
+			err = db.Create(category).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := db.Model(&models.Tournament{}).
+			Where("category_id IS NULL AND LOWER(category) = LOWER(?)", name).
+			Update("category_id", category.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// CompetitorChanges resume el resultado de sincronizar los competidores de un evento
+type CompetitorChanges struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Removed   int
+
+	// Nombres de los competidores que no se enviaron pero se conservan porque una selección
+	// (y con ella los picks) los referencia
+	Retained []string
+}
+
+// UpsertEventCompetitors sincroniza los competidores del evento con la lista recibida. Los existentes
+// se actualizan en su lugar (por id, competitor_id o nombre) para no romper las selecciones que los
+// referencian, los cambios de cuota y runline quedan en el historial y los que no se envían se eliminan,
+// salvo que alguna selección los referencie: esos se conservan y se informan en Retained.
+func UpsertEventCompetitors(tx *gorm.DB, eventID uint, inputs []dtos.EventCompetitorInput, changedBy *uint) (*CompetitorChanges, error) {
+	var existing []models.EventCompetitor
+	if err := tx.Where("event_id = ?", eventID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	result := &CompetitorChanges{}
+	kept := make(map[uint]bool)
+	for _, comp := range inputs {
+		current := matchEventCompetitor(existing, comp)
+
+		if current == nil {
+			newComp := models.EventCompetitor{
+				EventID:        eventID,
+				CompetitorID:   comp.CompetitorID,
+				Name:           comp.Name,
+				AssignedNumber: comp.AssignedNumber,
+				Odds:           comp.Odds,
+				Runline:        comp.Runline,
+				SuperRunline:   comp.SuperRunline,
+				IsFavorite:     comp.IsFavorite,
+			}
+			if err := tx.Create(&newComp).Error; err != nil {
+				return nil, err
+			}
+			kept[newComp.ID] = true
+			result.Created++
+			continue
+		}
+		kept[current.ID] = true
+
+		if sameEventCompetitor(current, comp) {
+			result.Unchanged++
+			continue
+		}
+
+		changes := []LineChange{
+			{EntityType: models.LineEntityCompetitor, EntityID: current.ID, Field: models.LineFieldOdds, OldValue: float64(current.Odds), NewValue: float64(comp.Odds)},
+			{EntityType: models.LineEntityCompetitor, EntityID: current.ID, Field: models.LineFieldRunline, OldValue: current.Runline, NewValue: comp.Runline},
+			{EntityType: models.LineEntityCompetitor, EntityID: current.ID, Field: models.LineFieldSuperRunline, OldValue: current.SuperRunline, NewValue: comp.SuperRunline},
+		}
+
+		if err := tx.Model(current).Updates(map[string]interface{}{
+			"competitor_id":   comp.CompetitorID,
+			"name":            comp.Name,
+			"assigned_number": comp.AssignedNumber,
+			"odds":            comp.Odds,
+			"runline":         comp.Runline,
+			"super_runline":   comp.SuperRunline,
+			"is_favorite":     comp.IsFavorite,
+		}).Error; err != nil {
+			return nil, err
+		}
+
+		if err := RecordLineChanges(tx, eventID, changedBy, changes...); err != nil {
+			return nil, err
+		}
+		result.Updated++
+	}
+
+	// Eliminar los competidores que ya no forman parte del evento y que ninguna selección referencia
+	var referenced []uint
+	if err := tx.Model(&models.PickableSelection{}).
+		Where("event_id = ? AND competitor_id IS NOT NULL", eventID).
+		Distinct().Pluck("competitor_id", &referenced).Error; err != nil {
+		return nil, err
+	}
+	inUse := make(map[uint]bool, len(referenced))
+	for _, id := range referenced {
+		inUse[id] = true
+	}
+
+	for _, comp := range existing {
+		if kept[comp.ID] {
+			continue
+		}
+		if inUse[comp.ID] {
+			result.Retained = append(result.Retained, comp.Name)
+			continue
+		}
+		if err := tx.Delete(&comp).Error; err != nil {
+			return nil, err
+		}
+		result.Removed++
+	}
+
+	return result, nil
+}
+
+// matchEventCompetitor busca el competidor existente que corresponde a la entrada:
+// primero por ID, luego por competidor global y por último por nombre
+func matchEventCompetitor(existing []models.EventCompetitor, input dtos.EventCompetitorInput) *models.EventCompetitor {
+	for i := range existing {
+		if input.ID != 0 && existing[i].ID == input.ID {
+			return &existing[i]
+		}
+	}
+	if input.ID != 0 {
+		return nil
+	}
+	for i := range existing {
+		if input.CompetitorID != nil && existing[i].CompetitorID != nil && *existing[i].CompetitorID == *input.CompetitorID {
+			return &existing[i]
+		}
+	}
+	for i := range existing {
+		if existing[i].Name == input.Name {
+			return &existing[i]
+		}
+	}
+	return nil
+}
+
+func sameEventCompetitor(current *models.EventCompetitor, input dtos.EventCompetitorInput) bool {
+	sameCatalog := (current.CompetitorID == nil && input.CompetitorID == nil) ||
+		(current.CompetitorID != nil && input.CompetitorID != nil && *current.CompetitorID == *input.CompetitorID)
+	return sameCatalog && current.Name == input.Name && current.AssignedNumber == input.AssignedNumber &&
+		current.Odds == input.Odds && current.Runline == input.Runline &&
+		current.SuperRunline == input.SuperRunline && current.IsFavorite == input.IsFavorite
+}
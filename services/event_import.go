@@ -0,0 +1,544 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+)
+
+// Columnas reconocidas en el CSV de importación (una fila por competidor)
+var eventImportColumns = []string{"event", "start_time", "venue", "line", "order", "competitor", "number", "odds", "runline", "super_runline", "favorite"}
+
+// ParseEventImportCSV convierte un CSV con cabecera en eventos agrupados por nombre. Los datos del
+// evento se toman de su primera fila; cada fila aporta un competidor. Las filas inválidas se reportan
+// y no detienen el análisis.
+func ParseEventImportCSV(r io.Reader) ([]dtos.EventImportRow, []dtos.ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("no se pudo leer la cabecera del CSV: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"event", "start_time"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("falta la columna %q (columnas: %s)", required, strings.Join(eventImportColumns, ", "))
+		}
+	}
+
+	var (
+		events  []dtos.EventImportRow
+		rowErrs []dtos.ImportRowError
+		byName  = make(map[string]int)
+	)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rowErrs = append(rowErrs, dtos.ImportRowError{Row: line, Message: err.Error()})
+			continue
+		}
+
+		get := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		rowErr := func(field, message string) {
+			rowErrs = append(rowErrs, dtos.ImportRowError{Row: line, Event: get("event"), Field: field, Message: message})
+		}
+
+		name := get("event")
+		if name == "" {
+			rowErr("event", "el nombre del evento es obligatorio")
+			continue
+		}
+
+		index, seen := byName[name]
+		if !seen {
+			event := dtos.EventImportRow{Row: line, Name: name, Venue: get("venue"), StartTime: get("start_time")}
+			var ok bool
+			if event.Line, ok = parseFloatColumn(get("line")); !ok {
+				rowErr("line", "línea inválida")
+				continue
+			}
+			if event.Order, ok = parseIntColumn(get("order")); !ok {
+				rowErr("order", "orden inválido")
+				continue
+			}
+			events = append(events, event)
+			index = len(events) - 1
+			byName[name] = index
+		} else if startTime := get("start_time"); startTime != "" && startTime != events[index].StartTime {
+			rowErr("start_time", "la hora no coincide con la primera fila del evento")
+			continue
+		}
+
+		if get("competitor") == "" && get("number") == "" {
+			continue
+		}
+		competitor := dtos.CompetitorImportRow{Row: line, Name: get("competitor")}
+		var ok bool
+		if competitor.AssignedNumber, ok = parseIntColumn(get("number")); !ok {
+			rowErr("number", "número inválido")
+			continue
+		}
+		if competitor.Odds, ok = parseIntColumn(get("odds")); !ok {
+			rowErr("odds", "cuota inválida")
+			continue
+		}
+		if competitor.Runline, ok = parseFloatColumn(get("runline")); !ok {
+			rowErr("runline", "runline inválido")
+			continue
+		}
+		if competitor.SuperRunline, ok = parseFloatColumn(get("super_runline")); !ok {
+			rowErr("super_runline", "super runline inválido")
+			continue
+		}
+		if favorite := strings.ToLower(get("favorite")); favorite != "" {
+			competitor.IsFavorite = favorite == "true" || favorite == "1" || favorite == "si" || favorite == "sí" || favorite == "x"
+		}
+		events[index].Competitors = append(events[index].Competitors, competitor)
+	}
+
+	return events, rowErrs, nil
+}
+
+// ImportEvents crea o actualiza los eventos del fixture dentro de tx. Los eventos con errores de fila
+// se omiten y se reportan; el llamador decide si confirma la transacción (nunca en dry-run ni con
+// errores). Solo devuelve error ante fallos de base de datos.
+func ImportEvents(tx *gorm.DB, input *dtos.EventImportRequest, changedBy *uint, dryRun bool) (*dtos.EventImportReport, error) {
+	report := &dtos.EventImportReport{
+		DryRun:   dryRun,
+		Events:   []dtos.EventImportResult{},
+		Errors:   append([]dtos.ImportRowError{}, input.ParseErrors...),
+		Warnings: []dtos.ImportRowError{},
+	}
+
+	var (
+		tournament *models.Tournament
+		session    *models.Session
+	)
+	if input.TournamentID != nil {
+		tournament = &models.Tournament{}
+		if err := tx.First(tournament, *input.TournamentID).Error; err != nil {
+			report.Errors = append(report.Errors, dtos.ImportRowError{Field: "tournament_id", Message: "torneo no encontrado"})
+			return report, nil
+		}
+	}
+	if input.SessionID != nil {
+		session = &models.Session{}
+		if tournament == nil {
+			report.Errors = append(report.Errors, dtos.ImportRowError{Field: "session_id", Message: "la sesión requiere tournament_id"})
+			return report, nil
+		}
+		// Las ligas juegan la cartilla de su torneo base: la sesión debe ser de esa cartilla
+		if err := tx.First(session, *input.SessionID).Error; err != nil || session.TournamentID != tournament.SlateTournamentID() {
+			report.Errors = append(report.Errors, dtos.ImportRowError{Field: "session_id", Message: "la sesión no pertenece al torneo"})
+			return report, nil
+		}
+		if session.Status == models.SessionStatusClosed || session.Status == models.SessionStatusSettled {
+			report.Errors = append(report.Errors, dtos.ImportRowError{Field: "session_id", Message: "la sesión ya está cerrada"})
+			return report, nil
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, row := range input.Events {
+		startTime, rowErrs := validateEventImportRow(row)
+		if key := slug.Make(row.Name); row.Name != "" && seen[key] {
+			rowErrs = append(rowErrs, dtos.ImportRowError{Row: row.Row, Event: row.Name, Field: "name", Message: "evento repetido en el fixture"})
+		} else {
+			seen[key] = true
+		}
+		if len(rowErrs) > 0 {
+			report.Errors = append(report.Errors, rowErrs...)
+			continue
+		}
+
+		result, err := importEvent(tx, report, input, tournament, session, row, startTime, changedBy)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			continue
+		}
+		if dryRun && result.Action == "created" {
+			result.EventID = 0
+		}
+		report.Events = append(report.Events, *result)
+	}
+
+	return report, nil
+}
+
+// validateEventImportRow revisa los datos de un evento antes de tocar la base de datos
+func validateEventImportRow(row dtos.EventImportRow) (time.Time, []dtos.ImportRowError) {
+	var errs []dtos.ImportRowError
+	fail := func(line int, field, message string) {
+		if line == 0 {
+			line = row.Row
+		}
+		errs = append(errs, dtos.ImportRowError{Row: line, Event: row.Name, Field: field, Message: message})
+	}
+
+	if strings.TrimSpace(row.Name) == "" {
+		fail(0, "name", "el nombre del evento es obligatorio")
+	}
+	startTime, err := time.Parse(time.RFC3339, row.StartTime)
+	if err != nil {
+		fail(0, "start_time", "formato de fecha inválido (RFC3339)")
+	}
+	if row.Line < 0 {
+		fail(0, "line", "la línea no puede ser negativa")
+	}
+
+	names := make(map[string]bool)
+	numbers := make(map[int]bool)
+	for _, comp := range row.Competitors {
+		if comp.Name == "" && comp.AssignedNumber == 0 {
+			fail(comp.Row, "competitor", "el competidor necesita nombre o número")
+			continue
+		}
+		if comp.Name != "" {
+			if names[strings.ToLower(comp.Name)] {
+				fail(comp.Row, "competitor", "competidor repetido: "+comp.Name)
+			}
+			names[strings.ToLower(comp.Name)] = true
+		}
+		if comp.AssignedNumber != 0 {
+			if numbers[comp.AssignedNumber] {
+				fail(comp.Row, "number", fmt.Sprintf("número repetido: %d", comp.AssignedNumber))
+			}
+			numbers[comp.AssignedNumber] = true
+		}
+	}
+
+	for _, sel := range row.Selections {
+		if !models.IsValidSelectionType(sel.SelectionType) {
+			fail(0, "selection_type", "tipo de selección desconocido: "+sel.SelectionType)
+		}
+		if sel.Competitor != "" && !names[strings.ToLower(sel.Competitor)] {
+			fail(0, "competitor", "la selección referencia un competidor que no está en el evento: "+sel.Competitor)
+		}
+		if sel.PointsForWin < 0 || sel.PointsForPush < 0 {
+			fail(0, "points_for_win", "los puntos no pueden ser negativos")
+		}
+	}
+
+	return startTime, errs
+}
+
+// importEvent aplica un evento validado. Devuelve nil si el evento no se puede tocar (se reporta como error).
+func importEvent(tx *gorm.DB, report *dtos.EventImportReport, input *dtos.EventImportRequest, tournament *models.Tournament, session *models.Session, row dtos.EventImportRow, startTime time.Time, changedBy *uint) (*dtos.EventImportResult, error) {
+	result := &dtos.EventImportResult{Row: row.Row, Name: row.Name, Action: "unchanged"}
+	addError := func(field, message string) {
+		report.Errors = append(report.Errors, dtos.ImportRowError{Row: row.Row, Event: row.Name, Field: field, Message: message})
+	}
+
+	// 1. Evento: se reconoce por slug (el nombre es único)
+	var event models.Event
+	err := tx.Where("slug = ?", slug.Make(row.Name)).First(&event).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		event = models.Event{Name: row.Name, Venue: row.Venue, Line: row.Line, StartTime: startTime, Order: row.Order, Status: "scheduled"}
+		if err := tx.Create(&event).Error; err != nil {
+			return nil, err
+		}
+		result.Action = "created"
+	case err != nil:
+		return nil, err
+	case event.Status == "completed" || event.Status == "cancelled":
+		addError("name", "el evento ya finalizó y no se puede modificar")
+		return nil, nil
+	default:
+		updates := map[string]interface{}{}
+		if event.Venue != row.Venue {
+			updates["venue"] = row.Venue
+		}
+		if event.Order != row.Order {
+			updates["order"] = row.Order
+		}
+
+		// La línea y la hora solo cambian mientras el evento no ha comenzado
+		moved := event.Line != row.Line || !event.StartTime.Equal(startTime)
+		if moved && event.Status != "scheduled" {
+			report.Warnings = append(report.Warnings, dtos.ImportRowError{Row: row.Row, Event: row.Name, Field: "line",
+				Message: "el evento ya está en juego; se conservan su línea y su hora"})
+			moved = false
+		}
+		if moved {
+			updates["line"] = row.Line
+			updates["start_time"] = startTime
+		}
+
+		if len(updates) > 0 {
+			previousLine := event.Line
+			if err := tx.Model(&event).Updates(updates).Error; err != nil {
+				return nil, err
+			}
+			if moved {
+				if err := RecordLineChanges(tx, event.ID, changedBy, LineChange{
+					EntityType: models.LineEntityEvent, EntityID: event.ID, Field: models.LineFieldLine, OldValue: previousLine, NewValue: row.Line,
+				}); err != nil {
+					return nil, err
+				}
+			}
+			result.Action = "updated"
+		}
+	}
+	result.EventID = event.ID
+
+	// 2. Competidores, emparejados con el catálogo global
+	if len(row.Competitors) > 0 {
+		inputs := make([]dtos.EventCompetitorInput, 0, len(row.Competitors))
+		for _, comp := range row.Competitors {
+			competitorInput := dtos.EventCompetitorInput{
+				Name:           comp.Name,
+				AssignedNumber: comp.AssignedNumber,
+				Odds:           comp.Odds,
+				Runline:        comp.Runline,
+				SuperRunline:   comp.SuperRunline,
+				IsFavorite:     comp.IsFavorite,
+			}
+			if catalog := matchCatalogCompetitor(tx, input.CompetitorCategory, comp); catalog != nil {
+				competitorInput.CompetitorID = &catalog.ID
+				if competitorInput.Name == "" {
+					competitorInput.Name = catalog.Name
+				}
+				if competitorInput.AssignedNumber == 0 {
+					competitorInput.AssignedNumber = catalog.AssignedNumber
+				}
+			} else if comp.Name == "" {
+				report.Errors = append(report.Errors, dtos.ImportRowError{Row: comp.Row, Event: row.Name, Field: "number",
+					Message: fmt.Sprintf("no hay competidor con el número %d en el catálogo", comp.AssignedNumber)})
+				continue
+			} else {
+				report.Warnings = append(report.Warnings, dtos.ImportRowError{Row: comp.Row, Event: row.Name, Field: "competitor",
+					Message: comp.Name + " no está en el catálogo; se agrega solo al evento"})
+			}
+			inputs = append(inputs, competitorInput)
+		}
+
+		changes, err := UpsertEventCompetitors(tx, event.ID, inputs, changedBy)
+		if err != nil {
+			return nil, err
+		}
+		result.CompetitorsCreated = changes.Created
+		result.CompetitorsUpdated = changes.Updated
+		result.CompetitorsRemoved = changes.Removed
+		for _, name := range changes.Retained {
+			report.Warnings = append(report.Warnings, dtos.ImportRowError{Row: row.Row, Event: row.Name, Field: "competitor",
+				Message: name + " ya no está en el fixture pero tiene selecciones; se conserva en el evento"})
+		}
+		if result.Action == "unchanged" && (changes.Created+changes.Updated+changes.Removed) > 0 {
+			result.Action = "updated"
+		}
+	}
+
+	// 3. Asignación a la cartilla del torneo (la del torneo base si es una liga) y a la sesión.
+	// Sin session_id se conserva la sesión que ya tuviera el evento.
+	if tournament != nil {
+		slateID := tournament.SlateTournamentID()
+		var tournamentEvent models.TournamentEvent
+		err := tx.Where("tournament_id = ? AND event_id = ?", slateID, event.ID).First(&tournamentEvent).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		tournamentEvent.TournamentID = slateID
+		tournamentEvent.EventID = event.ID
+		if input.SessionID != nil {
+			tournamentEvent.SessionID = input.SessionID
+		}
+		tournamentEvent.Order = row.Order
+		if err := tx.Save(&tournamentEvent).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Selecciones explícitas y/o generadas
+	if len(row.Selections) > 0 || input.GenerateSelections {
+		if err := tx.Where("event_id = ?", event.ID).Find(&event.Competitors).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := importSelections(tx, report, result, tournament, &event, row, changedBy); err != nil {
+		return nil, err
+	}
+
+	if input.GenerateSelections {
+		opts := GenerateOptions{DefaultPoints: 1, ChangedBy: changedBy}
+		if tournament != nil {
+			opts.PointsByType = tournament.Settings.PointsBySelectionType
+			if category, err := TournamentCategory(tx, tournament); err == nil {
+				for _, t := range category.SelectionTypes {
+					opts.SelectionTypes = append(opts.SelectionTypes, t.SelectionType)
+				}
+			}
+		}
+		if session != nil {
+			opts.SuperLine = session.SuperLine
+		}
+
+		generated, err := GenerateStandardSelections(tx, &event, opts)
+		if err != nil {
+			return nil, err
+		}
+		result.SelectionsCreated += len(generated.Created)
+		result.SelectionsUpdated += len(generated.Updated)
+	}
+
+	if result.Action == "unchanged" && (result.SelectionsCreated+result.SelectionsUpdated) > 0 {
+		result.Action = "updated"
+	}
+	return result, nil
+}
+
+// importSelections crea o actualiza las selecciones explícitas del evento (por tipo y competidor)
+func importSelections(tx *gorm.DB, report *dtos.EventImportReport, result *dtos.EventImportResult, tournament *models.Tournament, event *models.Event, row dtos.EventImportRow, changedBy *uint) error {
+	if len(row.Selections) == 0 {
+		return nil
+	}
+
+	var existing []models.PickableSelection
+	if err := tx.Where("event_id = ?", event.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+
+	for _, sel := range row.Selections {
+		if err := ValidateSelectionTypeForEvent(tx, event.ID, sel.SelectionType); err != nil {
+			if errors.Is(err, ErrSelectionTypeNotAllowed) {
+				report.Errors = append(report.Errors, dtos.ImportRowError{Row: row.Row, Event: row.Name, Field: "selection_type", Message: err.Error()})
+				continue
+			}
+			return err
+		}
+
+		candidate := models.PickableSelection{
+			EventID:       event.ID,
+			SelectionType: sel.SelectionType,
+			Description:   sel.Description,
+			Line:          sel.Line,
+			Odds:          sel.Odds,
+			PointsForWin:  sel.PointsForWin,
+			PointsForPush: sel.PointsForPush,
+			Status:        "pending",
+		}
+		for i := range event.Competitors {
+			if sel.Competitor != "" && strings.EqualFold(event.Competitors[i].Name, sel.Competitor) {
+				id := event.Competitors[i].ID
+				candidate.CompetitorID = &id
+				if candidate.Odds == 0 {
+					candidate.Odds = event.Competitors[i].Odds
+				}
+			}
+		}
+		if sel.Competitor != "" && candidate.CompetitorID == nil {
+			report.Errors = append(report.Errors, dtos.ImportRowError{Row: row.Row, Event: row.Name, Field: "competitor",
+				Message: sel.Competitor + " no es competidor del evento"})
+			continue
+		}
+		if candidate.Description == "" {
+			candidate.Description = strings.TrimSpace(sel.SelectionType + " " + sel.Competitor)
+		}
+		if candidate.PointsForWin == 0 {
+			candidate.PointsForWin = 1
+			if tournament != nil {
+				if points, ok := tournament.Settings.PointsBySelectionType[sel.SelectionType]; ok {
+					candidate.PointsForWin = points
+				}
+			}
+		}
+
+		current := findGenerated(existing, candidate)
+		if current == nil {
+			if err := tx.Create(&candidate).Error; err != nil {
+				return err
+			}
+			existing = append(existing, candidate)
+			result.SelectionsCreated++
+			continue
+		}
+		if current.Status != "pending" || (sameGenerated(current, &candidate) &&
+			current.PointsForWin == candidate.PointsForWin && current.PointsForPush == candidate.PointsForPush) {
+			continue
+		}
+
+		previous := *current
+		if err := tx.Model(current).Updates(map[string]interface{}{
+			"description":     candidate.Description,
+			"line":            candidate.Line,
+			"odds":            candidate.Odds,
+			"points_for_win":  candidate.PointsForWin,
+			"points_for_push": candidate.PointsForPush,
+		}).Error; err != nil {
+			return err
+		}
+		if err := RecordLineChanges(tx, event.ID, changedBy,
+			LineChange{EntityType: models.LineEntitySelection, EntityID: current.ID, Field: models.LineFieldLine, OldValue: previous.Line, NewValue: candidate.Line},
+			LineChange{EntityType: models.LineEntitySelection, EntityID: current.ID, Field: models.LineFieldOdds, OldValue: float64(previous.Odds), NewValue: float64(candidate.Odds)},
+		); err != nil {
+			return err
+		}
+		result.SelectionsUpdated++
+	}
+	return nil
+}
+
+// matchCatalogCompetitor busca el competidor en el catálogo global por nombre o, si no hay nombre
+// que coincida, por número dentro de la categoría
+func matchCatalogCompetitor(tx *gorm.DB, category string, row dtos.CompetitorImportRow) *models.Competitor {
+	scope := func() *gorm.DB {
+		query := tx.Model(&models.Competitor{})
+		if category != "" {
+			query = query.Where("LOWER(category) = LOWER(?)", category)
+		}
+		return query
+	}
+
+	var competitor models.Competitor
+	if row.Name != "" {
+		if err := scope().Where("LOWER(name) = LOWER(?)", row.Name).First(&competitor).Error; err == nil {
+			return &competitor
+		}
+	}
+	if row.AssignedNumber != 0 && category != "" {
+		if err := scope().Where("assigned_number = ?", row.AssignedNumber).First(&competitor).Error; err == nil {
+			return &competitor
+		}
+	}
+	return nil
+}
+
+func parseIntColumn(value string) (int, bool) {
+	if value == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(value, "+"))
+	return n, err == nil
+}
+
+func parseFloatColumn(value string) (float64, bool) {
+	if value == "" {
+		return 0, true
+	}
+	f, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	return f, err == nil
+}
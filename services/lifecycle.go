@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidTransition se devuelve cuando el cambio de estado no está permitido
+var ErrInvalidTransition = errors.New("transición de estado no permitida")
+
+// ErrTransitionConflict se devuelve cuando otro proceso cambió el estado antes de aplicar la transición
+var ErrTransitionConflict = errors.New("el estado cambió mientras se procesaba; recargue e intente de nuevo")
+
+// ErrTransitionBlocked se devuelve cuando una condición de negocio impide llegar al estado destino
+var ErrTransitionBlocked = errors.New("la transición no cumple sus condiciones")
+
+// TransitionTournament valida y aplica un cambio de estado del torneo dentro de tx,
+// registrando el historial con el actor (nil si lo ejecuta el sistema).
+func TransitionTournament(tx *gorm.DB, tournament *models.Tournament, to string, actorID *uint, note string) error {
+	from := tournament.Status
+	if !models.CanTournamentTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+
+	if err := checkTournamentGuards(tx, tournament, to); err != nil {
+		return err
+	}
+
+	// Compare-and-set: solo gana quien todavía ve el estado de origen, así dos
+	// finalizaciones simultáneas no pagan premios dos veces
+	result := tx.Model(tournament).
+		Where("status = ?", from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tournament.Status = from
+		return ErrTransitionConflict
+	}
+	tournament.Status = to
+
+	return recordTransition(tx, models.EntityTypeTournament, tournament.ID, from, to, actorID, note)
+}
+
+// TransitionSession valida y aplica un cambio de estado de la sesión dentro de tx,
+// registrando el historial con el actor (nil si lo ejecuta el sistema).
+func TransitionSession(tx *gorm.DB, session *models.Session, to string, actorID *uint, note string) error {
+	from := session.Status
+	if !models.CanSessionTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+
+	if err := checkSessionGuards(tx, session, to); err != nil {
+		return err
+	}
+
+	result := tx.Model(session).
+		Where("status = ?", from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		session.Status = from
+		return ErrTransitionConflict
+	}
+	session.Status = to
+
+	return recordTransition(tx, models.EntityTypeSession, session.ID, from, to, actorID, note)
+}
+
+// checkTournamentGuards verifica las condiciones de negocio del estado destino
+func checkTournamentGuards(tx *gorm.DB, tournament *models.Tournament, to string) error {
+	switch to {
+	case models.TournamentStatusClosed:
+		// No puede cerrarse mientras haya sesiones por jugar
+		var pending int64
+		if err := tx.Model(&models.Session{}).
+			Where("tournament_id = ? AND status IN ?", tournament.SlateTournamentID(),
+				[]string{models.SessionStatusScheduled, models.SessionStatusOpen}).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return fmt.Errorf("%w: hay %d sesiones programadas o abiertas", ErrTransitionBlocked, pending)
+		}
+	case models.TournamentStatusFinished:
+		// Solo se finaliza (y se pagan premios) con todas las sesiones liquidadas
+		// Un error de lectura no puede dejar pasar el pago: se devuelve y la transacción se revierte
+		var unsettled int64
+		if err := tx.Model(&models.Session{}).
+			Where("tournament_id = ? AND status <> ?", tournament.SlateTournamentID(), models.SessionStatusSettled).
+			Count(&unsettled).Error; err != nil {
+			return err
+		}
+		if unsettled > 0 {
+			return fmt.Errorf("%w: hay %d sesiones sin liquidar", ErrTransitionBlocked, unsettled)
+		}
+	}
+	return nil
+}
+
+// checkSessionGuards verifica las condiciones de negocio del estado destino
+func checkSessionGuards(tx *gorm.DB, session *models.Session, to string) error {
+	switch to {
+	case models.SessionStatusOpen:
+		var tournament models.Tournament
+		if err := tx.First(&tournament, session.TournamentID).Error; err != nil {
+			return err
+		}
+		if tournament.Status != models.TournamentStatusOpen && tournament.Status != models.TournamentStatusRunning {
+			return fmt.Errorf("%w: el torneo está en estado %s", ErrTransitionBlocked, tournament.Status)
+		}
+	case models.SessionStatusSettled:
+		// Todos los eventos de la sesión deben estar completados o cancelados
+		var pending int64
+		if err := tx.Model(&models.TournamentEvent{}).
+			Joins("JOIN events ON events.id = tournament_events.event_id").
+			Where("tournament_events.session_id = ? AND events.status NOT IN ?", session.ID,
+				[]string{"completed", "cancelled"}).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return fmt.Errorf("%w: hay %d eventos sin liquidar en la sesión", ErrTransitionBlocked, pending)
+		}
+	}
+	return nil
+}
+
+func recordTransition(tx *gorm.DB, entityType string, entityID uint, from, to string, actorID *uint, note string) error {
+	return tx.Create(&models.StatusTransition{
+		EntityType: entityType,
+		EntityID:   entityID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		Note:       note,
+	}).Error
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// LineChange describe un valor versionado que pudo cambiar
+type LineChange struct {
+	EntityType string
+	EntityID   uint
+	Field      string
+	OldValue   float64
+	NewValue   float64
+}
+
+// RecordLineChanges guarda en el historial los cambios cuyo valor realmente se modificó
+func RecordLineChanges(tx *gorm.DB, eventID uint, changedBy *uint, changes ...LineChange) error {
+	for _, change := range changes {
+		if change.OldValue == change.NewValue {
+			continue
+		}
+		entry := models.LineHistory{
+			EventID:    eventID,
+			EntityType: change.EntityType,
+			EntityID:   change.EntityID,
+			Field:      change.Field,
+			OldValue:   change.OldValue,
+			NewValue:   change.NewValue,
+			ChangedBy:  changedBy,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SnapshotPick guarda en el pick la línea y la cuota vigentes de la selección al momento de hacerlo.
+// Si la selección no tiene cuota propia se usa la del competidor que representa, y si un runline
+// no tiene línea propia se guarda su RunlineHome, que es el hándicap con el que se liquida.
+func SnapshotPick(tx *gorm.DB, pick *models.UserPick, selection *models.PickableSelection) {
+	line := selection.Line
+	odds := selection.Odds
+
+	if line == 0 && models.IsRunlineSelectionType(selection.SelectionType) {
+		line = selection.RunlineHome
+	}
+
+	if odds == 0 && selection.CompetitorID != nil {
+		var competitor models.EventCompetitor
+		if err := tx.Select("odds").First(&competitor, *selection.CompetitorID).Error; err == nil {
+			odds = competitor.Odds
+		}
+	}
+
+	pick.LineAtPick = &line
+	pick.OddsAtPick = &odds
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// ErrEventNotInPlay indica que el evento ya terminó o fue cancelado y no acepta marcador en vivo
+var ErrEventNotInPlay = errors.New("el evento ya terminó o fue cancelado")
+
+// Estados provisionales de un pick mientras su evento está en vivo
+const (
+	ProvisionalWinning = "winning"
+	ProvisionalLosing  = "losing"
+	ProvisionalPush    = "push"
+)
+
+// ApplyLiveUpdate guarda el marcador parcial del evento y lo marca en vivo. No toca los
+// resultados finales, las selecciones ni los picks.
+func ApplyLiveUpdate(tx *gorm.DB, eventID uint, input dtos.LiveUpdateRequest, now time.Time) (*models.Event, error) {
+	var event models.Event
+	if err := tx.Preload("Competitors").First(&event, eventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+	if event.Status == "completed" || event.Status == "cancelled" {
+		return nil, ErrEventNotInPlay
+	}
+
+	competitors := make(map[uint]*models.EventCompetitor, len(event.Competitors))
+	for i := range event.Competitors {
+		competitors[event.Competitors[i].ID] = &event.Competitors[i]
+	}
+	seen := make(map[uint]bool, len(input.Competitors))
+	for _, update := range input.Competitors {
+		if competitors[update.CompetitorID] == nil {
+			return nil, fmt.Errorf("%w: el competidor %d no pertenece al evento", ErrInvalidResults, update.CompetitorID)
+		}
+		if seen[update.CompetitorID] {
+			return nil, fmt.Errorf("%w: el competidor %d está repetido", ErrInvalidResults, update.CompetitorID)
+		}
+		seen[update.CompetitorID] = true
+		if update.Score < 0 || update.Position < 0 {
+			return nil, fmt.Errorf("%w: marcador o posición negativos", ErrInvalidResults)
+		}
+	}
+
+	for _, update := range input.Competitors {
+		comp := competitors[update.CompetitorID]
+		changes := map[string]interface{}{"live_score": update.Score, "live_position": update.Position}
+		comp.LiveScore = update.Score
+		comp.LivePosition = update.Position
+		if update.ScoredFirst != nil {
+			changes["scored_first"] = *update.ScoredFirst
+			comp.ScoredFirst = *update.ScoredFirst
+		}
+		if update.ScoredFirstHalf != nil {
+			changes["scored_first_half"] = *update.ScoredFirstHalf
+			comp.ScoredFirstHalf = *update.ScoredFirstHalf
+		}
+		if update.ScoredSecondHalf != nil {
+			changes["scored_second_half"] = *update.ScoredSecondHalf
+			comp.ScoredSecondHalf = *update.ScoredSecondHalf
+		}
+		if update.ScoredFirstInning != nil {
+			changes["scored_first_inning"] = *update.ScoredFirstInning
+			comp.ScoredFirstInning = *update.ScoredFirstInning
+		}
+		if err := tx.Model(&models.EventCompetitor{}).Where("id = ?", comp.ID).Updates(changes).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Model(&event).Omit("Competitors").Updates(map[string]interface{}{
+		"status":          "live",
+		"period":          input.Period,
+		"live_updated_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	event.Status = "live"
+	event.Period = input.Period
+	event.LiveUpdatedAt = &now
+
+	return &event, nil
+}
+
+// liveCompetitorResults arma los resultados parciales del evento para evaluarlos como si fueran
+// finales. Si nadie trae posición, el único líder por marcador queda en la posición 1.
+// Requiere event.Competitors.
+func liveCompetitorResults(event *models.Event) (map[uint]dtos.CompetitorResult, int) {
+	results := make(map[uint]dtos.CompetitorResult, len(event.Competitors))
+	total := 0
+	hasPositions := false
+	for _, comp := range event.Competitors {
+		results[comp.ID] = dtos.CompetitorResult{CompetitorID: comp.ID, FinalScore: comp.LiveScore, Position: comp.LivePosition}
+		total += comp.LiveScore
+		if comp.LivePosition > 0 {
+			hasPositions = true
+		}
+	}
+
+	if !hasPositions && len(event.Competitors) > 1 {
+		var leader *models.EventCompetitor
+		tied := false
+		for i := range event.Competitors {
+			comp := &event.Competitors[i]
+			if leader == nil || comp.LiveScore > leader.LiveScore {
+				leader, tied = comp, false
+			} else if comp.LiveScore == leader.LiveScore {
+				tied = true
+			}
+		}
+		if !tied {
+			res := results[leader.ID]
+			res.Position = 1
+			results[leader.ID] = res
+		}
+	}
+	return results, total
+}
+
+// ProvisionalStatus evalúa un pick pendiente contra el marcador en vivo de su evento, con la
+// línea que tenía al hacerse. Devuelve "" si el evento no está en vivo. Requiere event.Competitors.
+func ProvisionalStatus(event *models.Event, selection *models.PickableSelection, lineAtPick *float64) string {
+	if event.Status != "live" {
+		return ""
+	}
+
+	line := selection.Line
+	if lineAtPick != nil {
+		line = *lineAtPick
+	}
+	results, total := liveCompetitorResults(event)
+
+	switch status, _ := gradeSelection(event, selection, line, total, results); status {
+	case "won":
+		return ProvisionalWinning
+	case "push":
+		return ProvisionalPush
+	default:
+		return ProvisionalLosing
+	}
+}
+
+// ProjectedStanding es la posición de un participante si los eventos en vivo terminaran ahora
+type ProjectedStanding struct {
+	Rank              int    `json:"rank"`
+	ParticipantID     uint   `json:"participant_id"`
+	UserID            uint   `json:"user_id"`
+	Username          string `json:"username"`
+	TotalPoints       int    `json:"total_points"`       // Puntos ya liquidados
+	ProvisionalPoints int    `json:"provisional_points"` // Puntos de picks que hoy van ganando o empatando
+	ProjectedPoints   int    `json:"projected_points"`
+	LivePicks         int    `json:"live_picks"`
+	PicksWinning      int    `json:"picks_winning"`
+}
+
+// ProjectedLeaderboard calcula la clasificación del torneo sumando a los puntos liquidados los
+// que darían los picks pendientes de eventos en vivo según el marcador actual. No guarda nada.
+func ProjectedLeaderboard(db *gorm.DB, tournamentID uint) ([]ProjectedStanding, error) {
+	var tournament models.Tournament
+	if err := db.First(&tournament, tournamentID).Error; err != nil {
+		return nil, err
+	}
+
+	var participants []models.TournamentParticipant
+	if err := db.Preload("User").Where("tournament_id = ?", tournamentID).Find(&participants).Error; err != nil {
+		return nil, err
+	}
+
+	standings := make([]ProjectedStanding, 0, len(participants))
+	index := make(map[uint]int, len(participants))
+	for _, p := range participants {
+		index[p.ID] = len(standings)
+		standings = append(standings, ProjectedStanding{
+			ParticipantID:   p.ID,
+			UserID:          p.UserID,
+			Username:        p.User.Username,
+			TotalPoints:     p.TotalPoints,
+			ProjectedPoints: p.TotalPoints,
+		})
+	}
+
+	var picks []models.UserPick
+	if err := db.Preload("Selection.Event.Competitors").
+		Joins("JOIN tournament_participants ON tournament_participants.id = user_picks.participant_id").
+		Joins("JOIN pickable_selections ON pickable_selections.id = user_picks.selection_id").
+		Joins("JOIN events ON events.id = pickable_selections.event_id").
+		Where("tournament_participants.tournament_id = ? AND user_picks.status = ? AND events.status = ?", tournamentID, "pending", "live").
+		Find(&picks).Error; err != nil {
+		return nil, err
+	}
+
+	for _, pick := range picks {
+		i, ok := index[pick.ParticipantID]
+		if !ok {
+			continue
+		}
+		standing := &standings[i]
+		standing.LivePicks++
+
+		points := 0
+		switch ProvisionalStatus(&pick.Selection.Event, &pick.Selection, pick.LineAtPick) {
+		case ProvisionalWinning:
+			odds := pick.Selection.Odds
+			if pick.OddsAtPick != nil {
+				odds = *pick.OddsAtPick
+			}
+			points = tournament.Settings.WinPointsAtOdds(&pick.Selection, odds)
+			standing.PicksWinning++
+		case ProvisionalPush:
+			points = pick.Selection.PointsForPush
+		}
+		standing.ProvisionalPoints += points
+		standing.ProjectedPoints += points
+	}
+
+	sort.SliceStable(standings, func(a, b int) bool {
+		if standings[a].ProjectedPoints != standings[b].ProjectedPoints {
+			return standings[a].ProjectedPoints > standings[b].ProjectedPoints
+		}
+		return standings[a].TotalPoints > standings[b].TotalPoints
+	})
+	for i := range standings {
+		// Empates en puntos proyectados comparten posición
+		if i > 0 && standings[i].ProjectedPoints == standings[i-1].ProjectedPoints {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+	return standings, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/cesarbmathec/bets-backend/models"
+)
+
+// Idiomas de los mensajes salientes
+const (
+	DefaultLocale = "es"
+	LocaleEnglish = "en"
+)
+
+// Plantillas de mensajes salientes
+const (
+	TemplateWithdrawalOTP    = "withdrawal_otp"
+	TemplateWithdrawalStatus = "withdrawal_status"
+)
+
+// messageTemplate es el asunto y cuerpo de un mensaje en un idioma. El SMS usa solo SMSBody.
+type messageTemplate struct {
+	Subject string
+	Body    string
+	SMSBody string
+}
+
+var messageTemplates = map[string]map[string]messageTemplate{
+	TemplateWithdrawalOTP: {
+		DefaultLocale: {
+			Subject: "Tu código para confirmar el retiro",
+			Body:    "Hola {{.Username}},\n\nTu código para confirmar el retiro de {{printf \"%.2f\" .Amount}} es {{.Code}}.\nVence en {{.ExpiresIn}} minutos. Si no solicitaste este retiro, cancélalo desde la app.",
+			SMSBody: "Tu código de retiro es {{.Code}}. Vence en {{.ExpiresIn}} min. No lo compartas.",
+		},
+		LocaleEnglish: {
+			Subject: "Your withdrawal confirmation code",
+			Body:    "Hi {{.Username}},\n\nYour code to confirm the withdrawal of {{printf \"%.2f\" .Amount}} is {{.Code}}.\nIt expires in {{.ExpiresIn}} minutes. If you did not request this withdrawal, cancel it in the app.",
+			SMSBody: "Your withdrawal code is {{.Code}}. It expires in {{.ExpiresIn}} min. Do not share it.",
+		},
+	},
+	TemplateWithdrawalStatus: {
+		DefaultLocale: {
+			Subject: "{{.Title}}",
+			Body:    "Hola {{.Username}},\n\n{{.Message}}",
+			SMSBody: "{{.Message}}",
+		},
+		LocaleEnglish: {
+			Subject: "Withdrawal update",
+			Body:    "Hi {{.Username}},\n\nYour withdrawal of {{printf \"%.2f\" .Amount}} is now {{.Status}}.",
+			SMSBody: "Your withdrawal of {{printf \"%.2f\" .Amount}} is now {{.Status}}.",
+		},
+	},
+}
+
+// RenderMessage arma el asunto y cuerpo de la plantilla para el canal e idioma.
+// Si no hay plantilla en el idioma pedido se usa español.
+func RenderMessage(name, locale, channel string, data interface{}) (subject, body string, err error) {
+	locales, ok := messageTemplates[name]
+	if !ok {
+		return "", "", fmt.Errorf("plantilla %q no existe", name)
+	}
+	tmpl, ok := locales[locale]
+	if !ok {
+		tmpl = locales[DefaultLocale]
+	}
+
+	source := tmpl.Body
+	if channel == models.ChannelSMS {
+		source = tmpl.SMSBody
+	}
+	if body, err = renderTemplate(name, source, data); err != nil {
+		return "", "", err
+	}
+	if channel == models.ChannelSMS {
+		return "", body, nil
+	}
+	subject, err = renderTemplate(name, tmpl.Subject, data)
+	return subject, body, err
+}
+
+func renderTemplate(name, source string, data interface{}) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := t.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Anticipación de los avisos programados por el scheduler
+const (
+	sessionOpeningNotice = time.Hour
+	pickDeadlineNotice   = time.Hour
+)
+
+// NotificationEnabled indica si el usuario acepta el tipo de notificación (activado por defecto)
+func NotificationEnabled(db *gorm.DB, userID uint, notificationType string) bool {
+	var pref models.NotificationPreference
+	if err := db.Where("user_id = ? AND type = ?", userID, notificationType).First(&pref).Error; err != nil {
+		return true
+	}
+	return pref.Enabled
+}
+
+// Notify guarda una notificación en la bandeja del usuario y la envía en tiempo real.
+// Devuelve nil sin error si el usuario desactivó el tipo o si dedupeKey ya se usó.
+func Notify(db *gorm.DB, userID uint, notificationType, title, body string, data models.NotificationData, dedupeKey string) (*models.Notification, error) {
+	if !NotificationEnabled(db, userID, notificationType) {
+		return nil, nil
+	}
+
+	notification := models.Notification{UserID: userID, Type: notificationType, Title: title, Body: body, Data: data}
+	if dedupeKey != "" {
+		notification.DedupeKey = &dedupeKey
+	}
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&notification)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	Publish(UserChannel(userID), MessageNotification, notification)
+	return &notification, nil
+}
+
+// notify es Notify para los productores: los errores solo se registran porque la notificación
+// nunca debe hacer fallar la operación que la origina
+func notify(db *gorm.DB, userID uint, notificationType, title, body string, data models.NotificationData, dedupeKey string) {
+	if _, err := Notify(db, userID, notificationType, title, body, data, dedupeKey); err != nil {
+		log.Printf("⚠️  No se pudo notificar %s al usuario %d: %v", notificationType, userID, err)
+	}
+}
+
+// NotifyWithdrawalStatus avisa al usuario el nuevo estado de su retiro
+func NotifyWithdrawalStatus(db *gorm.DB, withdrawal *models.Withdrawal, status string) {
+	var title, body string
+	switch status {
+	case "verified":
+		title = "Retiro verificado"
+		body = fmt.Sprintf("Tu retiro de %.2f fue verificado y está en revisión.", withdrawal.Amount)
+	case "approved":
+		title = "Retiro aprobado"
+		body = fmt.Sprintf("Tu retiro de %.2f fue aprobado.", withdrawal.Amount)
+	case "completed":
+		title = "Retiro completado"
+		body = fmt.Sprintf("Tu retiro de %.2f fue pagado.", withdrawal.Amount)
+	case "cancelled":
+		title = "Retiro cancelado"
+		body = fmt.Sprintf("Cancelaste tu retiro de %.2f; el monto volvió a tu saldo.", withdrawal.Amount)
+	case "rejected":
+		title = "Retiro rechazado"
+		body = fmt.Sprintf("Tu retiro de %.2f fue rechazado: %s. El monto volvió a tu saldo.", withdrawal.Amount, withdrawal.RejectedReason)
+	default:
+		return
+	}
+
+	notify(db, withdrawal.UserID, models.NotificationWithdrawalStatus, title, body,
+		models.NotificationData{"withdrawal_id": withdrawal.ID, "status": status}, "")
+
+	// Las decisiones sobre el dinero también llegan por correo
+	if status != "rejected" && status != "approved" && status != "completed" {
+		return
+	}
+	if !NotificationEnabled(db, withdrawal.UserID, models.NotificationWithdrawalStatus) {
+		return
+	}
+	var user models.User
+	if err := db.First(&user, withdrawal.UserID).Error; err != nil {
+		return
+	}
+	if _, err := EnqueueMessage(db, &user.ID, models.ChannelEmail, user.Email, TemplateWithdrawalStatus, user.Locale, map[string]interface{}{
+		"Username": user.Username,
+		"Amount":   withdrawal.Amount,
+		"Status":   status,
+		"Title":    title,
+		"Message":  body,
+	}); err != nil {
+		log.Printf("⚠️  No se pudo encolar el correo del retiro %d: %v", withdrawal.ID, err)
+		return
+	}
+	WakeOutbox()
+}
+
+// NotifyPicksSettled avisa a cada participante cuántos picks ganó y los puntos obtenidos
+// en la liquidación de un evento
+func NotifyPicksSettled(db *gorm.DB, settlement *EventSettlement) {
+	for _, award := range settlement.Awards {
+		notifyAward(db, settlement.EventName, award)
+	}
+}
+
+// NotifySessionPicksSettled es NotifyPicksSettled para la liquidación masiva de una sesión:
+// un solo aviso por participante con el total de la sesión, identificada por su número
+func NotifySessionPicksSettled(db *gorm.DB, session *models.Session, report *SessionSettlement) {
+	for i := range report.Participants {
+		notifyAward(db, fmt.Sprintf("sesión %d", session.SessionNumber), &report.Participants[i])
+	}
+}
+
+func notifyAward(db *gorm.DB, source string, award *ParticipantAward) {
+	body := fmt.Sprintf("Resultados de %s: %d ganados, %d empatados, %d perdidos. Sumaste %d puntos.",
+		source, award.PicksWon, award.PicksPush, award.PicksLost, award.Points)
+	notify(db, award.UserID, models.NotificationPicksSettled, "Tus picks fueron liquidados", body,
+		models.NotificationData{"tournament_id": award.TournamentID, "points": award.Points}, "")
+}
+
+// NotifyPrizePaid avisa al ganador el premio acreditado en su billetera
+func NotifyPrizePaid(db *gorm.DB, userID uint, tournament *models.Tournament, position int, amount float64) {
+	body := fmt.Sprintf("Quedaste en la posición %d de %s y se acreditaron %.2f en tu billetera.", position, tournament.Name, amount)
+	notify(db, userID, models.NotificationPrizePaid, "¡Ganaste un premio!", body,
+		models.NotificationData{"tournament_id": tournament.ID, "position": position, "amount": amount},
+		fmt.Sprintf("prize_paid:%d", tournament.ID))
+}
+
+// sessionParticipantIDs devuelve los usuarios inscritos en el torneo de la sesión o en las
+// ligas que juegan su cartilla
+func sessionParticipantIDs(db *gorm.DB, session *models.Session) []uint {
+	var userIDs []uint
+	db.Model(&models.TournamentParticipant{}).
+		Joins("JOIN tournaments ON tournaments.id = tournament_participants.tournament_id AND tournaments.deleted_at IS NULL").
+		Where("tournaments.id = ? OR tournaments.parent_tournament_id = ?", session.TournamentID, session.TournamentID).
+		Distinct().Pluck("tournament_participants.user_id", &userIDs)
+	return userIDs
+}
+
+// SendSessionReminders avisa a los participantes las sesiones que abren en la próxima hora y
+// las que cierran picks en la próxima hora. Cada aviso se envía una sola vez por usuario.
+func SendSessionReminders(db *gorm.DB, now time.Time) {
+	var opening []models.Session
+	db.Where("status = ? AND start_time > ? AND start_time <= ?", models.SessionStatusScheduled, now, now.Add(sessionOpeningNotice)).
+		Find(&opening)
+	for _, session := range opening {
+		body := fmt.Sprintf("La sesión %d abre a las %s. Prepara tus picks.", session.SessionNumber, session.StartTime.Format("15:04"))
+		for _, userID := range sessionParticipantIDs(db, &session) {
+			notify(db, userID, models.NotificationSessionOpening, "Una sesión abre pronto", body,
+				models.NotificationData{"tournament_id": session.TournamentID, "session_id": session.ID},
+				fmt.Sprintf("session_opening:%d", session.ID))
+		}
+	}
+
+	var closing []models.Session
+	db.Where("status = ? AND end_time > ? AND end_time <= ?", models.SessionStatusOpen, now, now.Add(pickDeadlineNotice)).
+		Find(&closing)
+	for _, session := range closing {
+		body := fmt.Sprintf("Los picks de la sesión %d cierran a las %s.", session.SessionNumber, session.EndTime.Format("15:04"))
+		for _, userID := range sessionParticipantIDs(db, &session) {
+			notify(db, userID, models.NotificationPickDeadline, "Queda una hora para tus picks", body,
+				models.NotificationData{"tournament_id": session.TournamentID, "session_id": session.ID},
+				fmt.Sprintf("pick_deadline:%d", session.ID))
+		}
+	}
+}
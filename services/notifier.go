@@ -0,0 +1,138 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+)
+
+// OutboundMessage es un correo o SMS listo para enviar
+type OutboundMessage struct {
+	To      string
+	Subject string // Solo correo
+	Body    string
+}
+
+// Notifier entrega mensajes por un canal (correo o SMS)
+type Notifier interface {
+	// Channel es models.ChannelEmail o models.ChannelSMS
+	Channel() string
+	Send(ctx context.Context, msg OutboundMessage) error
+}
+
+// SMTPNotifier envía correos por SMTP con autenticación PLAIN
+type SMTPNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPNotifier crea un notificador SMTP. Sin usuario se envía sin autenticación.
+func NewSMTPNotifier(host, port, username, password, from string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPNotifier{addr: net.JoinHostPort(host, port), auth: auth, from: from}
+}
+
+func (n *SMTPNotifier) Channel() string {
+	return models.ChannelEmail
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, msg OutboundMessage) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", n.from)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", msg.Subject)
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(msg.Body)
+
+	return smtp.SendMail(n.addr, n.auth, n.from, []string{msg.To}, []byte(body.String()))
+}
+
+// SMSGatewayNotifier envía SMS a una pasarela HTTP con POST {url} y cuerpo {"to", "message"}
+type SMSGatewayNotifier struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewSMSGatewayNotifier crea un notificador SMS; token va como Bearer si no está vacío
+func NewSMSGatewayNotifier(url, token string) *SMSGatewayNotifier {
+	return &SMSGatewayNotifier{url: url, token: token, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *SMSGatewayNotifier) Channel() string {
+	return models.ChannelSMS
+}
+
+func (n *SMSGatewayNotifier) Send(ctx context.Context, msg OutboundMessage) error {
+	payload, _ := json.Marshal(map[string]string{"to": msg.To, "message": msg.Body})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("pasarela SMS respondió %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// LogNotifier reemplaza al proveedor real en desarrollo: escribe los mensajes en un archivo
+// o, si no hay archivo, en el log del servidor
+type LogNotifier struct {
+	channel string
+	path    string
+	mu      sync.Mutex
+}
+
+// NewLogNotifier crea un notificador de prueba para el canal; path puede ser vacío
+func NewLogNotifier(channel, path string) *LogNotifier {
+	return &LogNotifier{channel: channel, path: path}
+}
+
+func (n *LogNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *LogNotifier) Send(ctx context.Context, msg OutboundMessage) error {
+	line := fmt.Sprintf("[%s] %s para %s | %s | %s\n", time.Now().Format(time.RFC3339), n.channel, msg.To, msg.Subject, msg.Body)
+	if n.path == "" {
+		log.Print("✉️  " + line)
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(line)
+	return err
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// outboxLeaseName identifica el candado del despachador de mensajes entre réplicas
+const outboxLeaseName = "outbox"
+
+// Reintentos de los envíos en segundo plano (outbox y webhooks): espera base que se duplica en
+// cada fallo hasta el máximo
+const (
+	retryBaseBackoff = 30 * time.Second
+	retryMaxBackoff  = time.Hour
+)
+
+const (
+	outboxMaxAttempts = 8
+	outboxBatchSize   = 50
+)
+
+// ErrNoRecipient indica que el usuario no tiene correo ni teléfono para el mensaje
+var ErrNoRecipient = errors.New("el usuario no tiene a dónde enviar el mensaje")
+
+// EnqueueMessage renderiza la plantilla y guarda el mensaje en el outbox dentro de tx. Se envía
+// solo si tx se confirma, cuando el despachador lo toma.
+func EnqueueMessage(tx *gorm.DB, userID *uint, channel, recipient, templateName, locale string, data interface{}) (*models.OutboxMessage, error) {
+	return enqueueMessage(tx, userID, channel, recipient, templateName, locale, data, nil)
+}
+
+func enqueueMessage(tx *gorm.DB, userID *uint, channel, recipient, templateName, locale string, data interface{}, expiresAt *time.Time) (*models.OutboxMessage, error) {
+	if recipient == "" {
+		return nil, ErrNoRecipient
+	}
+	if locale == "" {
+		locale = DefaultLocale
+	}
+
+	subject, body, err := RenderMessage(templateName, locale, channel, data)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := models.OutboxMessage{
+		UserID:        userID,
+		Channel:       channel,
+		Recipient:     recipient,
+		Template:      templateName,
+		Locale:        locale,
+		Subject:       subject,
+		Body:          body,
+		Status:        models.OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+		ExpiresAt:     expiresAt,
+	}
+	if err := tx.Create(&msg).Error; err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// EnqueueUserMessage encola la plantilla para el usuario por correo y, si tiene teléfono, por SMS
+func EnqueueUserMessage(tx *gorm.DB, user *models.User, templateName string, data interface{}) error {
+	return enqueueUserMessage(tx, user, templateName, data, nil)
+}
+
+// EnqueueExpiringUserMessage es como EnqueueUserMessage pero el mensaje se descarta sin enviarse
+// si no salió antes de expiresAt (ej: un código que ya no sirve)
+func EnqueueExpiringUserMessage(tx *gorm.DB, user *models.User, templateName string, data interface{}, expiresAt time.Time) error {
+	return enqueueUserMessage(tx, user, templateName, data, &expiresAt)
+}
+
+func enqueueUserMessage(tx *gorm.DB, user *models.User, templateName string, data interface{}, expiresAt *time.Time) error {
+	if _, err := enqueueMessage(tx, &user.ID, models.ChannelEmail, user.Email, templateName, user.Locale, data, expiresAt); err != nil {
+		return err
+	}
+	if user.Phone != "" {
+		if _, err := enqueueMessage(tx, &user.ID, models.ChannelSMS, user.Phone, templateName, user.Locale, data, expiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outboxWake despierta al despachador apenas se confirma un mensaje urgente (ej: un código)
+var outboxWake = make(chan struct{}, 1)
+
+// WakeOutbox pide al despachador revisar el outbox sin esperar al siguiente ciclo. Se llama
+// después del commit; si nadie escucha no hace nada.
+func WakeOutbox() {
+	select {
+	case outboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// OutboxDispatcher envía los mensajes pendientes del outbox con el notificador de su canal
+type OutboxDispatcher struct {
+	db        *gorm.DB
+	notifiers map[string]Notifier
+	interval  time.Duration
+	leaseTTL  time.Duration
+	owner     string
+}
+
+// NewOutboxDispatcher crea un despachador que revisa el outbox cada interval
+func NewOutboxDispatcher(db *gorm.DB, interval time.Duration, notifiers ...Notifier) *OutboxDispatcher {
+	byChannel := make(map[string]Notifier, len(notifiers))
+	for _, notifier := range notifiers {
+		byChannel[notifier.Channel()] = notifier
+	}
+	return &OutboxDispatcher{
+		db:        db,
+		notifiers: byChannel,
+		interval:  interval,
+		leaseTTL:  3 * interval,
+		owner:     leaseOwner(),
+	}
+}
+
+// Start lanza el despachador en segundo plano hasta que ctx sea cancelado
+func (d *OutboxDispatcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			if acquireJobLease(d.db, outboxLeaseName, d.owner, d.leaseTTL, time.Now()) {
+				d.DispatchOnce(ctx, time.Now())
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-outboxWake:
+			}
+		}
+	}()
+}
+
+// DispatchOnce envía los mensajes pendientes cuyo próximo intento ya llegó y devuelve cuántos se enviaron.
+// Antes descarta los que vencieron sin enviarse para no mandar códigos que ya no sirven.
+func (d *OutboxDispatcher) DispatchOnce(ctx context.Context, now time.Time) int {
+	if err := d.db.Model(&models.OutboxMessage{}).
+		Where("status = ? AND expires_at IS NOT NULL AND expires_at <= ?", models.OutboxStatusPending, now).
+		Updates(map[string]interface{}{"status": models.OutboxStatusExpired, "body": ""}).Error; err != nil {
+		log.Printf("⚠️  Outbox: %v", err)
+		return 0
+	}
+
+	var messages []models.OutboxMessage
+	if err := d.db.Where("status = ? AND next_attempt_at <= ?", models.OutboxStatusPending, now).
+		Order("next_attempt_at, id").Limit(outboxBatchSize).Find(&messages).Error; err != nil {
+		log.Printf("⚠️  Outbox: %v", err)
+		return 0
+	}
+
+	sent := 0
+	for i := range messages {
+		if ctx.Err() != nil {
+			break
+		}
+		if d.deliver(ctx, &messages[i], now) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// deliver intenta enviar un mensaje y agenda el reintento si falla
+func (d *OutboxDispatcher) deliver(ctx context.Context, msg *models.OutboxMessage, now time.Time) bool {
+	notifier, ok := d.notifiers[msg.Channel]
+	var err error
+	if !ok {
+		err = errors.New("no hay notificador configurado para el canal " + msg.Channel)
+	} else {
+		err = notifier.Send(ctx, OutboundMessage{To: msg.Recipient, Subject: msg.Subject, Body: msg.Body})
+	}
+
+	if err == nil {
+		d.db.Model(msg).Updates(map[string]interface{}{
+			"status":     models.OutboxStatusSent,
+			"attempts":   msg.Attempts + 1,
+			"sent_at":    now,
+			"last_error": "",
+			"body":       "", // Ya entregado: no guardar el código en claro
+		})
+		return true
+	}
+
+	attempts := msg.Attempts + 1
+	changes := map[string]interface{}{"attempts": attempts, "last_error": err.Error()}
+	if attempts >= outboxMaxAttempts {
+		changes["status"] = models.OutboxStatusFailed
+		changes["body"] = ""
+		log.Printf("⚠️  Outbox: mensaje %d descartado tras %d intentos: %v", msg.ID, attempts, err)
+	} else {
+		changes["next_attempt_at"] = now.Add(retryBackoff(attempts))
+	}
+	d.db.Model(msg).Updates(changes)
+	return false
+}
+
+// retryBackoff es la espera antes del siguiente intento: 30s, 1m, 2m... hasta 1h
+func retryBackoff(attempts int) time.Duration {
+	backoff := retryBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= retryMaxBackoff {
+			return retryMaxBackoff
+		}
+	}
+	return backoff
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// Códigos de las violaciones de reglas de cartilla
+const (
+	RuleSelectionNotFound   = "selection_not_found"
+	RuleSelectionCount      = "selection_count"
+	RuleTooFewSelections    = "too_few_selections"
+	RuleTooManySelections   = "too_many_selections"
+	RuleRequiredTypes       = "required_types_missing"
+	RuleDuplicateSelection  = "duplicate_selection"
+	RuleDuplicateEvent      = "duplicate_event"
+	RuleContradictoryPicks  = "contradictory_picks"
+	RuleSelectionNotSession = "selection_not_in_session"
+)
+
+// RuleViolation describe una regla incumplida por la cartilla enviada
+type RuleViolation struct {
+	Code         string `json:"code"`
+	Message      string `json:"message"`
+	SelectionIDs []uint `json:"selection_ids,omitempty"`
+}
+
+// contradictoryTypes agrupa los tipos de selección opuestos sobre un mismo evento
+var contradictoryTypes = map[string]string{
+	models.SelectionTypeAlta:      models.SelectionTypeBaja,
+	models.SelectionTypeMacho:     models.SelectionTypeHembra,
+	models.SelectionTypeMachoRL:   models.SelectionTypeHembraRL,
+	models.SelectionTypeMachoSRL:  models.SelectionTypeHembraSRL,
+	models.SelectionTypeSuperAlta: models.SelectionTypeSuperBaja,
+}
+
+// slate es la cartilla a evaluar con todo lo que necesitan las reglas
+type slate struct {
+	tournament      *models.Tournament
+	category        models.CategorySettingsJSON
+	selectionIDs    []uint
+	selections      map[uint]models.PickableSelection
+	sessionEventIDs map[uint]bool
+}
+
+// pickRule es una regla declarativa. Las reglas de completitud (cantidad y tipos obligatorios)
+// solo aplican a la cartilla completa, no a cambios de un pick individual.
+type pickRule struct {
+	completeness bool
+	check        func(s *slate) []RuleViolation
+}
+
+// pickRules es el conjunto de reglas evaluadas en orden
+var pickRules = []pickRule{
+	{check: ruleSelectionsExist},
+	{completeness: true, check: ruleSelectionCount},
+	{completeness: true, check: ruleRequiredTypes},
+	{check: ruleDuplicateSelections},
+	{check: ruleSelectionsInSession},
+	{check: ruleDuplicateEvents},
+	{check: ruleContradictoryPicks},
+}
+
+// ValidateSlate evalúa la cartilla completa de una sesión contra las reglas del torneo
+// y de su categoría. Devuelve todas las violaciones encontradas.
+func ValidateSlate(db *gorm.DB, tournament *models.Tournament, session *models.Session, selectionIDs []uint) ([]RuleViolation, error) {
+	return validate(db, tournament, session, selectionIDs, true)
+}
+
+// ValidatePartialSlate evalúa una cartilla en edición (sin exigir cantidad ni tipos obligatorios)
+func ValidatePartialSlate(db *gorm.DB, tournament *models.Tournament, session *models.Session, selectionIDs []uint) ([]RuleViolation, error) {
+	return validate(db, tournament, session, selectionIDs, false)
+}
+
+func validate(db *gorm.DB, tournament *models.Tournament, session *models.Session, selectionIDs []uint, complete bool) ([]RuleViolation, error) {
+	s, err := loadSlate(db, tournament, session, selectionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := []RuleViolation{}
+	for _, rule := range pickRules {
+		if rule.completeness && !complete {
+			continue
+		}
+		violations = append(violations, rule.check(s)...)
+	}
+	return violations, nil
+}
+
+func loadSlate(db *gorm.DB, tournament *models.Tournament, session *models.Session, selectionIDs []uint) (*slate, error) {
+	s := &slate{
+		tournament:      tournament,
+		selectionIDs:    selectionIDs,
+		selections:      make(map[uint]models.PickableSelection),
+		sessionEventIDs: make(map[uint]bool),
+	}
+
+	// Las reglas de la categoría son opcionales
+	if category, err := TournamentCategory(db, tournament); err == nil {
+		s.category = category.Settings
+	}
+
+	var selections []models.PickableSelection
+	if len(selectionIDs) > 0 {
+		if err := db.Where("id IN ?", selectionIDs).Find(&selections).Error; err != nil {
+			return nil, err
+		}
+	}
+	for _, sel := range selections {
+		s.selections[sel.ID] = sel
+	}
+
+	var eventIDs []uint
+	if err := db.Model(&models.TournamentEvent{}).
+		Where("tournament_id = ? AND session_id = ?", tournament.SlateTournamentID(), session.ID).
+		Pluck("event_id", &eventIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range eventIDs {
+		s.sessionEventIDs[id] = true
+	}
+
+	return s, nil
+}
+
+// distinctSelections devuelve las selecciones existentes sin repetir, en el orden enviado
+func (s *slate) distinctSelections() []models.PickableSelection {
+	seen := make(map[uint]bool)
+	var result []models.PickableSelection
+	for _, id := range s.selectionIDs {
+		sel, ok := s.selections[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, sel)
+	}
+	return result
+}
+
+func ruleSelectionsExist(s *slate) []RuleViolation {
+	var missing []uint
+	for _, id := range s.selectionIDs {
+		if _, ok := s.selections[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return []RuleViolation{{Code: RuleSelectionNotFound, Message: "Hay selecciones que no existen", SelectionIDs: missing}}
+}
+
+func ruleSelectionCount(s *slate) []RuleViolation {
+	var violations []RuleViolation
+	count := len(s.selectionIDs)
+
+	if required := s.tournament.Settings.SelectionsPerSession; required > 0 && count != required {
+		violations = append(violations, RuleViolation{
+			Code:    RuleSelectionCount,
+			Message: fmt.Sprintf("Debe hacer exactamente %d selecciones por sesión (envió %d)", required, count),
+		})
+	}
+	if min := s.category.MinSelectionsPerSession; min > 0 && count < min {
+		violations = append(violations, RuleViolation{
+			Code:    RuleTooFewSelections,
+			Message: fmt.Sprintf("La categoría exige al menos %d selecciones por sesión", min),
+		})
+	}
+	if max := s.category.MaxSelectionsPerSession; max > 0 && count > max {
+		violations = append(violations, RuleViolation{
+			Code:    RuleTooManySelections,
+			Message: fmt.Sprintf("La categoría permite como máximo %d selecciones por sesión", max),
+		})
+	}
+	return violations
+}
+
+// ruleRequiredTypes exige los tipos obligatorios como multiconjunto, sin importar el orden
+func ruleRequiredTypes(s *slate) []RuleViolation {
+	if s.tournament.Settings.FreeSelection || len(s.tournament.Settings.RequiredSelectionTypes) == 0 {
+		return nil
+	}
+
+	available := make(map[string]int)
+	for _, sel := range s.distinctSelections() {
+		available[sel.SelectionType]++
+	}
+
+	missing := make(map[string]int)
+	for _, t := range s.tournament.Settings.RequiredSelectionTypes {
+		if available[t] > 0 {
+			available[t]--
+		} else {
+			missing[t]++
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	parts := make([]string, 0, len(missing))
+	for t, n := range missing {
+		parts = append(parts, fmt.Sprintf("%d %s", n, t))
+	}
+	sort.Strings(parts)
+	return []RuleViolation{{
+		Code:    RuleRequiredTypes,
+		Message: "Faltan selecciones de tipo obligatorio: " + strings.Join(parts, ", "),
+	}}
+}
+
+func ruleDuplicateSelections(s *slate) []RuleViolation {
+	seen := make(map[uint]bool)
+	var duplicates []uint
+	for _, id := range s.selectionIDs {
+		if seen[id] {
+			duplicates = append(duplicates, id)
+		}
+		seen[id] = true
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+	return []RuleViolation{{Code: RuleDuplicateSelection, Message: "Hay selecciones repetidas", SelectionIDs: duplicates}}
+}
+
+func ruleSelectionsInSession(s *slate) []RuleViolation {
+	var outside []uint
+	for _, sel := range s.distinctSelections() {
+		if !s.sessionEventIDs[sel.EventID] {
+			outside = append(outside, sel.ID)
+		}
+	}
+	if len(outside) == 0 {
+		return nil
+	}
+	return []RuleViolation{{Code: RuleSelectionNotSession, Message: "Hay selecciones de eventos que no pertenecen a esta sesión", SelectionIDs: outside}}
+}
+
+func ruleDuplicateEvents(s *slate) []RuleViolation {
+	if s.category.AllowMultipleEvents {
+		return nil
+	}
+
+	var violations []RuleViolation
+	for _, ids := range s.selectionsByEvent() {
+		if len(ids) > 1 {
+			violations = append(violations, RuleViolation{
+				Code:         RuleDuplicateEvent,
+				Message:      "Solo se permite un pick por evento",
+				SelectionIDs: ids,
+			})
+		}
+	}
+	return violations
+}
+
+func ruleContradictoryPicks(s *slate) []RuleViolation {
+	var violations []RuleViolation
+	selections := s.distinctSelections()
+	for i, a := range selections {
+		for _, b := range selections[i+1:] {
+			if a.EventID == b.EventID && contradicts(a, b) {
+				violations = append(violations, RuleViolation{
+					Code:         RuleContradictoryPicks,
+					Message:      fmt.Sprintf("'%s' y '%s' son contradictorias", a.Description, b.Description),
+					SelectionIDs: []uint{a.ID, b.ID},
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// contradicts indica si dos selecciones del mismo evento se excluyen entre sí
+func contradicts(a, b models.PickableSelection) bool {
+	if contradictoryTypes[a.SelectionType] != b.SelectionType && contradictoryTypes[b.SelectionType] != a.SelectionType {
+		return false
+	}
+	// Alta y baja solo se contradicen sobre la misma línea
+	if a.SelectionType == models.SelectionTypeAlta || a.SelectionType == models.SelectionTypeBaja {
+		return a.Line == b.Line
+	}
+	return true
+}
+
+// selectionsByEvent agrupa las selecciones por evento, ordenado por ID de evento
+func (s *slate) selectionsByEvent() [][]uint {
+	groups := make(map[uint][]uint)
+	var eventIDs []uint
+	for _, sel := range s.distinctSelections() {
+		if _, ok := groups[sel.EventID]; !ok {
+			eventIDs = append(eventIDs, sel.EventID)
+		}
+		groups[sel.EventID] = append(groups[sel.EventID], sel.ID)
+	}
+	sort.Slice(eventIDs, func(i, j int) bool { return eventIDs[i] < eventIDs[j] })
+
+	result := make([][]uint, 0, len(eventIDs))
+	for _, id := range eventIDs {
+		result = append(result, groups[id])
+	}
+	return result
+}
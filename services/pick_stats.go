@@ -0,0 +1,126 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ContrarianScore mide qué tan a contracorriente jugó un participante: el promedio de
+// (100 - % de participantes que eligió lo mismo) sobre sus picks ya liquidados.
+type ContrarianScore struct {
+	ParticipantID uint    `json:"participant_id"`
+	UserID        uint    `json:"user_id"`
+	Username      string  `json:"username"`
+	SettledPicks  int     `json:"settled_picks"`
+	Score         float64 `json:"score"`
+}
+
+// AdjustPickStat suma delta al contador de picks de la selección en el torneo (delta negativo al eliminar)
+func AdjustPickStat(tx *gorm.DB, tournamentID, sessionID uint, selection *models.PickableSelection, delta int) error {
+	stat := models.SelectionPickStat{
+		TournamentID: tournamentID,
+		SelectionID:  selection.ID,
+		SessionID:    sessionID,
+		EventID:      selection.EventID,
+		PickCount:    delta,
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tournament_id"}, {Name: "selection_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"pick_count": gorm.Expr("selection_pick_stats.pick_count + ?", delta),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&stat).Error
+}
+
+// RebuildPickStats recalcula desde cero los contadores de un torneo a partir de los picks guardados
+func RebuildPickStats(tx *gorm.DB, tournamentID uint) error {
+	if err := tx.Unscoped().Where("tournament_id = ?", tournamentID).Delete(&models.SelectionPickStat{}).Error; err != nil {
+		return err
+	}
+
+	var stats []models.SelectionPickStat
+	if err := tx.Model(&models.UserPick{}).
+		Select("tournament_participants.tournament_id AS tournament_id, user_picks.selection_id AS selection_id, "+
+			"MAX(user_picks.session_id) AS session_id, MAX(pickable_selections.event_id) AS event_id, COUNT(*) AS pick_count").
+		Joins("JOIN tournament_participants ON tournament_participants.id = user_picks.participant_id").
+		Joins("JOIN pickable_selections ON pickable_selections.id = user_picks.selection_id").
+		Where("tournament_participants.tournament_id = ?", tournamentID).
+		Group("tournament_participants.tournament_id, user_picks.selection_id").
+		Scan(&stats).Error; err != nil {
+		return err
+	}
+
+	if len(stats) == 0 {
+		return nil
+	}
+	return tx.Create(&stats).Error
+}
+
+// PickPercentage calcula el porcentaje de participantes que eligió una selección
+func PickPercentage(pickCount int, participants int64) float64 {
+	if participants == 0 {
+		return 0
+	}
+	return float64(pickCount) * 100 / float64(participants)
+}
+
+// ContrarianScores calcula el puntaje contrarian de cada participante del torneo
+func ContrarianScores(db *gorm.DB, tournamentID uint) ([]ContrarianScore, error) {
+	var participants []models.TournamentParticipant
+	if err := db.Preload("User").Where("tournament_id = ?", tournamentID).Find(&participants).Error; err != nil {
+		return nil, err
+	}
+
+	var stats []models.SelectionPickStat
+	if err := db.Where("tournament_id = ?", tournamentID).Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int, len(stats))
+	for _, stat := range stats {
+		counts[stat.SelectionID] = stat.PickCount
+	}
+
+	// Solo picks ya liquidados: antes del bloqueo la popularidad no es definitiva
+	type settledPick struct {
+		ParticipantID uint
+		SelectionID   uint
+	}
+	var picks []settledPick
+	if err := db.Model(&models.UserPick{}).
+		Select("user_picks.participant_id, user_picks.selection_id").
+		Joins("JOIN tournament_participants ON tournament_participants.id = user_picks.participant_id").
+		Where("tournament_participants.tournament_id = ? AND user_picks.status <> ?", tournamentID, "pending").
+		Scan(&picks).Error; err != nil {
+		return nil, err
+	}
+
+	total := int64(len(participants))
+	sums := make(map[uint]float64)
+	settled := make(map[uint]int)
+	for _, pick := range picks {
+		sums[pick.ParticipantID] += 100 - PickPercentage(counts[pick.SelectionID], total)
+		settled[pick.ParticipantID]++
+	}
+
+	scores := make([]ContrarianScore, 0, len(participants))
+	for _, p := range participants {
+		score := ContrarianScore{
+			ParticipantID: p.ID,
+			UserID:        p.UserID,
+			Username:      p.User.Username,
+			SettledPicks:  settled[p.ID],
+		}
+		if settled[p.ID] > 0 {
+			score.Score = sums[p.ID] / float64(settled[p.ID])
+		}
+		scores = append(scores, score)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	return scores, nil
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// principalCacheTTL acota cuánto tarda otra réplica en ver un cambio de rol o de estado; en la
+// réplica que hace el cambio la caché se invalida de inmediato
+const principalCacheTTL = 30 * time.Second
+
+// Principal es el usuario autenticado de la petición, resuelto desde la base de datos
+type Principal struct {
+	UserID       uint
+	Username     string
+	Role         string
+	IsActive     bool
+	TokenVersion int
+	Permissions  map[string]bool // Permisos del rol
+	// TwoFactorEnabled indica si el usuario tiene la verificación en dos pasos activa
+	TwoFactorEnabled bool
+}
+
+// IsAdmin indica si el usuario tiene rol de administrador
+func (p Principal) IsAdmin() bool {
+	return p.Role == models.RoleAdmin
+}
+
+// HasPermission indica si el rol del usuario otorga el permiso; admin los tiene todos
+func (p Principal) HasPermission(code string) bool {
+	return p.IsAdmin() || p.Permissions[code]
+}
+
+// IsStaff indica si el usuario tiene algún permiso de administración; a ellos se les exige 2FA
+func (p Principal) IsStaff() bool {
+	return p.IsAdmin() || len(p.Permissions) > 0
+}
+
+type cachedPrincipal struct {
+	principal Principal
+	expiresAt time.Time
+}
+
+var (
+	principalMu    sync.RWMutex
+	principalCache = make(map[uint]cachedPrincipal)
+)
+
+// LoadPrincipal devuelve el rol, estado y versión de token actuales del usuario, con una caché
+// de vida corta para no consultar la base de datos en cada petición
+func LoadPrincipal(db *gorm.DB, userID uint) (Principal, error) {
+	now := time.Now()
+	principalMu.RLock()
+	cached, ok := principalCache[userID]
+	principalMu.RUnlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.principal, nil
+	}
+
+	var user models.User
+	if err := db.Select("id", "username", "role", "is_active", "token_version", "two_factor_enabled").First(&user, userID).Error; err != nil {
+		return Principal{}, err
+	}
+	principal := Principal{
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
+		IsActive:     user.IsActive,
+		TokenVersion: user.TokenVersion,
+		Permissions:  make(map[string]bool),
+
+		TwoFactorEnabled: user.TwoFactorEnabled,
+	}
+	codes, err := RolePermissionCodes(db, user.Role)
+	if err != nil {
+		return Principal{}, err
+	}
+	for _, code := range codes {
+		principal.Permissions[code] = true
+	}
+
+	principalMu.Lock()
+	principalCache[userID] = cachedPrincipal{principal: principal, expiresAt: now.Add(principalCacheTTL)}
+	principalMu.Unlock()
+	return principal, nil
+}
+
+// InvalidatePrincipal descarta el usuario de la caché; se llama al cambiar su rol, estado o
+// versión de token
+func InvalidatePrincipal(userID uint) {
+	principalMu.Lock()
+	delete(principalCache, userID)
+	principalMu.Unlock()
+}
+
+// ClearPrincipalCache vacía la caché (ej: tras cambios masivos de roles)
+func ClearPrincipalCache() {
+	principalMu.Lock()
+	principalCache = make(map[uint]cachedPrincipal)
+	principalMu.Unlock()
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// Errores de la gestión de roles
+var (
+	ErrRoleNotFound      = errors.New("el rol no existe")
+	ErrSystemRole        = errors.New("los roles del sistema no se pueden modificar ni eliminar")
+	ErrRoleInUse         = errors.New("el rol está asignado a usuarios")
+	ErrUnknownPermission = errors.New("permiso desconocido")
+	ErrRoleEscalation    = errors.New("no puede otorgar permisos que no tiene")
+	ErrAdminProtected    = errors.New("solo un administrador puede cambiar el rol de otro administrador")
+)
+
+// defaultRoles son los roles que se crean si no existen, con sus permisos iniciales
+var defaultRoles = []struct {
+	name        string
+	description string
+	system      bool
+	permissions []string
+}{
+	{models.RoleAdmin, "Acceso total", true, nil}, // nil: todos los permisos
+	{models.RoleUser, "Apostador", true, []string{}},
+	{models.RoleResultsOperator, "Carga y liquidación de resultados", false, []string{
+		models.PermEventsRead, models.PermEventsSettle, models.PermTournamentsRead,
+	}},
+	{models.RoleWithdrawalOperator, "Procesamiento de retiros", false, []string{
+		models.PermWithdrawalsRead, models.PermWithdrawalsApprove, models.PermUsersRead,
+	}},
+	{models.RoleAuditor, "Solo lectura", false, []string{
+		models.PermUsersRead, models.PermTournamentsRead, models.PermEventsRead,
+		models.PermWithdrawalsRead, models.PermWebhooksRead,
+	}},
+}
+
+// AllPermissionCodes devuelve todos los permisos del sistema en orden alfabético
+func AllPermissionCodes() []string {
+	codes := make([]string, 0, len(models.PermissionDescriptions))
+	for code := range models.PermissionDescriptions {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// SeedRBAC crea los permisos y los roles por defecto que falten. admin siempre recibe todos los
+// permisos, incluidos los nuevos; los demás roles existentes no se tocan.
+func SeedRBAC(db *gorm.DB) error {
+	for _, code := range AllPermissionCodes() {
+		permission := models.Permission{Code: code}
+		if err := db.Where(models.Permission{Code: code}).
+			Assign(models.Permission{Description: models.PermissionDescriptions[code]}).
+			FirstOrCreate(&permission).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, def := range defaultRoles {
+		var role models.Role
+		err := db.Where("name = ?", def.name).First(&role).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		codes := def.permissions
+		if def.name == models.RoleAdmin {
+			codes = AllPermissionCodes()
+		} else if err == nil {
+			continue
+		}
+
+		permissions, err := loadPermissions(db, codes)
+		if err != nil {
+			return err
+		}
+		if role.ID == 0 {
+			role = models.Role{Name: def.name, Description: def.description, IsSystem: def.system}
+			if err := db.Create(&role).Error; err != nil {
+				return err
+			}
+		}
+		if err := db.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPermissions busca los permisos por código; falla si alguno no existe
+func loadPermissions(db *gorm.DB, codes []string) ([]models.Permission, error) {
+	permissions := []models.Permission{}
+	if len(codes) == 0 {
+		return permissions, nil
+	}
+	if err := db.Where("code IN ?", codes).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		found[p.Code] = true
+	}
+	for _, code := range codes {
+		if !found[code] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, code)
+		}
+	}
+	return permissions, nil
+}
+
+// RolePermissionCodes devuelve los permisos del rol por nombre; vacío si el rol no existe
+func RolePermissionCodes(db *gorm.DB, roleName string) ([]string, error) {
+	var codes []string
+	err := db.Table("role_permissions").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id AND roles.deleted_at IS NULL").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("roles.name = ?", roleName).
+		Pluck("permissions.code", &codes).Error
+	return codes, err
+}
+
+// canGrant indica si el actor tiene todos los permisos; evita que un operador se otorgue o
+// entregue más de lo que tiene
+func canGrant(actor Principal, codes []string) bool {
+	for _, code := range codes {
+		if !actor.HasPermission(code) {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateRole crea un rol de operador con los permisos indicados
+func CreateRole(db *gorm.DB, actor Principal, name, description string, codes []string) (*models.Role, error) {
+	if !canGrant(actor, codes) {
+		return nil, ErrRoleEscalation
+	}
+	permissions, err := loadPermissions(db, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	role := models.Role{Name: name, Description: description, Permissions: permissions}
+	if err := db.Create(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// UpdateRolePermissions reemplaza los permisos de un rol de operador. Los usuarios con el rol
+// ven el cambio en su siguiente petición.
+func UpdateRolePermissions(db *gorm.DB, actor Principal, role *models.Role, description *string, codes []string) error {
+	if role.IsSystem {
+		return ErrSystemRole
+	}
+	if !canGrant(actor, codes) {
+		return ErrRoleEscalation
+	}
+	permissions, err := loadPermissions(db, codes)
+	if err != nil {
+		return err
+	}
+
+	if description != nil {
+		role.Description = *description
+		if err := db.Model(role).Update("description", *description).Error; err != nil {
+			return err
+		}
+	}
+	if err := db.Model(role).Association("Permissions").Replace(permissions); err != nil {
+		return err
+	}
+	role.Permissions = permissions
+	ClearPrincipalCache()
+	return nil
+}
+
+// DeleteRole elimina un rol de operador que ningún usuario tenga asignado
+func DeleteRole(db *gorm.DB, role *models.Role) error {
+	if role.IsSystem {
+		return ErrSystemRole
+	}
+	var assigned int64
+	db.Model(&models.User{}).Where("role = ?", role.Name).Count(&assigned)
+	if assigned > 0 {
+		return ErrRoleInUse
+	}
+	if err := db.Model(role).Association("Permissions").Clear(); err != nil {
+		return err
+	}
+	// Borrado definitivo para poder volver a crear un rol con el mismo nombre
+	return db.Unscoped().Delete(role).Error
+}
+
+// CanManageUser verifica que el actor pueda actuar sobre el usuario (cambiar su rol o su estado):
+// solo un admin actúa sobre otro admin, y nadie actúa sobre quien tiene permisos que él no tiene.
+func CanManageUser(db *gorm.DB, actor Principal, user *models.User) error {
+	if user.Role == models.RoleAdmin && !actor.IsAdmin() {
+		return ErrAdminProtected
+	}
+	current, err := RolePermissionCodes(db, user.Role)
+	if err != nil {
+		return err
+	}
+	if !canGrant(actor, current) {
+		return ErrRoleEscalation
+	}
+	return nil
+}
+
+// AssignRole cambia el rol de un usuario. El actor no puede cambiar su propio rol, asignar un
+// rol con permisos que él no tenga ni quitar un rol con permisos que él no tenga (así no puede
+// degradar a quien está por encima). Solo un admin cambia el rol de otro admin. Revoca las
+// sesiones del usuario si el rol cambia.
+func AssignRole(db *gorm.DB, actor Principal, user *models.User, roleName string) error {
+	if err := CanManageUser(db, actor, user); err != nil {
+		return err
+	}
+
+	var role models.Role
+	if err := db.Preload("Permissions").Where("name = ?", roleName).First(&role).Error; err != nil {
+		return ErrRoleNotFound
+	}
+	if !canGrant(actor, role.PermissionCodes()) {
+		return ErrRoleEscalation
+	}
+	if user.Role == roleName {
+		return nil
+	}
+
+	if err := db.Model(user).Update("role", roleName).Error; err != nil {
+		return err
+	}
+	user.Role = roleName
+	return RevokeUserSessions(db, user.ID, time.Now())
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// Publicadores de alto nivel. Se llaman después de confirmar la transacción para que los
+// clientes nunca vean cambios que luego se revierten.
+
+// EventStatusUpdate es el mensaje de cambio de estado o marcador de un evento
+type EventStatusUpdate struct {
+	EventID       uint                  `json:"event_id"`
+	Name          string                `json:"name"`
+	Status        string                `json:"status"`
+	Period        string                `json:"period,omitempty"`
+	LiveUpdatedAt *time.Time            `json:"live_updated_at,omitempty"`
+	Scores        []CompetitorLiveScore `json:"scores,omitempty"`
+}
+
+// CompetitorLiveScore es el marcador en vivo de un competidor dentro del mensaje
+type CompetitorLiveScore struct {
+	CompetitorID uint `json:"competitor_id"`
+	Score        int  `json:"score"`
+	Position     int  `json:"position,omitempty"`
+}
+
+// LeaderboardEntry es una fila de la clasificación enviada en tiempo real
+type LeaderboardEntry struct {
+	ParticipantID uint   `json:"participant_id"`
+	UserID        uint   `json:"user_id"`
+	Username      string `json:"username"`
+	TotalPoints   int    `json:"total_points"`
+}
+
+// LeaderboardUpdate es el mensaje con la clasificación completa del torneo
+type LeaderboardUpdate struct {
+	TournamentID uint               `json:"tournament_id"`
+	Standings    []LeaderboardEntry `json:"standings"`
+}
+
+// SessionStatusUpdate es el mensaje de cambio de estado de una sesión
+type SessionStatusUpdate struct {
+	SessionID     uint   `json:"session_id"`
+	TournamentID  uint   `json:"tournament_id"`
+	SessionNumber int    `json:"session_number"`
+	Status        string `json:"status"`
+}
+
+// WalletBalanceUpdate es el mensaje de saldo enviado solo al dueño de la billetera
+type WalletBalanceUpdate struct {
+	Balance        float64 `json:"balance"`
+	Bonus          float64 `json:"bonus"`
+	Frozen         float64 `json:"frozen"`
+	TotalAvailable float64 `json:"total_available"`
+}
+
+// eventTournamentIDs devuelve los torneos en los que está asignado el evento y las ligas que
+// juegan su cartilla
+func eventTournamentIDs(db *gorm.DB, eventID uint) []uint {
+	var ids []uint
+	db.Model(&models.TournamentEvent{}).Where("event_id = ?", eventID).Distinct().Pluck("tournament_id", &ids)
+	return withLeagueIDs(db, ids)
+}
+
+// withLeagueIDs agrega a los torneos base las ligas de usuarios que comparten su cartilla
+func withLeagueIDs(db *gorm.DB, slateIDs []uint) []uint {
+	if len(slateIDs) == 0 {
+		return slateIDs
+	}
+	var leagueIDs []uint
+	db.Model(&models.Tournament{}).Where("parent_tournament_id IN ?", slateIDs).Pluck("id", &leagueIDs)
+	return append(slateIDs, leagueIDs...)
+}
+
+// PublishEventStatus avisa el estado y marcador del evento en su canal y en el de sus torneos.
+// Usa event.Competitors si vienen precargados.
+func PublishEventStatus(db *gorm.DB, event *models.Event) {
+	update := EventStatusUpdate{
+		EventID:       event.ID,
+		Name:          event.Name,
+		Status:        event.Status,
+		Period:        event.Period,
+		LiveUpdatedAt: event.LiveUpdatedAt,
+	}
+	if event.Status == "live" {
+		for _, comp := range event.Competitors {
+			update.Scores = append(update.Scores, CompetitorLiveScore{CompetitorID: comp.ID, Score: comp.LiveScore, Position: comp.LivePosition})
+		}
+	}
+
+	Publish(EventChannel(event.ID), MessageEventStatus, update)
+	for _, tournamentID := range eventTournamentIDs(db, event.ID) {
+		Publish(TournamentChannel(tournamentID), MessageEventStatus, update)
+	}
+}
+
+// PublishEventSettled avisa la liquidación del evento y envía la nueva clasificación de cada
+// torneo afectado
+func PublishEventSettled(db *gorm.DB, settlement *EventSettlement) {
+	Publish(EventChannel(settlement.EventID), MessageEventSettled, settlement)
+
+	tournaments := make(map[uint]bool)
+	for _, tournamentID := range eventTournamentIDs(db, settlement.EventID) {
+		tournaments[tournamentID] = true
+	}
+	for _, award := range settlement.Awards {
+		tournaments[award.TournamentID] = true
+	}
+	for tournamentID := range tournaments {
+		Publish(TournamentChannel(tournamentID), MessageEventSettled, settlement)
+		PublishLeaderboard(db, tournamentID)
+	}
+}
+
+// currentLeaderboard arma la clasificación actual del torneo
+func currentLeaderboard(db *gorm.DB, tournamentID uint) (*LeaderboardUpdate, error) {
+	var participants []models.TournamentParticipant
+	if err := db.Preload("User").Where("tournament_id = ?", tournamentID).
+		Order("total_points desc").Find(&participants).Error; err != nil {
+		return nil, err
+	}
+
+	update := LeaderboardUpdate{TournamentID: tournamentID, Standings: make([]LeaderboardEntry, 0, len(participants))}
+	for _, p := range participants {
+		update.Standings = append(update.Standings, LeaderboardEntry{
+			ParticipantID: p.ID,
+			UserID:        p.UserID,
+			Username:      p.User.Username,
+			TotalPoints:   p.TotalPoints,
+		})
+	}
+	return &update, nil
+}
+
+// PublishLeaderboard envía la clasificación actual del torneo a su canal
+func PublishLeaderboard(db *gorm.DB, tournamentID uint) {
+	update, err := currentLeaderboard(db, tournamentID)
+	if err != nil {
+		return
+	}
+	Publish(TournamentChannel(tournamentID), MessageLeaderboardUpdated, update)
+}
+
+// PublishSessionStatus avisa el cambio de estado de la sesión en el canal de su torneo y en el
+// de las ligas que juegan sus sesiones
+func PublishSessionStatus(db *gorm.DB, session *models.Session) {
+	update := SessionStatusUpdate{
+		SessionID:     session.ID,
+		TournamentID:  session.TournamentID,
+		SessionNumber: session.SessionNumber,
+		Status:        session.Status,
+	}
+	for _, tournamentID := range withLeagueIDs(db, []uint{session.TournamentID}) {
+		Publish(TournamentChannel(tournamentID), MessageSessionStatus, update)
+	}
+}
+
+// PublishWalletBalance envía el saldo actual de la billetera al canal privado de su dueño
+func PublishWalletBalance(wallet *models.Wallet) {
+	Publish(UserChannel(wallet.UserID), MessageWalletBalance, WalletBalanceUpdate{
+		Balance:        wallet.Balance,
+		Bonus:          wallet.BonusBalance,
+		Frozen:         wallet.FrozenBalance,
+		TotalAvailable: wallet.Balance + wallet.BonusBalance,
+	})
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// resultsPollerLeaseName identifica el candado del poller de resultados entre réplicas
+const resultsPollerLeaseName = "results-poller"
+
+var ErrProposalNotPending = errors.New("la propuesta ya fue revisada")
+
+// ResultsPoller consulta periódicamente a los proveedores los eventos comenzados que tienen
+// mapeo y aún no se liquidan. Los resultados parciales actualizan el marcador en vivo; cada
+// resultado final queda como propuesta pendiente para que un admin la apruebe y, si dos
+// proveedores coinciden, el evento se liquida automáticamente.
+type ResultsPoller struct {
+	db        *gorm.DB
+	providers map[string]ResultsProvider
+	interval  time.Duration
+	leaseTTL  time.Duration
+	owner     string
+}
+
+// NewResultsPoller crea un poller que se ejecuta cada interval con los proveedores indicados
+func NewResultsPoller(db *gorm.DB, interval time.Duration, providers ...ResultsProvider) *ResultsPoller {
+	byName := make(map[string]ResultsProvider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+	return &ResultsPoller{
+		db:        db,
+		providers: byName,
+		interval:  interval,
+		leaseTTL:  3 * interval,
+		owner:     leaseOwner(),
+	}
+}
+
+// Start lanza el poller en segundo plano hasta que ctx sea cancelado
+func (p *ResultsPoller) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			if acquireJobLease(p.db, resultsPollerLeaseName, p.owner, p.leaseTTL, time.Now()) {
+				p.PollOnce(ctx, time.Now())
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// PollOnce consulta los eventos pendientes de resultado tomando now como hora actual
+func (p *ResultsPoller) PollOnce(ctx context.Context, now time.Time) {
+	if len(p.providers) == 0 {
+		return
+	}
+	names := make([]string, 0, len(p.providers))
+	for name := range p.providers {
+		names = append(names, name)
+	}
+
+	var mappings []models.EventProviderMapping
+	if err := p.db.Joins("JOIN events ON events.id = event_provider_mappings.event_id AND events.deleted_at IS NULL").
+		Where("event_provider_mappings.provider IN ?", names).
+		Where("events.status NOT IN ? AND events.start_time <= ?", []string{"completed", "cancelled"}, now).
+		Order("event_provider_mappings.event_id").
+		Find(&mappings).Error; err != nil {
+		log.Printf("⚠️  Poller de resultados: %v", err)
+		return
+	}
+
+	byEvent := make(map[uint][]models.EventProviderMapping)
+	var eventIDs []uint
+	for _, mapping := range mappings {
+		if _, ok := byEvent[mapping.EventID]; !ok {
+			eventIDs = append(eventIDs, mapping.EventID)
+		}
+		byEvent[mapping.EventID] = append(byEvent[mapping.EventID], mapping)
+	}
+
+	for _, eventID := range eventIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		p.pollEvent(ctx, eventID, byEvent[eventID])
+	}
+}
+
+// pollEvent consulta cada proveedor mapeado del evento y registra sus propuestas o su marcador en vivo
+func (p *ResultsPoller) pollEvent(ctx context.Context, eventID uint, mappings []models.EventProviderMapping) {
+	var event models.Event
+	if err := p.db.Preload("Competitors").First(&event, eventID).Error; err != nil {
+		return
+	}
+
+	for _, mapping := range mappings {
+		result, err := p.providers[mapping.Provider].FetchResult(ctx, mapping.ExternalID)
+		if errors.Is(err, ErrResultNotAvailable) {
+			continue
+		}
+		if err != nil {
+			log.Printf("⚠️  Proveedor %s, evento %s: %v", mapping.Provider, mapping.ExternalID, err)
+			continue
+		}
+
+		results, err := mapProviderResult(&event, result)
+		if err != nil {
+			log.Printf("⚠️  Proveedor %s, evento %s: %v", mapping.Provider, mapping.ExternalID, err)
+			continue
+		}
+
+		if !result.Final {
+			update := dtos.LiveUpdateRequest{Period: result.Period}
+			for _, r := range results {
+				update.Competitors = append(update.Competitors, dtos.LiveCompetitorUpdate{CompetitorID: r.CompetitorID, Score: r.FinalScore, Position: r.Position})
+			}
+			tx := p.db.Begin()
+			live, err := ApplyLiveUpdate(tx, event.ID, update, time.Now())
+			if err != nil {
+				tx.Rollback()
+				log.Printf("⚠️  No se pudo actualizar el marcador en vivo de %s: %v", event.Name, err)
+				continue
+			}
+			tx.Commit()
+			PublishEventStatus(p.db, live)
+			continue
+		}
+		if err := RecordProposal(p.db, event.ID, mapping.Provider, mapping.ExternalID, results); err != nil {
+			log.Printf("⚠️  No se pudo guardar la propuesta de %s: %v", mapping.Provider, err)
+		}
+	}
+
+	if err := AutoSettleAgreedProposals(p.db, event.ID); err != nil {
+		log.Printf("⚠️  No se pudo liquidar automáticamente el evento %s: %v", event.Name, err)
+	}
+}
+
+// RecordProposal guarda el resultado de un proveedor como propuesta pendiente. Si el proveedor ya
+// tenía una propuesta pendiente igual no hace nada; si era distinta la reemplaza.
+func RecordProposal(db *gorm.DB, eventID uint, provider, externalID string, results models.ProposedResults) error {
+	var current models.ResultProposal
+	err := db.Where("event_id = ? AND provider = ? AND status = ?", eventID, provider, models.ProposalStatusPending).
+		First(&current).Error
+	if err == nil {
+		if current.SameResults(results) {
+			return nil
+		}
+		if err := db.Model(&current).Update("status", models.ProposalStatusSuperseded).Error; err != nil {
+			return err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	proposal := models.ResultProposal{
+		EventID:    eventID,
+		Provider:   provider,
+		ExternalID: externalID,
+		Results:    results,
+		Status:     models.ProposalStatusPending,
+	}
+	return db.Create(&proposal).Error
+}
+
+// AutoSettleAgreedProposals liquida el evento si dos proveedores distintos tienen propuestas
+// pendientes con el mismo resultado. Las propuestas que coinciden quedan como auto_settled.
+func AutoSettleAgreedProposals(db *gorm.DB, eventID uint) error {
+	var pending []models.ResultProposal
+	if err := db.Where("event_id = ? AND status = ?", eventID, models.ProposalStatusPending).
+		Order("created_at").Find(&pending).Error; err != nil {
+		return err
+	}
+
+	for i := range pending {
+		var agreeing []uint
+		for j := range pending {
+			if pending[j].Provider != pending[i].Provider && pending[i].SameResults(pending[j].Results) {
+				agreeing = append(agreeing, pending[j].ID)
+			}
+		}
+		if len(agreeing) == 0 {
+			continue
+		}
+		agreeing = append(agreeing, pending[i].ID)
+
+		tx := db.Begin()
+		note := fmt.Sprintf("Resultado confirmado por %d proveedores", len(agreeing))
+		settlement, err := SettleEvent(tx, eventID, competitorResults(pending[i].Results), note)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Model(&models.ResultProposal{}).Where("id IN ?", agreeing).
+			Update("status", models.ProposalStatusAutoSettled).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+		PublishEventSettled(db, settlement)
+		NotifyPicksSettled(db, settlement)
+		EmitEventSettled(db, settlement)
+		return nil
+	}
+	return nil
+}
+
+// ApproveProposal liquida el evento con el resultado de la propuesta y la marca como aprobada.
+// Las demás propuestas pendientes del evento quedan reemplazadas.
+func ApproveProposal(tx *gorm.DB, proposalID uint, reviewerID uint) (*EventSettlement, error) {
+	proposal, err := pendingProposal(tx, proposalID)
+	if err != nil {
+		return nil, err
+	}
+
+	note := "Resultado de " + proposal.Provider
+	settlement, err := SettleEvent(tx, proposal.EventID, competitorResults(proposal.Results), note)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := tx.Model(proposal).Updates(map[string]interface{}{
+		"status":      models.ProposalStatusApproved,
+		"reviewed_by": reviewerID,
+		"reviewed_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return settlement, nil
+}
+
+// RejectProposal descarta la propuesta sin tocar el evento
+func RejectProposal(db *gorm.DB, proposalID uint, reviewerID uint, note string) (*models.ResultProposal, error) {
+	proposal, err := pendingProposal(db, proposalID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare-and-set: si otra ruta ya aprobó o liquidó la propuesta, no se pisa su estado
+	now := time.Now()
+	result := db.Model(proposal).
+		Where("status = ?", models.ProposalStatusPending).
+		Updates(map[string]interface{}{
+			"status":      models.ProposalStatusRejected,
+			"reviewed_by": reviewerID,
+			"reviewed_at": now,
+			"note":        note,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrProposalNotPending
+	}
+	proposal.Status = models.ProposalStatusRejected
+	proposal.ReviewedBy = &reviewerID
+	proposal.ReviewedAt = &now
+	proposal.Note = note
+	return proposal, nil
+}
+
+func pendingProposal(db *gorm.DB, proposalID uint) (*models.ResultProposal, error) {
+	var proposal models.ResultProposal
+	if err := db.First(&proposal, proposalID).Error; err != nil {
+		return nil, err
+	}
+	if proposal.Status != models.ProposalStatusPending {
+		return nil, ErrProposalNotPending
+	}
+	return &proposal, nil
+}
+
+func competitorResults(results models.ProposedResults) []dtos.CompetitorResult {
+	converted := make([]dtos.CompetitorResult, 0, len(results))
+	for _, r := range results {
+		converted = append(converted, dtos.CompetitorResult{CompetitorID: r.CompetitorID, FinalScore: r.FinalScore, Position: r.Position})
+	}
+	return converted
+}
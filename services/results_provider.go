@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+)
+
+// ErrResultNotAvailable indica que el proveedor aún no tiene el resultado final del evento
+var ErrResultNotAvailable = errors.New("resultado no disponible")
+
+// ProviderResult es el resultado de un evento según un proveedor externo
+type ProviderResult struct {
+	ExternalID  string                     `json:"external_id"`
+	Final       bool                       `json:"final"`            // Solo los resultados finales se proponen
+	Period      string                     `json:"period,omitempty"` // Periodo en curso si aún no es final
+	Competitors []ProviderCompetitorResult `json:"competitors"`
+}
+
+// ProviderCompetitorResult identifica al competidor por nombre o número, como lo publica el proveedor
+type ProviderCompetitorResult struct {
+	Name       string `json:"name"`
+	Number     int    `json:"number"`
+	FinalScore int    `json:"final_score"`
+	Position   int    `json:"position"`
+}
+
+// ResultsProvider obtiene resultados de eventos por su ID externo
+type ResultsProvider interface {
+	// Name identifica al proveedor en los mapeos (EventProviderMapping.Provider)
+	Name() string
+	// FetchResult devuelve el resultado final o parcial (Final=false) del evento, o
+	// ErrResultNotAvailable si el proveedor aún no tiene datos
+	FetchResult(ctx context.Context, externalID string) (*ProviderResult, error)
+}
+
+// FileResultsProvider lee resultados de un archivo JSON local con la forma
+// {"<external_id>": {"final": true, "competitors": [...]}}. Se relee en cada consulta.
+type FileResultsProvider struct {
+	name string
+	path string
+}
+
+// NewFileResultsProvider crea un proveedor basado en archivo
+func NewFileResultsProvider(name, path string) *FileResultsProvider {
+	return &FileResultsProvider{name: name, path: path}
+}
+
+func (p *FileResultsProvider) Name() string {
+	return p.name
+}
+
+func (p *FileResultsProvider) FetchResult(ctx context.Context, externalID string) (*ProviderResult, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var results map[string]ProviderResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("archivo de resultados inválido: %w", err)
+	}
+
+	result, ok := results[externalID]
+	if !ok {
+		return nil, ErrResultNotAvailable
+	}
+	result.ExternalID = externalID
+	return &result, nil
+}
+
+// HTTPResultsProvider consulta GET {baseURL}/events/{external_id}, que responde un ProviderResult
+// en JSON (final o parcial) o 404 si el evento aún no tiene datos.
+type HTTPResultsProvider struct {
+	name    string
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPResultsProvider crea un proveedor HTTP con un timeout razonable por consulta
+func NewHTTPResultsProvider(name, baseURL string) *HTTPResultsProvider {
+	return &HTTPResultsProvider{
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *HTTPResultsProvider) Name() string {
+	return p.name
+}
+
+func (p *HTTPResultsProvider) FetchResult(ctx context.Context, externalID string) (*ProviderResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/events/"+url.PathEscape(externalID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrResultNotAvailable
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("proveedor %s respondió %d", p.name, resp.StatusCode)
+	}
+
+	var result ProviderResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("respuesta inválida del proveedor %s: %w", p.name, err)
+	}
+	result.ExternalID = externalID
+	return &result, nil
+}
+
+// mapProviderResult traduce los competidores del proveedor a los competidores del evento
+// (por nombre, sin distinguir mayúsculas, o por número asignado)
+func mapProviderResult(event *models.Event, result *ProviderResult) (models.ProposedResults, error) {
+	mapped := make(models.ProposedResults, 0, len(result.Competitors))
+	used := make(map[uint]bool)
+
+	for _, pc := range result.Competitors {
+		var match *models.EventCompetitor
+		for i := range event.Competitors {
+			comp := &event.Competitors[i]
+			if (pc.Name != "" && strings.EqualFold(comp.Name, pc.Name)) ||
+				(pc.Name == "" && pc.Number != 0 && comp.AssignedNumber == pc.Number) {
+				match = comp
+				break
+			}
+		}
+		if match == nil {
+			return nil, fmt.Errorf("competidor %q (#%d) no existe en el evento %s", pc.Name, pc.Number, event.Name)
+		}
+		if used[match.ID] {
+			return nil, fmt.Errorf("competidor %s repetido en el resultado", match.Name)
+		}
+		used[match.ID] = true
+		mapped = append(mapped, models.ProposedResult{CompetitorID: match.ID, FinalScore: pc.FinalScore, Position: pc.Position})
+	}
+
+	if len(mapped) == 0 {
+		return nil, fmt.Errorf("el resultado del evento %s no trae competidores", event.Name)
+	}
+	return mapped, nil
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// schedulerLeaseName identifica el candado compartido por todas las réplicas
+const schedulerLeaseName = "scheduler"
+
+// Scheduler ejecuta periódicamente las transiciones automáticas de torneos, sesiones y eventos.
+// Solo la réplica que posee el candado (JobLease) ejecuta los trabajos en cada ciclo.
+type Scheduler struct {
+	db       *gorm.DB
+	interval time.Duration
+	leaseTTL time.Duration
+	owner    string
+}
+
+// NewScheduler crea un scheduler que se ejecuta cada interval
+func NewScheduler(db *gorm.DB, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		db:       db,
+		interval: interval,
+		// El candado dura varios ciclos para tolerar retrasos, pero expira si la réplica muere
+		leaseTTL: 3 * interval,
+		owner:    leaseOwner(),
+	}
+}
+
+// leaseOwner identifica a esta réplica como dueña de los candados
+func leaseOwner() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano())
+}
+
+// Start lanza el scheduler en segundo plano hasta que ctx sea cancelado
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			if s.acquireLease(time.Now()) {
+				s.RunOnce(time.Now())
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce ejecuta todos los trabajos tomando now como hora actual.
+// El orden importa: el torneo debe estar en juego antes de abrir sus sesiones.
+func (s *Scheduler) RunOnce(now time.Time) {
+	s.startDueTournaments(now)
+	s.openDueSessions(now)
+	s.closeDueSessions(now)
+	s.markEventsLive(now)
+	s.settleCompletedSessions()
+	s.closeDueTournaments(now)
+	SendSessionReminders(s.db, now)
+}
+
+// acquireLease toma o renueva el candado del scheduler. Devuelve false si otra réplica lo posee.
+func (s *Scheduler) acquireLease(now time.Time) bool {
+	return acquireJobLease(s.db, schedulerLeaseName, s.owner, s.leaseTTL, now)
+}
+
+// acquireJobLease toma o renueva el candado name para owner. Devuelve false si otra réplica lo posee.
+func acquireJobLease(db *gorm.DB, name, owner string, ttl time.Duration, now time.Time) bool {
+	expiresAt := now.Add(ttl)
+
+	result := db.Model(&models.JobLease{}).
+		Where("name = ? AND (owner = ? OR expires_at < ?)", name, owner, now).
+		Updates(map[string]interface{}{"owner": owner, "expires_at": expiresAt})
+	if result.Error == nil && result.RowsAffected > 0 {
+		return true
+	}
+
+	var count int64
+	db.Model(&models.JobLease{}).Where("name = ?", name).Count(&count)
+	if count > 0 {
+		return false
+	}
+
+	// Primera ejecución: el índice único resuelve la carrera entre réplicas
+	lease := models.JobLease{Name: name, Owner: owner, ExpiresAt: expiresAt}
+	return db.Create(&lease).Error == nil
+}
+
+// startDueTournaments pasa a running los torneos abiertos cuya fecha de inicio ya llegó
+func (s *Scheduler) startDueTournaments(now time.Time) {
+	var tournaments []models.Tournament
+	s.db.Where("status = ? AND start_date <= ?", models.TournamentStatusOpen, now).Find(&tournaments)
+
+	for i := range tournaments {
+		s.transitionTournament(&tournaments[i], models.TournamentStatusRunning)
+	}
+}
+
+// closeDueTournaments cierra los torneos en juego cuya fecha de fin ya pasó.
+// Los que aún tienen sesiones por jugar en su cartilla se reintentan en el siguiente ciclo.
+func (s *Scheduler) closeDueTournaments(now time.Time) {
+	var tournaments []models.Tournament
+	s.db.Where("status = ? AND end_date <= ?", models.TournamentStatusRunning, now).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM tournament_sessions ts
+			WHERE ts.tournament_id = COALESCE(tournaments.parent_tournament_id, tournaments.id)
+			AND ts.deleted_at IS NULL AND ts.status IN ?
+		)`, []string{models.SessionStatusScheduled, models.SessionStatusOpen}).
+		Find(&tournaments)
+
+	for i := range tournaments {
+		s.transitionTournament(&tournaments[i], models.TournamentStatusClosed)
+	}
+}
+
+// openDueSessions abre para picks las sesiones programadas cuya hora de inicio ya llegó
+func (s *Scheduler) openDueSessions(now time.Time) {
+	var sessions []models.Session
+	s.db.Where("status = ? AND start_time <= ?", models.SessionStatusScheduled, now).Find(&sessions)
+
+	for i := range sessions {
+		s.transitionSession(&sessions[i], models.SessionStatusOpen)
+	}
+}
+
+// closeDueSessions cierra las sesiones abiertas cuya hora límite ya pasó
+func (s *Scheduler) closeDueSessions(now time.Time) {
+	var sessions []models.Session
+	s.db.Where("status = ? AND end_time <= ?", models.SessionStatusOpen, now).Find(&sessions)
+
+	for i := range sessions {
+		s.transitionSession(&sessions[i], models.SessionStatusClosed)
+	}
+}
+
+// settleCompletedSessions liquida las sesiones cerradas cuyos eventos ya terminaron todos. Una
+// sesión sin eventos, o con todos cancelados, no tiene nada que liquidar y queda para el admin.
+func (s *Scheduler) settleCompletedSessions() {
+	var sessions []models.Session
+	s.db.Where("status = ?", models.SessionStatusClosed).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM tournament_events te
+			JOIN events e ON e.id = te.event_id
+			WHERE te.session_id = tournament_sessions.id
+			AND te.deleted_at IS NULL AND e.status NOT IN ?
+		)`, []string{"completed", "cancelled"}).
+		Where(`EXISTS (
+			SELECT 1 FROM tournament_events te
+			JOIN events e ON e.id = te.event_id
+			WHERE te.session_id = tournament_sessions.id
+			AND te.deleted_at IS NULL AND e.status = ?
+		)`, "completed").
+		Find(&sessions)
+
+	for i := range sessions {
+		s.transitionSession(&sessions[i], models.SessionStatusSettled)
+	}
+}
+
+// markEventsLive marca en vivo los eventos programados que ya comenzaron
+func (s *Scheduler) markEventsLive(now time.Time) {
+	var events []models.Event
+	if err := s.db.Where("status = ? AND start_time <= ?", "scheduled", now).Find(&events).Error; err != nil || len(events) == 0 {
+		return
+	}
+
+	ids := make([]uint, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	result := s.db.Model(&models.Event{}).
+		Where("id IN ? AND status = ?", ids, "scheduled").
+		Update("status", "live")
+	if result.Error != nil {
+		log.Printf("⚠️  Scheduler: error al marcar eventos en vivo: %v", result.Error)
+		return
+	}
+
+	for i := range events {
+		events[i].Status = "live"
+		PublishEventStatus(s.db, &events[i])
+	}
+}
+
+func (s *Scheduler) transitionTournament(tournament *models.Tournament, to string) {
+	tx := s.db.Begin()
+	if err := TransitionTournament(tx, tournament, to, nil, "automático"); err != nil {
+		tx.Rollback()
+		log.Printf("Scheduler: torneo %d no pasó a %s: %v", tournament.ID, to, err)
+		return
+	}
+	tx.Commit()
+}
+
+func (s *Scheduler) transitionSession(session *models.Session, to string) {
+	tx := s.db.Begin()
+	if err := TransitionSession(tx, session, to, nil, "automático"); err != nil {
+		tx.Rollback()
+		log.Printf("Scheduler: sesión %d no pasó a %s: %v", session.ID, to, err)
+		return
+	}
+	tx.Commit()
+	PublishSessionStatus(s.db, session)
+	EmitSessionOpened(s.db, session)
+}
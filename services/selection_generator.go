@@ -0,0 +1,234 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// StandardSelectionTypes es el conjunto estándar generado cuando la categoría no define sus tipos
+var StandardSelectionTypes = []string{
+	models.SelectionTypeMacho,
+	models.SelectionTypeHembra,
+	models.SelectionTypeMachoRL,
+	models.SelectionTypeHembraRL,
+	models.SelectionTypeMachoSRL,
+	models.SelectionTypeHembraSRL,
+	models.SelectionTypeAlta,
+	models.SelectionTypeBaja,
+	models.SelectionTypeSuperAlta,
+	models.SelectionTypeSuperBaja,
+	models.SelectionTypeEmpate,
+}
+
+// GenerateOptions configura la generación de selecciones estándar
+type GenerateOptions struct {
+	SelectionTypes []string       // Tipos a generar (por defecto StandardSelectionTypes)
+	SuperLine      float64        // Super línea de la sesión (0 = no genera super alta/baja)
+	PointsByType   map[string]int // Puntos por tipo (ej: settings del torneo)
+	DefaultPoints  int            // Puntos si el tipo no está en PointsByType
+	PointsForPush  int
+	ChangedBy      *uint
+}
+
+// GenerateResult resume qué selecciones se crearon, actualizaron o ya estaban al día
+type GenerateResult struct {
+	Created   []models.PickableSelection `json:"created"`
+	Updated   []models.PickableSelection `json:"updated"`
+	Unchanged int                        `json:"unchanged"`
+	Skipped   []string                   `json:"skipped,omitempty"` // Tipos sin datos suficientes (ej: sin runline)
+}
+
+// GenerateStandardSelections crea el set estándar de selecciones del evento a partir de sus
+// competidores y líneas. Es idempotente: una selección existente del mismo tipo y competidor se
+// actualiza (si sigue pendiente y cambió) en lugar de duplicarse. Requiere event.Competitors.
+func GenerateStandardSelections(tx *gorm.DB, event *models.Event, opts GenerateOptions) (*GenerateResult, error) {
+	types := opts.SelectionTypes
+	if len(types) == 0 {
+		types = StandardSelectionTypes
+	}
+
+	favorite, underdog := splitFavorite(event.Competitors)
+
+	var existing []models.PickableSelection
+	if err := tx.Where("event_id = ?", event.ID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	result := &GenerateResult{Created: []models.PickableSelection{}, Updated: []models.PickableSelection{}}
+	for _, selectionType := range types {
+		candidate, ok := buildSelection(event, selectionType, favorite, underdog, opts.SuperLine)
+		if !ok {
+			result.Skipped = append(result.Skipped, selectionType)
+			continue
+		}
+
+		candidate.PointsForWin = opts.DefaultPoints
+		if points, found := opts.PointsByType[selectionType]; found {
+			candidate.PointsForWin = points
+		}
+		candidate.PointsForPush = opts.PointsForPush
+
+		current := findGenerated(existing, candidate)
+		if current == nil {
+			if err := tx.Create(&candidate).Error; err != nil {
+				return nil, err
+			}
+			result.Created = append(result.Created, candidate)
+			continue
+		}
+
+		// Selecciones liquidadas o sin cambios se dejan como están
+		if current.Status != "pending" || sameGenerated(current, &candidate) {
+			result.Unchanged++
+			continue
+		}
+
+		previous := *current
+		if err := tx.Model(current).Updates(map[string]interface{}{
+			"description":      candidate.Description,
+			"line":             candidate.Line,
+			"odds":             candidate.Odds,
+			"runline_home":     candidate.RunlineHome,
+			"runline_away":     candidate.RunlineAway,
+			"is_super_runline": candidate.IsSuperRunline,
+		}).Error; err != nil {
+			return nil, err
+		}
+		if err := RecordLineChanges(tx, event.ID, opts.ChangedBy,
+			LineChange{EntityType: models.LineEntitySelection, EntityID: current.ID, Field: models.LineFieldLine, OldValue: previous.Line, NewValue: candidate.Line},
+			LineChange{EntityType: models.LineEntitySelection, EntityID: current.ID, Field: models.LineFieldOdds, OldValue: float64(previous.Odds), NewValue: float64(candidate.Odds)},
+		); err != nil {
+			return nil, err
+		}
+		result.Updated = append(result.Updated, *current)
+	}
+
+	return result, nil
+}
+
+// splitFavorite identifica favorito y no favorito en un evento de dos competidores.
+// Sin marca IsFavorite, el favorito es el de cuota más baja.
+func splitFavorite(competitors []models.EventCompetitor) (*models.EventCompetitor, *models.EventCompetitor) {
+	if len(competitors) != 2 {
+		return nil, nil
+	}
+	a, b := &competitors[0], &competitors[1]
+	switch {
+	case a.IsFavorite && !b.IsFavorite:
+		return a, b
+	case b.IsFavorite && !a.IsFavorite:
+		return b, a
+	case b.Odds < a.Odds:
+		return b, a
+	}
+	return a, b
+}
+
+// buildSelection arma la selección de un tipo. En runline y super runline, Line guarda el
+// hándicap aplicado al competidor de la selección. Devuelve false si faltan datos.
+func buildSelection(event *models.Event, selectionType string, favorite, underdog *models.EventCompetitor, superLine float64) (models.PickableSelection, bool) {
+	selection := models.PickableSelection{
+		EventID:       event.ID,
+		SelectionType: selectionType,
+		Status:        "pending",
+	}
+
+	competitorSelection := func(label string, competitor *models.EventCompetitor, handicap float64, super bool) (models.PickableSelection, bool) {
+		if competitor == nil {
+			return selection, false
+		}
+		id := competitor.ID
+		selection.CompetitorID = &id
+		selection.Odds = competitor.Odds
+		selection.Description = fmt.Sprintf("%s: %s", label, competitor.Name)
+		if handicap != 0 {
+			selection.Line = handicap
+			selection.RunlineHome = handicap
+			selection.RunlineAway = -handicap
+			selection.IsSuperRunline = super
+			selection.Description = fmt.Sprintf("%s: %s (%+.1f)", label, competitor.Name, handicap)
+		}
+		return selection, true
+	}
+
+	switch selectionType {
+	case models.SelectionTypeMacho:
+		return competitorSelection("Macho", favorite, 0, false)
+	case models.SelectionTypeHembra:
+		return competitorSelection("Hembra", underdog, 0, false)
+	case models.SelectionTypeMachoRL:
+		if favorite == nil || favorite.Runline == 0 {
+			return selection, false
+		}
+		return competitorSelection("Macho RL", favorite, favorite.Runline, false)
+	case models.SelectionTypeHembraRL:
+		if underdog == nil || underdog.Runline == 0 {
+			return selection, false
+		}
+		return competitorSelection("Hembra RL", underdog, underdog.Runline, false)
+	case models.SelectionTypeMachoSRL:
+		if favorite == nil || favorite.SuperRunline == 0 {
+			return selection, false
+		}
+		return competitorSelection("Macho SRL", favorite, favorite.SuperRunline, true)
+	case models.SelectionTypeHembraSRL:
+		if underdog == nil || underdog.SuperRunline == 0 {
+			return selection, false
+		}
+		return competitorSelection("Hembra SRL", underdog, underdog.SuperRunline, true)
+	case models.SelectionTypeAlta, models.SelectionTypeBaja:
+		if event.Line <= 0 {
+			return selection, false
+		}
+		label := "Alta"
+		if selectionType == models.SelectionTypeBaja {
+			label = "Baja"
+		}
+		selection.Line = event.Line
+		selection.Description = fmt.Sprintf("%s %.1f", label, event.Line)
+		return selection, true
+	case models.SelectionTypeSuperAlta, models.SelectionTypeSuperBaja:
+		if superLine <= 0 {
+			return selection, false
+		}
+		label := "Super Alta"
+		if selectionType == models.SelectionTypeSuperBaja {
+			label = "Super Baja"
+		}
+		selection.Line = superLine
+		selection.Description = fmt.Sprintf("%s %.1f", label, superLine)
+		return selection, true
+	case models.SelectionTypeEmpate:
+		if favorite == nil {
+			return selection, false
+		}
+		selection.Description = fmt.Sprintf("Empate: %s vs %s", favorite.Name, underdog.Name)
+		return selection, true
+	}
+
+	return selection, false
+}
+
+// findGenerated busca una selección existente con el mismo tipo y competidor
+func findGenerated(existing []models.PickableSelection, candidate models.PickableSelection) *models.PickableSelection {
+	for i := range existing {
+		if existing[i].SelectionType != candidate.SelectionType {
+			continue
+		}
+		if (existing[i].CompetitorID == nil) != (candidate.CompetitorID == nil) {
+			continue
+		}
+		if candidate.CompetitorID != nil && *existing[i].CompetitorID != *candidate.CompetitorID {
+			continue
+		}
+		return &existing[i]
+	}
+	return nil
+}
+
+func sameGenerated(a, b *models.PickableSelection) bool {
+	return a.Description == b.Description && a.Line == b.Line && a.Odds == b.Odds &&
+		a.RunlineHome == b.RunlineHome && a.RunlineAway == b.RunlineAway && a.IsSuperRunline == b.IsSuperRunline
+}
@@ -0,0 +1,506 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEventNotFound       = errors.New("evento no encontrado")
+	ErrEventAlreadySettled = errors.New("este evento ya ha sido liquidado")
+	ErrInvalidResults      = errors.New("resultados inválidos")
+)
+
+// DefaultResultNote se usa cuando el admin no escribe una nota (el evento exige una al completarse)
+const DefaultResultNote = "Resultado oficial"
+
+// EventSettlement resume la liquidación de un evento
+type EventSettlement struct {
+	EventID       uint   `json:"event_id"`
+	EventName     string `json:"event_name"`
+	TotalScore    int    `json:"total_score"`
+	SelectionsWon int    `json:"selections_won"`
+	PicksSettled  int    `json:"picks_settled"`
+
+	// Puntos otorgados por participante en este evento
+	Awards map[uint]*ParticipantAward `json:"-"`
+}
+
+// ParticipantAward acumula los puntos ganados por un participante durante la liquidación
+type ParticipantAward struct {
+	ParticipantID uint   `json:"participant_id"`
+	TournamentID  uint   `json:"tournament_id"`
+	UserID        uint   `json:"user_id"`
+	Username      string `json:"username,omitempty"`
+	PicksWon      int    `json:"picks_won"`
+	PicksPush     int    `json:"picks_push"`
+	PicksLost     int    `json:"picks_lost"`
+	Points        int    `json:"points"`
+}
+
+// ValidateEventResults verifica que el evento pueda liquidarse con los resultados recibidos:
+// que no esté liquidado, que cada competidor pertenezca al evento y que no se repitan.
+// Requiere event.Competitors.
+func ValidateEventResults(event *models.Event, results []dtos.CompetitorResult) error {
+	if event.Status == "completed" {
+		return ErrEventAlreadySettled
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("%w: no hay resultados", ErrInvalidResults)
+	}
+
+	belongs := make(map[uint]bool, len(event.Competitors))
+	for _, comp := range event.Competitors {
+		belongs[comp.ID] = true
+	}
+	seen := make(map[uint]bool, len(results))
+	for _, res := range results {
+		if !belongs[res.CompetitorID] {
+			return fmt.Errorf("%w: el competidor %d no pertenece al evento", ErrInvalidResults, res.CompetitorID)
+		}
+		if seen[res.CompetitorID] {
+			return fmt.Errorf("%w: el competidor %d está repetido", ErrInvalidResults, res.CompetitorID)
+		}
+		seen[res.CompetitorID] = true
+		if res.FinalScore < 0 || res.Position < 0 {
+			return fmt.Errorf("%w: marcador o posición negativos", ErrInvalidResults)
+		}
+	}
+	return nil
+}
+
+// SettleEvent registra los resultados del evento, evalúa sus selecciones, liquida los picks
+// contra la línea y cuota con que se hicieron, suma los puntos a los participantes y marca
+// el evento como completado. Todo ocurre dentro de tx.
+func SettleEvent(tx *gorm.DB, eventID uint, results []dtos.CompetitorResult, resultNote string) (*EventSettlement, error) {
+	// 1. Cargar Evento y sus relaciones
+	var event models.Event
+	if err := tx.Preload("Competitors").Preload("PickableSelections").First(&event, eventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+
+	if err := ValidateEventResults(&event, results); err != nil {
+		return nil, err
+	}
+
+	if resultNote == "" {
+		resultNote = DefaultResultNote
+	}
+	totalScore := 0
+	for _, res := range results {
+		totalScore += res.FinalScore
+	}
+
+	// 2. Reclamar el evento con compare-and-set: solo una liquidación concurrente
+	// (manual, masiva, propuesta aprobada o auto-liquidación) llega a sumar puntos
+	claim := tx.Model(&models.Event{}).
+		Where("id = ? AND status NOT IN ?", event.ID, []string{"completed", "cancelled"}).
+		UpdateColumns(map[string]interface{}{
+			"status":      "completed",
+			"total_score": float64(totalScore),
+			"result_note": resultNote,
+			"updated_at":  time.Now(),
+		})
+	if claim.Error != nil {
+		return nil, claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		return nil, ErrEventAlreadySettled
+	}
+	event.Status = "completed"
+	event.TotalScore = float64(totalScore)
+	event.ResultNote = resultNote
+
+	settlement := &EventSettlement{EventID: event.ID, EventName: event.Name, Awards: make(map[uint]*ParticipantAward)}
+
+	// 3. Actualizar resultados en los competidores del evento
+	competitorResults := make(map[uint]dtos.CompetitorResult)
+	for _, res := range results {
+		competitorResults[res.CompetitorID] = res
+		err := tx.Model(&models.EventCompetitor{}).Where("event_id = ? AND id = ?", event.ID, res.CompetitorID).Updates(map[string]interface{}{
+			"final_score": res.FinalScore,
+			"position":    res.Position,
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	settlement.TotalScore = totalScore
+
+	// 4. Evaluar cada 'PickableSelection' del evento con su línea actual
+	for _, selection := range event.PickableSelections {
+		status, _ := gradeSelection(&event, &selection, selection.Line, totalScore, competitorResults)
+		if status == "won" {
+			settlement.SelectionsWon++
+		}
+
+		// Actualizar el estado de la PickableSelection
+		if err := tx.Model(&selection).Update("status", status).Error; err != nil {
+			return nil, err
+		}
+
+		// 5. Liquidar los UserPicks de esta selección contra la línea y cuota con que se hicieron
+		if err := settleSelectionPicks(tx, &event, &selection, totalScore, competitorResults, settlement); err != nil {
+			return nil, err
+		}
+	}
+
+	// Las propuestas de proveedores aún pendientes quedan reemplazadas por este resultado
+	if err := tx.Model(&models.ResultProposal{}).
+		Where("event_id = ? AND status = ?", event.ID, models.ProposalStatusPending).
+		Update("status", models.ProposalStatusSuperseded).Error; err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+// gradeSelection evalúa una selección contra el resultado usando la línea indicada.
+// Devuelve el estado (won, lost, push) y los puntos fijos de la selección.
+func gradeSelection(event *models.Event, selection *models.PickableSelection, line float64, totalScore int, competitorResults map[uint]dtos.CompetitorResult) (string, int) {
+	status := "lost" // Por defecto, una selección es perdedora
+	points := 0
+
+	switch selection.SelectionType {
+	case "alta", "super_alta":
+		if float64(totalScore) > line {
+			status = "won"
+			points = selection.PointsForWin
+		} else if float64(totalScore) == line {
+			status = "push"
+			points = selection.PointsForPush
+		}
+	case "baja", "super_baja":
+		if float64(totalScore) < line {
+			status = "won"
+			points = selection.PointsForWin
+		} else if float64(totalScore) == line {
+			status = "push"
+			points = selection.PointsForPush
+		}
+	case "macho", "hembra", "ganador": // 'macho' y 'hembra' son terminos para favorito/no favorito
+		if selection.CompetitorID != nil {
+			if result, ok := competitorResults[*selection.CompetitorID]; ok {
+				// Asumimos que el ganador es el que tiene la posición 1 o el score más alto en un vs
+				if result.Position == 1 { // Ideal para carreras
+					status = "won"
+					points = selection.PointsForWin
+				}
+			}
+		}
+	case "macho_rl", "hembra_rl", "macho_srl", "hembra_srl":
+		// Runline: al score del competidor se le suma su hándicap (Line) y se compara con el rival
+		if selection.CompetitorID != nil && len(event.Competitors) == 2 {
+			handicap := line
+			if handicap == 0 {
+				handicap = selection.RunlineHome
+			}
+			rivalID := event.Competitors[0].ID
+			if rivalID == *selection.CompetitorID {
+				rivalID = event.Competitors[1].ID
+			}
+			margin := float64(competitorResults[*selection.CompetitorID].FinalScore) + handicap -
+				float64(competitorResults[rivalID].FinalScore)
+			if margin > 0 {
+				status = "won"
+				points = selection.PointsForWin
+			} else if margin == 0 {
+				status = "push"
+				points = selection.PointsForPush
+			}
+		}
+	case "empate":
+		if len(event.Competitors) == 2 {
+			c1Score := competitorResults[event.Competitors[0].ID].FinalScore
+			c2Score := competitorResults[event.Competitors[1].ID].FinalScore
+			if c1Score == c2Score {
+				status = "won"
+				points = selection.PointsForWin
+			}
+		}
+	}
+
+	return status, points
+}
+
+// settleSelectionPicks liquida cada UserPick de la selección contra la línea y la cuota guardadas
+// al hacer el pick, y suma los puntos al participante. Los aciertos se puntúan según el modo
+// de puntuación del torneo de cada participante.
+func settleSelectionPicks(tx *gorm.DB, event *models.Event, selection *models.PickableSelection, totalScore int, competitorResults map[uint]dtos.CompetitorResult, settlement *EventSettlement) error {
+	var picks []models.UserPick
+	if err := tx.Preload("Participant").Where("selection_id = ?", selection.ID).Find(&picks).Error; err != nil {
+		return err
+	}
+
+	tournaments := make(map[uint]*models.Tournament)
+	for _, pick := range picks {
+		line := selection.Line
+		if pick.LineAtPick != nil {
+			line = *pick.LineAtPick
+		}
+		status, points := gradeSelection(event, selection, line, totalScore, competitorResults)
+
+		if status == "won" {
+			tournament, ok := tournaments[pick.Participant.TournamentID]
+			if !ok {
+				tournament = &models.Tournament{}
+				if err := tx.First(tournament, pick.Participant.TournamentID).Error; err != nil {
+					return err
+				}
+				tournaments[tournament.ID] = tournament
+			}
+
+			odds := selection.Odds
+			if pick.OddsAtPick != nil {
+				odds = *pick.OddsAtPick
+			}
+			points = tournament.Settings.WinPointsAtOdds(selection, odds)
+		}
+
+		if err := tx.Model(&pick).Updates(map[string]interface{}{"status": status, "awarded_points": points}).Error; err != nil {
+			return err
+		}
+
+		if points > 0 {
+			if err := tx.Model(&models.TournamentParticipant{}).
+				Where("id = ?", pick.ParticipantID).
+				Update("total_points", gorm.Expr("total_points + ?", points)).Error; err != nil {
+				return err
+			}
+		}
+
+		award, ok := settlement.Awards[pick.ParticipantID]
+		if !ok {
+			award = &ParticipantAward{ParticipantID: pick.ParticipantID, TournamentID: pick.Participant.TournamentID, UserID: pick.Participant.UserID}
+			settlement.Awards[pick.ParticipantID] = award
+		}
+		switch status {
+		case "won":
+			award.PicksWon++
+		case "push":
+			award.PicksPush++
+		default:
+			award.PicksLost++
+		}
+		award.Points += points
+		settlement.PicksSettled++
+	}
+
+	return nil
+}
+
+// SessionSettlement es el reporte consolidado de la liquidación masiva de una sesión
+type SessionSettlement struct {
+	SessionID    uint               `json:"session_id"`
+	Events       []*EventSettlement `json:"events"`
+	Participants []ParticipantAward `json:"participants"` // Ordenados por puntos otorgados
+}
+
+// SettleSessionResults valida los resultados de todos los eventos y, solo si todos son válidos,
+// los liquida en el orden de la sesión dentro de tx. Devuelve los errores de validación por
+// evento; cualquier error al liquidar se devuelve como error para que el llamador revierta todo.
+func SettleSessionResults(tx *gorm.DB, sessionID uint, inputs []dtos.EventResultInput) (*SessionSettlement, []dtos.ImportRowError, error) {
+	var tournamentEvents []models.TournamentEvent
+	if err := tx.Where("session_id = ?", sessionID).Find(&tournamentEvents).Error; err != nil {
+		return nil, nil, err
+	}
+	order := make(map[uint]int, len(tournamentEvents))
+	for _, te := range tournamentEvents {
+		order[te.EventID] = te.Order
+	}
+
+	eventIDs := make([]uint, 0, len(inputs))
+	for _, input := range inputs {
+		eventIDs = append(eventIDs, input.EventID)
+	}
+	var events []models.Event
+	if err := tx.Preload("Competitors").Where("id IN ?", eventIDs).Find(&events).Error; err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uint]*models.Event, len(events))
+	for i := range events {
+		byID[events[i].ID] = &events[i]
+	}
+
+	// 1. Validar todo antes de tocar nada
+	var errs []dtos.ImportRowError
+	seen := make(map[uint]bool)
+	for i, input := range inputs {
+		fail := func(name, message string) {
+			errs = append(errs, dtos.ImportRowError{Row: i + 1, Event: name, Field: "event_id", Message: message})
+		}
+		event, ok := byID[input.EventID]
+		switch {
+		case !ok:
+			fail("", fmt.Sprintf("el evento %d no existe", input.EventID))
+		case seen[input.EventID]:
+			fail(event.Name, "evento repetido en la carga")
+		default:
+			if _, inSession := order[input.EventID]; !inSession {
+				fail(event.Name, "el evento no pertenece a la sesión")
+			} else if err := ValidateEventResults(event, input.Results); err != nil {
+				fail(event.Name, err.Error())
+			}
+		}
+		seen[input.EventID] = true
+	}
+	if len(errs) > 0 {
+		return nil, errs, nil
+	}
+
+	// 2. Liquidar en el orden de la sesión (orden, hora de inicio, ID)
+	sorted := append([]dtos.EventResultInput(nil), inputs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := byID[sorted[i].EventID], byID[sorted[j].EventID]
+		if order[a.ID] != order[b.ID] {
+			return order[a.ID] < order[b.ID]
+		}
+		if !a.StartTime.Equal(b.StartTime) {
+			return a.StartTime.Before(b.StartTime)
+		}
+		return a.ID < b.ID
+	})
+
+	report := &SessionSettlement{SessionID: sessionID, Events: []*EventSettlement{}, Participants: []ParticipantAward{}}
+	totals := make(map[uint]*ParticipantAward)
+	for _, input := range sorted {
+		settlement, err := SettleEvent(tx, input.EventID, input.Results, input.ResultNote)
+		if err != nil {
+			return nil, nil, fmt.Errorf("evento %s: %w", byID[input.EventID].Name, err)
+		}
+		report.Events = append(report.Events, settlement)
+
+		for participantID, award := range settlement.Awards {
+			total, ok := totals[participantID]
+			if !ok {
+				total = &ParticipantAward{ParticipantID: award.ParticipantID, TournamentID: award.TournamentID, UserID: award.UserID}
+				totals[participantID] = total
+			}
+			total.PicksWon += award.PicksWon
+			total.PicksPush += award.PicksPush
+			total.PicksLost += award.PicksLost
+			total.Points += award.Points
+		}
+	}
+
+	// 3. Consolidar puntos por participante con su nombre de usuario
+	userIDs := make([]uint, 0, len(totals))
+	for _, total := range totals {
+		userIDs = append(userIDs, total.UserID)
+	}
+	usernames := make(map[uint]string)
+	if len(userIDs) > 0 {
+		var users []models.User
+		if err := tx.Select("id", "username").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, user := range users {
+			usernames[user.ID] = user.Username
+		}
+	}
+	for _, total := range totals {
+		total.Username = usernames[total.UserID]
+		report.Participants = append(report.Participants, *total)
+	}
+	sort.Slice(report.Participants, func(i, j int) bool {
+		if report.Participants[i].Points != report.Participants[j].Points {
+			return report.Participants[i].Points > report.Participants[j].Points
+		}
+		return report.Participants[i].ParticipantID < report.Participants[j].ParticipantID
+	})
+
+	return report, nil, nil
+}
+
+// ParseSessionResultsCSV convierte un CSV con columnas event_id, competitor_id, final_score,
+// position y result_note (una fila por competidor) en resultados agrupados por evento.
+func ParseSessionResultsCSV(r io.Reader) ([]dtos.EventResultInput, []dtos.ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("no se pudo leer la cabecera del CSV: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"event_id", "competitor_id"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("falta la columna %q", required)
+		}
+	}
+
+	var (
+		inputs  []dtos.EventResultInput
+		rowErrs []dtos.ImportRowError
+		byEvent = make(map[uint]int)
+	)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rowErrs = append(rowErrs, dtos.ImportRowError{Row: line, Message: err.Error()})
+			continue
+		}
+		get := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		eventID, errEvent := strconv.ParseUint(get("event_id"), 10, 32)
+		competitorID, errCompetitor := strconv.ParseUint(get("competitor_id"), 10, 32)
+		finalScore, okScore := parseIntColumn(get("final_score"))
+		position, okPosition := parseIntColumn(get("position"))
+		switch {
+		case errEvent != nil || eventID == 0:
+			rowErrs = append(rowErrs, dtos.ImportRowError{Row: line, Field: "event_id", Message: "event_id inválido"})
+			continue
+		case errCompetitor != nil || competitorID == 0:
+			rowErrs = append(rowErrs, dtos.ImportRowError{Row: line, Field: "competitor_id", Message: "competitor_id inválido"})
+			continue
+		case !okScore:
+			rowErrs = append(rowErrs, dtos.ImportRowError{Row: line, Field: "final_score", Message: "marcador inválido"})
+			continue
+		case !okPosition:
+			rowErrs = append(rowErrs, dtos.ImportRowError{Row: line, Field: "position", Message: "posición inválida"})
+			continue
+		}
+
+		index, ok := byEvent[uint(eventID)]
+		if !ok {
+			inputs = append(inputs, dtos.EventResultInput{EventID: uint(eventID)})
+			index = len(inputs) - 1
+			byEvent[uint(eventID)] = index
+		}
+		if note := get("result_note"); note != "" {
+			inputs[index].ResultNote = note
+		}
+		inputs[index].Results = append(inputs[index].Results, dtos.CompetitorResult{
+			CompetitorID: uint(competitorID),
+			FinalScore:   finalScore,
+			Position:     position,
+		})
+	}
+
+	return inputs, rowErrs, nil
+}
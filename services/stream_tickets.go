@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"gorm.io/gorm"
+)
+
+// StreamTicketTTL es el tiempo para abrir el flujo SSE con el ticket recién emitido
+const StreamTicketTTL = 30 * time.Second
+
+// ErrInvalidStreamTicket se devuelve si el ticket no existe, venció o ya se usó
+var ErrInvalidStreamTicket = errors.New("ticket de conexión inválido o vencido")
+
+// IssueStreamTicket emite un ticket de un solo uso para abrir /stream sin poner el JWT en la URL
+func IssueStreamTicket(db *gorm.DB, principal Principal, now time.Time) (string, error) {
+	raw, err := utils.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	ticket := models.StreamTicket{
+		UserID:       principal.UserID,
+		TokenHash:    utils.HashToken(raw),
+		TokenVersion: principal.TokenVersion,
+		ExpiresAt:    now.Add(StreamTicketTTL),
+	}
+	if err := db.Create(&ticket).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// RedeemStreamTicket consume el ticket y devuelve el usuario al que pertenece. Solo la primera
+// conexión que lo presenta lo consume.
+func RedeemStreamTicket(db *gorm.DB, raw string, now time.Time) (*models.StreamTicket, error) {
+	var ticket models.StreamTicket
+	if err := db.Where("token_hash = ?", utils.HashToken(raw)).First(&ticket).Error; err != nil {
+		return nil, ErrInvalidStreamTicket
+	}
+	if ticket.ConsumedAt != nil || !now.Before(ticket.ExpiresAt) {
+		return nil, ErrInvalidStreamTicket
+	}
+
+	result := db.Model(&models.StreamTicket{}).Where("id = ? AND consumed_at IS NULL", ticket.ID).
+		Update("consumed_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidStreamTicket
+	}
+	ticket.ConsumedAt = &now
+	return &ticket, nil
+}
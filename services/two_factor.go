@@ -0,0 +1,394 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	RecoveryCodeCount     = 10               // Códigos de recuperación por usuario
+	TwoFactorChallengeTTL = 5 * time.Minute  // Tiempo para enviar el código tras la contraseña
+	StepUpTTL             = 5 * time.Minute  // Vigencia de un step-up para la misma sesión
+	maxChallengeAttempts  = 5                // Códigos erróneos antes de invalidar el reto
+	maxSecondFactorFails  = 5                // Códigos erróneos seguidos antes de bloquear la 2FA del usuario
+	TwoFactorLockout      = 15 * time.Minute // Bloqueo tras maxSecondFactorFails códigos erróneos
+	recoveryCodeBytes     = 5                // 10 caracteres hexadecimales, "xxxxx-xxxxx"
+	stepUpSweepInterval   = 10 * time.Minute // Cada cuánto se limpian los step-up vencidos
+)
+
+// Errores de la verificación en dos pasos
+var (
+	ErrTwoFactorNotEnabled       = errors.New("la verificación en dos pasos no está activa")
+	ErrTwoFactorAlreadyEnabled   = errors.New("la verificación en dos pasos ya está activa")
+	ErrTwoFactorNotSetUp         = errors.New("primero inicie el alta de la verificación en dos pasos")
+	ErrTwoFactorRequired         = errors.New("su rol exige la verificación en dos pasos")
+	ErrInvalidTwoFactorCode      = errors.New("código de verificación inválido")
+	ErrInvalidTwoFactorChallenge = errors.New("el inicio de sesión expiró o no es válido; ingrese de nuevo")
+	ErrTwoFactorLocked           = errors.New("demasiados códigos erróneos; intente de nuevo en 15 minutos")
+)
+
+// RequireStaffTwoFactor exige 2FA a los usuarios con permisos de administración: sin ella no
+// entran a /admin ni pasan los step-up. Se desactiva con REQUIRE_STAFF_2FA=false.
+var RequireStaffTwoFactor = true
+
+// TOTPIssuer es el nombre con el que la cuenta aparece en la app autenticadora
+var TOTPIssuer = "BetSystem"
+
+// IsStaffRole indica si el rol tiene algún permiso de administración
+func IsStaffRole(db *gorm.DB, role string) bool {
+	if role == models.RoleAdmin {
+		return true
+	}
+	codes, err := RolePermissionCodes(db, role)
+	return err == nil && len(codes) > 0
+}
+
+// StaffTwoFactorPending indica si el rol del usuario exige 2FA y todavía no la activó
+func StaffTwoFactorPending(db *gorm.DB, user *models.User) bool {
+	return RequireStaffTwoFactor && !user.TwoFactorEnabled && IsStaffRole(db, user.Role)
+}
+
+// BeginTwoFactorSetup genera un secreto nuevo para el usuario y devuelve el URI otpauth:// para
+// el QR. La 2FA no se activa hasta confirmar un código con EnableTwoFactor.
+func BeginTwoFactorSetup(db *gorm.DB, user *models.User) (string, string, error) {
+	if user.TwoFactorEnabled {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := db.Model(user).Updates(map[string]interface{}{"two_factor_secret": secret, "two_factor_counter": 0}).Error; err != nil {
+		return "", "", err
+	}
+	user.TwoFactorSecret = secret
+	user.TwoFactorCounter = 0
+	return secret, utils.TOTPProvisioningURI(TOTPIssuer, user.Email, secret), nil
+}
+
+// EnableTwoFactor confirma el alta con un código de la app y devuelve los códigos de
+// recuperación, que solo se muestran esta vez
+func EnableTwoFactor(db *gorm.DB, user *models.User, code string, now time.Time) ([]string, error) {
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TwoFactorSecret == "" {
+		return nil, ErrTwoFactorNotSetUp
+	}
+	if err := verifyTOTP(db, user, code, now); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("two_factor_enabled", true).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	user.TwoFactorEnabled = true
+	InvalidatePrincipal(user.ID)
+	return codes, nil
+}
+
+// DisableTwoFactor apaga la 2FA tras verificar un código (TOTP o de recuperación). Los usuarios
+// con permisos de administración no pueden apagarla mientras sea obligatoria.
+func DisableTwoFactor(db *gorm.DB, user *models.User, staff bool, code string, now time.Time) error {
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if staff && RequireStaffTwoFactor {
+		return ErrTwoFactorRequired
+	}
+	if err := VerifySecondFactorWithLockout(db, user, code, now); err != nil {
+		return err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"two_factor_enabled": false,
+			"two_factor_secret":  "",
+			"two_factor_counter": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	InvalidatePrincipal(user.ID)
+	return nil
+}
+
+// RegenerateRecoveryCodes invalida los códigos de recuperación anteriores y emite unos nuevos
+func RegenerateRecoveryCodes(db *gorm.DB, user *models.User, code string, now time.Time) ([]string, error) {
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := VerifySecondFactorWithLockout(db, user, code, now); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// RemainingRecoveryCodes cuenta los códigos de recuperación sin usar
+func RemainingRecoveryCodes(db *gorm.DB, userID uint) int64 {
+	var remaining int64
+	db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&remaining)
+	return remaining
+}
+
+// VerifySecondFactor acepta un código de la app (6 dígitos) o un código de recuperación, que
+// queda consumido
+func VerifySecondFactor(db *gorm.DB, user *models.User, code string, now time.Time) error {
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	code = strings.TrimSpace(code)
+	if len(code) == utils.TOTPDigits {
+		return verifyTOTP(db, user, code, now)
+	}
+	return useRecoveryCode(db, user.ID, code, now)
+}
+
+// VerifySecondFactorWithLockout es VerifySecondFactor con bloqueo por usuario (segundo paso del
+// login, step-up, desactivación, regeneración de códigos). Los códigos erróneos se cuentan por
+// usuario, no por sesión ni por reto, y tras maxSecondFactorFails seguidos la 2FA se bloquea durante TwoFactorLockout sin
+// siquiera comprobar el código.
+func VerifySecondFactorWithLockout(db *gorm.DB, user *models.User, code string, now time.Time) error {
+	if user.TwoFactorLockedUntil != nil && now.Before(*user.TwoFactorLockedUntil) {
+		return ErrTwoFactorLocked
+	}
+
+	err := VerifySecondFactor(db, user, code, now)
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		return registerSecondFactorFailure(db, user, now)
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.TwoFactorFailedAttempts > 0 || user.TwoFactorLockedUntil != nil {
+		if err := db.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+			"two_factor_failed_attempts": 0,
+			"two_factor_locked_until":    nil,
+		}).Error; err != nil {
+			return err
+		}
+		user.TwoFactorFailedAttempts = 0
+		user.TwoFactorLockedUntil = nil
+	}
+	return nil
+}
+
+// registerSecondFactorFailure suma un código erróneo y bloquea la 2FA al llegar al máximo. El
+// incremento es atómico para que peticiones en paralelo no se salten el límite.
+func registerSecondFactorFailure(db *gorm.DB, user *models.User, now time.Time) error {
+	if err := db.Model(&models.User{}).Where("id = ?", user.ID).
+		UpdateColumn("two_factor_failed_attempts", gorm.Expr("two_factor_failed_attempts + 1")).Error; err != nil {
+		return err
+	}
+	var failures int
+	if err := db.Model(&models.User{}).Where("id = ?", user.ID).
+		Pluck("two_factor_failed_attempts", &failures).Error; err != nil {
+		return err
+	}
+	user.TwoFactorFailedAttempts = failures
+	if failures < maxSecondFactorFails {
+		return ErrInvalidTwoFactorCode
+	}
+
+	lockedUntil := now.Add(TwoFactorLockout)
+	if err := db.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+		"two_factor_failed_attempts": 0,
+		"two_factor_locked_until":    lockedUntil,
+	}).Error; err != nil {
+		return err
+	}
+	user.TwoFactorFailedAttempts = 0
+	user.TwoFactorLockedUntil = &lockedUntil
+	return ErrTwoFactorLocked
+}
+
+// verifyTOTP valida el código y registra su intervalo; un código ya usado (o anterior al último
+// usado) se rechaza aunque siga dentro de su ventana
+func verifyTOTP(db *gorm.DB, user *models.User, code string, now time.Time) error {
+	counter, ok := utils.ValidateTOTP(user.TwoFactorSecret, code, now)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	result := db.Model(&models.User{}).Where("id = ? AND two_factor_counter < ?", user.ID, counter).
+		UpdateColumn("two_factor_counter", counter)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	user.TwoFactorCounter = counter
+	return nil
+}
+
+func useRecoveryCode(db *gorm.DB, userID uint, code string, now time.Time) error {
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return ErrInvalidTwoFactorCode
+	}
+
+	// Solo gana un uso concurrente del mismo código
+	result := db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, utils.HashToken(normalized)).
+		Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	records := make([]models.RecoveryCode, RecoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		raw := hex.EncodeToString(buf)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: utils.HashToken(raw)}
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode ignora mayúsculas, guiones y espacios al escribir el código
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// CreateTwoFactorChallenge abre el segundo paso del login y devuelve el token que el cliente
+// envía junto con el código
+func CreateTwoFactorChallenge(db *gorm.DB, userID uint, now time.Time) (string, error) {
+	raw, err := utils.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	challenge := models.TwoFactorChallenge{
+		UserID:    userID,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: now.Add(TwoFactorChallengeTTL),
+	}
+	if err := db.Create(&challenge).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// CompleteTwoFactorChallenge verifica el código del segundo paso y devuelve el usuario al que
+// emitir los tokens. Tras maxChallengeAttempts códigos erróneos el reto deja de valer, y los
+// fallos cuentan además para el bloqueo por usuario: pedir un reto nuevo no da más intentos.
+func CompleteTwoFactorChallenge(db *gorm.DB, raw, code string, now time.Time) (*models.User, error) {
+	var challenge models.TwoFactorChallenge
+	if err := db.Where("token_hash = ?", utils.HashToken(raw)).First(&challenge).Error; err != nil {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+	if challenge.ConsumedAt != nil || !now.Before(challenge.ExpiresAt) || challenge.Attempts >= maxChallengeAttempts {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	var user models.User
+	if err := db.Preload("Wallet").First(&user, challenge.UserID).Error; err != nil {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+
+	if err := VerifySecondFactorWithLockout(db, &user, code, now); err != nil {
+		db.Model(&challenge).UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+		if errors.Is(err, ErrTwoFactorNotEnabled) {
+			return nil, ErrInvalidTwoFactorChallenge
+		}
+		return nil, err
+	}
+
+	result := db.Model(&models.TwoFactorChallenge{}).Where("id = ? AND consumed_at IS NULL", challenge.ID).
+		Update("consumed_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+	return &user, nil
+}
+
+// Los step-up se recuerdan por sesión (jti del token de acceso) para no pedir el código en cada
+// acción sensible seguida. Viven en memoria, como la caché de Principal.
+var (
+	stepUpMu        sync.Mutex
+	stepUpGrants    = make(map[string]time.Time)
+	stepUpLastSweep time.Time
+)
+
+// GrantStepUp registra que la sesión verificó su segundo factor
+func GrantStepUp(sessionID string, now time.Time) {
+	if sessionID == "" {
+		return
+	}
+	stepUpMu.Lock()
+	defer stepUpMu.Unlock()
+	if now.Sub(stepUpLastSweep) > stepUpSweepInterval {
+		for id, expiresAt := range stepUpGrants {
+			if !now.Before(expiresAt) {
+				delete(stepUpGrants, id)
+			}
+		}
+		stepUpLastSweep = now
+	}
+	stepUpGrants[sessionID] = now.Add(StepUpTTL)
+}
+
+// HasStepUp indica si la sesión verificó su segundo factor hace menos de StepUpTTL
+func HasStepUp(sessionID string, now time.Time) bool {
+	if sessionID == "" {
+		return false
+	}
+	stepUpMu.Lock()
+	defer stepUpMu.Unlock()
+	expiresAt, ok := stepUpGrants[sessionID]
+	return ok && now.Before(expiresAt)
+}
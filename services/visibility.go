@@ -0,0 +1,22 @@
+package services
+
+import (
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// CanViewTournament indica si el usuario puede ver el torneo: los públicos los ve cualquiera y
+// los privados solo sus participantes y los administradores
+func CanViewTournament(db *gorm.DB, principal Principal, tournament *models.Tournament) bool {
+	if !tournament.IsPrivate() || principal.IsAdmin() {
+		return true
+	}
+	if principal.UserID == 0 {
+		return false
+	}
+	var count int64
+	db.Model(&models.TournamentParticipant{}).
+		Where("user_id = ? AND tournament_id = ?", principal.UserID, tournament.ID).
+		Count(&count)
+	return count > 0
+}
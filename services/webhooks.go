@@ -0,0 +1,413 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"gorm.io/gorm"
+)
+
+// Cabeceras de cada entrega. La firma es HMAC-SHA256 con el secreto del webhook sobre
+// "{timestamp}.{cuerpo}" en hexadecimal, precedida de "sha256=".
+const (
+	WebhookHeaderEventID   = "X-Webhook-Id"
+	WebhookHeaderEventType = "X-Webhook-Event"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// webhookLeaseName identifica el candado del despachador de webhooks entre réplicas
+const webhookLeaseName = "webhooks"
+
+const (
+	webhookMaxAttempts = 8
+	webhookBatchSize   = 50
+	webhookTimeout     = 10 * time.Second
+)
+
+// Errores de los webhooks
+var (
+	ErrWebhookSubscriptionInactive = errors.New("el webhook está desactivado o fue eliminado")
+	ErrWebhookAlreadyPending       = errors.New("la entrega ya está pendiente de envío")
+)
+
+// WebhookEnvelope es el cuerpo JSON que recibe el socio
+type WebhookEnvelope struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Datos de cada tipo de evento
+
+// TournamentCreatedData es el evento tournament.created
+type TournamentCreatedData struct {
+	TournamentID    uint      `json:"tournament_id"`
+	Name            string    `json:"name"`
+	Category        string    `json:"category"`
+	Status          string    `json:"status"`
+	StartDate       time.Time `json:"start_date"`
+	EndDate         time.Time `json:"end_date"`
+	EntryFee        float64   `json:"entry_fee"`
+	MaxParticipants int       `json:"max_participants"`
+}
+
+// SessionOpenedData es el evento session.opened
+type SessionOpenedData struct {
+	SessionID     uint      `json:"session_id"`
+	TournamentID  uint      `json:"tournament_id"`
+	SessionNumber int       `json:"session_number"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+}
+
+// EventSettledData es el evento event.settled
+type EventSettledData struct {
+	*EventSettlement
+	TournamentIDs []uint `json:"tournament_ids"`
+}
+
+// PrizePaidData es el evento prize.paid
+type PrizePaidData struct {
+	TournamentID   uint    `json:"tournament_id"`
+	TournamentName string  `json:"tournament_name"`
+	UserID         uint    `json:"user_id"`
+	Position       int     `json:"position"`
+	Amount         float64 `json:"amount"`
+}
+
+// SignWebhookPayload calcula la firma que acompaña al cuerpo enviado en el instante timestamp
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewWebhookSecret genera un secreto aleatorio para firmar las entregas
+func NewWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+func newWebhookEventID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return "evt_" + hex.EncodeToString(buf)
+}
+
+// EmitWebhook crea una entrega del evento para cada webhook activo suscrito al tipo. Se llama
+// después del commit; los errores solo se registran porque los webhooks nunca deben hacer
+// fallar la operación que los origina.
+func EmitWebhook(db *gorm.DB, eventType string, data interface{}) {
+	var subscriptions []models.WebhookSubscription
+	if err := db.Where("is_active = ?", true).Find(&subscriptions).Error; err != nil {
+		log.Printf("⚠️  Webhooks: %v", err)
+		return
+	}
+
+	var deliveries []models.WebhookDelivery
+	envelope := WebhookEnvelope{ID: newWebhookEventID(), Type: eventType, CreatedAt: time.Now()}
+	for _, subscription := range subscriptions {
+		if !subscription.EventTypes.Includes(eventType) {
+			continue
+		}
+		if envelope.Data == nil {
+			payload, err := json.Marshal(data)
+			if err != nil {
+				log.Printf("⚠️  Webhooks: no se pudo serializar %s: %v", eventType, err)
+				return
+			}
+			envelope.Data = payload
+		}
+		body, _ := json.Marshal(envelope)
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        envelope.ID,
+			EventType:      eventType,
+			Payload:        string(body),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  envelope.CreatedAt,
+		})
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	if err := db.Create(&deliveries).Error; err != nil {
+		log.Printf("⚠️  Webhooks: no se pudo encolar %s: %v", eventType, err)
+		return
+	}
+	WakeWebhooks()
+}
+
+// EmitTournamentCreated avisa un torneo nuevo. Los torneos privados y las ligas no se anuncian.
+func EmitTournamentCreated(db *gorm.DB, tournament *models.Tournament) {
+	if tournament.IsPrivate() {
+		return
+	}
+	EmitWebhook(db, models.WebhookTournamentCreated, TournamentCreatedData{
+		TournamentID:    tournament.ID,
+		Name:            tournament.Name,
+		Category:        tournament.Category,
+		Status:          tournament.Status,
+		StartDate:       tournament.StartDate,
+		EndDate:         tournament.EndDate,
+		EntryFee:        tournament.EntryFee,
+		MaxParticipants: tournament.MaxParticipants,
+	})
+}
+
+// EmitSessionOpened avisa que la sesión abrió para picks; no hace nada con otros estados
+func EmitSessionOpened(db *gorm.DB, session *models.Session) {
+	if session.Status != models.SessionStatusOpen {
+		return
+	}
+	EmitWebhook(db, models.WebhookSessionOpened, SessionOpenedData{
+		SessionID:     session.ID,
+		TournamentID:  session.TournamentID,
+		SessionNumber: session.SessionNumber,
+		StartTime:     session.StartTime,
+		EndTime:       session.EndTime,
+	})
+}
+
+// EmitEventSettled avisa la liquidación de un evento con los torneos públicos en los que estaba
+// asignado; los privados y las ligas no se revelan a los socios
+func EmitEventSettled(db *gorm.DB, settlement *EventSettlement) {
+	tournamentIDs := []uint{}
+	if ids := eventTournamentIDs(db, settlement.EventID); len(ids) > 0 {
+		if err := db.Model(&models.Tournament{}).Where("id IN ? AND visibility <> ?", ids, models.TournamentVisibilityPrivate).
+			Pluck("id", &tournamentIDs).Error; err != nil {
+			log.Printf("⚠️  Webhooks: torneos del evento %d: %v", settlement.EventID, err)
+			return
+		}
+	}
+	EmitWebhook(db, models.WebhookEventSettled, EventSettledData{
+		EventSettlement: settlement,
+		TournamentIDs:   tournamentIDs,
+	})
+}
+
+// EmitLeaderboardFinal envía la clasificación final de un torneo finalizado. La de los torneos
+// privados y las ligas no sale del sistema.
+func EmitLeaderboardFinal(db *gorm.DB, tournamentID uint) {
+	var tournament models.Tournament
+	if err := db.First(&tournament, tournamentID).Error; err != nil {
+		log.Printf("⚠️  Webhooks: torneo %d: %v", tournamentID, err)
+		return
+	}
+	if tournament.IsPrivate() {
+		return
+	}
+
+	leaderboard, err := currentLeaderboard(db, tournamentID)
+	if err != nil {
+		log.Printf("⚠️  Webhooks: clasificación del torneo %d: %v", tournamentID, err)
+		return
+	}
+	EmitWebhook(db, models.WebhookLeaderboardFinal, leaderboard)
+}
+
+// EmitPrizePaid avisa un premio acreditado. Los premios de torneos privados y ligas no se avisan.
+func EmitPrizePaid(db *gorm.DB, userID uint, tournament *models.Tournament, position int, amount float64) {
+	if tournament.IsPrivate() {
+		return
+	}
+	EmitWebhook(db, models.WebhookPrizePaid, PrizePaidData{
+		TournamentID:   tournament.ID,
+		TournamentName: tournament.Name,
+		UserID:         userID,
+		Position:       position,
+		Amount:         amount,
+	})
+}
+
+// ReplayWebhookDelivery vuelve a poner en cola una entrega ya enviada o agotada, con los
+// intentos en cero. El cuerpo y su ID de evento no cambian para que el socio pueda deduplicar.
+func ReplayWebhookDelivery(db *gorm.DB, delivery *models.WebhookDelivery, now time.Time) error {
+	if delivery.Status == models.WebhookDeliveryPending {
+		return ErrWebhookAlreadyPending
+	}
+
+	var subscription models.WebhookSubscription
+	if err := db.First(&subscription, delivery.SubscriptionID).Error; err != nil || !subscription.IsActive {
+		return ErrWebhookSubscriptionInactive
+	}
+
+	if err := db.Model(delivery).Updates(map[string]interface{}{
+		"status":           models.WebhookDeliveryPending,
+		"attempts":         0,
+		"next_attempt_at":  now,
+		"last_status_code": 0,
+		"last_error":       "",
+		"delivered_at":     nil,
+	}).Error; err != nil {
+		return err
+	}
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.LastStatusCode = 0
+	delivery.LastError = ""
+	delivery.DeliveredAt = nil
+
+	WakeWebhooks()
+	return nil
+}
+
+// webhookWake despierta al despachador cuando se encola una entrega
+var webhookWake = make(chan struct{}, 1)
+
+// WakeWebhooks pide al despachador revisar las entregas pendientes sin esperar al siguiente ciclo
+func WakeWebhooks() {
+	select {
+	case webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// WebhookDispatcher envía las entregas pendientes con reintentos y backoff exponencial. Tras
+// agotar los intentos la entrega queda como "dead" hasta que un admin la reenvíe.
+type WebhookDispatcher struct {
+	db       *gorm.DB
+	client   *http.Client
+	interval time.Duration
+	leaseTTL time.Duration
+	owner    string
+}
+
+// NewWebhookDispatcher crea un despachador que revisa las entregas cada interval
+func NewWebhookDispatcher(db *gorm.DB, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		db:       db,
+		client:   &http.Client{Timeout: webhookTimeout},
+		interval: interval,
+		leaseTTL: 3 * interval,
+		owner:    leaseOwner(),
+	}
+}
+
+// Start lanza el despachador en segundo plano hasta que ctx sea cancelado
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			if acquireJobLease(d.db, webhookLeaseName, d.owner, d.leaseTTL, time.Now()) {
+				d.DispatchOnce(ctx, time.Now())
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-webhookWake:
+			}
+		}
+	}()
+}
+
+// DispatchOnce envía las entregas cuyo próximo intento ya llegó y devuelve cuántas se entregaron
+func (d *WebhookDispatcher) DispatchOnce(ctx context.Context, now time.Time) int {
+	var deliveries []models.WebhookDelivery
+	if err := d.db.Preload("Subscription").
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at, id").Limit(webhookBatchSize).Find(&deliveries).Error; err != nil {
+		log.Printf("⚠️  Webhooks: %v", err)
+		return 0
+	}
+
+	delivered := 0
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+		if d.deliver(ctx, &deliveries[i], now) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// deliver hace el POST firmado y agenda el reintento si falla
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) bool {
+	subscription := delivery.Subscription
+	var statusCode int
+	var err error
+	if subscription.ID == 0 || !subscription.IsActive {
+		err = ErrWebhookSubscriptionInactive
+	} else {
+		statusCode, err = d.post(ctx, &subscription, delivery)
+	}
+
+	attempts := delivery.Attempts + 1
+	if err == nil {
+		d.db.Model(delivery).Updates(map[string]interface{}{
+			"status":           models.WebhookDeliveryDelivered,
+			"attempts":         attempts,
+			"last_status_code": statusCode,
+			"last_error":       "",
+			"delivered_at":     now,
+		})
+		return true
+	}
+
+	changes := map[string]interface{}{"attempts": attempts, "last_status_code": statusCode, "last_error": err.Error()}
+	if attempts >= webhookMaxAttempts || errors.Is(err, ErrWebhookSubscriptionInactive) {
+		changes["status"] = models.WebhookDeliveryDead
+		log.Printf("⚠️  Webhooks: entrega %d pasó a dead-letter tras %d intentos: %v", delivery.ID, attempts, err)
+	} else {
+		changes["next_attempt_at"] = now.Add(retryBackoff(attempts))
+	}
+	d.db.Model(delivery).Updates(changes)
+	return false
+}
+
+// post envía la entrega firmada. La firma usa la hora del envío, no la del inicio del lote, para
+// que las últimas entregas de un lote lento no lleguen fuera de la ventana anti-replay del receptor.
+func (d *WebhookDispatcher) post(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bets-backend-webhooks/1.0")
+	req.Header.Set(WebhookHeaderEventID, delivery.EventID)
+	req.Header.Set(WebhookHeaderEventType, delivery.EventType)
+	req.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(subscription.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("el receptor respondió %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
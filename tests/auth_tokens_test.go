@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type tokenPairEnvelope struct {
+	Data dtos.TokenPairResponse `json:"data"`
+}
+
+func TestRefreshTokens_RotationReuseAndRevocation(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	user := models.User{Username: "ana", Email: "ana@example.com", Role: "user", IsActive: true}
+	assert.NoError(t, user.HashPassword("secreto123"))
+	assert.NoError(t, db.Create(&user).Error)
+	admin := models.User{Username: "admin", Email: "admin@example.com", Password: "x", Role: "admin", IsActive: true}
+	assert.NoError(t, db.Create(&admin).Error)
+	adminToken, _ := utils.GenerateToken(admin.ID, admin.Username, admin.Role, admin.TokenVersion)
+
+	var login tokenPairEnvelope
+	w := MakeJSONRequest(router, "POST", "/api/v1/auth/login", map[string]string{"username": "ana", "password": "secreto123"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+	assert.NotEmpty(t, login.Data.RefreshToken)
+	assert.Equal(t, int(utils.AccessTokenTTL.Seconds()), login.Data.ExpiresIn)
+
+	// Rotación: el refresh token usado deja de valer
+	var rotated tokenPairEnvelope
+	w = MakeJSONRequest(router, "POST", "/api/v1/auth/refresh", map[string]string{"refresh_token": login.Data.RefreshToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.NotEqual(t, login.Data.RefreshToken, rotated.Data.RefreshToken)
+
+	// Reusar el token ya rotado revoca toda la familia, incluido el token nuevo
+	w = MakeJSONRequest(router, "POST", "/api/v1/auth/refresh", map[string]string{"refresh_token": login.Data.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = MakeJSONRequest(router, "POST", "/api/v1/auth/refresh", map[string]string{"refresh_token": rotated.Data.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Logout-all invalida de inmediato los tokens de acceso
+	w = MakeAuthRequest(router, "GET", "/api/v1/me", rotated.Data.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = MakeAuthRequest(router, "POST", "/api/v1/auth/logout-all", rotated.Data.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = MakeAuthRequest(router, "GET", "/api/v1/me", rotated.Data.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Desactivar al usuario revoca la sesión que abrió después
+	w = MakeJSONRequest(router, "POST", "/api/v1/auth/login", map[string]string{"username": "ana", "password": "secreto123"})
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(user.ID)+"/status", adminToken, map[string]bool{"is_active": false})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = MakeAuthRequest(router, "GET", "/api/v1/me", login.Data.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = MakeJSONRequest(router, "POST", "/api/v1/auth/refresh", map[string]string{"refresh_token": login.Data.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
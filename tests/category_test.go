@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestBackfillTournamentCategories(t *testing.T) {
+	db := SetupTestDB(t)
+
+	existing := models.Category{Name: "Futbol", Slug: "futbol", IsActive: true}
+	assert.NoError(t, db.Create(&existing).Error)
+
+	start := time.Now()
+	for _, tournament := range []models.Tournament{
+		{Name: "Liga A", Category: "futbol", StartDate: start, EndDate: start},
+		{Name: "Polla B", Category: "Hipica", StartDate: start, EndDate: start},
+	} {
+		assert.NoError(t, db.Create(&tournament).Error)
+	}
+
+	assert.NoError(t, services.BackfillTournamentCategories(db))
+
+	var liga, polla models.Tournament
+	db.Where("name = ?", "Liga A").First(&liga)
+	db.Where("name = ?", "Polla B").First(&polla)
+	assert.Equal(t, existing.ID, *liga.CategoryID)
+
+	// Las categorías que no existían se crean
+	var hipica models.Category
+	assert.NoError(t, db.Where("name = ?", "Hipica").First(&hipica).Error)
+	assert.Equal(t, hipica.ID, *polla.CategoryID)
+}
+
+func TestCategoryDrivesSelectionTypes(t *testing.T) {
+	db := SetupTestDB(t)
+
+	category := models.Category{Name: "Beisbol", Slug: "beisbol", IsActive: true,
+		Settings: models.CategorySettingsJSON{DefaultSelectionsPerSession: 4}}
+	assert.NoError(t, db.Create(&category).Error)
+	for _, selectionType := range []models.CategorySelectionType{
+		{CategoryID: category.ID, SelectionType: models.SelectionTypeMacho, IsRequired: true, DefaultPoints: 3},
+		{CategoryID: category.ID, SelectionType: models.SelectionTypeAlta, DefaultPoints: 2},
+	} {
+		assert.NoError(t, db.Create(&selectionType).Error)
+	}
+
+	loaded, err := services.ResolveCategory(db, nil, "beisbol")
+	assert.NoError(t, err)
+
+	settings := models.TournamentSettings{}
+	services.ApplyCategoryDefaults(loaded, &settings)
+	assert.Equal(t, 4, settings.SelectionsPerSession)
+	assert.Equal(t, []string{models.SelectionTypeMacho}, settings.RequiredSelectionTypes)
+	assert.Equal(t, map[string]int{models.SelectionTypeMacho: 3, models.SelectionTypeAlta: 2}, settings.PointsBySelectionType)
+
+	settings.RequiredSelectionTypes = append(settings.RequiredSelectionTypes, models.SelectionTypeEmpate)
+	assert.True(t, errors.Is(services.ValidateTournamentSettings(loaded, settings), services.ErrSelectionTypeNotAllowed))
+
+	start := time.Now()
+	tournament := models.Tournament{Name: "Serie", Category: category.Name, CategoryID: &category.ID, StartDate: start, EndDate: start}
+	assert.NoError(t, db.Create(&tournament).Error)
+	event := models.Event{Name: "Juego 1", StartTime: start}
+	assert.NoError(t, db.Create(&event).Error)
+	assert.NoError(t, db.Create(&models.TournamentEvent{TournamentID: tournament.ID, EventID: event.ID}).Error)
+
+	assert.NoError(t, services.ValidateSelectionTypeForEvent(db, event.ID, models.SelectionTypeAlta))
+	assert.True(t, errors.Is(services.ValidateSelectionTypeForEvent(db, event.ID, models.SelectionTypeEmpate), services.ErrSelectionTypeNotAllowed))
+}
+
+// createBeisbolEvent crea la categoría Beisbol (solo macho y alta) y un juego de un torneo de esa categoría
+func createBeisbolEvent(t *testing.T, db *gorm.DB) (models.Category, models.Tournament, models.Event) {
+	category := models.Category{Name: "Beisbol", Slug: "beisbol", IsActive: true}
+	assert.NoError(t, db.Create(&category).Error)
+	for _, selectionType := range []string{models.SelectionTypeMacho, models.SelectionTypeAlta} {
+		assert.NoError(t, db.Create(&models.CategorySelectionType{CategoryID: category.ID, SelectionType: selectionType, DefaultPoints: 1}).Error)
+	}
+
+	start := time.Now().Add(time.Hour)
+	tournament := models.Tournament{Name: "Serie", Category: category.Name, CategoryID: &category.ID, StartDate: start, EndDate: start}
+	assert.NoError(t, db.Create(&tournament).Error)
+	event := models.Event{Name: "Leones vs Tigres", StartTime: start, Line: 8.5}
+	assert.NoError(t, db.Create(&event).Error)
+	assert.NoError(t, db.Create(&models.TournamentEvent{TournamentID: tournament.ID, EventID: event.ID}).Error)
+	competitors := []models.EventCompetitor{
+		{EventID: event.ID, Name: "Leones", Odds: -150, Runline: -1.5, IsFavorite: true},
+		{EventID: event.ID, Name: "Tigres", Odds: 130, Runline: 1.5},
+	}
+	assert.NoError(t, db.Create(&competitors).Error)
+	return category, tournament, event
+}
+
+func TestGenerateEventSelections_ValidatesTypesAgainstCategory(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+	_, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+	_, tournament, event := createBeisbolEvent(t, db)
+	path := "/api/v1/admin/events/" + utils.UintToString(event.ID) + "/selections/generate"
+
+	// Tipos explícitos no permitidos se rechazan, con o sin torneo
+	w := MakeAuthRequest(router, "POST", path, adminToken, map[string]interface{}{"selection_types": []string{models.SelectionTypeEmpate}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = MakeAuthRequest(router, "POST", path, adminToken, map[string]interface{}{"tournament_id": tournament.ID, "selection_types": []string{models.SelectionTypeMacho, models.SelectionTypeEmpate}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var count int64
+	db.Model(&models.PickableSelection{}).Where("event_id = ?", event.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// Sin torneo ni tipos, de los estándar solo se generan los permitidos
+	w = MakeAuthRequest(router, "POST", path, adminToken, map[string]interface{}{})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var types []string
+	db.Model(&models.PickableSelection{}).Where("event_id = ?", event.ID).Order("selection_type").Pluck("selection_type", &types)
+	assert.Equal(t, []string{models.SelectionTypeAlta, models.SelectionTypeMacho}, types)
+	assert.Contains(t, w.Body.String(), models.SelectionTypeEmpate)
+}
+
+func TestUpdateCategory_RenamesTournamentCategory(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+	_, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+	category, tournament, _ := createBeisbolEvent(t, db)
+
+	start := time.Now()
+	other := models.Tournament{Name: "Liga Futbol", Category: "Futbol", StartDate: start, EndDate: start}
+	assert.NoError(t, db.Create(&other).Error)
+
+	w := MakeAuthRequest(router, "PUT", "/api/v1/admin/categories/"+utils.UintToString(category.ID), adminToken, map[string]string{"name": "Béisbol Profesional"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var renamed, untouched models.Tournament
+	assert.NoError(t, db.First(&renamed, tournament.ID).Error)
+	assert.NoError(t, db.First(&untouched, other.ID).Error)
+	assert.Equal(t, "Béisbol Profesional", renamed.Category)
+	assert.Equal(t, "Serie", renamed.Name)
+	assert.Equal(t, "Futbol", untouched.Category)
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const raceCardCSV = `event,start_time,venue,line,competitor,number,odds,favorite
+Carrera 1,2026-10-20T18:00:00Z,La Rinconada,0,Relámpago,1,-120,si
+Carrera 1,,,,,2,300,
+Carrera 2,2026-10-20T18:30:00Z,La Rinconada,0,Tormenta,1,150,
+Carrera 3,mañana,La Rinconada,0,Centella,4,200,
+`
+
+func TestImportEvents_DryRunThenCommit(t *testing.T) {
+	db := SetupTestDB(t)
+
+	catalog := models.Competitor{Name: "Huracán", Category: "Caballos", AssignedNumber: 2}
+	assert.NoError(t, db.Create(&catalog).Error)
+
+	events, rowErrs, err := services.ParseEventImportCSV(strings.NewReader(raceCardCSV))
+	assert.NoError(t, err)
+	assert.Empty(t, rowErrs)
+	assert.Len(t, events, 3)
+	input := &dtos.EventImportRequest{CompetitorCategory: "Caballos", Events: events}
+
+	// Dry-run: reporta el error de fecha de la carrera 3 y no guarda nada
+	tx := db.Begin()
+	report, err := services.ImportEvents(tx, input, nil, true)
+	assert.NoError(t, err)
+	tx.Rollback()
+	assert.Len(t, report.Errors, 1)
+	assert.Equal(t, 5, report.Errors[0].Row)
+	assert.Equal(t, "start_time", report.Errors[0].Field)
+	assert.Len(t, report.Events, 2)
+	assert.Equal(t, "created", report.Events[0].Action)
+	assert.Equal(t, 2, report.Events[0].CompetitorsCreated)
+
+	var count int64
+	db.Model(&models.Event{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// Sin la fila inválida se guarda y el número 2 se empareja con el catálogo
+	input.Events = events[:2]
+	tx = db.Begin()
+	report, err = services.ImportEvents(tx, input, nil, false)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Errors)
+	tx.Commit()
+
+	var huracan models.EventCompetitor
+	assert.NoError(t, db.Where("name = ?", "Huracán").First(&huracan).Error)
+	assert.Equal(t, catalog.ID, *huracan.CompetitorID)
+
+	// Reimportar el mismo fixture no duplica nada
+	tx = db.Begin()
+	report, err = services.ImportEvents(tx, input, nil, false)
+	assert.NoError(t, err)
+	tx.Commit()
+	assert.Equal(t, "unchanged", report.Events[0].Action)
+	db.Model(&models.EventCompetitor{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+}
+
+// importFixture importa y confirma el fixture, fallando el test ante errores de base de datos
+func importFixture(t *testing.T, db *gorm.DB, input *dtos.EventImportRequest) *dtos.EventImportReport {
+	tx := db.Begin()
+	report, err := services.ImportEvents(tx, input, nil, false)
+	assert.NoError(t, err)
+	tx.Commit()
+	return report
+}
+
+// importWarnings devuelve los avisos del reporte que contienen text
+func importWarnings(report *dtos.EventImportReport, text string) []dtos.ImportRowError {
+	var warnings []dtos.ImportRowError
+	for _, warning := range report.Warnings {
+		if strings.Contains(warning.Message, text) {
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
+
+func fixtureEvent(startTime string, line float64, competitors ...string) dtos.EventImportRow {
+	row := dtos.EventImportRow{Row: 2, Name: "Leones vs Tigres", StartTime: startTime, Line: line}
+	for _, name := range competitors {
+		row.Competitors = append(row.Competitors, dtos.CompetitorImportRow{Name: name})
+	}
+	return row
+}
+
+func TestImportEvents_KeepsLineAndStartTimeOfLiveEvents(t *testing.T) {
+	db := SetupTestDB(t)
+	input := &dtos.EventImportRequest{Events: []dtos.EventImportRow{fixtureEvent("2026-10-20T19:00:00Z", 8.5, "Leones", "Tigres")}}
+	importFixture(t, db, input)
+
+	var event models.Event
+	assert.NoError(t, db.Where("name = ?", "Leones vs Tigres").First(&event).Error)
+	assert.NoError(t, db.Model(&event).Update("status", "live").Error)
+
+	input.Events = []dtos.EventImportRow{fixtureEvent("2026-10-20T21:00:00Z", 9.5, "Leones", "Tigres")}
+	report := importFixture(t, db, input)
+	assert.Empty(t, report.Errors)
+	assert.Len(t, importWarnings(report, "en juego"), 1)
+
+	var stored models.Event
+	assert.NoError(t, db.First(&stored, event.ID).Error)
+	assert.InDelta(t, 8.5, stored.Line, 0.001)
+	assert.True(t, stored.StartTime.Equal(event.StartTime))
+	var history int64
+	db.Model(&models.LineHistory{}).Where("event_id = ?", event.ID).Count(&history)
+	assert.Equal(t, int64(0), history)
+
+	// Programado, el mismo fixture sí mueve la línea y la hora
+	assert.NoError(t, db.Model(&event).Update("status", "scheduled").Error)
+	report = importFixture(t, db, input)
+	assert.Empty(t, importWarnings(report, "en juego"))
+	assert.Equal(t, "updated", report.Events[0].Action)
+	assert.NoError(t, db.First(&stored, event.ID).Error)
+	assert.InDelta(t, 9.5, stored.Line, 0.001)
+	assert.Equal(t, 21, stored.StartTime.UTC().Hour())
+	db.Model(&models.LineHistory{}).Where("event_id = ?", event.ID).Count(&history)
+	assert.Equal(t, int64(1), history)
+}
+
+func TestImportEvents_LeagueUsesBaseSlateAndKeepsSession(t *testing.T) {
+	db := SetupTestDB(t)
+	base := createLifecycleTournament(t, db, "Torneo Base", models.TournamentStatusOpen)
+	session := models.Session{TournamentID: base.ID, SessionNumber: 1, StartTime: base.StartDate, EndTime: base.StartDate.Add(time.Hour), Status: models.SessionStatusScheduled}
+	assert.NoError(t, db.Create(&session).Error)
+	league := createLifecycleTournament(t, db, "Liga Amigos", models.TournamentStatusOpen)
+	assert.NoError(t, db.Model(&league).Updates(map[string]interface{}{"parent_tournament_id": base.ID, "visibility": models.TournamentVisibilityPrivate}).Error)
+
+	input := &dtos.EventImportRequest{TournamentID: &league.ID, SessionID: &session.ID, Events: []dtos.EventImportRow{fixtureEvent("2026-10-20T19:00:00Z", 8.5, "Leones", "Tigres")}}
+	report := importFixture(t, db, input)
+	assert.Empty(t, report.Errors)
+
+	var assignments []models.TournamentEvent
+	assert.NoError(t, db.Find(&assignments).Error)
+	if assert.Len(t, assignments, 1) {
+		assert.Equal(t, base.ID, assignments[0].TournamentID)
+		assert.Equal(t, session.ID, *assignments[0].SessionID)
+	}
+
+	// Reimportar sin session_id no saca el evento de su sesión
+	input.SessionID = nil
+	report = importFixture(t, db, input)
+	assert.Empty(t, report.Errors)
+	assert.NoError(t, db.Find(&assignments).Error)
+	if assert.Len(t, assignments, 1) {
+		assert.Equal(t, session.ID, *assignments[0].SessionID)
+	}
+
+	// Una sesión cerrada ya no recibe eventos
+	assert.NoError(t, db.Model(&session).Update("status", models.SessionStatusClosed).Error)
+	input.SessionID = &session.ID
+	report = importFixture(t, db, input)
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, "session_id", report.Errors[0].Field)
+	}
+	assert.Empty(t, report.Events)
+}
+
+func TestImportEvents_RetainsCompetitorsReferencedBySelections(t *testing.T) {
+	db := SetupTestDB(t)
+	input := &dtos.EventImportRequest{Events: []dtos.EventImportRow{fixtureEvent("2026-10-20T19:00:00Z", 0, "Relámpago", "Trueno", "Centella")}}
+	importFixture(t, db, input)
+
+	var trueno models.EventCompetitor
+	assert.NoError(t, db.Where("name = ?", "Trueno").First(&trueno).Error)
+	selection := models.PickableSelection{EventID: trueno.EventID, Description: "Gana Trueno", SelectionType: models.SelectionTypeMacho, CompetitorID: &trueno.ID, PointsForWin: 1}
+	assert.NoError(t, db.Create(&selection).Error)
+
+	// Trueno y Centella salen del fixture: Centella se elimina, Trueno se conserva por su selección
+	input.Events = []dtos.EventImportRow{fixtureEvent("2026-10-20T19:00:00Z", 0, "Relámpago")}
+	report := importFixture(t, db, input)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 1, report.Events[0].CompetitorsRemoved)
+	assert.Len(t, importWarnings(report, "Trueno ya no está en el fixture"), 1)
+
+	var names []string
+	db.Model(&models.EventCompetitor{}).Where("event_id = ?", trueno.EventID).Order("id").Pluck("name", &names)
+	assert.Equal(t, []string{"Relámpago", "Trueno"}, names)
+}
+
+func TestImportEvents_SelectionCompetitorsAndLineAudit(t *testing.T) {
+	db := SetupTestDB(t)
+	admin := models.User{Username: "root", Email: "root@example.com", Password: "x", Role: models.RoleAdmin}
+	assert.NoError(t, db.Create(&admin).Error)
+
+	row := fixtureEvent("2026-10-20T19:00:00Z", 0, "Leones", "Tigres")
+	row.Selections = []dtos.SelectionImportRow{
+		{SelectionType: models.SelectionTypeMacho, Competitor: "Leones", Odds: -130},
+		{SelectionType: models.SelectionTypeHembra, Competitor: "Águilas", Odds: 110},
+	}
+	input := &dtos.EventImportRequest{Events: []dtos.EventImportRow{row}}
+
+	// Una selección de un competidor ajeno se reporta y no se crea sin vínculo
+	tx := db.Begin()
+	report, err := services.ImportEvents(tx, input, &admin.ID, false)
+	assert.NoError(t, err)
+	tx.Commit()
+	assert.Len(t, report.Errors, 1)
+	assert.Equal(t, "competitor", report.Errors[0].Field)
+	var count int64
+	db.Model(&models.PickableSelection{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	input.Events[0].Selections = input.Events[0].Selections[:1]
+	report = importFixture(t, db, input)
+	assert.Empty(t, report.Errors)
+	var selection models.PickableSelection
+	assert.NoError(t, db.First(&selection).Error)
+	assert.NotNil(t, selection.CompetitorID)
+
+	// Al cambiar la cuota, el historial registra quién importó
+	input.Events[0].Selections[0].Odds = -150
+	tx = db.Begin()
+	_, err = services.ImportEvents(tx, input, &admin.ID, false)
+	assert.NoError(t, err)
+	tx.Commit()
+
+	var history models.LineHistory
+	assert.NoError(t, db.Where("entity_type = ? AND field = ?", models.LineEntitySelection, models.LineFieldOdds).First(&history).Error)
+	if assert.NotNil(t, history.ChangedBy) {
+		assert.Equal(t, admin.ID, *history.ChangedBy)
+	}
+}
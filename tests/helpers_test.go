@@ -11,6 +11,7 @@ import (
 	"github.com/cesarbmathec/bets-backend/config"
 	"github.com/cesarbmathec/bets-backend/models"
 	"github.com/cesarbmathec/bets-backend/routes"
+	"github.com/cesarbmathec/bets-backend/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -34,6 +35,8 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 		&models.Wallet{},
 		&models.Transaction{},
 		&models.Payment{},
+		&models.UserPaymentMethod{},
+		&models.Withdrawal{},
 		&models.Event{},
 		&models.EventCompetitor{},
 		&models.Tournament{},
@@ -41,10 +44,37 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 		&models.UserPick{},
 		&models.PickableSelection{},
 		&models.Session{},
+		&models.TournamentEvent{},
+		&models.TournamentTemplate{},
+		&models.StatusTransition{},
+		&models.JobLease{},
+		&models.Category{},
+		&models.CategorySelectionType{},
+		&models.Competitor{},
+		&models.SelectionPickStat{},
+		&models.LineHistory{},
+		&models.EventProviderMapping{},
+		&models.ResultProposal{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.OutboxMessage{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.RefreshToken{},
+		&models.Permission{},
+		&models.Role{},
+		&models.RecoveryCode{},
+		&models.TwoFactorChallenge{},
+		&models.StreamTicket{},
 	)
+	services.SeedRBAC(db)
 
 	// Reemplazar la base de datos global
 	config.DB = db
+	// Los IDs se repiten entre bases de prueba; un usuario cacheado de otro test no debe filtrarse
+	services.ClearPrincipalCache()
+	// Los tests de /admin no dan de alta 2FA; los de 2FA la vuelven a exigir
+	services.RequireStaffTwoFactor = false
 
 	return db
 }
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func createLifecycleTournament(t *testing.T, db *gorm.DB, name, status string) models.Tournament {
+	start := time.Now().Add(time.Hour)
+	tournament := models.Tournament{
+		Name:       name,
+		Category:   "Futbol",
+		Status:     status,
+		StartDate:  start,
+		EndDate:    start.Add(4 * time.Hour),
+		CreatedBy:  1,
+		PrizePool:  100,
+		EntryFee:   10,
+		Visibility: models.TournamentVisibilityPublic,
+		Settings:   models.TournamentSettings{PrizeDistribution: []float64{1}},
+	}
+	assert.NoError(t, db.Create(&tournament).Error)
+	return tournament
+}
+
+func TestTransitionTournament_ValidAndInvalidEdges(t *testing.T) {
+	db := SetupTestDB(t)
+
+	tournament := createLifecycleTournament(t, db, "Torneo Ciclo", models.TournamentStatusDraft)
+	for _, to := range []string{models.TournamentStatusOpen, models.TournamentStatusRunning, models.TournamentStatusClosed, models.TournamentStatusFinished} {
+		tx := db.Begin()
+		assert.NoError(t, services.TransitionTournament(tx, &tournament, to, nil, "test"))
+		tx.Commit()
+	}
+	var stored models.Tournament
+	assert.NoError(t, db.First(&stored, tournament.ID).Error)
+	assert.Equal(t, models.TournamentStatusFinished, stored.Status)
+
+	var history int64
+	db.Model(&models.StatusTransition{}).Where("entity_type = ? AND entity_id = ?", models.EntityTypeTournament, tournament.ID).Count(&history)
+	assert.Equal(t, int64(4), history)
+
+	// Saltos de estado, retrocesos y salidas de un estado final no están permitidos
+	invalid := map[string]string{
+		models.TournamentStatusDraft:     models.TournamentStatusRunning,
+		models.TournamentStatusOpen:      models.TournamentStatusFinished,
+		models.TournamentStatusRunning:   models.TournamentStatusOpen,
+		models.TournamentStatusFinished:  models.TournamentStatusCancelled,
+		models.TournamentStatusCancelled: models.TournamentStatusOpen,
+	}
+	for from, to := range invalid {
+		other := createLifecycleTournament(t, db, "Torneo "+from, from)
+		err := services.TransitionTournament(db, &other, to, nil, "test")
+		assert.ErrorIs(t, err, services.ErrInvalidTransition, "%s -> %s", from, to)
+		var reloaded models.Tournament
+		assert.NoError(t, db.First(&reloaded, other.ID).Error)
+		assert.Equal(t, from, reloaded.Status)
+	}
+}
+
+func TestTransitionTournament_StaleCopyConflicts(t *testing.T) {
+	db := SetupTestDB(t)
+
+	tournament := createLifecycleTournament(t, db, "Torneo Final", models.TournamentStatusClosed)
+	first, second := tournament, tournament
+
+	assert.NoError(t, services.TransitionTournament(db, &first, models.TournamentStatusFinished, nil, "primero"))
+
+	// La segunda copia todavía ve "closed" y pasa la validación, pero el compare-and-set la frena
+	err := services.TransitionTournament(db, &second, models.TournamentStatusFinished, nil, "segundo")
+	assert.ErrorIs(t, err, services.ErrTransitionConflict)
+	assert.Equal(t, models.TournamentStatusClosed, second.Status)
+
+	var history int64
+	db.Model(&models.StatusTransition{}).Where("entity_type = ? AND entity_id = ?", models.EntityTypeTournament, tournament.ID).Count(&history)
+	assert.Equal(t, int64(1), history)
+}
+
+func TestTransitionSession_StaleCopyConflicts(t *testing.T) {
+	db := SetupTestDB(t)
+
+	tournament := createLifecycleTournament(t, db, "Torneo Sesiones", models.TournamentStatusRunning)
+	session := models.Session{
+		TournamentID:  tournament.ID,
+		SessionNumber: 1,
+		StartTime:     tournament.StartDate,
+		EndTime:       tournament.StartDate.Add(time.Hour),
+		Status:        models.SessionStatusOpen,
+	}
+	assert.NoError(t, db.Create(&session).Error)
+	stale := session
+
+	assert.NoError(t, services.TransitionSession(db, &session, models.SessionStatusClosed, nil, "test"))
+	err := services.TransitionSession(db, &stale, models.SessionStatusClosed, nil, "test")
+	assert.ErrorIs(t, err, services.ErrTransitionConflict)
+
+	err = services.TransitionSession(db, &session, models.SessionStatusOpen, nil, "test")
+	assert.ErrorIs(t, err, services.ErrInvalidTransition)
+}
+
+func TestUpdateTournamentStatus_DoubleFinishPaysPrizesOnce(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	_, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+	winner, _ := createUserWithRole(t, db, "ganador", models.RoleUser)
+	wallet := models.Wallet{UserID: winner.ID}
+	assert.NoError(t, db.Create(&wallet).Error)
+
+	tournament := createLifecycleTournament(t, db, "Torneo Final", models.TournamentStatusClosed)
+	assert.NoError(t, db.Create(&models.TournamentParticipant{TournamentID: tournament.ID, UserID: winner.ID, TotalPoints: 10}).Error)
+
+	path := "/api/v1/admin/tournaments/" + utils.UintToString(tournament.ID) + "/status"
+	w := MakeAuthRequest(router, "PATCH", path, adminToken, map[string]string{"status": models.TournamentStatusFinished})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = MakeAuthRequest(router, "PATCH", path, adminToken, map[string]string{"status": models.TournamentStatusFinished})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	assert.NoError(t, db.First(&wallet, wallet.ID).Error)
+	assert.InDelta(t, 100, wallet.Balance, 0.001)
+	var prizes int64
+	db.Model(&models.Transaction{}).Where("wallet_id = ? AND type = ?", wallet.ID, "prize").Count(&prizes)
+	assert.Equal(t, int64(1), prizes)
+}
+
+func TestTransitionTournament_GuardQueryErrorBlocksFinish(t *testing.T) {
+	db := SetupTestDB(t)
+
+	tournament := createLifecycleTournament(t, db, "Torneo Roto", models.TournamentStatusClosed)
+	session := models.Session{TournamentID: tournament.ID, SessionNumber: 1, StartTime: time.Now(), EndTime: time.Now().Add(time.Hour), Status: models.SessionStatusClosed}
+	assert.NoError(t, db.Create(&session).Error)
+
+	// Si no se pueden contar las sesiones sin liquidar, el torneo no finaliza
+	tx := db.Begin()
+	assert.NoError(t, tx.Migrator().DropTable(&models.Session{}))
+	err := services.TransitionTournament(tx, &tournament, models.TournamentStatusFinished, nil, "test")
+	tx.Rollback()
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, services.ErrTransitionBlocked)
+
+	var stored models.Tournament
+	assert.NoError(t, db.First(&stored, tournament.ID).Error)
+	assert.Equal(t, models.TournamentStatusClosed, stored.Status)
+
+	// Con la sesión por liquidar, la guarda la rechaza como regla de negocio
+	err = services.TransitionTournament(db, &stored, models.TournamentStatusFinished, nil, "test")
+	assert.ErrorIs(t, err, services.ErrTransitionBlocked)
+}
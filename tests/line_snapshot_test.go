@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPicks_GradedAtLineWhenPicked(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+	_, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+	tournament, session, ids, ana, anaToken := openSlate(t, db, "ana")
+	beto, betoToken := createUserWithRole(t, db, "beto", models.RoleUser)
+	betoParticipant := models.TournamentParticipant{UserID: beto.ID, TournamentID: tournament.ID}
+	assert.NoError(t, db.Create(&betoParticipant).Error)
+
+	// Alta 7.5 en el juego A y runline -1.5 del local en el juego B
+	assert.NoError(t, db.Model(&models.PickableSelection{}).Where("id = ?", ids["A_alta"]).Update("line", 7.5).Error)
+	var alta, other models.PickableSelection
+	assert.NoError(t, db.First(&alta, ids["A_alta"]).Error)
+	assert.NoError(t, db.First(&other, ids["B_alta"]).Error)
+
+	competitors := make(map[uint][]models.EventCompetitor)
+	for _, eventID := range []uint{alta.EventID, other.EventID} {
+		pair := []models.EventCompetitor{{EventID: eventID, Name: "Local"}, {EventID: eventID, Name: "Visitante"}}
+		assert.NoError(t, db.Create(&pair).Error)
+		competitors[eventID] = pair
+	}
+	runline := models.PickableSelection{EventID: other.EventID, Description: "Local -1.5", SelectionType: models.SelectionTypeMachoRL,
+		CompetitorID: &competitors[other.EventID][0].ID, RunlineHome: -1.5, RunlineAway: 1.5, PointsForWin: 1}
+	assert.NoError(t, db.Create(&runline).Error)
+
+	picksPath := "/api/v1/tournaments/" + utils.UintToString(tournament.ID) + "/sessions/picks"
+	slate := map[string]interface{}{"session_id": session.ID, "selection_ids": []uint{alta.ID, runline.ID}}
+	w := MakeAuthRequest(router, "POST", picksPath, anaToken, slate)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// La línea se mueve a 8.5 y el runline a -2.5 / +2.5 después del pick de ana
+	w = MakeAuthRequest(router, "PUT", "/api/v1/admin/events/selections/"+utils.UintToString(alta.ID), adminToken, map[string]float64{"line": 8.5})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = MakeAuthRequest(router, "PUT", "/api/v1/admin/events/selections/"+utils.UintToString(runline.ID), adminToken, map[string]float64{"runline_home": -2.5, "runline_away": 2.5})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = MakeAuthRequest(router, "POST", picksPath, betoToken, slate)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var anaPicks, betoPicks []models.UserPick
+	assert.NoError(t, db.Where("participant_id = ?", ana.ID).Order("selection_id").Find(&anaPicks).Error)
+	assert.NoError(t, db.Where("participant_id = ?", betoParticipant.ID).Order("selection_id").Find(&betoPicks).Error)
+	assert.Len(t, anaPicks, 2)
+	assert.Len(t, betoPicks, 2)
+	assert.InDelta(t, 7.5, *anaPicks[0].LineAtPick, 0.001)
+	assert.InDelta(t, -1.5, *anaPicks[1].LineAtPick, 0.001)
+	assert.InDelta(t, 8.5, *betoPicks[0].LineAtPick, 0.001)
+	assert.InDelta(t, -2.5, *betoPicks[1].LineAtPick, 0.001)
+
+	// Mis picks muestran la línea con que ana jugó, no la actual
+	var myPicks struct {
+		Data []dtos.MyPickResponse `json:"data"`
+	}
+	w = MakeAuthRequest(router, "GET", "/api/v1/my-picks", anaToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &myPicks))
+	assert.Len(t, myPicks.Data, 2)
+	for _, pick := range myPicks.Data {
+		if pick.SelectionID == alta.ID {
+			assert.InDelta(t, 7.5, pick.Line, 0.001)
+		}
+	}
+
+	// El historial guarda la línea y ambos lados del runline
+	var fields []string
+	db.Model(&models.LineHistory{}).Where("entity_type = ?", models.LineEntitySelection).Order("id").Pluck("field", &fields)
+	assert.Equal(t, []string{models.LineFieldLine, models.LineFieldRunline, models.LineFieldRunlineAway}, fields)
+
+	// 5-3 en ambos juegos: 8 carreras superan 7.5 pero no 8.5, y ganar por 2 cubre -1.5 pero no -2.5
+	tx := db.Begin()
+	for _, eventID := range []uint{alta.EventID, other.EventID} {
+		_, err := services.SettleEvent(tx, eventID, []dtos.CompetitorResult{
+			{CompetitorID: competitors[eventID][0].ID, FinalScore: 5},
+			{CompetitorID: competitors[eventID][1].ID, FinalScore: 3},
+		}, "")
+		assert.NoError(t, err)
+	}
+	tx.Commit()
+
+	for i := range anaPicks {
+		assert.NoError(t, db.First(&anaPicks[i], anaPicks[i].ID).Error)
+		assert.NoError(t, db.First(&betoPicks[i], betoPicks[i].ID).Error)
+		assert.Equal(t, "won", anaPicks[i].Status)
+		assert.Equal(t, "lost", betoPicks[i].Status)
+	}
+}
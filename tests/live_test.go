@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveUpdate_ProjectsLeaderboardWithoutAwardingPoints(t *testing.T) {
+	db := SetupTestDB(t)
+
+	start := time.Now().Add(-time.Hour)
+	owner := models.User{Username: "admin", Email: "admin@example.com", Password: "x", Role: "admin"}
+	assert.NoError(t, db.Create(&owner).Error)
+	tournament := models.Tournament{Name: "Jornada en vivo", Category: "Beisbol", StartDate: start, EndDate: start.Add(24 * time.Hour), CreatedBy: owner.ID}
+	assert.NoError(t, db.Create(&tournament).Error)
+	session := models.Session{TournamentID: tournament.ID, SessionNumber: 1, StartTime: start, EndTime: start.Add(time.Hour)}
+	assert.NoError(t, db.Create(&session).Error)
+
+	event := models.Event{Name: "Leones vs Tigres", StartTime: start, Line: 7.5}
+	assert.NoError(t, db.Create(&event).Error)
+	pair := []models.EventCompetitor{{EventID: event.ID, Name: "Leones"}, {EventID: event.ID, Name: "Tigres"}}
+	assert.NoError(t, db.Create(&pair).Error)
+
+	alta := models.PickableSelection{EventID: event.ID, Description: "Alta 7.5", SelectionType: models.SelectionTypeAlta, Line: 7.5, PointsForWin: 2, Status: "pending"}
+	ganador := models.PickableSelection{EventID: event.ID, Description: "Leones", SelectionType: models.SelectionTypeMacho, CompetitorID: &pair[0].ID, PointsForWin: 3, Status: "pending"}
+	assert.NoError(t, db.Create(&alta).Error)
+	assert.NoError(t, db.Create(&ganador).Error)
+
+	var participants []models.TournamentParticipant
+	for i, name := range []string{"ana", "beto"} {
+		user := models.User{Username: name, Email: name + "@example.com", Password: "x", Role: "user"}
+		assert.NoError(t, db.Create(&user).Error)
+		participant := models.TournamentParticipant{UserID: user.ID, TournamentID: tournament.ID, TotalPoints: i}
+		assert.NoError(t, db.Create(&participant).Error)
+		participants = append(participants, participant)
+	}
+	// ana va con los Leones; beto con la alta
+	assert.NoError(t, db.Create(&models.UserPick{ParticipantID: participants[0].ID, SelectionID: ganador.ID, SessionID: session.ID, Status: "pending"}).Error)
+	assert.NoError(t, db.Create(&models.UserPick{ParticipantID: participants[1].ID, SelectionID: alta.ID, SessionID: session.ID, Status: "pending"}).Error)
+
+	scored := true
+	tx := db.Begin()
+	updated, err := services.ApplyLiveUpdate(tx, event.ID, dtos.LiveUpdateRequest{Period: "Baja del 6to", Competitors: []dtos.LiveCompetitorUpdate{
+		{CompetitorID: pair[0].ID, Score: 4, ScoredFirst: &scored},
+		{CompetitorID: pair[1].ID, Score: 2},
+	}}, time.Now())
+	assert.NoError(t, err)
+	tx.Commit()
+	assert.Equal(t, "live", updated.Status)
+
+	assert.Equal(t, services.ProvisionalWinning, services.ProvisionalStatus(updated, &ganador, nil))
+	assert.Equal(t, services.ProvisionalLosing, services.ProvisionalStatus(updated, &alta, nil))
+
+	standings, err := services.ProjectedLeaderboard(db, tournament.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "ana", standings[0].Username)
+	assert.Equal(t, 3, standings[0].ProjectedPoints)
+	assert.Equal(t, 1, standings[0].PicksWinning)
+	assert.Equal(t, 1, standings[1].ProjectedPoints)
+
+	// Nada quedó liquidado
+	var stored models.TournamentParticipant
+	assert.NoError(t, db.First(&stored, participants[0].ID).Error)
+	assert.Equal(t, 0, stored.TotalPoints)
+	var comp models.EventCompetitor
+	assert.NoError(t, db.First(&comp, pair[0].ID).Error)
+	assert.Equal(t, 4, comp.LiveScore)
+	assert.Equal(t, 0, comp.FinalScore)
+	assert.True(t, comp.ScoredFirst)
+
+	// Un evento liquidado ya no acepta marcador en vivo
+	tx = db.Begin()
+	_, err = services.SettleEvent(tx, event.ID, []dtos.CompetitorResult{{CompetitorID: pair[0].ID, FinalScore: 5, Position: 1}, {CompetitorID: pair[1].ID, FinalScore: 4}}, "")
+	assert.NoError(t, err)
+	tx.Commit()
+
+	tx = db.Begin()
+	_, err = services.ApplyLiveUpdate(tx, event.ID, dtos.LiveUpdateRequest{Competitors: []dtos.LiveCompetitorUpdate{{CompetitorID: pair[0].ID, Score: 6}}}, time.Now())
+	tx.Rollback()
+	assert.ErrorIs(t, err, services.ErrEventNotInPlay)
+}
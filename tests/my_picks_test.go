@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetSessionPicks_ResolvesParticipantBySession verifica que un usuario inscrito en
+// varios torneos obtenga los picks del torneo al que pertenece la sesión.
+func TestGetSessionPicks_ResolvesParticipantBySession(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	user := models.User{Username: "multitorneo", Email: "multi@example.com", Password: "x", Role: "user"}
+	db.Create(&user)
+	token, _ := utils.GenerateToken(user.ID, user.Username, user.Role, user.TokenVersion)
+
+	start := time.Now().Add(time.Hour)
+	var sessions []models.Session
+	for i := 1; i <= 2; i++ {
+		tournament := models.Tournament{
+			Name:      fmt.Sprintf("Torneo %d", i),
+			Category:  "Futbol",
+			StartDate: start,
+			EndDate:   start.Add(24 * time.Hour),
+			CreatedBy: user.ID,
+		}
+		db.Create(&tournament)
+		db.Create(&models.TournamentParticipant{UserID: user.ID, TournamentID: tournament.ID})
+
+		session := models.Session{TournamentID: tournament.ID, SessionNumber: 1, StartTime: start, EndTime: start.Add(time.Hour)}
+		db.Create(&session)
+		sessions = append(sessions, session)
+	}
+
+	// Pick en la sesión del segundo torneo
+	var participant models.TournamentParticipant
+	db.Where("user_id = ? AND tournament_id = ?", user.ID, sessions[1].TournamentID).First(&participant)
+	event := models.Event{Name: "Partido Unico", StartTime: start.Add(2 * time.Hour)}
+	db.Create(&event)
+	selection := models.PickableSelection{EventID: event.ID, Description: "Local", SelectionType: models.SelectionTypeMacho, PointsForWin: 1}
+	db.Create(&selection)
+	db.Create(&models.UserPick{ParticipantID: participant.ID, SelectionID: selection.ID, SessionID: sessions[1].ID, Status: "won", AwardedPoints: 3})
+
+	w := MakeAuthRequest(router, "GET", fmt.Sprintf("/api/v1/my-sessions/%d/picks", sessions[1].ID), token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data []models.UserPick `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Len(t, response.Data, 1)
+
+	// El resumen cuenta el pick ganado con sus puntos
+	w = MakeAuthRequest(router, "GET", "/api/v1/my-picks/summary", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var summary struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &summary)
+	assert.Len(t, summary.Data, 1)
+	assert.Equal(t, float64(1), summary.Data[0]["won"])
+	assert.Equal(t, float64(3), summary.Data[0]["points"])
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionReminders_SentOnceAndRespectPreferences(t *testing.T) {
+	db := SetupTestDB(t)
+
+	now := time.Now()
+	owner := models.User{Username: "admin", Email: "admin@example.com", Password: "x", Role: "admin"}
+	assert.NoError(t, db.Create(&owner).Error)
+	tournament := models.Tournament{Name: "Semana 1", Category: "Beisbol", StartDate: now, EndDate: now.Add(72 * time.Hour), CreatedBy: owner.ID}
+	assert.NoError(t, db.Create(&tournament).Error)
+
+	opening := models.Session{TournamentID: tournament.ID, SessionNumber: 1, StartTime: now.Add(30 * time.Minute), EndTime: now.Add(3 * time.Hour), Status: models.SessionStatusScheduled}
+	closing := models.Session{TournamentID: tournament.ID, SessionNumber: 2, StartTime: now.Add(-time.Hour), EndTime: now.Add(45 * time.Minute), Status: models.SessionStatusOpen}
+	later := models.Session{TournamentID: tournament.ID, SessionNumber: 3, StartTime: now.Add(5 * time.Hour), EndTime: now.Add(8 * time.Hour), Status: models.SessionStatusScheduled}
+	assert.NoError(t, db.Create(&[]models.Session{opening, closing, later}).Error)
+
+	var users []models.User
+	for _, name := range []string{"ana", "beto"} {
+		user := models.User{Username: name, Email: name + "@example.com", Password: "x", Role: "user"}
+		assert.NoError(t, db.Create(&user).Error)
+		assert.NoError(t, db.Create(&models.TournamentParticipant{UserID: user.ID, TournamentID: tournament.ID}).Error)
+		users = append(users, user)
+	}
+	// beto no quiere el aviso de cierre de picks
+	assert.NoError(t, db.Create(&models.NotificationPreference{UserID: users[1].ID, Type: models.NotificationPickDeadline, Enabled: false}).Error)
+
+	services.SendSessionReminders(db, now)
+	services.SendSessionReminders(db, now.Add(time.Minute))
+
+	count := func(userID uint, notificationType string) int64 {
+		var n int64
+		db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", userID, notificationType).Count(&n)
+		return n
+	}
+	assert.Equal(t, int64(1), count(users[0].ID, models.NotificationSessionOpening))
+	assert.Equal(t, int64(1), count(users[0].ID, models.NotificationPickDeadline))
+	assert.Equal(t, int64(1), count(users[1].ID, models.NotificationSessionOpening))
+	assert.Equal(t, int64(0), count(users[1].ID, models.NotificationPickDeadline))
+}
+
+func TestNotificationInbox(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	user := models.User{Username: "ana", Email: "ana@example.com", Password: "x", Role: "user"}
+	assert.NoError(t, db.Create(&user).Error)
+	other := models.User{Username: "beto", Email: "beto@example.com", Password: "x", Role: "user"}
+	assert.NoError(t, db.Create(&other).Error)
+	token, _ := utils.GenerateToken(user.ID, user.Username, user.Role, user.TokenVersion)
+
+	first, err := services.Notify(db, user.ID, models.NotificationPrizePaid, "Premio", "Ganaste", nil, "")
+	assert.NoError(t, err)
+	_, err = services.Notify(db, user.ID, models.NotificationPicksSettled, "Picks", "Liquidados", nil, "")
+	assert.NoError(t, err)
+	foreign, err := services.Notify(db, other.ID, models.NotificationPicksSettled, "Picks", "Liquidados", nil, "")
+	assert.NoError(t, err)
+
+	var unread struct {
+		Data struct {
+			Unread int64 `json:"unread"`
+		} `json:"data"`
+	}
+	w := MakeAuthRequest(router, "GET", "/api/v1/notifications/unread-count", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &unread))
+	assert.Equal(t, int64(2), unread.Data.Unread)
+
+	// No se pueden marcar notificaciones ajenas
+	w = MakeAuthRequest(router, "POST", fmt.Sprintf("/api/v1/notifications/%d/read", foreign.ID), token, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = MakeAuthRequest(router, "POST", fmt.Sprintf("/api/v1/notifications/%d/read", first.ID), token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var list struct {
+		Data []models.Notification `json:"data"`
+	}
+	w = MakeAuthRequest(router, "GET", "/api/v1/notifications?unread=true", token, nil)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Data, 1)
+	assert.Equal(t, models.NotificationPicksSettled, list.Data[0].Type)
+
+	// Preferencias: tipo inválido rechazado; desactivar un tipo bloquea nuevos avisos
+	w = MakeAuthRequest(router, "PUT", "/api/v1/notifications/preferences", token, map[string]interface{}{
+		"preferences": []map[string]interface{}{{"type": "spam", "enabled": false}},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = MakeAuthRequest(router, "PUT", "/api/v1/notifications/preferences", token, map[string]interface{}{
+		"preferences": []map[string]interface{}{{"type": models.NotificationPrizePaid, "enabled": false}},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	skipped, err := services.Notify(db, user.ID, models.NotificationPrizePaid, "Premio", "Otro", nil, "")
+	assert.NoError(t, err)
+	assert.Nil(t, skipped)
+}
+
+func TestNotifySessionPicksSettled_UsesSessionNumber(t *testing.T) {
+	db := SetupTestDB(t)
+
+	user := models.User{Username: "apostador", Email: "apostador@example.com", Password: "x", Role: "user"}
+	assert.NoError(t, db.Create(&user).Error)
+	tournament := models.Tournament{Name: "Serie Regular", Category: "Beisbol", StartDate: time.Now(), EndDate: time.Now().Add(24 * time.Hour), CreatedBy: user.ID}
+	assert.NoError(t, db.Create(&tournament).Error)
+
+	// El ID de la sesión no coincide con su número dentro del torneo
+	for i := 0; i < 4; i++ {
+		other := models.Session{TournamentID: tournament.ID, SessionNumber: 10 + i, StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)}
+		assert.NoError(t, db.Create(&other).Error)
+	}
+	session := models.Session{TournamentID: tournament.ID, SessionNumber: 2, StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)}
+	assert.NoError(t, db.Create(&session).Error)
+	assert.NotEqual(t, uint(session.SessionNumber), session.ID)
+
+	report := &services.SessionSettlement{SessionID: session.ID, Participants: []services.ParticipantAward{
+		{TournamentID: tournament.ID, UserID: user.ID, PicksWon: 2, Points: 4},
+	}}
+	services.NotifySessionPicksSettled(db, &session, report)
+
+	var notification models.Notification
+	assert.NoError(t, db.Where("user_id = ? AND type = ?", user.ID, models.NotificationPicksSettled).First(&notification).Error)
+	assert.Contains(t, notification.Body, "sesión 2:")
+	assert.NotContains(t, notification.Body, fmt.Sprintf("sesión %d:", session.ID))
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAmericanToDecimal(t *testing.T) {
+	assert.InDelta(t, 5.0, models.AmericanToDecimal(400), 0.0001)
+	assert.InDelta(t, 1.3333, models.AmericanToDecimal(-300), 0.0001)
+	assert.InDelta(t, 2.0, models.AmericanToDecimal(100), 0.0001)
+	assert.Equal(t, 0.0, models.AmericanToDecimal(0))
+}
+
+func TestOddsScoring_Points(t *testing.T) {
+	tests := []struct {
+		name    string
+		scoring models.OddsScoring
+		odds    int
+		want    int
+	}{
+		{"decimal por defecto", models.OddsScoring{}, 400, 5},
+		{"decimal favorito redondeado", models.OddsScoring{BasePoints: 10}, -300, 13},
+		{"ganancia underdog", models.OddsScoring{Formula: models.OddsFormulaProfit, BasePoints: 10}, 400, 40},
+		{"ganancia favorito floor", models.OddsScoring{Formula: models.OddsFormulaProfit, BasePoints: 10, Rounding: "floor"}, -300, 3},
+		{"ganancia favorito ceil", models.OddsScoring{Formula: models.OddsFormulaProfit, BasePoints: 10, Rounding: "ceil"}, -300, 4},
+		{"mínimo", models.OddsScoring{Formula: models.OddsFormulaProfit, MinPoints: 2}, -500, 2},
+		{"máximo", models.OddsScoring{BasePoints: 10, MaxPoints: 30}, 400, 30},
+		{"sin cuota", models.OddsScoring{BasePoints: 10}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.scoring.Points(tt.odds))
+		})
+	}
+}
+
+func TestTournamentSettings_WinPoints(t *testing.T) {
+	selection := &models.PickableSelection{Odds: 150, PointsForWin: 3}
+
+	fixed := models.TournamentSettings{}
+	assert.Equal(t, 3, fixed.WinPoints(selection))
+
+	odds := models.TournamentSettings{
+		ScoringMode: models.ScoringModeOdds,
+		OddsScoring: models.OddsScoring{Formula: models.OddsFormulaProfit, BasePoints: 4},
+	}
+	assert.Equal(t, 6, odds.WinPoints(selection))
+
+	// Sin cuota se usan los puntos fijos
+	assert.Equal(t, 7, odds.WinPoints(&models.PickableSelection{PointsForWin: 7}))
+}
+
+func TestGetSelectionPoints_DoesNotRewriteSharedSelection(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+	_, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+
+	start := time.Now().Add(2 * time.Hour)
+	event := models.Event{Name: "Partido Compartido", StartTime: start}
+	assert.NoError(t, db.Create(&event).Error)
+	selection := models.PickableSelection{EventID: event.ID, SelectionType: "macho", Odds: 400, PointsForWin: 3, Status: "pending"}
+	assert.NoError(t, db.Create(&selection).Error)
+
+	newTournament := func(name string, settings models.TournamentSettings) models.Tournament {
+		tournament := models.Tournament{Name: name, Category: "Futbol", Status: models.TournamentStatusOpen, StartDate: start, EndDate: start.Add(time.Hour), Settings: settings}
+		assert.NoError(t, db.Create(&tournament).Error)
+		assert.NoError(t, db.Create(&models.TournamentEvent{TournamentID: tournament.ID, EventID: event.ID}).Error)
+		return tournament
+	}
+	oddsTournament := newTournament("Por Cuotas", models.TournamentSettings{
+		ScoringMode: models.ScoringModeOdds,
+		OddsScoring: models.OddsScoring{Formula: models.OddsFormulaProfit, BasePoints: 10},
+	})
+	fixedTournament := newTournament("Puntos Fijos", models.TournamentSettings{})
+
+	pointsOf := func(tournament models.Tournament) int {
+		var body struct {
+			Data []dtos.SelectionPointsResponse `json:"data"`
+		}
+		w := MakeAuthRequest(router, "GET", "/api/v1/admin/tournaments/"+utils.UintToString(tournament.ID)+"/selection-points", adminToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Len(t, body.Data, 1)
+		return body.Data[0].PointsForWin
+	}
+	assert.Equal(t, 40, pointsOf(oddsTournament))
+	assert.Equal(t, 3, pointsOf(fixedTournament))
+
+	// La selección es compartida: el torneo por cuotas no altera los puntos fijos del otro
+	var stored models.PickableSelection
+	assert.NoError(t, db.First(&stored, selection.ID).Error)
+	assert.Equal(t, 3, stored.PointsForWin)
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeNotifier registra los mensajes enviados y falla mientras err no sea nil
+type fakeNotifier struct {
+	channel string
+	err     error
+	sent    []services.OutboundMessage
+}
+
+func (n *fakeNotifier) Channel() string { return n.channel }
+
+func (n *fakeNotifier) Send(ctx context.Context, msg services.OutboundMessage) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestCreateWithdrawal_CodeOnlyTravelsThroughOutbox(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	user := models.User{Username: "ana", Email: "ana@example.com", Phone: "+584141234567", Password: "x", Role: "user"}
+	assert.NoError(t, db.Create(&user).Error)
+	assert.NoError(t, db.Create(&models.Wallet{UserID: user.ID, Balance: 100}).Error)
+	method := models.UserPaymentMethod{UserID: user.ID, Method: "zelle", ZelleEmail: "ana@example.com"}
+	assert.NoError(t, db.Create(&method).Error)
+	token, _ := utils.GenerateToken(user.ID, user.Username, user.Role, user.TokenVersion)
+
+	w := MakeAuthRequest(router, "POST", "/api/v1/wallet/withdraw", token, map[string]interface{}{
+		"amount": 20, "payment_method_id": method.ID,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var withdrawal models.Withdrawal
+	assert.NoError(t, db.Where("user_id = ?", user.ID).First(&withdrawal).Error)
+	assert.Regexp(t, `^[0-9]{6}$`, withdrawal.WithdrawalCode)
+	assert.NotContains(t, w.Body.String(), withdrawal.WithdrawalCode)
+	assert.NotContains(t, w.Body.String(), "ana@example.com")
+
+	var messages []models.OutboxMessage
+	assert.NoError(t, db.Where("user_id = ?", user.ID).Order("id").Find(&messages).Error)
+	assert.Len(t, messages, 2)
+	for _, msg := range messages {
+		assert.Equal(t, models.OutboxStatusPending, msg.Status)
+		assert.True(t, strings.Contains(msg.Body, withdrawal.WithdrawalCode))
+		if assert.NotNil(t, msg.ExpiresAt) {
+			assert.True(t, msg.ExpiresAt.After(time.Now().Add(29*time.Minute)))
+		}
+	}
+	assert.Equal(t, models.ChannelEmail, messages[0].Channel)
+	assert.Equal(t, models.ChannelSMS, messages[1].Channel)
+}
+
+func TestOutboxDispatcher_RetriesWithBackoff(t *testing.T) {
+	db := SetupTestDB(t)
+
+	msg, err := services.EnqueueMessage(db, nil, models.ChannelEmail, "ana@example.com", services.TemplateWithdrawalOTP, services.LocaleEnglish,
+		map[string]interface{}{"Username": "ana", "Amount": 20.0, "Code": "123456", "ExpiresIn": 30})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	email := &fakeNotifier{channel: models.ChannelEmail, err: errors.New("smtp caído")}
+	dispatcher := services.NewOutboxDispatcher(db, time.Minute, email)
+
+	assert.Equal(t, 0, dispatcher.DispatchOnce(context.Background(), now))
+	var stored models.OutboxMessage
+	assert.NoError(t, db.First(&stored, msg.ID).Error)
+	assert.Equal(t, models.OutboxStatusPending, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.Equal(t, "smtp caído", stored.LastError)
+	assert.True(t, stored.NextAttemptAt.After(now))
+
+	// Antes de que venza la espera no se reintenta
+	email.err = nil
+	assert.Equal(t, 0, dispatcher.DispatchOnce(context.Background(), now.Add(10*time.Second)))
+
+	assert.Equal(t, 1, dispatcher.DispatchOnce(context.Background(), now.Add(time.Minute)))
+	assert.NoError(t, db.First(&stored, msg.ID).Error)
+	assert.Equal(t, models.OutboxStatusSent, stored.Status)
+	assert.Equal(t, 2, stored.Attempts)
+	assert.Len(t, email.sent, 1)
+	assert.Contains(t, email.sent[0].Body, "123456")
+	assert.Empty(t, stored.Body)
+}
+
+func TestOutboxDispatcher_DropsExpiredCodes(t *testing.T) {
+	db := SetupTestDB(t)
+
+	user := models.User{Username: "ana", Email: "ana@example.com", Password: "x", Role: "user"}
+	assert.NoError(t, db.Create(&user).Error)
+	now := time.Now()
+	assert.NoError(t, services.EnqueueExpiringUserMessage(db, &user, services.TemplateWithdrawalOTP, map[string]interface{}{
+		"Username": "ana", "Amount": 20.0, "Code": "654321", "ExpiresIn": 30,
+	}, now.Add(30*time.Minute)))
+
+	email := &fakeNotifier{channel: models.ChannelEmail}
+	dispatcher := services.NewOutboxDispatcher(db, time.Minute, email)
+
+	// El proveedor estuvo caído hasta después del vencimiento: el código ya no se envía
+	assert.Equal(t, 0, dispatcher.DispatchOnce(context.Background(), now.Add(31*time.Minute)))
+	assert.Empty(t, email.sent)
+
+	var stored models.OutboxMessage
+	assert.NoError(t, db.Where("user_id = ?", user.ID).First(&stored).Error)
+	assert.Equal(t, models.OutboxStatusExpired, stored.Status)
+	assert.Empty(t, stored.Body)
+}
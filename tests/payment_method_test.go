@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentMethods_ScopedToOwner(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	_, anaToken := createUserWithRole(t, db, "ana", models.RoleUser)
+	beto, betoToken := createUserWithRole(t, db, "beto", models.RoleUser)
+
+	var created struct {
+		Data dtos.UserPaymentMethodResponse `json:"data"`
+	}
+	w := MakeAuthRequest(router, "POST", "/api/v1/payment-methods", betoToken, map[string]string{"method": "zelle", "zelle_email": "beto@example.com"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	var method models.UserPaymentMethod
+	assert.NoError(t, db.First(&method, created.Data.ID).Error)
+	assert.Equal(t, beto.ID, method.UserID)
+
+	// Ana no ve ni borra el método de Beto
+	w = MakeAuthRequest(router, "GET", "/api/v1/payment-methods", anaToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "beto@example.com")
+	w = MakeAuthRequest(router, "DELETE", "/api/v1/payment-methods/"+utils.UintToString(method.ID), anaToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = MakeAuthRequest(router, "GET", "/api/v1/payment-methods", betoToken, nil)
+	assert.Contains(t, w.Body.String(), "beto@example.com")
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupSlate crea un torneo con una sesión de dos eventos y sus selecciones
+func setupSlate(t *testing.T, db *gorm.DB, settings models.TournamentSettings) (models.Tournament, models.Session, map[string]uint) {
+	start := time.Now().Add(time.Hour)
+	tournament := models.Tournament{
+		Name:      "Torneo Reglas",
+		Category:  "Beisbol",
+		Status:    models.TournamentStatusOpen,
+		StartDate: start,
+		EndDate:   start.Add(24 * time.Hour),
+		Settings:  settings,
+		CreatedBy: 1,
+	}
+	assert.NoError(t, db.Create(&tournament).Error)
+
+	session := models.Session{TournamentID: tournament.ID, SessionNumber: 1, StartTime: start, EndTime: start.Add(time.Hour)}
+	assert.NoError(t, db.Create(&session).Error)
+
+	ids := make(map[string]uint)
+	for _, name := range []string{"A", "B"} {
+		event := models.Event{Name: "Juego " + name, StartTime: start.Add(2 * time.Hour)}
+		assert.NoError(t, db.Create(&event).Error)
+		assert.NoError(t, db.Create(&models.TournamentEvent{TournamentID: tournament.ID, EventID: event.ID, SessionID: &session.ID}).Error)
+
+		for _, selectionType := range []string{models.SelectionTypeMacho, models.SelectionTypeHembra, models.SelectionTypeAlta, models.SelectionTypeBaja} {
+			selection := models.PickableSelection{EventID: event.ID, Description: name + " " + selectionType, SelectionType: selectionType, Line: 8.5, PointsForWin: 1}
+			assert.NoError(t, db.Create(&selection).Error)
+			ids[name+"_"+selectionType] = selection.ID
+		}
+	}
+
+	return tournament, session, ids
+}
+
+func violationCodes(violations []services.RuleViolation) []string {
+	codes := make([]string, 0, len(violations))
+	for _, v := range violations {
+		codes = append(codes, v.Code)
+	}
+	return codes
+}
+
+func TestValidateSlate_Valid(t *testing.T) {
+	db := SetupTestDB(t)
+	tournament, session, ids := setupSlate(t, db, models.TournamentSettings{
+		SelectionsPerSession:   2,
+		RequiredSelectionTypes: []string{models.SelectionTypeAlta, models.SelectionTypeMacho},
+	})
+
+	// Los tipos obligatorios se cumplen sin importar el orden
+	violations, err := services.ValidateSlate(db, &tournament, &session, []uint{ids["A_macho"], ids["B_alta"]})
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestValidateSlate_ReportsAllViolations(t *testing.T) {
+	db := SetupTestDB(t)
+	tournament, session, ids := setupSlate(t, db, models.TournamentSettings{
+		SelectionsPerSession:   2,
+		RequiredSelectionTypes: []string{models.SelectionTypeMacho, models.SelectionTypeMacho},
+	})
+
+	// Selección de otra sesión
+	otherEvent := models.Event{Name: "Juego Otra Sesion", StartTime: time.Now().Add(3 * time.Hour)}
+	db.Create(&otherEvent)
+	outside := models.PickableSelection{EventID: otherEvent.ID, Description: "Otro macho", SelectionType: models.SelectionTypeMacho, PointsForWin: 1}
+	db.Create(&outside)
+
+	violations, err := services.ValidateSlate(db, &tournament, &session, []uint{ids["A_alta"], ids["A_baja"], outside.ID})
+	assert.NoError(t, err)
+
+	codes := violationCodes(violations)
+	assert.Contains(t, codes, services.RuleSelectionCount)
+	assert.Contains(t, codes, services.RuleRequiredTypes)
+	assert.Contains(t, codes, services.RuleSelectionNotSession)
+	assert.Contains(t, codes, services.RuleDuplicateEvent)
+	assert.Contains(t, codes, services.RuleContradictoryPicks)
+}
+
+func TestValidateSlate_CategorySettings(t *testing.T) {
+	db := SetupTestDB(t)
+	tournament, session, ids := setupSlate(t, db, models.TournamentSettings{FreeSelection: true})
+
+	db.Create(&models.Category{
+		Name: "Beisbol",
+		Slug: "beisbol",
+		Settings: models.CategorySettingsJSON{
+			MinSelectionsPerSession: 1,
+			MaxSelectionsPerSession: 2,
+			AllowMultipleEvents:     true,
+		},
+	})
+
+	// Varios picks del mismo evento permitidos por la categoría, pero no más del máximo
+	violations, err := services.ValidateSlate(db, &tournament, &session, []uint{ids["A_macho"], ids["A_alta"], ids["B_baja"]})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{services.RuleTooManySelections}, violationCodes(violations))
+
+	// Una edición parcial no exige la cantidad
+	violations, err = services.ValidatePartialSlate(db, &tournament, &session, []uint{ids["A_macho"], ids["A_alta"], ids["B_baja"]})
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickStats_IncrementalAndContrarian(t *testing.T) {
+	db := SetupTestDB(t)
+	tournament, session, ids := setupSlate(t, db, models.TournamentSettings{FreeSelection: true})
+
+	var users []models.User
+	for _, name := range []string{"ana", "beto", "carla", "dani"} {
+		user := models.User{Username: name, Email: name + "@example.com", Password: "x", Role: "user"}
+		db.Create(&user)
+		users = append(users, user)
+	}
+
+	var favorite, underdog models.PickableSelection
+	db.First(&favorite, ids["A_macho"])
+	db.First(&underdog, ids["A_hembra"])
+
+	// Tres eligen al favorito y uno a la sorpresa
+	for i, user := range users {
+		participant := models.TournamentParticipant{UserID: user.ID, TournamentID: tournament.ID}
+		db.Create(&participant)
+
+		selection := favorite
+		status := "lost"
+		if i == 3 {
+			selection = underdog
+			status = "won"
+		}
+		db.Create(&models.UserPick{ParticipantID: participant.ID, SelectionID: selection.ID, SessionID: session.ID, Status: status})
+		assert.NoError(t, services.AdjustPickStat(db, tournament.ID, session.ID, &selection, 1))
+	}
+
+	var stat models.SelectionPickStat
+	db.Where("tournament_id = ? AND selection_id = ?", tournament.ID, favorite.ID).First(&stat)
+	assert.Equal(t, 3, stat.PickCount)
+
+	// Quitar un pick descuenta el contador y reconstruir da el mismo resultado
+	assert.NoError(t, services.AdjustPickStat(db, tournament.ID, session.ID, &favorite, -1))
+	db.First(&stat, stat.ID)
+	assert.Equal(t, 2, stat.PickCount)
+
+	assert.NoError(t, services.RebuildPickStats(db, tournament.ID))
+	var rebuilt models.SelectionPickStat
+	db.Where("tournament_id = ? AND selection_id = ?", tournament.ID, favorite.ID).First(&rebuilt)
+	assert.Equal(t, 3, rebuilt.PickCount)
+
+	scores, err := services.ContrarianScores(db, tournament.ID)
+	assert.NoError(t, err)
+	assert.Len(t, scores, 4)
+	assert.Equal(t, "dani", scores[0].Username)
+	assert.InDelta(t, 75.0, scores[0].Score, 0.001)
+	assert.InDelta(t, 25.0, scores[1].Score, 0.001)
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParticipantCard_HidesPicksUntilLock(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+	tournament, session, ids := setupSlate(t, db, models.TournamentSettings{FreeSelection: true})
+
+	ana, anaToken := createUserWithRole(t, db, "ana", models.RoleUser)
+	beto, betoToken := createUserWithRole(t, db, "beto", models.RoleUser)
+	anaParticipant := models.TournamentParticipant{UserID: ana.ID, TournamentID: tournament.ID}
+	betoParticipant := models.TournamentParticipant{UserID: beto.ID, TournamentID: tournament.ID}
+	assert.NoError(t, db.Create(&anaParticipant).Error)
+	assert.NoError(t, db.Create(&betoParticipant).Error)
+	assert.NoError(t, db.Create(&models.UserPick{ParticipantID: anaParticipant.ID, SelectionID: ids["A_macho"], SessionID: session.ID}).Error)
+	assert.NoError(t, db.Create(&models.UserPick{ParticipantID: betoParticipant.ID, SelectionID: ids["B_alta"], SessionID: session.ID}).Error)
+
+	cardPath := "/api/v1/tournaments/" + utils.UintToString(tournament.ID) + "/participants/" + utils.UintToString(anaParticipant.ID) + "/card"
+	popularityPath := "/api/v1/tournaments/" + utils.UintToString(tournament.ID) + "/sessions/" + utils.UintToString(session.ID) + "/popularity"
+	card := func(token string) dtos.ParticipantCardResponse {
+		var body struct {
+			Data dtos.ParticipantCardResponse `json:"data"`
+		}
+		w := MakeAuthRequest(router, "GET", cardPath, token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Len(t, body.Data.Sessions, 1)
+		return body.Data
+	}
+	popularity := func() []dtos.SelectionPopularity {
+		var body struct {
+			Data []dtos.SelectionPopularity `json:"data"`
+		}
+		w := MakeAuthRequest(router, "GET", popularityPath, betoToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Data
+	}
+
+	// Antes del inicio del evento Beto solo sabe cuántos picks tiene Ana; ella ve los suyos
+	hidden := card(betoToken)
+	assert.Empty(t, hidden.Sessions[0].Picks)
+	assert.Equal(t, 1, hidden.Sessions[0].HiddenCount)
+	own := card(anaToken)
+	assert.Len(t, own.Sessions[0].Picks, 1)
+	assert.Empty(t, popularity())
+
+	// Al comenzar el evento del pick se revela, aunque la sesión siga abierta
+	var selection models.PickableSelection
+	assert.NoError(t, db.First(&selection, ids["A_macho"]).Error)
+	assert.NoError(t, db.Model(&models.Event{}).Where("id = ?", selection.EventID).Update("start_time", time.Now().Add(-time.Minute)).Error)
+
+	revealed := card(betoToken)
+	assert.Equal(t, 0, revealed.Sessions[0].HiddenCount)
+	if assert.Len(t, revealed.Sessions[0].Picks, 1) {
+		assert.Equal(t, ids["A_macho"], revealed.Sessions[0].Picks[0].SelectionID)
+	}
+
+	// La popularidad solo incluye la selección bloqueada, sobre los dos participantes
+	stats := popularity()
+	if assert.Len(t, stats, 1) {
+		assert.Equal(t, ids["A_macho"], stats[0].SelectionID)
+		assert.Equal(t, int64(1), stats[0].PickCount)
+		assert.InDelta(t, 50.0, stats[0].Percentage, 0.001)
+	}
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPrincipal_CachesUntilInvalidated(t *testing.T) {
+	db := SetupTestDB(t)
+
+	user := models.User{Username: "ana", Email: "ana@example.com", Password: "x", Role: "admin"}
+	assert.NoError(t, db.Create(&user).Error)
+
+	principal, err := services.LoadPrincipal(db, user.ID)
+	assert.NoError(t, err)
+	assert.True(t, principal.IsAdmin())
+	assert.True(t, principal.IsActive)
+
+	// Un cambio fuera de los handlers no se ve hasta invalidar o vencer la caché
+	assert.NoError(t, db.Model(&user).Update("role", "user").Error)
+	principal, _ = services.LoadPrincipal(db, user.ID)
+	assert.True(t, principal.IsAdmin())
+
+	services.InvalidatePrincipal(user.ID)
+	principal, _ = services.LoadPrincipal(db, user.ID)
+	assert.False(t, principal.IsAdmin())
+}
+
+func TestDemotedAdminLosesAccessImmediately(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	root := models.User{Username: "root", Email: "root@example.com", Password: "x", Role: "admin"}
+	assert.NoError(t, db.Create(&root).Error)
+	other := models.User{Username: "beto", Email: "beto@example.com", Password: "x", Role: "admin"}
+	assert.NoError(t, db.Create(&other).Error)
+	rootToken, _ := utils.GenerateToken(root.ID, root.Username, root.Role, root.TokenVersion)
+	otherToken, _ := utils.GenerateToken(other.ID, other.Username, other.Role, other.TokenVersion)
+
+	// Cachea el principal de beto como admin
+	w := MakeAuthRequest(router, "GET", "/api/v1/admin/users", otherToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(other.ID)+"/role", rootToken, map[string]string{"role": "user"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = MakeAuthRequest(router, "GET", "/api/v1/admin/users", otherToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Con un token nuevo entra como usuario común
+	assert.NoError(t, db.First(&other, other.ID).Error)
+	otherToken, _ = utils.GenerateToken(other.ID, other.Username, "admin", other.TokenVersion)
+	w = MakeAuthRequest(router, "GET", "/api/v1/admin/users", otherToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func createUserWithRole(t *testing.T, db *gorm.DB, username, role string) (models.User, string) {
+	user := models.User{Username: username, Email: username + "@example.com", Password: "x", Role: role, IsActive: true}
+	assert.NoError(t, db.Create(&user).Error)
+	token, _ := utils.GenerateToken(user.ID, user.Username, user.Role, user.TokenVersion)
+	return user, token
+}
+
+func TestWithdrawalOperator_ApprovesWithdrawalsButCannotSettle(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	_, operatorToken := createUserWithRole(t, db, "caja", models.RoleWithdrawalOperator)
+	player, _ := createUserWithRole(t, db, "jugador", models.RoleUser)
+
+	wallet := models.Wallet{UserID: player.ID, Balance: 30, BonusBalance: 20, FrozenBalance: 40}
+	assert.NoError(t, db.Create(&wallet).Error)
+	method := models.UserPaymentMethod{UserID: player.ID, Method: "zelle", ZelleEmail: "jugador@example.com"}
+	assert.NoError(t, db.Create(&method).Error)
+	withdrawal := models.Withdrawal{UserID: player.ID, Amount: 40, PaymentMethodID: method.ID, Status: "pending", Verified: true}
+	assert.NoError(t, db.Create(&withdrawal).Error)
+
+	w := MakeAuthRequest(router, "GET", "/api/v1/admin/withdrawals?status=pending", operatorToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "jugador@example.com")
+
+	w = MakeAuthRequest(router, "POST", "/api/v1/admin/withdrawals/"+utils.UintToString(withdrawal.ID)+"/approve", operatorToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Se descuenta primero el saldo líquido y el resto del bono
+	assert.NoError(t, db.First(&wallet, wallet.ID).Error)
+	assert.InDelta(t, 0, wallet.Balance, 0.001)
+	assert.InDelta(t, 10, wallet.BonusBalance, 0.001)
+	assert.InDelta(t, 0, wallet.FrozenBalance, 0.001)
+	var trx models.Transaction
+	assert.NoError(t, db.Where("wallet_id = ? AND type = ?", wallet.ID, "withdraw").First(&trx).Error)
+	assert.InDelta(t, -40, trx.Amount, 0.001)
+
+	// Ya aprobado no se puede rechazar
+	w = MakeAuthRequest(router, "POST", "/api/v1/admin/withdrawals/"+utils.UintToString(withdrawal.ID)+"/reject", operatorToken, map[string]string{"reason": "Tarde para rechazar"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = MakeAuthRequest(router, "POST", "/api/v1/admin/events/1/settle", operatorToken, map[string]interface{}{})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), models.PermEventsSettle)
+}
+
+func TestAuditor_ReadsButCannotWrite(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	_, auditorToken := createUserWithRole(t, db, "auditora", models.RoleAuditor)
+	player, _ := createUserWithRole(t, db, "jugador", models.RoleUser)
+
+	w := MakeAuthRequest(router, "GET", "/api/v1/admin/users", auditorToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = MakeAuthRequest(router, "GET", "/api/v1/admin/withdrawals", auditorToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(player.ID)+"/status", auditorToken, map[string]bool{"is_active": false})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = MakeAuthRequest(router, "POST", "/api/v1/admin/webhooks", auditorToken, map[string]interface{}{})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = MakeAuthRequest(router, "GET", "/api/v1/admin/roles", auditorToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRoleManager_CannotGrantMoreThanItHas(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	_, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+	manager, _ := createUserWithRole(t, db, "rrhh", models.RoleUser)
+	player, _ := createUserWithRole(t, db, "jugador", models.RoleUser)
+
+	w := MakeAuthRequest(router, "POST", "/api/v1/admin/roles", adminToken, map[string]interface{}{
+		"name":        "role_manager",
+		"permissions": []string{models.PermRolesManage, models.PermUsersRead},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(manager.ID)+"/role", adminToken, map[string]string{"role": "role_manager"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.NoError(t, db.First(&manager, manager.ID).Error)
+	managerToken, _ := utils.GenerateToken(manager.ID, manager.Username, manager.Role, manager.TokenVersion)
+
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(player.ID)+"/role", managerToken, map[string]string{"role": models.RoleAdmin})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(manager.ID)+"/role", managerToken, map[string]string{"role": models.RoleUser})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = MakeAuthRequest(router, "POST", "/api/v1/admin/roles", managerToken, map[string]interface{}{
+		"name":        "settler",
+		"permissions": []string{models.PermEventsSettle},
+	})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(player.ID)+"/role", managerToken, map[string]string{"role": "no_existe"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Sí puede asignar un rol cuyos permisos tiene
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(player.ID)+"/role", managerToken, map[string]string{"role": "role_manager"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Un rol asignado no se puede eliminar y los del sistema tampoco
+	var role models.Role
+	assert.NoError(t, db.Where("name = ?", "role_manager").First(&role).Error)
+	w = MakeAuthRequest(router, "DELETE", "/api/v1/admin/roles/"+utils.UintToString(role.ID), adminToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var adminRole models.Role
+	assert.NoError(t, db.Where("name = ?", models.RoleAdmin).First(&adminRole).Error)
+	w = MakeAuthRequest(router, "DELETE", "/api/v1/admin/roles/"+utils.UintToString(adminRole.ID), adminToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestRoleManager_CannotDemoteAdmin(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	admin, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+	otherAdmin, _ := createUserWithRole(t, db, "root2", models.RoleAdmin)
+	operator, _ := createUserWithRole(t, db, "caja", models.RoleWithdrawalOperator)
+	manager, _ := createUserWithRole(t, db, "rrhh", models.RoleUser)
+
+	w := MakeAuthRequest(router, "POST", "/api/v1/admin/roles", adminToken, map[string]interface{}{
+		"name":        "role_manager",
+		"permissions": []string{models.PermRolesManage, models.PermUsersRead},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(manager.ID)+"/role", adminToken, map[string]string{"role": "role_manager"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, db.First(&manager, manager.ID).Error)
+	managerToken, _ := utils.GenerateToken(manager.ID, manager.Username, manager.Role, manager.TokenVersion)
+
+	// Degradar a un admin a un rol sin permisos sería quitarle permisos que el actor no tiene
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(admin.ID)+"/role", managerToken, map[string]string{"role": models.RoleUser})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(admin.ID)+"/role", managerToken, map[string]string{"role": "role_manager"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Tampoco a un operador con permisos que el actor no tiene
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(operator.ID)+"/role", managerToken, map[string]string{"role": models.RoleUser})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var stored models.User
+	assert.NoError(t, db.First(&stored, admin.ID).Error)
+	assert.Equal(t, models.RoleAdmin, stored.Role)
+	var storedOperator models.User
+	assert.NoError(t, db.First(&storedOperator, operator.ID).Error)
+	assert.Equal(t, models.RoleWithdrawalOperator, storedOperator.Role)
+
+	// Un admin sí puede cambiar el rol de otro admin
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(otherAdmin.ID)+"/role", adminToken, map[string]string{"role": models.RoleUser})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestUserManager_CannotDeactivateAdmin(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	admin, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+	player, _ := createUserWithRole(t, db, "ana", models.RoleUser)
+	manager, _ := createUserWithRole(t, db, "soporte", models.RoleUser)
+
+	w := MakeAuthRequest(router, "POST", "/api/v1/admin/roles", adminToken, map[string]interface{}{
+		"name":        "user_manager",
+		"permissions": []string{models.PermUsersManage, models.PermUsersRead},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(manager.ID)+"/role", adminToken, map[string]string{"role": "user_manager"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, db.First(&manager, manager.ID).Error)
+	managerToken, _ := utils.GenerateToken(manager.ID, manager.Username, manager.Role, manager.TokenVersion)
+
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(admin.ID)+"/status", managerToken, map[string]bool{"is_active": false})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var stored models.User
+	assert.NoError(t, db.First(&stored, admin.ID).Error)
+	assert.True(t, stored.IsActive)
+
+	// Sobre un jugador solo cambia is_active
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(player.ID)+"/status", managerToken, map[string]bool{"is_active": false})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var storedPlayer models.User
+	assert.NoError(t, db.First(&storedPlayer, player.ID).Error)
+	assert.False(t, storedPlayer.IsActive)
+	assert.Equal(t, player.Password, storedPlayer.Password)
+}
+
+func TestUserManager_CannotDeactivateOperatorWithMorePermissions(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	_, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+	cashier, _ := createUserWithRole(t, db, "caja", models.RoleWithdrawalOperator)
+	helper, _ := createUserWithRole(t, db, "ayudante", models.RoleUser)
+	manager, _ := createUserWithRole(t, db, "soporte", models.RoleUser)
+
+	// Dos roles de operador: el gestor puede administrar usuarios, el ayudante solo verlos
+	for name, permissions := range map[string][]string{
+		"user_manager": {models.PermUsersManage, models.PermUsersRead},
+		"user_viewer":  {models.PermUsersRead},
+	} {
+		w := MakeAuthRequest(router, "POST", "/api/v1/admin/roles", adminToken, map[string]interface{}{"name": name, "permissions": permissions})
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+	for user, role := range map[uint]string{manager.ID: "user_manager", helper.ID: "user_viewer"} {
+		w := MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(user)+"/role", adminToken, map[string]string{"role": role})
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.NoError(t, db.First(&manager, manager.ID).Error)
+	managerToken, _ := utils.GenerateToken(manager.ID, manager.Username, manager.Role, manager.TokenVersion)
+
+	// El operador de retiros tiene permisos que el gestor no tiene: no puede desactivarlo
+	w := MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(cashier.ID)+"/status", managerToken, map[string]bool{"is_active": false})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var storedCashier models.User
+	assert.NoError(t, db.First(&storedCashier, cashier.ID).Error)
+	assert.True(t, storedCashier.IsActive)
+
+	// Al ayudante, cuyos permisos el gestor sí tiene, puede desactivarlo
+	w = MakeAuthRequest(router, "PATCH", "/api/v1/admin/users/"+utils.UintToString(helper.ID)+"/status", managerToken, map[string]bool{"is_active": false})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var storedHelper models.User
+	assert.NoError(t, db.First(&storedHelper, helper.ID).Error)
+	assert.False(t, storedHelper.IsActive)
+}
@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/controllers"
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// nextSSEvent lee el siguiente evento SSE y devuelve su nombre y datos
+func nextSSEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	var name, data string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("flujo cerrado: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimPrefix(line, "data:")
+		case line == "" && name != "":
+			return name, data
+		}
+	}
+}
+
+// streamTicket pide un ticket de un solo uso para abrir el flujo
+func streamTicket(t *testing.T, serverURL, token string) string {
+	req, _ := http.NewRequest(http.MethodPost, serverURL+"/api/v1/stream/ticket", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var body struct {
+		Data dtos.StreamTicketResponse `json:"data"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.NotEmpty(t, body.Data.Ticket)
+	return body.Data.Ticket
+}
+
+// openStream abre /stream con la query indicada y devuelve la respuesta sin leer
+func openStream(t *testing.T, ctx context.Context, serverURL, query string) *http.Response {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, serverURL+"/api/v1/stream?"+query, nil)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	return resp
+}
+
+func createStreamTournament(t *testing.T, db *gorm.DB, id uint, visibility string) models.Tournament {
+	start := time.Now().Add(time.Hour)
+	tournament := models.Tournament{BaseModel: models.BaseModel{ID: id}, Name: "Torneo " + utils.UintToString(id), Category: "Futbol",
+		Status: models.TournamentStatusOpen, Visibility: visibility, StartDate: start, EndDate: start.Add(time.Hour)}
+	assert.NoError(t, db.Create(&tournament).Error)
+	return tournament
+}
+
+func TestStreamUpdates_DeliversTournamentAndPrivateMessages(t *testing.T) {
+	db := SetupTestDB(t)
+	server := httptest.NewServer(SetupRouter())
+	user := models.User{BaseModel: models.BaseModel{ID: 42}, Username: "ana", Email: "ana@example.com", Password: "x", Role: "user", IsActive: true}
+	assert.NoError(t, db.Create(&user).Error)
+	createStreamTournament(t, db, 7, models.TournamentVisibilityPublic)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Sin token ni ticket no se abre el flujo, y el JWT ya no se acepta en la URL
+	token, err := utils.GenerateToken(user.ID, user.Username, user.Role, user.TokenVersion)
+	assert.NoError(t, err)
+	for _, query := range []string{"tournaments=7", "tournaments=7&token=" + token, "tournaments=7&ticket=inventado"} {
+		resp := openStream(t, ctx, server.URL, query)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, query)
+	}
+
+	ticket := streamTicket(t, server.URL, token)
+	resp := openStream(t, ctx, server.URL, "tournaments=7&ticket="+ticket)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+	// El ticket es de un solo uso
+	reused := openStream(t, ctx, server.URL, "tournaments=7&ticket="+ticket)
+	reused.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, reused.StatusCode)
+
+	reader := bufio.NewReader(resp.Body)
+	name, data := nextSSEvent(t, reader)
+	assert.Equal(t, "ready", name)
+	assert.Contains(t, data, "tournament:7")
+	assert.Contains(t, data, "user:42")
+
+	// Mensajes de otros usuarios o torneos no llegan; los suscritos sí, en orden
+	services.PublishWalletBalance(&models.Wallet{UserID: 99, Balance: 1})
+	services.Publish(services.TournamentChannel(8), services.MessageSessionStatus, map[string]int{"session_id": 1})
+	services.PublishSessionStatus(db, &models.Session{BaseModel: models.BaseModel{ID: 3}, TournamentID: 7, SessionNumber: 2, Status: "closed"})
+	services.PublishWalletBalance(&models.Wallet{UserID: 42, Balance: 150, BonusBalance: 10})
+
+	name, data = nextSSEvent(t, reader)
+	assert.Equal(t, services.MessageSessionStatus, name)
+	assert.Contains(t, data, `"status":"closed"`)
+
+	name, data = nextSSEvent(t, reader)
+	assert.Equal(t, services.MessageWalletBalance, name)
+	assert.Contains(t, data, `"total_available":160`)
+}
+
+func TestStreamUpdates_PrivateTournamentRequiresMembership(t *testing.T) {
+	db := SetupTestDB(t)
+	server := httptest.NewServer(SetupRouter())
+	defer server.Close()
+
+	member, memberToken := createUserWithRole(t, db, "socia", models.RoleUser)
+	_, outsiderToken := createUserWithRole(t, db, "curioso", models.RoleUser)
+	private := createStreamTournament(t, db, 9, models.TournamentVisibilityPrivate)
+	assert.NoError(t, db.Create(&models.TournamentParticipant{TournamentID: private.ID, UserID: member.ID}).Error)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp := openStream(t, ctx, server.URL, "tournaments=9&ticket="+streamTicket(t, server.URL, outsiderToken))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = openStream(t, ctx, server.URL, "tournaments=404&ticket="+streamTicket(t, server.URL, outsiderToken))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = openStream(t, ctx, server.URL, "tournaments=9&ticket="+streamTicket(t, server.URL, memberToken))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestStreamUpdates_ClosesWhenSessionsAreRevoked(t *testing.T) {
+	db := SetupTestDB(t)
+	server := httptest.NewServer(SetupRouter())
+	defer server.Close()
+
+	previous := controllers.StreamRecheckInterval
+	controllers.StreamRecheckInterval = 50 * time.Millisecond
+	defer func() { controllers.StreamRecheckInterval = previous }()
+
+	user, token := createUserWithRole(t, db, "ana", models.RoleUser)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp := openStream(t, ctx, server.URL, "ticket="+streamTicket(t, server.URL, token))
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	reader := bufio.NewReader(resp.Body)
+	name, _ := nextSSEvent(t, reader)
+	assert.Equal(t, "ready", name)
+
+	assert.NoError(t, services.RevokeUserSessions(db, user.ID, time.Now()))
+	name, _ = nextSSEvent(t, reader)
+	assert.Equal(t, "revoked", name)
+	_, err := reader.ReadString('\n')
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestMemoryBroker_UnsubscribesOnCancel(t *testing.T) {
+	broker := services.NewMemoryBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	messages, err := broker.Subscribe(ctx, "event:1")
+	assert.NoError(t, err)
+	assert.NoError(t, broker.Publish(context.Background(), services.BrokerMessage{Channel: "event:1", Type: services.MessageEventStatus}))
+	assert.Equal(t, services.MessageEventStatus, (<-messages).Type)
+
+	cancel()
+	select {
+	case _, ok := <-messages:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("la suscripción no se cerró al cancelar")
+	}
+	assert.NoError(t, broker.Publish(context.Background(), services.BrokerMessage{Channel: "event:1"}))
+}
+
+func TestPublishSessionStatus_ReachesLeaguesOfTheSlate(t *testing.T) {
+	db := SetupTestDB(t)
+	base := createStreamTournament(t, db, 7, models.TournamentVisibilityPublic)
+	league := createStreamTournament(t, db, 11, models.TournamentVisibilityPrivate)
+	assert.NoError(t, db.Model(&league).Update("parent_tournament_id", base.ID).Error)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	messages, err := services.GetBroker().Subscribe(ctx, services.TournamentChannel(base.ID), services.TournamentChannel(league.ID))
+	assert.NoError(t, err)
+
+	services.PublishSessionStatus(db, &models.Session{BaseModel: models.BaseModel{ID: 3}, TournamentID: base.ID, SessionNumber: 2, Status: "open"})
+
+	channels := make(map[string]bool)
+	for len(channels) < 2 {
+		select {
+		case msg := <-messages:
+			assert.Equal(t, services.MessageSessionStatus, msg.Type)
+			channels[msg.Channel] = true
+		case <-ctx.Done():
+			t.Fatalf("solo llegaron %v", channels)
+		}
+	}
+	assert.True(t, channels["tournament:11"])
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultsPoller_AutoSettlesWhenProvidersAgree(t *testing.T) {
+	db := SetupTestDB(t)
+
+	start := time.Now().Add(-3 * time.Hour)
+	agreed := models.Event{Name: "Yankees vs Red Sox", StartTime: start}
+	single := models.Event{Name: "Mets vs Phillies", StartTime: start}
+	assert.NoError(t, db.Create(&agreed).Error)
+	assert.NoError(t, db.Create(&single).Error)
+	for _, event := range []models.Event{agreed, single} {
+		pair := []models.EventCompetitor{{EventID: event.ID, Name: "Local", AssignedNumber: 1}, {EventID: event.ID, Name: "Visitante", AssignedNumber: 2}}
+		assert.NoError(t, db.Create(&pair).Error)
+	}
+
+	final := map[string]interface{}{"final": true, "competitors": []map[string]interface{}{
+		{"name": "Local", "final_score": 5},
+		{"name": "Visitante", "final_score": 3},
+	}}
+
+	// El archivo publica ambos juegos; el proveedor HTTP solo el primero (por número de competidor)
+	path := filepath.Join(t.TempDir(), "resultados.json")
+	data, _ := json.Marshal(map[string]interface{}{"nyy-bos": final, "nym-phi": final})
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/events/NYY-BOS" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"final": true, "competitors": []map[string]interface{}{
+			{"number": 2, "final_score": 3},
+			{"number": 1, "final_score": 5},
+		}})
+	}))
+	defer server.Close()
+
+	assert.NoError(t, db.Create(&[]models.EventProviderMapping{
+		{EventID: agreed.ID, Provider: "file", ExternalID: "nyy-bos"},
+		{EventID: agreed.ID, Provider: "http", ExternalID: "NYY-BOS"},
+		{EventID: single.ID, Provider: "file", ExternalID: "nym-phi"},
+		{EventID: single.ID, Provider: "http", ExternalID: "NYM-PHI"},
+	}).Error)
+
+	poller := services.NewResultsPoller(db, time.Minute,
+		services.NewFileResultsProvider("file", path),
+		services.NewHTTPResultsProvider("http", server.URL))
+	poller.PollOnce(context.Background(), time.Now())
+
+	// Dos proveedores coinciden: el evento queda liquidado sin intervención
+	assert.NoError(t, db.First(&agreed, agreed.ID).Error)
+	assert.Equal(t, "completed", agreed.Status)
+	var autoSettled int64
+	db.Model(&models.ResultProposal{}).Where("event_id = ? AND status = ?", agreed.ID, models.ProposalStatusAutoSettled).Count(&autoSettled)
+	assert.Equal(t, int64(2), autoSettled)
+
+	// Un solo proveedor: queda pendiente hasta que un admin la apruebe
+	assert.NoError(t, db.First(&single, single.ID).Error)
+	assert.NotEqual(t, "completed", single.Status)
+	var proposal models.ResultProposal
+	assert.NoError(t, db.Where("event_id = ? AND status = ?", single.ID, models.ProposalStatusPending).First(&proposal).Error)
+
+	// Repetir la consulta no duplica la propuesta
+	poller.PollOnce(context.Background(), time.Now())
+	var pending int64
+	db.Model(&models.ResultProposal{}).Where("event_id = ? AND status = ?", single.ID, models.ProposalStatusPending).Count(&pending)
+	assert.Equal(t, int64(1), pending)
+
+	tx := db.Begin()
+	settlement, err := services.ApproveProposal(tx, proposal.ID, 1)
+	assert.NoError(t, err)
+	tx.Commit()
+	assert.Equal(t, 8, settlement.TotalScore)
+
+	assert.NoError(t, db.First(&single, single.ID).Error)
+	assert.Equal(t, "completed", single.Status)
+	assert.NoError(t, db.First(&proposal, proposal.ID).Error)
+	assert.Equal(t, models.ProposalStatusApproved, proposal.Status)
+
+	_, err = services.RejectProposal(db, proposal.ID, 1, "tarde")
+	assert.ErrorIs(t, err, services.ErrProposalNotPending)
+}
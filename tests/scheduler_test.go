@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_SessionLifecycle(t *testing.T) {
+	// Setup
+	db := SetupTestDB(t)
+	start := time.Now().Add(time.Hour)
+
+	tournament := models.Tournament{
+		Name:      "Torneo Programado",
+		Category:  "Futbol",
+		Status:    models.TournamentStatusOpen,
+		StartDate: start,
+		EndDate:   start.Add(4 * time.Hour),
+		CreatedBy: 1,
+	}
+	assert.NoError(t, db.Create(&tournament).Error)
+
+	session := models.Session{
+		TournamentID:  tournament.ID,
+		SessionNumber: 1,
+		StartTime:     start,
+		EndTime:       start.Add(time.Hour),
+		Status:        models.SessionStatusScheduled,
+	}
+	assert.NoError(t, db.Create(&session).Error)
+
+	event := models.Event{Name: "Partido Programado", StartTime: start.Add(2 * time.Hour)}
+	assert.NoError(t, db.Create(&event).Error)
+	assert.NoError(t, db.Create(&models.TournamentEvent{TournamentID: tournament.ID, EventID: event.ID, SessionID: &session.ID}).Error)
+
+	scheduler := services.NewScheduler(db, time.Minute)
+
+	// Antes de la hora de inicio no cambia nada
+	scheduler.RunOnce(time.Now())
+	db.First(&session, session.ID)
+	assert.Equal(t, models.SessionStatusScheduled, session.Status)
+
+	// En StartTime el torneo entra en juego y la sesión abre
+	scheduler.RunOnce(start.Add(time.Minute))
+	db.First(&tournament, tournament.ID)
+	db.First(&session, session.ID)
+	assert.Equal(t, models.TournamentStatusRunning, tournament.Status)
+	assert.Equal(t, models.SessionStatusOpen, session.Status)
+
+	// En EndTime la sesión cierra y el evento que ya comenzó pasa a vivo
+	scheduler.RunOnce(start.Add(2*time.Hour + time.Minute))
+	db.First(&session, session.ID)
+	db.First(&event, event.ID)
+	assert.Equal(t, models.SessionStatusClosed, session.Status)
+	assert.Equal(t, "live", event.Status)
+
+	// Con el evento completado la sesión se liquida y el torneo cierra al vencer
+	db.Model(&event).Updates(map[string]interface{}{"status": "completed", "result_note": "Final 2-1"})
+	scheduler.RunOnce(start.Add(5 * time.Hour))
+	db.First(&session, session.ID)
+	db.First(&tournament, tournament.ID)
+	assert.Equal(t, models.SessionStatusSettled, session.Status)
+	assert.Equal(t, models.TournamentStatusClosed, tournament.Status)
+
+	var history int64
+	db.Model(&models.StatusTransition{}).Where("actor_id IS NULL").Count(&history)
+	assert.Equal(t, int64(5), history)
+}
+
+func TestScheduler_DoesNotSettleSessionsWithoutCompletedEvents(t *testing.T) {
+	db := SetupTestDB(t)
+	start := time.Now().Add(-3 * time.Hour)
+	tournament := createLifecycleTournament(t, db, "Torneo Vacío", models.TournamentStatusRunning)
+
+	sessions := make([]models.Session, 3)
+	for i := range sessions {
+		sessions[i] = models.Session{TournamentID: tournament.ID, SessionNumber: i + 1, StartTime: start, EndTime: start.Add(time.Hour), Status: models.SessionStatusClosed}
+		assert.NoError(t, db.Create(&sessions[i]).Error)
+	}
+
+	// Sesión 1 sin eventos, sesión 2 con su único evento cancelado, sesión 3 con uno completado y otro cancelado
+	for i, statuses := range [][]string{nil, {"cancelled"}, {"completed", "cancelled"}} {
+		for j, status := range statuses {
+			event := models.Event{Name: "Juego " + utils.UintToString(uint(10*i+j)), StartTime: start, Status: status, ResultNote: "Final"}
+			assert.NoError(t, db.Create(&event).Error)
+			assert.NoError(t, db.Create(&models.TournamentEvent{TournamentID: tournament.ID, EventID: event.ID, SessionID: &sessions[i].ID}).Error)
+		}
+	}
+
+	services.NewScheduler(db, time.Minute).RunOnce(time.Now())
+
+	for i, want := range []string{models.SessionStatusClosed, models.SessionStatusClosed, models.SessionStatusSettled} {
+		var stored models.Session
+		assert.NoError(t, db.First(&stored, sessions[i].ID).Error)
+		assert.Equal(t, want, stored.Status, "sesión %d", i+1)
+	}
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateStandardSelections_Idempotent(t *testing.T) {
+	db := SetupTestDB(t)
+
+	event := models.Event{Name: "Leones vs Tigres", StartTime: time.Now().Add(time.Hour), Line: 8.5}
+	assert.NoError(t, db.Create(&event).Error)
+	competitors := []models.EventCompetitor{
+		{EventID: event.ID, Name: "Leones", Odds: -150, Runline: -1.5, IsFavorite: true},
+		{EventID: event.ID, Name: "Tigres", Odds: 130, Runline: 1.5},
+	}
+	assert.NoError(t, db.Create(&competitors).Error)
+	assert.NoError(t, db.Preload("Competitors").First(&event, event.ID).Error)
+
+	opts := services.GenerateOptions{SuperLine: 10.5, DefaultPoints: 1, PointsByType: map[string]int{models.SelectionTypeHembra: 2}}
+	first, err := services.GenerateStandardSelections(db, &event, opts)
+	assert.NoError(t, err)
+	// Sin super runline no se generan macho_srl/hembra_srl
+	assert.Len(t, first.Created, 9)
+	assert.ElementsMatch(t, []string{models.SelectionTypeMachoSRL, models.SelectionTypeHembraSRL}, first.Skipped)
+
+	second, err := services.GenerateStandardSelections(db, &event, opts)
+	assert.NoError(t, err)
+	assert.Empty(t, second.Created)
+	assert.Empty(t, second.Updated)
+	assert.Equal(t, 9, second.Unchanged)
+
+	// Un cambio de línea actualiza alta/baja en lugar de duplicarlas
+	event.Line = 9.5
+	third, err := services.GenerateStandardSelections(db, &event, opts)
+	assert.NoError(t, err)
+	assert.Empty(t, third.Created)
+	assert.Len(t, third.Updated, 2)
+
+	var hembra models.PickableSelection
+	assert.NoError(t, db.Where("event_id = ? AND selection_type = ?", event.ID, models.SelectionTypeHembra).First(&hembra).Error)
+	assert.Equal(t, 2, hembra.PointsForWin)
+	assert.Equal(t, competitors[1].ID, *hembra.CompetitorID)
+
+	var count int64
+	db.Model(&models.PickableSelection{}).Where("event_id = ?", event.ID).Count(&count)
+	assert.Equal(t, int64(9), count)
+}
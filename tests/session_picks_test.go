@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// openSlate arma la cartilla de setupSlate con la sesión abierta y un participante inscrito
+func openSlate(t *testing.T, db *gorm.DB, username string) (models.Tournament, models.Session, map[string]uint, models.TournamentParticipant, string) {
+	tournament, session, ids := setupSlate(t, db, models.TournamentSettings{FreeSelection: true})
+	assert.NoError(t, db.Model(&session).Update("status", models.SessionStatusOpen).Error)
+
+	user, token := createUserWithRole(t, db, username, models.RoleUser)
+	participant := models.TournamentParticipant{UserID: user.ID, TournamentID: tournament.ID}
+	assert.NoError(t, db.Create(&participant).Error)
+	return tournament, session, ids, participant, token
+}
+
+func sessionSelectionIDs(t *testing.T, db *gorm.DB, participantID uint) map[uint]uint {
+	var picks []models.UserPick
+	assert.NoError(t, db.Where("participant_id = ?", participantID).Find(&picks).Error)
+	bySelection := make(map[uint]uint, len(picks))
+	for _, pick := range picks {
+		bySelection[pick.SelectionID] = pick.ID
+	}
+	return bySelection
+}
+
+func startEventOf(t *testing.T, db *gorm.DB, selectionID uint) {
+	var selection models.PickableSelection
+	assert.NoError(t, db.First(&selection, selectionID).Error)
+	assert.NoError(t, db.Model(&models.Event{}).Where("id = ?", selection.EventID).Update("start_time", time.Now().Add(-time.Minute)).Error)
+}
+
+func TestSubmitPicksBySession_ReplacesSlate(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+	tournament, session, ids, participant, token := openSlate(t, db, "ana")
+	path := "/api/v1/tournaments/" + utils.UintToString(tournament.ID) + "/sessions/picks"
+
+	w := MakeAuthRequest(router, "POST", path, token, map[string]interface{}{"session_id": session.ID, "selection_ids": []uint{ids["A_macho"], ids["B_alta"]}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	first := sessionSelectionIDs(t, db, participant.ID)
+
+	// Reenviar reemplaza la cartilla: se conserva el pick repetido y se quita el que ya no está
+	w = MakeAuthRequest(router, "POST", path, token, map[string]interface{}{"session_id": session.ID, "selection_ids": []uint{ids["A_macho"], ids["B_baja"]}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	second := sessionSelectionIDs(t, db, participant.ID)
+	assert.Len(t, second, 2)
+	assert.Equal(t, first[ids["A_macho"]], second[ids["A_macho"]])
+	assert.Contains(t, second, ids["B_baja"])
+	assert.NotContains(t, second, ids["B_alta"])
+}
+
+func TestSubmitPicksBySession_FrozenAfterEventStart(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+	tournament, session, ids, participant, token := openSlate(t, db, "ana")
+	path := "/api/v1/tournaments/" + utils.UintToString(tournament.ID) + "/sessions/picks"
+
+	w := MakeAuthRequest(router, "POST", path, token, map[string]interface{}{"session_id": session.ID, "selection_ids": []uint{ids["A_macho"], ids["B_alta"]}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	startEventOf(t, db, ids["A_macho"])
+
+	// El pick del evento comenzado no se puede quitar ni cambiar por otra selección del mismo evento
+	w = MakeAuthRequest(router, "POST", path, token, map[string]interface{}{"session_id": session.ID, "selection_ids": []uint{ids["B_alta"]}})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = MakeAuthRequest(router, "POST", path, token, map[string]interface{}{"session_id": session.ID, "selection_ids": []uint{ids["A_hembra"], ids["B_alta"]}})
+	assert.NotEqual(t, http.StatusCreated, w.Code)
+
+	picks := sessionSelectionIDs(t, db, participant.ID)
+	assert.Len(t, picks, 2)
+	assert.Contains(t, picks, ids["A_macho"])
+
+	// Manteniendo el pick congelado sí se pueden cambiar los demás
+	w = MakeAuthRequest(router, "POST", path, token, map[string]interface{}{"session_id": session.ID, "selection_ids": []uint{ids["A_macho"], ids["B_baja"]}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, sessionSelectionIDs(t, db, participant.ID), ids["B_baja"])
+}
+
+func TestUpdateAndDeletePick_OwnershipAndLock(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+	_, session, ids, participant, anaToken := openSlate(t, db, "ana")
+	_, betoToken := createUserWithRole(t, db, "beto", models.RoleUser)
+
+	frozen := models.UserPick{ParticipantID: participant.ID, SelectionID: ids["A_macho"], SessionID: session.ID}
+	editable := models.UserPick{ParticipantID: participant.ID, SelectionID: ids["B_alta"], SessionID: session.ID}
+	assert.NoError(t, db.Create(&frozen).Error)
+	assert.NoError(t, db.Create(&editable).Error)
+	editablePath := "/api/v1/my-picks/" + utils.UintToString(editable.ID)
+	frozenPath := "/api/v1/my-picks/" + utils.UintToString(frozen.ID)
+
+	// Otro usuario no ve ni toca el pick
+	w := MakeAuthRequest(router, "PUT", editablePath, betoToken, map[string]uint{"selection_id": ids["B_baja"]})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = MakeAuthRequest(router, "DELETE", editablePath, betoToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = MakeAuthRequest(router, "PUT", editablePath, anaToken, map[string]uint{"selection_id": ids["B_baja"]})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var stored models.UserPick
+	assert.NoError(t, db.First(&stored, editable.ID).Error)
+	assert.Equal(t, ids["B_baja"], stored.SelectionID)
+
+	// Con su evento comenzado el pick queda congelado
+	startEventOf(t, db, ids["A_macho"])
+	w = MakeAuthRequest(router, "PUT", frozenPath, anaToken, map[string]uint{"selection_id": ids["A_hembra"]})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = MakeAuthRequest(router, "DELETE", frozenPath, anaToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Cerrada la sesión ya no se edita nada
+	assert.NoError(t, db.Model(&session).Update("status", models.SessionStatusClosed).Error)
+	w = MakeAuthRequest(router, "DELETE", editablePath, anaToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	assert.NoError(t, db.Model(&session).Update("status", models.SessionStatusOpen).Error)
+	w = MakeAuthRequest(router, "DELETE", editablePath, anaToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var remaining int64
+	db.Model(&models.UserPick{}).Where("participant_id = ?", participant.ID).Count(&remaining)
+	assert.Equal(t, int64(1), remaining)
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettleSessionResults_AllOrNothing(t *testing.T) {
+	db := SetupTestDB(t)
+
+	user := models.User{Username: "apostador", Email: "apostador@example.com", Password: "x", Role: "user"}
+	assert.NoError(t, db.Create(&user).Error)
+
+	start := time.Now().Add(-3 * time.Hour)
+	tournament := models.Tournament{Name: "Serie Final", Category: "Beisbol", StartDate: start, EndDate: start.Add(24 * time.Hour), CreatedBy: user.ID}
+	assert.NoError(t, db.Create(&tournament).Error)
+	session := models.Session{TournamentID: tournament.ID, SessionNumber: 1, StartTime: start, EndTime: start.Add(time.Hour)}
+	assert.NoError(t, db.Create(&session).Error)
+	participant := models.TournamentParticipant{UserID: user.ID, TournamentID: tournament.ID}
+	assert.NoError(t, db.Create(&participant).Error)
+
+	// Dos juegos con pick a la alta (línea 8.5) en cada uno
+	var events []models.Event
+	var competitors [][]models.EventCompetitor
+	for i, name := range []string{"Juego 1", "Juego 2"} {
+		event := models.Event{Name: name, StartTime: start, Line: 8.5}
+		assert.NoError(t, db.Create(&event).Error)
+		assert.NoError(t, db.Create(&models.TournamentEvent{TournamentID: tournament.ID, EventID: event.ID, SessionID: &session.ID, Order: i}).Error)
+
+		pair := []models.EventCompetitor{{EventID: event.ID, Name: "Local"}, {EventID: event.ID, Name: "Visitante"}}
+		assert.NoError(t, db.Create(&pair).Error)
+
+		selection := models.PickableSelection{EventID: event.ID, Description: "Alta 8.5", SelectionType: models.SelectionTypeAlta, Line: 8.5, PointsForWin: 2, Status: "pending"}
+		assert.NoError(t, db.Create(&selection).Error)
+		assert.NoError(t, db.Create(&models.UserPick{ParticipantID: participant.ID, SelectionID: selection.ID, SessionID: session.ID, Status: "pending"}).Error)
+
+		events = append(events, event)
+		competitors = append(competitors, pair)
+	}
+
+	results := func(event int, home, away int) dtos.EventResultInput {
+		return dtos.EventResultInput{EventID: events[event].ID, Results: []dtos.CompetitorResult{
+			{CompetitorID: competitors[event][0].ID, FinalScore: home},
+			{CompetitorID: competitors[event][1].ID, FinalScore: away},
+		}}
+	}
+
+	// El segundo juego trae un competidor ajeno: no se liquida nada
+	invalid := results(1, 2, 1)
+	invalid.Results[1].CompetitorID = competitors[0][1].ID
+	tx := db.Begin()
+	report, errs, err := services.SettleSessionResults(tx, session.ID, []dtos.EventResultInput{results(0, 6, 5), invalid})
+	tx.Rollback()
+	assert.NoError(t, err)
+	assert.Nil(t, report)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "Juego 2", errs[0].Event)
+
+	var completed int64
+	db.Model(&models.Event{}).Where("status = ?", "completed").Count(&completed)
+	assert.Equal(t, int64(0), completed)
+
+	// Con resultados válidos se liquidan ambos y se consolida por participante
+	tx = db.Begin()
+	report, errs, err = services.SettleSessionResults(tx, session.ID, []dtos.EventResultInput{results(1, 2, 1), results(0, 6, 5)})
+	assert.NoError(t, err)
+	assert.Empty(t, errs)
+	tx.Commit()
+
+	assert.Equal(t, events[0].ID, report.Events[0].EventID)
+	assert.Len(t, report.Participants, 1)
+	assert.Equal(t, "apostador", report.Participants[0].Username)
+	assert.Equal(t, 1, report.Participants[0].PicksWon)
+	assert.Equal(t, 1, report.Participants[0].PicksLost)
+	assert.Equal(t, 2, report.Participants[0].Points)
+
+	db.First(&participant, participant.ID)
+	assert.Equal(t, 2, participant.TotalPoints)
+	db.Model(&models.Event{}).Where("status = ?", "completed").Count(&completed)
+	assert.Equal(t, int64(2), completed)
+}
+
+func TestSettleEvent_GradesRunlineAndSuperTotals(t *testing.T) {
+	db := SetupTestDB(t)
+
+	tests := []struct {
+		name          string
+		selectionType string
+		line          float64 // Hándicap del runline o super línea
+		runlineHome   float64 // Hándicap usado cuando el runline no trae línea propia
+		underdog      bool    // La selección es del visitante
+		home, away    int
+		want          string
+	}{
+		{"macho RL cubre", models.SelectionTypeMachoRL, -1.5, 0, false, 5, 3, "won"},
+		{"macho RL no cubre", models.SelectionTypeMachoRL, -1.5, 0, false, 4, 3, "lost"},
+		{"macho RL empata el hándicap", models.SelectionTypeMachoRL, -1, 0, false, 4, 3, "push"},
+		{"macho RL usa runline_home sin línea", models.SelectionTypeMachoRL, 0, -1.5, false, 5, 3, "won"},
+		{"hembra RL pierde por uno", models.SelectionTypeHembraRL, 1.5, 0, true, 4, 3, "won"},
+		{"hembra RL pierde por tres", models.SelectionTypeHembraRL, 1.5, 0, true, 5, 2, "lost"},
+		{"hembra RL empata el hándicap", models.SelectionTypeHembraRL, 2, 0, true, 5, 3, "push"},
+		{"macho SRL cubre", models.SelectionTypeMachoSRL, -2.5, 0, false, 6, 3, "won"},
+		{"macho SRL no cubre", models.SelectionTypeMachoSRL, -2.5, 0, false, 5, 3, "lost"},
+		{"hembra SRL empata el hándicap", models.SelectionTypeHembraSRL, 2, 0, true, 5, 3, "push"},
+		{"super alta gana", models.SelectionTypeSuperAlta, 10.5, 0, false, 6, 5, "won"},
+		{"super alta pierde", models.SelectionTypeSuperAlta, 10.5, 0, false, 5, 5, "lost"},
+		{"super alta push", models.SelectionTypeSuperAlta, 10, 0, false, 5, 5, "push"},
+		{"super baja gana", models.SelectionTypeSuperBaja, 10.5, 0, false, 5, 5, "won"},
+		{"super baja pierde", models.SelectionTypeSuperBaja, 10.5, 0, false, 6, 5, "lost"},
+		{"super baja push", models.SelectionTypeSuperBaja, 10, 0, false, 6, 4, "push"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := models.Event{Name: tt.name, StartTime: time.Now().Add(-time.Hour)}
+			assert.NoError(t, db.Create(&event).Error)
+			pair := []models.EventCompetitor{{EventID: event.ID, Name: "Local", IsFavorite: true}, {EventID: event.ID, Name: "Visitante"}}
+			assert.NoError(t, db.Create(&pair).Error)
+
+			selection := models.PickableSelection{EventID: event.ID, Description: tt.name, SelectionType: tt.selectionType,
+				Line: tt.line, RunlineHome: tt.runlineHome, PointsForWin: 2, PointsForPush: 1, Status: "pending"}
+			if models.IsRunlineSelectionType(tt.selectionType) {
+				selection.CompetitorID = &pair[0].ID
+				if tt.underdog {
+					selection.CompetitorID = &pair[1].ID
+				}
+			}
+			assert.NoError(t, db.Create(&selection).Error)
+
+			tx := db.Begin()
+			_, err := services.SettleEvent(tx, event.ID, []dtos.CompetitorResult{
+				{CompetitorID: pair[0].ID, FinalScore: tt.home},
+				{CompetitorID: pair[1].ID, FinalScore: tt.away},
+			}, "")
+			assert.NoError(t, err)
+			tx.Commit()
+
+			var graded models.PickableSelection
+			assert.NoError(t, db.First(&graded, selection.ID).Error)
+			assert.Equal(t, tt.want, graded.Status)
+		})
+	}
+}
+
+func TestSettleEvent_ClaimsEventOnce(t *testing.T) {
+	db := SetupTestDB(t)
+
+	user := models.User{Username: "apostador", Email: "apostador@example.com", Password: "x", Role: "user"}
+	assert.NoError(t, db.Create(&user).Error)
+	start := time.Now().Add(-3 * time.Hour)
+	tournament := models.Tournament{Name: "Serie Final", Category: "Beisbol", StartDate: start, EndDate: start.Add(24 * time.Hour), CreatedBy: user.ID}
+	assert.NoError(t, db.Create(&tournament).Error)
+	session := models.Session{TournamentID: tournament.ID, SessionNumber: 1, StartTime: start, EndTime: start.Add(time.Hour)}
+	assert.NoError(t, db.Create(&session).Error)
+	participant := models.TournamentParticipant{UserID: user.ID, TournamentID: tournament.ID}
+	assert.NoError(t, db.Create(&participant).Error)
+
+	event := models.Event{Name: "Juego 1", StartTime: start, Line: 8.5}
+	assert.NoError(t, db.Create(&event).Error)
+	pair := []models.EventCompetitor{{EventID: event.ID, Name: "Local"}, {EventID: event.ID, Name: "Visitante"}}
+	assert.NoError(t, db.Create(&pair).Error)
+	selection := models.PickableSelection{EventID: event.ID, Description: "Alta 8.5", SelectionType: models.SelectionTypeAlta, Line: 8.5, PointsForWin: 2, Status: "pending"}
+	assert.NoError(t, db.Create(&selection).Error)
+	assert.NoError(t, db.Create(&models.UserPick{ParticipantID: participant.ID, SelectionID: selection.ID, SessionID: session.ID, Status: "pending"}).Error)
+
+	results := []dtos.CompetitorResult{{CompetitorID: pair[0].ID, FinalScore: 6}, {CompetitorID: pair[1].ID, FinalScore: 5}}
+	for i := 0; i < 2; i++ {
+		tx := db.Begin()
+		_, err := services.SettleEvent(tx, event.ID, results, "")
+		if i == 0 {
+			assert.NoError(t, err)
+			tx.Commit()
+		} else {
+			assert.ErrorIs(t, err, services.ErrEventAlreadySettled)
+			tx.Rollback()
+		}
+	}
+
+	// Los puntos solo se suman una vez
+	db.First(&participant, participant.ID)
+	assert.Equal(t, 2, participant.TotalPoints)
+
+	// Un evento cancelado tampoco puede liquidarse
+	cancelled := models.Event{Name: "Juego 2", StartTime: start, Status: "cancelled"}
+	assert.NoError(t, db.Create(&cancelled).Error)
+	other := []models.EventCompetitor{{EventID: cancelled.ID, Name: "Local"}, {EventID: cancelled.ID, Name: "Visitante"}}
+	assert.NoError(t, db.Create(&other).Error)
+	tx := db.Begin()
+	_, err := services.SettleEvent(tx, cancelled.ID, []dtos.CompetitorResult{{CompetitorID: other[0].ID, FinalScore: 1}}, "")
+	tx.Rollback()
+	assert.ErrorIs(t, err, services.ErrEventAlreadySettled)
+}
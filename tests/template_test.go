@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// createTemplateSource crea un torneo con dos sesiones y un evento (con competidores y una
+// selección de macho) asignado a la segunda sesión, dos horas después del inicio
+func createTemplateSource(t *testing.T, db *gorm.DB) (models.Tournament, models.Event) {
+	tournament := createLifecycleTournament(t, db, "Polla Semanal", models.TournamentStatusOpen)
+	for i := 1; i <= 2; i++ {
+		start := tournament.StartDate.Add(time.Duration(i-1) * time.Hour)
+		session := models.Session{TournamentID: tournament.ID, SessionNumber: i, StartTime: start, EndTime: start.Add(time.Hour)}
+		assert.NoError(t, db.Create(&session).Error)
+		if i == 2 {
+			event := models.Event{Name: "Carrera 1", StartTime: tournament.StartDate.Add(2 * time.Hour), Line: 8.5}
+			assert.NoError(t, db.Create(&event).Error)
+			competitors := []models.EventCompetitor{{EventID: event.ID, Name: "Relámpago"}, {EventID: event.ID, Name: "Trueno"}}
+			assert.NoError(t, db.Create(&competitors).Error)
+			selection := models.PickableSelection{EventID: event.ID, Description: "Gana Relámpago", SelectionType: models.SelectionTypeMacho, CompetitorID: &competitors[0].ID, PointsForWin: 1}
+			assert.NoError(t, db.Create(&selection).Error)
+			assert.NoError(t, db.Create(&models.TournamentEvent{TournamentID: tournament.ID, EventID: event.ID, SessionID: &session.ID, Order: 3}).Error)
+		}
+	}
+
+	var event models.Event
+	assert.NoError(t, db.Where("name = ?", "Carrera 1").First(&event).Error)
+	return tournament, event
+}
+
+// assertCopiedSlate verifica que el torneo tenga sus dos sesiones desde start y una copia del
+// evento de origen asignada a la segunda sesión, con su selección apuntando al competidor copiado
+func assertCopiedSlate(t *testing.T, db *gorm.DB, tournamentID uint, source models.Event, start time.Time) {
+	var sessions []models.Session
+	assert.NoError(t, db.Where("tournament_id = ?", tournamentID).Order("session_number").Find(&sessions).Error)
+	if !assert.Len(t, sessions, 2) {
+		return
+	}
+	assert.WithinDuration(t, start, sessions[0].StartTime, time.Second)
+	assert.WithinDuration(t, start.Add(time.Hour), sessions[1].StartTime, time.Second)
+
+	var assignments []models.TournamentEvent
+	assert.NoError(t, db.Preload("Event.Competitors").Preload("Event.PickableSelections").
+		Where("tournament_id = ?", tournamentID).Find(&assignments).Error)
+	if !assert.Len(t, assignments, 1) {
+		return
+	}
+	assignment := assignments[0]
+	assert.NotEqual(t, source.ID, assignment.EventID)
+	assert.Equal(t, sessions[1].ID, *assignment.SessionID)
+	assert.Equal(t, 3, assignment.Order)
+	assert.WithinDuration(t, start.Add(2*time.Hour), assignment.Event.StartTime, time.Second)
+	assert.Equal(t, "scheduled", assignment.Event.Status)
+	assert.InDelta(t, 8.5, assignment.Event.Line, 0.001)
+
+	assert.Len(t, assignment.Event.Competitors, 2)
+	if assert.Len(t, assignment.Event.PickableSelections, 1) {
+		selection := assignment.Event.PickableSelections[0]
+		assert.Equal(t, "pending", selection.Status)
+		assert.Equal(t, assignment.Event.Competitors[0].ID, *selection.CompetitorID)
+	}
+}
+
+func TestCloneTournament_CopiesSessionsAndEventAssignments(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+	_, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+	source, event := createTemplateSource(t, db)
+	start := source.StartDate.Add(7 * 24 * time.Hour)
+	path := "/api/v1/admin/tournaments/" + utils.UintToString(source.ID) + "/clone"
+
+	w := MakeAuthRequest(router, "POST", path, adminToken, map[string]interface{}{"name": "Polla Semana 2", "start_date": start, "clone_events": true})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var clone models.Tournament
+	assert.NoError(t, db.Where("name = ?", "Polla Semana 2").First(&clone).Error)
+	assert.WithinDuration(t, source.EndDate.Add(7*24*time.Hour), clone.EndDate, time.Second)
+	assertCopiedSlate(t, db, clone.ID, event, start)
+
+	// Sin clone_events solo se copian las sesiones
+	w = MakeAuthRequest(router, "POST", path, adminToken, map[string]interface{}{"name": "Polla Semana 3", "start_date": start.Add(7 * 24 * time.Hour)})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var bare models.Tournament
+	assert.NoError(t, db.Where("name = ?", "Polla Semana 3").First(&bare).Error)
+	var sessions, assignments int64
+	db.Model(&models.Session{}).Where("tournament_id = ?", bare.ID).Count(&sessions)
+	db.Model(&models.TournamentEvent{}).Where("tournament_id = ?", bare.ID).Count(&assignments)
+	assert.Equal(t, int64(2), sessions)
+	assert.Equal(t, int64(0), assignments)
+
+	// El torneo de origen no cambia
+	var sourceAssignments int64
+	db.Model(&models.TournamentEvent{}).Where("tournament_id = ?", source.ID).Count(&sourceAssignments)
+	assert.Equal(t, int64(1), sourceAssignments)
+}
+
+func TestInstantiateTemplate_CreatesEventAssignments(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+	_, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+	source, event := createTemplateSource(t, db)
+
+	w := MakeAuthRequest(router, "POST", "/api/v1/admin/tournament-templates", adminToken, map[string]interface{}{"name": "Plantilla Polla", "source_tournament_id": source.ID})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var template models.TournamentTemplate
+	assert.NoError(t, db.Where("name = ?", "Plantilla Polla").First(&template).Error)
+	assert.Len(t, template.Sessions, 2)
+	assert.Equal(t, models.TemplateEvents{{EventID: event.ID, StartOffsetMinutes: 120, SessionNumber: 2, Order: 3}}, template.Events)
+
+	start := source.StartDate.Add(14 * 24 * time.Hour)
+	w = MakeAuthRequest(router, "POST", "/api/v1/admin/tournament-templates/"+utils.UintToString(template.ID)+"/instantiate", adminToken,
+		map[string]interface{}{"name": "Polla Desde Plantilla", "start_date": start})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var tournament models.Tournament
+	assert.NoError(t, db.Where("name = ?", "Polla Desde Plantilla").First(&tournament).Error)
+	assertCopiedSlate(t, db, tournament.ID, event, start)
+
+	// Si el evento de origen ya no existe, no se crea nada
+	assert.NoError(t, db.Delete(&models.Event{}, event.ID).Error)
+	w = MakeAuthRequest(router, "POST", "/api/v1/admin/tournament-templates/"+utils.UintToString(template.ID)+"/instantiate", adminToken,
+		map[string]interface{}{"name": "Polla Sin Evento", "start_date": start})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var orphan int64
+	db.Model(&models.Tournament{}).Where("name = ?", "Polla Sin Evento").Count(&orphan)
+	assert.Equal(t, int64(0), orphan)
+}
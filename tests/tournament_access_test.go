@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// createPrivateTournament crea un torneo privado abierto con el código de invitación dado
+func createPrivateTournament(t *testing.T, db *gorm.DB, name, code string, maxParticipants int) models.Tournament {
+	tournament := createLifecycleTournament(t, db, name, models.TournamentStatusOpen)
+	assert.NoError(t, db.Model(&tournament).Updates(map[string]interface{}{
+		"visibility":       models.TournamentVisibilityPrivate,
+		"invite_code":      code,
+		"max_participants": maxParticipants,
+	}).Error)
+	return tournament
+}
+
+// createPlayerWithWallet crea un jugador con saldo suficiente para pagar inscripciones
+func createPlayerWithWallet(t *testing.T, db *gorm.DB, username string) (models.User, string) {
+	user, token := createUserWithRole(t, db, username, models.RoleUser)
+	assert.NoError(t, db.Create(&models.Wallet{UserID: user.ID, Balance: 100}).Error)
+	return user, token
+}
+
+func TestPrivateTournament_HiddenFromNonMembersByIDAndSlug(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	tournament := createPrivateTournament(t, db, "Liga Privada", "ABCD1234", 0)
+	member, memberToken := createUserWithRole(t, db, "socio", models.RoleUser)
+	assert.NoError(t, db.Create(&models.TournamentParticipant{UserID: member.ID, TournamentID: tournament.ID}).Error)
+	_, outsiderToken := createUserWithRole(t, db, "curioso", models.RoleUser)
+	_, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+
+	paths := []string{
+		"/api/v1/tournaments/id/" + utils.UintToString(tournament.ID),
+		"/api/v1/tournaments/s/" + tournament.Slug,
+	}
+	for _, path := range paths {
+		assert.Equal(t, http.StatusNotFound, MakeRequest(router, "GET", path).Code, path)
+		assert.Equal(t, http.StatusNotFound, MakeAuthRequest(router, "GET", path, outsiderToken, nil).Code, path)
+		assert.Equal(t, http.StatusOK, MakeAuthRequest(router, "GET", path, memberToken, nil).Code, path)
+		assert.Equal(t, http.StatusOK, MakeAuthRequest(router, "GET", path, adminToken, nil).Code, path)
+	}
+
+	// El listado público tampoco lo muestra, y un token inválido no se ignora
+	w := MakeRequest(router, "GET", "/api/v1/tournaments")
+	assert.NotContains(t, w.Body.String(), "Liga Privada")
+	w = MakeAuthRequest(router, "GET", paths[0], "token-falso", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestPrivateTournament_ReadsRequireMembership(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	tournament := createPrivateTournament(t, db, "Liga Cerrada", "CERR1234", 0)
+	member, memberToken := createUserWithRole(t, db, "socio", models.RoleUser)
+	assert.NoError(t, db.Create(&models.TournamentParticipant{UserID: member.ID, TournamentID: tournament.ID}).Error)
+	_, outsiderToken := createUserWithRole(t, db, "curioso", models.RoleUser)
+
+	base := "/api/v1/tournaments/id/" + utils.UintToString(tournament.ID)
+	for _, path := range []string{base + "/leaderboard", base + "/leaderboard/projected", base + "/events", base + "/sessions"} {
+		assert.Equal(t, http.StatusForbidden, MakeRequest(router, "GET", path).Code, path)
+		assert.Equal(t, http.StatusForbidden, MakeAuthRequest(router, "GET", path, outsiderToken, nil).Code, path)
+		assert.Equal(t, http.StatusOK, MakeAuthRequest(router, "GET", path, memberToken, nil).Code, path)
+	}
+}
+
+func TestJoinPrivateTournament_RequiresInviteCode(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	tournament := createPrivateTournament(t, db, "Liga Oficina", "OFIC1234", 0)
+	player, token := createPlayerWithWallet(t, db, "ana")
+	joinPath := "/api/v1/tournaments/" + utils.UintToString(tournament.ID) + "/join"
+
+	w := MakeAuthRequest(router, "POST", joinPath, token, map[string]string{})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = MakeAuthRequest(router, "POST", joinPath, token, map[string]string{"invite_code": "OTRO0000"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = MakeAuthRequest(router, "POST", joinPath, token, map[string]string{"invite_code": "ofic1234"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = MakeAuthRequest(router, "POST", joinPath, token, map[string]string{"invite_code": "OFIC1234"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var participants int64
+	db.Model(&models.TournamentParticipant{}).Where("user_id = ? AND tournament_id = ?", player.ID, tournament.ID).Count(&participants)
+	assert.Equal(t, int64(1), participants)
+}
+
+func TestJoinTournamentByCode_JoinsLeague(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	base := createLifecycleTournament(t, db, "Torneo Base", models.TournamentStatusOpen)
+	_, ownerToken := createUserWithRole(t, db, "dueno", models.RoleUser)
+	_, friendToken := createPlayerWithWallet(t, db, "amigo")
+
+	w := MakeAuthRequest(router, "POST", "/api/v1/leagues", ownerToken, map[string]interface{}{
+		"base_tournament_id": base.ID,
+		"name":               "Polla de la oficina",
+		"max_participants":   5,
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var league models.Tournament
+	assert.NoError(t, db.Where("parent_tournament_id = ?", base.ID).First(&league).Error)
+	assert.True(t, league.IsPrivate())
+	assert.NotNil(t, league.InviteCode)
+
+	w = MakeAuthRequest(router, "POST", "/api/v1/tournaments/join-by-code", friendToken, map[string]string{"invite_code": "NOEXISTE"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = MakeAuthRequest(router, "POST", "/api/v1/tournaments/join-by-code", friendToken, map[string]string{"invite_code": " " + *league.InviteCode + " "})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Ya inscrito, el amigo ve la liga por ID
+	var participants int64
+	db.Model(&models.TournamentParticipant{}).Where("tournament_id = ?", league.ID).Count(&participants)
+	assert.Equal(t, int64(2), participants)
+	w = MakeAuthRequest(router, "GET", "/api/v1/tournaments/id/"+utils.UintToString(league.ID), friendToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Una liga no puede servir de base para otra
+	w = MakeAuthRequest(router, "POST", "/api/v1/leagues", ownerToken, map[string]interface{}{
+		"base_tournament_id": league.ID,
+		"name":               "Liga de liga",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestJoinTournament_RespectsMaxParticipants(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	tournament := createPrivateTournament(t, db, "Liga Chica", "CHIC1234", 2)
+	body := map[string]string{"invite_code": "CHIC1234"}
+
+	for _, username := range []string{"ana", "beto"} {
+		_, token := createPlayerWithWallet(t, db, username)
+		w := MakeAuthRequest(router, "POST", "/api/v1/tournaments/join-by-code", token, body)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	_, lateToken := createPlayerWithWallet(t, db, "carla")
+	w := MakeAuthRequest(router, "POST", "/api/v1/tournaments/join-by-code", lateToken, body)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var participants int64
+	db.Model(&models.TournamentParticipant{}).Where("tournament_id = ?", tournament.ID).Count(&participants)
+	assert.Equal(t, int64(2), participants)
+}
@@ -0,0 +1,306 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/dtos"
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeStepUpRequest es MakeAuthRequest con el código de 2FA en la cabecera X-2FA-Code
+func makeStepUpRequest(router *gin.Engine, method, path, token, code string, body interface{}) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-2FA-Code", code)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// totpAt calcula el código de la app para el intervalo actual desplazado en offset
+func totpAt(t *testing.T, secret string, offset int64) string {
+	code, err := utils.TOTPCode(secret, utils.TOTPCounter(time.Now())+offset)
+	assert.NoError(t, err)
+	return code
+}
+
+// enrollTwoFactor da de alta la 2FA por la API y devuelve el secreto y los códigos de recuperación
+func enrollTwoFactor(t *testing.T, router *gin.Engine, token string) (string, []string) {
+	var setup struct {
+		Data dtos.TwoFactorSetupResponse `json:"data"`
+	}
+	w := MakeAuthRequest(router, "POST", "/api/v1/auth/2fa/setup", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &setup))
+	assert.Contains(t, setup.Data.ProvisioningURI, "otpauth://totp/")
+
+	var enabled struct {
+		Data dtos.RecoveryCodesResponse `json:"data"`
+	}
+	w = MakeAuthRequest(router, "POST", "/api/v1/auth/2fa/enable", token, map[string]string{"code": totpAt(t, setup.Data.Secret, 0)})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &enabled))
+	return setup.Data.Secret, enabled.Data.RecoveryCodes
+}
+
+func TestTOTPCode_MatchesRFC6238Vectors(t *testing.T) {
+	// Secreto ASCII "12345678901234567890" del apéndice B de la RFC 6238, truncado a 6 dígitos
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	for unix, expected := range map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924"} {
+		code, err := utils.TOTPCode(secret, utils.TOTPCounter(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code)
+	}
+
+	// Se acepta un intervalo de desfase, no dos
+	now := time.Unix(1234567890, 0)
+	previous, _ := utils.TOTPCode(secret, utils.TOTPCounter(now)-1)
+	_, ok := utils.ValidateTOTP(secret, previous, now)
+	assert.True(t, ok)
+	old, _ := utils.TOTPCode(secret, utils.TOTPCounter(now)-2)
+	_, ok = utils.ValidateTOTP(secret, old, now)
+	assert.False(t, ok)
+}
+
+func TestTwoFactorLogin_ChallengeCodesAndRecovery(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	user := models.User{Username: "ana", Email: "ana@example.com", Role: "user", IsActive: true}
+	assert.NoError(t, user.HashPassword("secreto123"))
+	assert.NoError(t, db.Create(&user).Error)
+	token, _ := utils.GenerateToken(user.ID, user.Username, user.Role, user.TokenVersion)
+
+	secret, recoveryCodes := enrollTwoFactor(t, router, token)
+	assert.Len(t, recoveryCodes, services.RecoveryCodeCount)
+
+	type challengeEnvelope struct {
+		Data dtos.TwoFactorChallengeResponse `json:"data"`
+	}
+	login := func() string {
+		var challenge challengeEnvelope
+		w := MakeJSONRequest(router, "POST", "/api/v1/auth/login", map[string]string{"username": "ana", "password": "secreto123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+		assert.True(t, challenge.Data.TwoFactorRequired)
+		assert.NotContains(t, w.Body.String(), "refresh_token")
+		return challenge.Data.ChallengeToken
+	}
+
+	challenge := login()
+	w := MakeJSONRequest(router, "POST", "/api/v1/auth/2fa/verify", map[string]string{"challenge_token": challenge, "code": "000000"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var tokens tokenPairEnvelope
+	code := totpAt(t, secret, 1)
+	w = MakeJSONRequest(router, "POST", "/api/v1/auth/2fa/verify", map[string]string{"challenge_token": challenge, "code": code})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	assert.NotEmpty(t, tokens.Data.RefreshToken)
+
+	// Un código de la app ya usado no sirve aunque siga en su ventana
+	w = MakeJSONRequest(router, "POST", "/api/v1/auth/2fa/verify", map[string]string{"challenge_token": login(), "code": code})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// El reto es de un solo uso
+	w = MakeJSONRequest(router, "POST", "/api/v1/auth/2fa/verify", map[string]string{"challenge_token": challenge, "code": recoveryCodes[0]})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Un código de recuperación entra una sola vez
+	w = MakeJSONRequest(router, "POST", "/api/v1/auth/2fa/verify", map[string]string{"challenge_token": login(), "code": recoveryCodes[0]})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = MakeJSONRequest(router, "POST", "/api/v1/auth/2fa/verify", map[string]string{"challenge_token": login(), "code": recoveryCodes[0]})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, int64(services.RecoveryCodeCount-1), services.RemainingRecoveryCodes(db, user.ID))
+
+	// Tras 5 códigos erróneos el reto deja de valer aunque llegue uno correcto
+	challenge = login()
+	for i := 0; i < 5; i++ {
+		MakeJSONRequest(router, "POST", "/api/v1/auth/2fa/verify", map[string]string{"challenge_token": challenge, "code": "000000"})
+	}
+	w = MakeJSONRequest(router, "POST", "/api/v1/auth/2fa/verify", map[string]string{"challenge_token": challenge, "code": recoveryCodes[1]})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestStepUp_PaymentMethodsRequireCodeOncePerSession(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	user, token := createUserWithRole(t, db, "beto", models.RoleUser)
+	assert.NoError(t, db.Create(&models.Wallet{UserID: user.ID, Balance: 200}).Error)
+
+	var created struct {
+		Data dtos.UserPaymentMethodResponse `json:"data"`
+	}
+
+	// Sin 2FA activa no se pide nada
+	w := MakeAuthRequest(router, "POST", "/api/v1/payment-methods", token, map[string]string{"method": "zelle", "zelle_email": "beto@example.com"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	secret, _ := enrollTwoFactor(t, router, token)
+	body := map[string]string{"method": "zelle", "zelle_email": "beto.2fa@example.com"}
+
+	w = MakeAuthRequest(router, "POST", "/api/v1/payment-methods", token, body)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "two_factor_required")
+	w = makeStepUpRequest(router, "POST", "/api/v1/payment-methods", token, "999999", body)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = makeStepUpRequest(router, "POST", "/api/v1/payment-methods", token, totpAt(t, secret, 1), body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	// El método quedó a nombre del usuario y aparece en su listado
+	var method models.UserPaymentMethod
+	assert.NoError(t, db.First(&method, created.Data.ID).Error)
+	assert.Equal(t, user.ID, method.UserID)
+	w = MakeAuthRequest(router, "GET", "/api/v1/payment-methods", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "beto.2fa@example.com")
+
+	// El step-up vale para la misma sesión, no para otra
+	withdraw := map[string]interface{}{"amount": 50, "payment_method_id": method.ID}
+	w = MakeAuthRequest(router, "POST", "/api/v1/wallet/withdraw", token, withdraw)
+	assert.Equal(t, http.StatusOK, w.Code)
+	otherToken, _ := utils.GenerateToken(user.ID, user.Username, user.Role, user.TokenVersion)
+	w = MakeAuthRequest(router, "POST", "/api/v1/wallet/withdraw", otherToken, withdraw)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "two_factor_required")
+}
+
+func TestStaffTwoFactor_RequiredForAdminRoutes(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+	services.RequireStaffTwoFactor = true
+
+	admin := models.User{Username: "root", Email: "root@example.com", Role: models.RoleAdmin, IsActive: true}
+	assert.NoError(t, admin.HashPassword("secreto123"))
+	assert.NoError(t, db.Create(&admin).Error)
+	_, playerToken := createUserWithRole(t, db, "jugador", models.RoleUser)
+
+	var login struct {
+		Data dtos.LoginResponse `json:"data"`
+	}
+	w := MakeJSONRequest(router, "POST", "/api/v1/auth/login", map[string]string{"username": "root", "password": "secreto123"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+	assert.True(t, login.Data.TwoFactorSetupRequired)
+
+	w = MakeAuthRequest(router, "GET", "/api/v1/admin/users", login.Data.Token, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "two_factor_setup_required")
+
+	// Los usuarios comunes no están obligados
+	w = MakeAuthRequest(router, "POST", "/api/v1/payment-methods", playerToken, map[string]string{"method": "zelle", "zelle_email": "jugador@example.com"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	secret, _ := enrollTwoFactor(t, router, login.Data.Token)
+	w = MakeAuthRequest(router, "GET", "/api/v1/admin/users", login.Data.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Mientras sea obligatoria no se puede apagar
+	w = MakeAuthRequest(router, "POST", "/api/v1/auth/2fa/disable", login.Data.Token, map[string]string{"code": totpAt(t, secret, 1)})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestStepUp_LocksAfterRepeatedWrongCodes(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	user, token := createUserWithRole(t, db, "beto", models.RoleUser)
+	secret, recoveryCodes := enrollTwoFactor(t, router, token)
+	body := map[string]string{"method": "zelle", "zelle_email": "beto@example.com"}
+
+	// Los fallos de step-up, desactivación y regeneración suman al mismo contador del usuario
+	for i := 0; i < 2; i++ {
+		w := makeStepUpRequest(router, "POST", "/api/v1/payment-methods", token, "000000", body)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	}
+	for i := 0; i < 2; i++ {
+		w := MakeAuthRequest(router, "POST", "/api/v1/auth/2fa/recovery-codes", token, map[string]string{"code": "000000"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w := MakeAuthRequest(router, "POST", "/api/v1/auth/2fa/disable", token, map[string]string{"code": "000000"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// Bloqueada, ni un código correcto ni uno de recuperación pasan
+	w = makeStepUpRequest(router, "POST", "/api/v1/payment-methods", token, totpAt(t, secret, 1), body)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	w = MakeAuthRequest(router, "POST", "/api/v1/auth/2fa/disable", token, map[string]string{"code": recoveryCodes[0]})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, int64(services.RecoveryCodeCount), services.RemainingRecoveryCodes(db, user.ID))
+
+	// Vencido el bloqueo, el código correcto vuelve a valer y reinicia el contador
+	past := time.Now().Add(-time.Minute)
+	assert.NoError(t, db.Model(&models.User{}).Where("id = ?", user.ID).Update("two_factor_locked_until", past).Error)
+	w = makeStepUpRequest(router, "POST", "/api/v1/payment-methods", token, totpAt(t, secret, 1), body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var stored models.User
+	assert.NoError(t, db.First(&stored, user.ID).Error)
+	assert.Equal(t, 0, stored.TwoFactorFailedAttempts)
+	assert.Nil(t, stored.TwoFactorLockedUntil)
+}
+
+func TestTwoFactorLogin_WrongCodesAcrossChallengesLockUser(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	user := models.User{Username: "ana", Email: "ana@example.com", Role: "user", IsActive: true}
+	assert.NoError(t, user.HashPassword("secreto123"))
+	assert.NoError(t, db.Create(&user).Error)
+	token, _ := utils.GenerateToken(user.ID, user.Username, user.Role, user.TokenVersion)
+	secret, _ := enrollTwoFactor(t, router, token)
+
+	login := func() string {
+		var challenge struct {
+			Data dtos.TwoFactorChallengeResponse `json:"data"`
+		}
+		w := MakeJSONRequest(router, "POST", "/api/v1/auth/login", map[string]string{"username": "ana", "password": "secreto123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+		return challenge.Data.ChallengeToken
+	}
+
+	// Un reto nuevo por intento no reinicia la cuenta: el quinto fallo bloquea la 2FA
+	for i := 0; i < 4; i++ {
+		w := MakeJSONRequest(router, "POST", "/api/v1/auth/2fa/verify", map[string]string{"challenge_token": login(), "code": "000000"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w := MakeJSONRequest(router, "POST", "/api/v1/auth/2fa/verify", map[string]string{"challenge_token": login(), "code": "000000"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// Bloqueada, ni el código correcto entra
+	w = MakeJSONRequest(router, "POST", "/api/v1/auth/2fa/verify", map[string]string{"challenge_token": login(), "code": totpAt(t, secret, 0)})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestStepUp_RequiredForTournamentStatus(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	_, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+	secret, _ := enrollTwoFactor(t, router, adminToken)
+	tournament := createLifecycleTournament(t, db, "Copa Step-up", models.TournamentStatusDraft)
+	path := "/api/v1/admin/tournaments/" + utils.UintToString(tournament.ID) + "/status"
+	body := map[string]string{"status": models.TournamentStatusOpen}
+
+	// Finalizar paga premios: el cambio de estado exige el código de 2FA
+	w := MakeAuthRequest(router, "PATCH", path, adminToken, body)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "two_factor_required")
+
+	w = makeStepUpRequest(router, "PATCH", path, adminToken, totpAt(t, secret, 1), body)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
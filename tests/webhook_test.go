@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/services"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookReceiver es un socio de prueba que valida la firma y su vigencia, y puede fallar a pedido
+type webhookReceiver struct {
+	mu       sync.Mutex
+	secret   string
+	status   int
+	received []services.WebhookEnvelope
+	badSigs  int
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	timestamp, _ := strconv.ParseInt(req.Header.Get(services.WebhookHeaderTimestamp), 10, 64)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Como un socio real, rechaza firmas fuera de la ventana anti-replay de 5 minutos
+	age := time.Since(time.Unix(timestamp, 0))
+	if req.Header.Get(services.WebhookHeaderSignature) != services.SignWebhookPayload(r.secret, timestamp, body) ||
+		age > 5*time.Minute || age < -5*time.Minute {
+		r.badSigs++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.status != http.StatusOK {
+		w.WriteHeader(r.status)
+		return
+	}
+	var envelope services.WebhookEnvelope
+	json.Unmarshal(body, &envelope)
+	r.received = append(r.received, envelope)
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestWebhooks_SignedDeliveryRetriesDeadLetterAndReplay(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	receiver := &webhookReceiver{status: http.StatusInternalServerError}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	admin := models.User{Username: "admin", Email: "admin@example.com", Password: "x", Role: "admin"}
+	assert.NoError(t, db.Create(&admin).Error)
+	token, _ := utils.GenerateToken(admin.ID, admin.Username, admin.Role, admin.TokenVersion)
+
+	var created struct {
+		Data struct {
+			Webhook models.WebhookSubscription `json:"webhook"`
+			Secret  string                     `json:"secret"`
+		} `json:"data"`
+	}
+	w := MakeAuthRequest(router, "POST", "/api/v1/admin/webhooks", token, map[string]interface{}{
+		"name": "Bot", "url": server.URL, "event_types": []string{models.WebhookPrizePaid},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.Data.Secret)
+	receiver.secret = created.Data.Secret
+
+	// Tipo desconocido
+	w = MakeAuthRequest(router, "POST", "/api/v1/admin/webhooks", token, map[string]interface{}{
+		"name": "Bot", "url": server.URL, "event_types": []string{"user.deleted"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Solo se encolan los tipos suscritos
+	tournament := models.Tournament{BaseModel: models.BaseModel{ID: 9}, Name: "Semana 1"}
+	services.EmitWebhook(db, models.WebhookEventSettled, map[string]int{"event_id": 1})
+	services.EmitPrizePaid(db, 5, &tournament, 1, 150)
+
+	var delivery models.WebhookDelivery
+	assert.NoError(t, db.Where("subscription_id = ?", created.Data.Webhook.ID).First(&delivery).Error)
+	var total int64
+	db.Model(&models.WebhookDelivery{}).Count(&total)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, models.WebhookPrizePaid, delivery.EventType)
+
+	// El receptor falla: cada intento agenda el siguiente con espera creciente hasta dead-letter
+	dispatcher := services.NewWebhookDispatcher(db, time.Minute)
+	now := time.Now()
+	var previousWait time.Duration
+	for attempt := 1; attempt <= 8; attempt++ {
+		assert.Equal(t, 0, dispatcher.DispatchOnce(context.Background(), now))
+		assert.NoError(t, db.First(&delivery, delivery.ID).Error)
+		assert.Equal(t, attempt, delivery.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, delivery.LastStatusCode)
+		if attempt < 8 {
+			assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+			wait := delivery.NextAttemptAt.Sub(now)
+			assert.Greater(t, wait, previousWait)
+			previousWait = wait
+			now = delivery.NextAttemptAt
+		}
+	}
+	assert.Equal(t, models.WebhookDeliveryDead, delivery.Status)
+	assert.Equal(t, 0, receiver.badSigs)
+
+	w = MakeAuthRequest(router, "GET", "/api/v1/admin/webhook-deliveries/dead", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), delivery.EventID)
+
+	// Replay con el receptor sano
+	receiver.status = http.StatusOK
+	w = MakeAuthRequest(router, "POST", "/api/v1/admin/webhook-deliveries/"+strconv.Itoa(int(delivery.ID))+"/replay", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = MakeAuthRequest(router, "POST", "/api/v1/admin/webhook-deliveries/"+strconv.Itoa(int(delivery.ID))+"/replay", token, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	assert.Equal(t, 1, dispatcher.DispatchOnce(context.Background(), time.Now().Add(time.Second)))
+	assert.NoError(t, db.First(&delivery, delivery.ID).Error)
+	assert.Equal(t, models.WebhookDeliveryDelivered, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+
+	assert.Len(t, receiver.received, 1)
+	assert.Equal(t, delivery.EventID, receiver.received[0].ID)
+	assert.Equal(t, models.WebhookPrizePaid, receiver.received[0].Type)
+	var data services.PrizePaidData
+	assert.NoError(t, json.Unmarshal(receiver.received[0].Data, &data))
+	assert.Equal(t, uint(9), data.TournamentID)
+	assert.Equal(t, 150.0, data.Amount)
+}
+
+func TestWebhooks_PrivateTournamentResultsStayInternal(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	_, adminToken := createUserWithRole(t, db, "root", models.RoleAdmin)
+	w := MakeAuthRequest(router, "POST", "/api/v1/admin/webhooks", adminToken, map[string]interface{}{
+		"name": "Bot", "url": "https://socio.example.com/hooks", "event_types": []string{models.WebhookPrizePaid, models.WebhookLeaderboardFinal},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	finish := func(name, visibility string) int64 {
+		winner, _ := createUserWithRole(t, db, "ganador-"+visibility, models.RoleUser)
+		assert.NoError(t, db.Create(&models.Wallet{UserID: winner.ID}).Error)
+		tournament := createLifecycleTournament(t, db, name, models.TournamentStatusClosed)
+		assert.NoError(t, db.Model(&tournament).Update("visibility", visibility).Error)
+		assert.NoError(t, db.Create(&models.TournamentParticipant{TournamentID: tournament.ID, UserID: winner.ID, TotalPoints: 10}).Error)
+
+		path := "/api/v1/admin/tournaments/" + utils.UintToString(tournament.ID) + "/status"
+		w := MakeAuthRequest(router, "PATCH", path, adminToken, map[string]string{"status": models.TournamentStatusFinished})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var deliveries int64
+		db.Model(&models.WebhookDelivery{}).Count(&deliveries)
+		return deliveries
+	}
+
+	// El premio y la clasificación de un torneo privado no se envían a los socios
+	assert.Equal(t, int64(0), finish("Liga Privada", models.TournamentVisibilityPrivate))
+	assert.Equal(t, int64(2), finish("Copa Abierta", models.TournamentVisibilityPublic))
+}
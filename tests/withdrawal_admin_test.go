@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cesarbmathec/bets-backend/models"
+	"github.com/cesarbmathec/bets-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// createPendingWithdrawal congela amount en la billetera del jugador como lo hace CreateWithdrawal
+func createPendingWithdrawal(t *testing.T, db *gorm.DB, wallet *models.Wallet, amount float64) models.Withdrawal {
+	method := models.UserPaymentMethod{UserID: wallet.UserID, Method: "zelle", ZelleEmail: "retiro@example.com"}
+	assert.NoError(t, db.Create(&method).Error)
+	withdrawal := models.Withdrawal{UserID: wallet.UserID, Amount: amount, PaymentMethodID: method.ID, Status: "pending", Verified: true}
+	assert.NoError(t, db.Create(&withdrawal).Error)
+	assert.NoError(t, db.Model(wallet).Update("frozen_balance", gorm.Expr("frozen_balance + ?", amount)).Error)
+	return withdrawal
+}
+
+func withdrawalPath(withdrawal models.Withdrawal, action string) string {
+	return "/api/v1/admin/withdrawals/" + utils.UintToString(withdrawal.ID) + "/" + action
+}
+
+func TestAdminWithdrawals_ApproveDebitsOnceAndCompletes(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	operator, operatorToken := createUserWithRole(t, db, "caja", models.RoleWithdrawalOperator)
+	player, playerToken := createUserWithRole(t, db, "jugador", models.RoleUser)
+	wallet := models.Wallet{UserID: player.ID, Balance: 100}
+	assert.NoError(t, db.Create(&wallet).Error)
+	withdrawal := createPendingWithdrawal(t, db, &wallet, 60)
+
+	w := MakeAuthRequest(router, "POST", withdrawalPath(withdrawal, "approve"), operatorToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = MakeAuthRequest(router, "POST", withdrawalPath(withdrawal, "approve"), operatorToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Ni el rechazo ni la cancelación del usuario deshacen un retiro aprobado
+	w = MakeAuthRequest(router, "POST", withdrawalPath(withdrawal, "reject"), operatorToken, map[string]string{"reason": "Tarde"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = MakeAuthRequest(router, "POST", "/api/v1/wallet/withdraw/cancel", playerToken, map[string]uint{"withdrawal_id": withdrawal.ID})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assert.NoError(t, db.First(&wallet, wallet.ID).Error)
+	assert.InDelta(t, 40, wallet.Balance, 0.001)
+	assert.InDelta(t, 0, wallet.FrozenBalance, 0.001)
+	var debits int64
+	db.Model(&models.Transaction{}).Where("wallet_id = ? AND type = ?", wallet.ID, "withdraw").Count(&debits)
+	assert.Equal(t, int64(1), debits)
+
+	w = MakeAuthRequest(router, "POST", withdrawalPath(withdrawal, "complete"), operatorToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = MakeAuthRequest(router, "POST", withdrawalPath(withdrawal, "complete"), operatorToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var stored models.Withdrawal
+	assert.NoError(t, db.First(&stored, withdrawal.ID).Error)
+	assert.Equal(t, "completed", stored.Status)
+	assert.Equal(t, operator.ID, *stored.ProcessedBy)
+	assert.NoError(t, db.First(&wallet, wallet.ID).Error)
+	assert.InDelta(t, 40, wallet.Balance, 0.001)
+}
+
+func TestAdminWithdrawals_RejectReleasesFrozenOnce(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	_, operatorToken := createUserWithRole(t, db, "caja", models.RoleWithdrawalOperator)
+	player, _ := createUserWithRole(t, db, "jugador", models.RoleUser)
+	wallet := models.Wallet{UserID: player.ID, Balance: 100}
+	assert.NoError(t, db.Create(&wallet).Error)
+	withdrawal := createPendingWithdrawal(t, db, &wallet, 60)
+
+	w := MakeAuthRequest(router, "POST", withdrawalPath(withdrawal, "reject"), operatorToken, map[string]string{"reason": "Cuenta no coincide"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = MakeAuthRequest(router, "POST", withdrawalPath(withdrawal, "reject"), operatorToken, map[string]string{"reason": "Otra vez"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = MakeAuthRequest(router, "POST", withdrawalPath(withdrawal, "approve"), operatorToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assert.NoError(t, db.First(&wallet, wallet.ID).Error)
+	assert.InDelta(t, 100, wallet.Balance, 0.001)
+	assert.InDelta(t, 0, wallet.FrozenBalance, 0.001)
+
+	var stored models.Withdrawal
+	assert.NoError(t, db.First(&stored, withdrawal.ID).Error)
+	assert.Equal(t, "rejected", stored.Status)
+	assert.Equal(t, "Cuenta no coincide", stored.RejectedReason)
+}
+
+func TestAdminWithdrawals_InsufficientBalanceLeavesWithdrawalPending(t *testing.T) {
+	db := SetupTestDB(t)
+	router := SetupRouter()
+
+	_, operatorToken := createUserWithRole(t, db, "caja", models.RoleWithdrawalOperator)
+	player, _ := createUserWithRole(t, db, "jugador", models.RoleUser)
+	wallet := models.Wallet{UserID: player.ID, Balance: 30}
+	assert.NoError(t, db.Create(&wallet).Error)
+	withdrawal := createPendingWithdrawal(t, db, &wallet, 60)
+
+	w := MakeAuthRequest(router, "POST", withdrawalPath(withdrawal, "approve"), operatorToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// La reclamación del retiro se revierte junto con la transacción
+	var stored models.Withdrawal
+	assert.NoError(t, db.First(&stored, withdrawal.ID).Error)
+	assert.Equal(t, "pending", stored.Status)
+	assert.Nil(t, stored.ProcessedBy)
+	assert.NoError(t, db.First(&wallet, wallet.ID).Error)
+	assert.InDelta(t, 30, wallet.Balance, 0.001)
+	assert.InDelta(t, 60, wallet.FrozenBalance, 0.001)
+}
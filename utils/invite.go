@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// inviteAlphabet excluye caracteres ambiguos (0/O, 1/I/L) para facilitar compartir el código
+const inviteAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// GenerateInviteCode genera un código de invitación aleatorio de la longitud indicada
+func GenerateInviteCode(length int) (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(inviteAlphabet)))
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(inviteAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+// InviteLink construye el enlace público para unirse a un torneo privado.
+// Usa APP_BASE_URL (frontend) y por defecto devuelve una ruta relativa.
+func InviteLink(code string) string {
+	base := strings.TrimRight(os.Getenv("APP_BASE_URL"), "/")
+	return fmt.Sprintf("%s/join/%s", base, code)
+}
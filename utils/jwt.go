@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"os"
 	"time"
@@ -8,31 +12,41 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// AccessTokenTTL es la vida del token de acceso; se renueva con el refresh token
+const AccessTokenTTL = 15 * time.Minute
+
 // Claims define la estructura de la carga útil del token
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	// TokenVersion debe coincidir con la del usuario; al subirla se revocan todos sus tokens
+	TokenVersion int `json:"tv"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken crea un nuevo JWT para un usuario
-func GenerateToken(userID uint, username string, role string) (string, error) {
+// GenerateToken crea un JWT de acceso de vida corta para un usuario con su versión de token
+func GenerateToken(userID uint, username string, role string, tokenVersion int) (string, error) {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
 		return "", errors.New("JWT_SECRET no configurado en el entorno")
 	}
 
-	// El token expira en 24 horas por defecto
-	expirationTime := time.Now().Add(24 * time.Hour)
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
 
+	now := time.Now()
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
+		UserID:       userID,
+		Username:     username,
+		Role:         role,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
@@ -46,7 +60,7 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -58,3 +72,18 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return nil, errors.New("token inválido")
 }
+
+// RandomToken genera un valor aleatorio de size bytes en base64 URL sin relleno
+func RandomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken es el SHA-256 en hexadecimal con el que se guardan los refresh tokens
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}